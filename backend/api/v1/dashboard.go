@@ -21,3 +21,29 @@ type DashboardSummaryResp struct {
 	ConfirmedReports int    `json:"confirmed_reports"`
 	LastUpdated      string `json:"last_updated"`
 }
+
+type PeriodDashboardReq struct {
+	PeriodType string `form:"period_type" json:"period_type" binding:"required" example:"quarter"`
+	Date       string `form:"date" json:"date" example:"2025-05-10"`             // 周期内任一天，默认用户时区的今天；custom 不使用
+	StartDate  string `form:"start_date" json:"start_date" example:"2025-04-01"` // 仅 custom
+	EndDate    string `form:"end_date" json:"end_date" example:"2025-06-30"`     // 仅 custom
+}
+
+type PeriodDashboardResp struct {
+	PeriodType      string             `json:"period_type"`
+	StartDate       string             `json:"start_date"`
+	EndDate         string             `json:"end_date"`
+	Title           string             `json:"title"`            // 生成报告时使用的标题
+	RecordedDays    int                `json:"recorded_days"`    // 截至今天完成记录的天数
+	MissingDays     int                `json:"missing_days"`     // 截至今天缺失记录的天数
+	Rate            int                `json:"rate"`             // 完成率
+	ConfirmedMonths int                `json:"confirmed_months"` // 周期内已确认的月报数
+	ConfirmedWeeks  int                `json:"confirmed_weeks"`  // 周期内已确认的周报数
+	Report          *PeriodReportState `json:"report,omitempty"` // 该周期已有的报告
+}
+
+type PeriodReportState struct {
+	ReportID  string `json:"report_id"`
+	Status    string `json:"status"`
+	Confirmed bool   `json:"confirmed"`
+}
@@ -27,27 +27,73 @@ var (
 	ErrGetUserSettingsFailed    = newError(1009, "获取用户设置失败")
 	ErrUpdateUserSettingsFailed = newError(1010, "更新用户设置失败")
 	ErrGetUserInfoFailed        = newError(1011, "获取用户信息失败")
+	ErrInvalidReportTime        = newError(1012, "自动生成时间格式应为HH:MM")
+	ErrInvalidTimezone          = newError(1013, "时区无效，请使用IANA时区名，如Asia/Shanghai")
+	ErrInvalidEncryptionKey     = newError(1014, "加密密钥参数错误")
+	ErrInvalidSprint            = newError(1015, "迭代长度需为1-60天，起始日期格式为YYYY-MM-DD")
 
 	// record errors
-	ErrRecordNotExist     = newError(2001, "记录不存在")
-	ErrGetRecordsFailed   = newError(2002, "获取记录失败")
-	ErrCreateRecordFailed = newError(2003, "创建记录失败")
-	ErrUpdateRecordFailed = newError(2004, "更新记录失败")
-	ErrDeleteRecordFailed = newError(2005, "删除记录失败")
-	ErrTooManyRecords     = newError(2006, "存在多条记录")
-	ErrInvalidDate        = newError(2007, "非法日期错误")
+	ErrRecordNotExist      = newError(2001, "记录不存在")
+	ErrGetRecordsFailed    = newError(2002, "获取记录失败")
+	ErrCreateRecordFailed  = newError(2003, "创建记录失败")
+	ErrUpdateRecordFailed  = newError(2004, "更新记录失败")
+	ErrDeleteRecordFailed  = newError(2005, "删除记录失败")
+	ErrTooManyRecords      = newError(2006, "存在多条记录")
+	ErrInvalidDate         = newError(2007, "非法日期错误")
+	ErrRevisionNotExist    = newError(2008, "历史版本不存在")
+	ErrGetRevisionsFailed  = newError(2009, "获取历史版本失败")
+	ErrRestoreRecordFailed = newError(2010, "恢复历史版本失败")
+	ErrRecordConflict      = newError(2011, "记录已在其他地方修改，请合并后重试")
+	ErrInvalidEnvelope     = newError(2012, "加密记录的密文或加密参数格式错误")
+	ErrRecordEncrypted     = newError(2013, "加密记录无法在服务端处理，请在客户端解密后操作")
+	ErrInvalidImportFile   = newError(2014, "导入文件格式错误，仅支持 zip、md、markdown、txt")
+	ErrImportTooLarge      = newError(2015, "导入文件过大或条目过多")
+	ErrImportEmpty         = newError(2016, "未识别到带日期的记录")
+	ErrInvalidImportPolicy = newError(2017, "冲突策略错误，应为 skip、overwrite 或 append")
+	ErrImportJobNotExist   = newError(2018, "导入任务不存在")
+	ErrImportFailed        = newError(2019, "导入失败")
 
 	// report errors
-	ErrReportNotExist        = newError(3001, "报告不存在")
-	ErrGetReportsFailed      = newError(3002, "获取报告失败")
-	ErrCreateReportFailed    = newError(3003, "创建报告失败")
-	ErrUpdateReportFailed    = newError(3004, "更新报告失败")
-	ErrInvalidReportPeriod   = newError(3005, "报告类型错误")
-	ErrInvalidReportTemplate = newError(3006, "报告版式错误")
-	ErrReportNotReady        = newError(3007, "报告尚未生成完成")
-	ErrCallLLMFailed         = newError(3008, "调用大模型失败")
-	ErrGenReportFailed       = newError(3009, "生成报告失败")
+	ErrReportNotExist           = newError(3001, "报告不存在")
+	ErrGetReportsFailed         = newError(3002, "获取报告失败")
+	ErrCreateReportFailed       = newError(3003, "创建报告失败")
+	ErrUpdateReportFailed       = newError(3004, "更新报告失败")
+	ErrInvalidReportPeriod      = newError(3005, "报告类型错误")
+	ErrInvalidReportTemplate    = newError(3006, "报告版式错误")
+	ErrReportNotReady           = newError(3007, "报告尚未生成完成")
+	ErrCallLLMFailed            = newError(3008, "调用大模型失败")
+	ErrGenReportFailed          = newError(3009, "生成报告失败")
+	ErrGetReportJobsFailed      = newError(3010, "获取生成记录失败")
+	ErrReportConflict           = newError(3011, "报告已在其他地方修改或重新生成，请合并后重试")
+	ErrEncryptedRecords         = newError(3012, "周期内包含加密记录，请在客户端解密后随生成请求提交")
+	ErrReportNotConfirmed       = newError(3013, "报告尚未确认，确认后才能导出")
+	ErrInvalidExportFormat      = newError(3014, "导出格式错误，支持 pdf/docx/html/md")
+	ErrInvalidBranding          = newError(3015, "品牌模板不存在或格式错误")
+	ErrRenderReportFailed       = newError(3016, "报告导出失败")
+	ErrReportTemplateNotExist   = newError(3017, "报告模板不存在")
+	ErrReportTemplateReadOnly   = newError(3018, "内置模板不可修改或删除")
+	ErrInvalidReportTemplateArg = newError(3019, "模板名称、适用周期、提示词或章节不合法")
+	ErrReportTemplateNameExists = newError(3020, "模板名称已存在")
+	ErrReportTemplateLimit      = newError(3021, "自定义模板数量已达上限")
+	ErrReportTemplatePeriod     = newError(3022, "该模板不适用于此报告周期")
+	ErrSaveReportTemplateFailed = newError(3023, "保存报告模板失败")
+	ErrNoComparisonBaseline     = newError(3024, "上一周期没有已确认的报告，无法对比")
+	ErrReportConfirmed          = newError(3025, "报告已确认，重新生成需设置 overwrite_confirmed")
+	ErrReportVersionNotExist    = newError(3026, "报告历史版本不存在")
+	ErrGetReportVersionsFailed  = newError(3027, "获取报告历史版本失败")
+	ErrInvalidRewriteTarget     = newError(3028, "改写范围不合法，标题或文本片段须二选一且在正文中唯一出现")
 
 	// dashboard errors
 	ErrGetDashboardFailed = newError(4001, "获取看板数据失败")
+
+	// search errors
+	ErrSearchFailed      = newError(5001, "搜索失败")
+	ErrInvalidSearchType = newError(5002, "搜索类型错误")
+
+	// export errors
+	ErrExportNotExist    = newError(6001, "导出任务不存在")
+	ErrExportFailed      = newError(6002, "导出失败")
+	ErrExportNotReady    = newError(6003, "导出尚未完成")
+	ErrExportLinkInvalid = newError(6004, "下载链接无效或已过期，请重新获取")
+	ErrExportExpired     = newError(6005, "导出文件已过期，请重新导出")
 )
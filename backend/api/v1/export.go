@@ -0,0 +1,62 @@
+package v1
+
+type ExportStatus string
+
+const (
+	ExportStatusRunning ExportStatus = "running"
+	ExportStatusReady   ExportStatus = "ready"
+	ExportStatusFailed  ExportStatus = "failed"
+	ExportStatusExpired ExportStatus = "expired" // 超过保留时长，压缩包已删除
+)
+
+// ExportFormat 导出压缩包 manifest.json 中的格式标识，导入时据此识别
+const (
+	ExportFormat        = "thinking-calendar-export"
+	ExportFormatVersion = 1
+)
+
+// ExportJob 账号数据导出任务
+type ExportJob struct {
+	ExportID      string `json:"export_id" example:"exportid_123"`
+	Status        string `json:"status" example:"ready"` // running/ready/failed/expired
+	Records       int    `json:"records"`                // 记录条数，含端到端加密记录
+	Reports       int    `json:"reports"`
+	Size          int64  `json:"size"` // 压缩包字节数
+	Error         string `json:"error,omitempty"`
+	DownloadURL   string `json:"download_url,omitempty"`    // ready 时返回的签名下载链接，无需登录态，短时有效
+	LinkExpiresAt string `json:"link_expires_at,omitempty"` // 下载链接过期时间，过期后重新查询任务获取新链接
+	CreatedAt     string `json:"created_at"`
+	FinishedAt    string `json:"finished_at,omitempty"`
+	ExpiresAt     string `json:"expires_at,omitempty"` // 压缩包保留截止时间
+}
+
+type ExportJobReq struct {
+	ExportID string `uri:"export_id" json:"export_id" binding:"required"`
+}
+
+// ExportDownloadReq 签名下载，expires 为 unix 秒
+type ExportDownloadReq struct {
+	ExportID string `uri:"export_id" binding:"required"`
+	Expires  int64  `form:"expires" binding:"required"`
+	Sig      string `form:"sig" binding:"required"`
+}
+
+// ExportManifest 压缩包内的 manifest.json
+type ExportManifest struct {
+	Format           string               `json:"format"`
+	FormatVersion    int                  `json:"format_version"`
+	ExportedAt       string               `json:"exported_at"`
+	UserID           string               `json:"user_id"`
+	Username         string               `json:"username"`
+	Records          int                  `json:"records"`
+	EncryptedRecords int                  `json:"encrypted_records"` // 端到端加密记录保存在 records/encrypted.json，需客户端解密
+	Reports          int                  `json:"reports"`
+	Files            []ExportManifestFile `json:"files"`
+}
+
+type ExportManifestFile struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"` // record/report/encrypted_records/settings
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
@@ -10,11 +10,25 @@ package v1
 
 // RecordItem 对外返回的工作记录字段
 type RecordItem struct {
-	RecordID  string `json:"record_id" example:"rec_123"`              // 唯一标识
-	Date      string `json:"date" example:"2025-12-11"`                // 日期，格式 YYYY-MM-DD
-	Content   string `json:"content" example:"完成接口定义与联调"`              // 工作内容
-	UpdatedAt string `json:"updatedAt" example:"2025-12-11T10:00:00Z"` // 最近更新时间
-	Version   int    `json:"version" example:"1"`                      // 版本计数
+	RecordID  string          `json:"record_id" example:"rec_123"`              // 唯一标识
+	Date      string          `json:"date" example:"2025-12-11"`                // 日期，格式 YYYY-MM-DD
+	Content   string          `json:"content" example:"完成接口定义与联调"`              // 工作内容
+	UpdatedAt string          `json:"updatedAt" example:"2025-12-11T10:00:00Z"` // 最近更新时间
+	Version   int             `json:"version" example:"1"`                      // 版本计数
+	Encrypted bool            `json:"encrypted"`                                // 为 true 时 content 为 base64 密文
+	Envelope  *RecordEnvelope `json:"envelope,omitempty"`                       // 加密参数，客户端解密使用
+}
+
+const (
+	EncAlgAES256GCM         = "AES-256-GCM"        // nonce 12 字节
+	EncAlgXChaCha20Poly1305 = "XChaCha20-Poly1305" // nonce 24 字节
+)
+
+// RecordEnvelope 端到端加密记录的密文参数，服务端仅校验格式并原样保存
+type RecordEnvelope struct {
+	Alg   string `json:"alg" binding:"required" example:"AES-256-GCM"`
+	Nonce string `json:"nonce" binding:"required" example:"3q2+78r+ur7e/w=="` // base64
+	KeyID string `json:"key_id" binding:"required" example:"k1"`              // 对应用户设置中的加密密钥
 }
 
 // QueryRecordsReq 查询工作记录请求
@@ -34,12 +48,143 @@ type RangeRecordsReq struct {
 
 // UpsertRecordReq 创建或更新工作记录请求
 type UpsertRecordReq struct {
-	Date    string         `json:"date" binding:"required" example:"2025-12-11"` // 记录日期
-	Content string         `json:"content" binding:"required"`                   // 记录内容
-	Meta    map[string]any `json:"meta,omitempty"`
+	Date      string          `json:"date" binding:"required" example:"2025-12-11"` // 记录日期
+	Content   string          `json:"content" binding:"required"`                   // 记录内容
+	Meta      map[string]any  `json:"meta,omitempty"`
+	Version   *int            `json:"version,omitempty" example:"3"` // 编辑所基于的版本号，与服务端不一致时返回冲突；也可通过 If-Match 传入 ETag
+	Encrypted bool            `json:"encrypted"`                     // 为 true 时 content 为 base64 密文，须同时传 envelope
+	Envelope  *RecordEnvelope `json:"envelope,omitempty"`
 }
 
 // DeleteRecordReq 删除工作记录请求
 type DeleteRecordReq struct {
 	RecordID string `uri:"record_id" json:"record_id" binding:"required"` // 路径参数
 }
+
+// RecordRevisionsReq 查询工作记录历史版本请求
+type RecordRevisionsReq struct {
+	RecordID string `uri:"record_id" json:"record_id" binding:"required"`
+}
+
+// RecordRevisionItem 历史版本摘要，不含正文
+type RecordRevisionItem struct {
+	Revision  int    `json:"revision" example:"3"`                      // 版本号，对应被覆盖时的 version
+	WordCount int    `json:"word_count" example:"120"`                  // 字数
+	CreatedAt string `json:"created_at" example:"2025-12-11T10:00:00Z"` // 被覆盖的时间
+}
+
+type RecordRevisionsResp struct {
+	RecordID       string               `json:"record_id"`
+	CurrentVersion int                  `json:"current_version"` // 当前版本号，可作为 diff 的 to
+	Revisions      []RecordRevisionItem `json:"revisions"`       // 按版本倒序
+}
+
+// RecordRevisionDiffReq 对比两个版本，to 不传表示当前版本
+type RecordRevisionDiffReq struct {
+	RecordID string `uri:"record_id" json:"record_id" binding:"required"`
+	From     int    `form:"from" json:"from" binding:"required" example:"2"`
+	To       int    `form:"to" json:"to" example:"3"`
+}
+
+const (
+	RecordDiffEqual  = "equal"
+	RecordDiffInsert = "insert"
+	RecordDiffDelete = "delete"
+)
+
+// RecordDiffItem 按行对比的片段，text 可能包含多行
+type RecordDiffItem struct {
+	Op   string `json:"op" example:"insert"` // equal/insert/delete
+	Text string `json:"text"`
+}
+
+type RecordRevisionDiffResp struct {
+	From    int              `json:"from"`
+	To      int              `json:"to"`
+	Added   int              `json:"added"`   // 新增行数
+	Removed int              `json:"removed"` // 删除行数
+	Diffs   []RecordDiffItem `json:"diffs"`
+}
+
+// RestoreRecordRevisionReq 恢复到指定历史版本，当前内容会先保存为新的历史版本
+type RestoreRecordRevisionReq struct {
+	RecordID string `uri:"record_id" json:"record_id" binding:"required"`
+	Revision int    `uri:"revision" json:"revision" binding:"required"`
+}
+
+type RecordImportPolicy string
+
+const (
+	RecordImportSkip      RecordImportPolicy = "skip"      // 已有记录的日期跳过
+	RecordImportOverwrite RecordImportPolicy = "overwrite" // 覆盖，原内容保存为历史版本
+	RecordImportAppend    RecordImportPolicy = "append"    // 追加到原内容之后
+)
+
+const (
+	RecordImportActionCreate    = "create"
+	RecordImportActionOverwrite = "overwrite"
+	RecordImportActionAppend    = "append"
+	RecordImportActionSkip      = "skip"
+	RecordImportActionInvalid   = "invalid"
+)
+
+type RecordImportStatus string
+
+const (
+	RecordImportStatusRunning RecordImportStatus = "running"
+	RecordImportStatusDone    RecordImportStatus = "done"
+	RecordImportStatusFailed  RecordImportStatus = "failed"
+)
+
+// ImportRecordsReq 批量导入历史记录，文件通过 multipart 字段 file 上传
+type ImportRecordsReq struct {
+	Policy string `form:"policy" json:"policy" example:"skip"` // skip（默认）/overwrite/append
+	DryRun bool   `form:"dry_run" json:"dry_run"`              // 为 true 时只返回预览，不写入
+}
+
+// RecordImportEntry 预览中按日期合并后的一条记录
+type RecordImportEntry struct {
+	Date      string `json:"date" example:"2024-03-05"`
+	Source    string `json:"source" example:"daily/2024-03-05.md"` // 来源文件，多个文件以逗号分隔
+	WordCount int    `json:"word_count"`
+	Action    string `json:"action" example:"create"` // create/overwrite/append/skip/invalid
+	Reason    string `json:"reason,omitempty"`        // 跳过或无效的原因
+}
+
+type RecordImportPreview struct {
+	Total     int                 `json:"total"`
+	Create    int                 `json:"create"`
+	Overwrite int                 `json:"overwrite"`
+	Append    int                 `json:"append"`
+	Skip      int                 `json:"skip"`
+	Invalid   int                 `json:"invalid"`
+	Entries   []RecordImportEntry `json:"entries"`
+}
+
+type RecordImportError struct {
+	Date   string `json:"date"`
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+// RecordImportJob 导入任务进度，processed 达到 total 且 status 为 done 时完成
+type RecordImportJob struct {
+	JobID      string              `json:"job_id" example:"importid_123"`
+	Status     string              `json:"status" example:"running"` // running/done/failed
+	Source     string              `json:"source" example:"notes.zip"`
+	Policy     string              `json:"policy" example:"skip"`
+	Total      int                 `json:"total"`
+	Processed  int                 `json:"processed"`
+	Created    int                 `json:"created"`
+	Updated    int                 `json:"updated"` // 覆盖与追加
+	Skipped    int                 `json:"skipped"`
+	Failed     int                 `json:"failed"` // 含无效条目
+	Errors     []RecordImportError `json:"errors"` // 最多保留前 100 条
+	Error      string              `json:"error,omitempty"`
+	CreatedAt  string              `json:"created_at"`
+	FinishedAt string              `json:"finished_at,omitempty"`
+}
+
+type RecordImportJobReq struct {
+	JobID string `uri:"job_id" json:"job_id" binding:"required"`
+}
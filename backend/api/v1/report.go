@@ -15,9 +15,13 @@ const (
 	ReportTemplateFormal ReportTemplateType = "formal"
 	ReportTemplateSimple ReportTemplateType = "simple"
 
-	ReportPeriodWeek  ReportPeriodType = "week"
-	ReportPeriodMonth ReportPeriodType = "month"
-	ReportPeriodYear  ReportPeriodType = "year"
+	ReportPeriodWeek     ReportPeriodType = "week"
+	ReportPeriodMonth    ReportPeriodType = "month"
+	ReportPeriodQuarter  ReportPeriodType = "quarter"
+	ReportPeriodHalfYear ReportPeriodType = "half_year"
+	ReportPeriodYear     ReportPeriodType = "year"
+	ReportPeriodSprint   ReportPeriodType = "sprint" // 迭代，长度与起始日期见用户设置
+	ReportPeriodCustom   ReportPeriodType = "custom" // 自定义起止日期
 
 	ReportStatusQueued     ReportStatus = "queued"
 	ReportStatusReady      ReportStatus = "ready"
@@ -26,19 +30,99 @@ const (
 )
 
 type ReportItem struct {
-	ReportID     string `json:"report_id" binding:"required"`
-	PeriodType   string `json:"period_type" binding:"required"`
-	StartDate    string `json:"start_date" binding:"required"`
-	EndDate      string `json:"end_date" binding:"required"`
-	Title        string `json:"title" binding:"required"`
-	Content      string `json:"content" binding:"required"`
-	Abstract     string `json:"abstract"`
-	Confirmed    bool   `json:"confirmed"`
-	Template     string `json:"template"`
-	Status       string `json:"status"`
-	FailedReason string `json:"failed_reason,omitempty"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
+	ReportID     string              `json:"report_id" binding:"required"`
+	PeriodType   string              `json:"period_type" binding:"required"`
+	StartDate    string              `json:"start_date" binding:"required"`
+	EndDate      string              `json:"end_date" binding:"required"`
+	Title        string              `json:"title" binding:"required"`
+	Content      string              `json:"content" binding:"required"`
+	Abstract     string              `json:"abstract"`
+	Summary      *ReportSummary      `json:"summary,omitempty"`      // 结构化摘要，旧报告或提取失败时为空
+	Citations    []ReportCitation    `json:"citations,omitempty"`    // 要点到原始记录的引用
+	Verification *ReportVerification `json:"verification,omitempty"` // 事实核对结果，确认前应提示用户复核 flagged
+	Comparison   *ReportBaseline     `json:"comparison,omitempty"`   // 对比生成时的上期报告
+	Confirmed    bool                `json:"confirmed"`
+	ConfirmedAt  string              `json:"confirmed_at,omitempty"` // 确认时间
+	Template     string              `json:"template"`
+	Status       string              `json:"status"`
+	FailedReason string              `json:"failed_reason,omitempty"`
+	LLMModel     string              `json:"llm_model,omitempty"`    // 最终应答的模型
+	LLMAttempts  []LLMAttempt        `json:"llm_attempts,omitempty"` // 最近一次生成的调用记录
+	Version      int                 `json:"version"`                // 手工编辑版本号
+	GenVersion   int                 `json:"gen_version"`            // 生成版本号
+	CreatedAt    string              `json:"created_at"`
+	UpdatedAt    string              `json:"updated_at"`
+}
+
+// ReportSummary 报告结构化摘要
+type ReportSummary struct {
+	Summary    string                   `json:"summary"`              // 一句话概述
+	KeyOutputs []string                 `json:"key_outputs"`          // 关键产出
+	Metrics    []ReportMetric           `json:"metrics"`              // 量化数据
+	Risks      []string                 `json:"risks"`                // 问题与风险
+	NextSteps  []string                 `json:"next_steps"`           // 后续计划
+	Projects   []string                 `json:"projects"`             // 涉及的项目
+	Comparison *ReportSummaryComparison `json:"comparison,omitempty"` // 与上期对比，仅对比生成的报告
+}
+
+type ReportSummaryComparison struct {
+	Continued []string `json:"continued"` // 持续推进
+	Started   []string `json:"started"`   // 新增事项
+	Dropped   []string `json:"dropped"`   // 未延续事项
+	Trends    []string `json:"trends"`    // 变化趋势
+}
+
+// ReportBaseline 对比基准：上一周期已确认的同类报告
+type ReportBaseline struct {
+	ReportID   string `json:"report_id"`
+	PeriodType string `json:"period_type"`
+	StartDate  string `json:"start_date"`
+	EndDate    string `json:"end_date"`
+	Title      string `json:"title"`
+	Version    int    `json:"version"` // 生成时上期报告的编辑版本号
+}
+
+type ReportMetric struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ReportCitation 正文中一条要点引用的记录
+type ReportCitation struct {
+	Line    int              `json:"line"` // 正文行号，从 1 开始
+	Text    string           `json:"text"` // 要点文本（不含列表标记）
+	Records []CitationRecord `json:"records"`
+}
+
+type CitationRecord struct {
+	RecordID string `json:"record_id"`
+	Date     string `json:"date"`
+}
+
+// ReportVerification 生成后的事实核对结果
+type ReportVerification struct {
+	Mode    string        `json:"mode"`    // lexical 或 llm
+	Checked int           `json:"checked"` // 核对的事实声明数
+	Flagged []ReportClaim `json:"flagged"` // 输入记录中找不到依据的声明
+}
+
+// ReportClaim 一条待复核的事实声明
+type ReportClaim struct {
+	Line    int    `json:"line"`    // 正文行号，从 1 开始
+	Claim   string `json:"claim"`   // 声明片段
+	Context string `json:"context"` // 所在要点文本
+	Kind    string `json:"kind"`    // number、date、term、person
+	Reason  string `json:"reason"`
+}
+
+// LLMAttempt 单次模型调用记录
+type LLMAttempt struct {
+	Provider  string `json:"provider"`
+	Model     string `json:"model"`
+	Try       int    `json:"try"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Skipped   bool   `json:"skipped,omitempty"` // 熔断跳过
 }
 
 type GetReportsReq struct {
@@ -64,6 +148,18 @@ type GenReportReq struct {
 	StartDate  string `json:"start_date" binding:"required" example:"2025-12-01"`
 	EndDate    string `json:"end_date" binding:"required" example:"2025-12-31"`
 	Template   string `json:"template" binding:"required" example:"formal"`
+	// 与上一周期已确认的同类报告对比，上一周期没有已确认报告时返回错误
+	Compare bool `json:"compare,omitempty"`
+	// 报告已确认时需显式传 true 才会重新生成，原正文保留在历史版本中
+	OverwriteConfirmed bool `json:"overwrite_confirmed,omitempty"`
+	// 周期内有加密记录时，由客户端解密后随请求提交，仅用于本次生成，不落库
+	DecryptedRecords []DecryptedRecord `json:"decrypted_records,omitempty"`
+}
+
+// DecryptedRecord 客户端解密后的记录明文
+type DecryptedRecord struct {
+	RecordID string `json:"record_id" binding:"required"`
+	Content  string `json:"content" binding:"required"`
 }
 
 type GenReportResp struct {
@@ -71,10 +167,169 @@ type GenReportResp struct {
 }
 
 type EditReportReq struct {
-	ReportID string `json:"report_id" binding:"required"`
-	Content  string `json:"content" binding:"required"`
+	ReportID   string `json:"report_id" binding:"required"`
+	Content    string `json:"content" binding:"required"`
+	Version    *int   `json:"version,omitempty" example:"1"`     // 编辑所基于的版本，与服务端不一致时返回冲突；也可通过 If-Match 传入 ETag
+	GenVersion *int   `json:"gen_version,omitempty" example:"2"` // 期间被重新生成同样视为冲突
 }
 
 type ConfirmReportReq struct {
-	ReportID string `json:"report_id" binding:"required"`
+	ReportID   string `json:"report_id" binding:"required"`
+	Version    *int   `json:"version,omitempty" example:"1"`     // 确认所基于的版本，与服务端不一致时返回冲突；也可通过 If-Match 传入 ETag
+	GenVersion *int   `json:"gen_version,omitempty" example:"2"` // 期间被重新生成同样视为冲突
+}
+
+const (
+	ReportAuthorLLM  = "llm"
+	ReportAuthorUser = "user"
+
+	ReportVersionGenerate = "generate"
+	ReportVersionEdit     = "edit"
+	ReportVersionRestore  = "restore"
+)
+
+type ReportVersionsReq struct {
+	ReportID string `uri:"report_id" json:"report_id" binding:"required"`
+}
+
+// ReportVersionItem 报告历史版本摘要，不含正文
+type ReportVersionItem struct {
+	Seq        int    `json:"seq" example:"3"`
+	Author     string `json:"author" example:"llm"`      // llm/user
+	Action     string `json:"action" example:"generate"` // generate/edit/restore
+	Version    int    `json:"version"`                   // 写入时报告的编辑版本号
+	GenVersion int    `json:"gen_version"`               // 写入时报告的生成版本号
+	LLMModel   string `json:"llm_model,omitempty"`       // 生成所用模型
+	Template   string `json:"template"`                  // 生成所用模板
+	CreatedAt  string `json:"created_at" example:"2025-12-11T10:00:00Z"`
+}
+
+type ReportVersionsResp struct {
+	ReportID string              `json:"report_id"`
+	Current  int                 `json:"current"`  // 与当前正文一致的版本序号，报告正在生成或尚无版本时为 0
+	Versions []ReportVersionItem `json:"versions"` // 按序号倒序
+}
+
+// ReportVersionDiffReq 对比两个版本，to 不传表示最新版本
+type ReportVersionDiffReq struct {
+	ReportID string `uri:"report_id" json:"report_id" binding:"required"`
+	From     int    `form:"from" json:"from" binding:"required" example:"1"`
+	To       int    `form:"to" json:"to" example:"2"`
+}
+
+type ReportVersionDiffResp struct {
+	From    int              `json:"from"`
+	To      int              `json:"to"`
+	Added   int              `json:"added"`   // 新增行数
+	Removed int              `json:"removed"` // 删除行数
+	Diffs   []RecordDiffItem `json:"diffs"`
+}
+
+// RestoreReportVersionReq 以指定版本的正文作为一次编辑写回，报告需重新确认
+type RestoreReportVersionReq struct {
+	ReportID string `uri:"report_id" json:"report_id" binding:"required"`
+	Seq      int    `uri:"seq" json:"seq" binding:"required"`
+}
+
+// RewriteReportReq 改写报告中的一个章节或文本片段；heading 与 text 二选一，须在正文中唯一出现
+type RewriteReportReq struct {
+	ReportID    string `uri:"report_id" json:"-" binding:"required"`
+	Heading     string `json:"heading,omitempty" example:"## 本周工作"` // 按标题定位，改写该标题及其下级内容，可不带 #
+	Text        string `json:"text,omitempty"`                      // 按原文片段定位
+	Instruction string `json:"instruction" binding:"required,max=500" example:"更简洁一些"`
+	Version     *int   `json:"version,omitempty" example:"1"` // 改写所基于的版本，与服务端不一致时返回冲突
+	GenVersion  *int   `json:"gen_version,omitempty" example:"2"`
+}
+
+// RewriteReportResp 改写建议，不修改报告；接受时把 content 连同 version/gen_version 提交到 /reports/edit
+type RewriteReportResp struct {
+	ReportID    string `json:"report_id"`
+	Version     int    `json:"version"`
+	GenVersion  int    `json:"gen_version"`
+	StartLine   int    `json:"start_line"` // 被替换内容在当前正文中的起止行号，从 1 开始
+	EndLine     int    `json:"end_line"`
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+	Content     string `json:"content"` // 替换后的完整正文
+	LLMModel    string `json:"llm_model"`
+}
+
+type ReportExportFormat string
+
+const (
+	ReportExportPDF      ReportExportFormat = "pdf"
+	ReportExportDOCX     ReportExportFormat = "docx"
+	ReportExportHTML     ReportExportFormat = "html"
+	ReportExportMarkdown ReportExportFormat = "md"
+)
+
+type ExportReportReq struct {
+	ReportID string `uri:"report_id" binding:"required"`
+	Format   string `form:"format"`   // pdf/docx/html/md，默认 md
+	Branding string `form:"branding"` // 品牌模板名，对应 report.export.branding_dir 下的同名 .html/.docx 文件
+}
+
+type StreamReportReq struct {
+	ReportID string `uri:"report_id" json:"report_id" binding:"required"`
+	Offset   int    `form:"offset" json:"offset" example:"0"` // 已接收的正文字符数，断线重连时续传（也可通过 Last-Event-ID 传入）
+}
+
+const (
+	ReportStreamDelta = "delta" // 正文增量
+	ReportStreamReset = "reset" // 报告被重新生成，客户端清空已接收内容
+	ReportStreamDone  = "done"  // 生成完成，附带完整报告
+	ReportStreamError = "error" // 生成失败
+)
+
+// ReportStreamEvent SSE 事件，Offset 为截至本事件已下发的正文字符数，作为 SSE id
+type ReportStreamEvent struct {
+	Event  string
+	Offset int
+	Data   any
+}
+
+type ReportStreamDeltaData struct {
+	Content string `json:"content"`
+}
+
+type ReportStreamResetData struct {
+	GenVersion int `json:"gen_version"`
+}
+
+type ReportStreamErrorData struct {
+	FailedReason string `json:"failed_reason"`
+}
+
+type GetReportJobsReq struct {
+	ReportID string `uri:"report_id" json:"report_id" binding:"required"`
+}
+
+// ReportJobItem 一次生成任务，按 gen_version 倒序返回
+type ReportJobItem struct {
+	ReportJobID  string                 `json:"report_job_id"`
+	GenVersion   int                    `json:"gen_version"`
+	Template     string                 `json:"template"`
+	Status       string                 `json:"status"`
+	LLMModel     string                 `json:"llm_model,omitempty"`
+	SystemPrompt string                 `json:"system_prompt"`
+	Prompt       string                 `json:"prompt"`
+	Result       string                 `json:"result,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+	Attempts     []ReportJobAttemptItem `json:"attempts"`
+	CreatedAt    string                 `json:"created_at"`
+	UpdatedAt    string                 `json:"updated_at"`
+}
+
+type ReportJobAttemptItem struct {
+	Provider         string `json:"provider"`
+	Model            string `json:"model"`
+	Try              int    `json:"try"`
+	LatencyMs        int64  `json:"latency_ms"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	TotalTokens      int    `json:"total_tokens"`
+	Raw              string `json:"raw,omitempty"` // 厂商原始响应
+	Error            string `json:"error,omitempty"`
+	Skipped          bool   `json:"skipped,omitempty"` // 熔断跳过
+	CreatedAt        string `json:"created_at"`
 }
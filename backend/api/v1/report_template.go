@@ -0,0 +1,28 @@
+package v1
+
+// ReportTemplateItem 报告模板；builtin 为内置模板，只读
+type ReportTemplateItem struct {
+	TemplateID   string   `json:"template_id"`
+	Name         string   `json:"name"`
+	PeriodTypes  []string `json:"period_types"`
+	SystemPrompt string   `json:"system_prompt"`
+	Sections     []string `json:"sections"`
+	Builtin      bool     `json:"builtin"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+}
+
+type GetReportTemplatesReq struct {
+	PeriodType string `form:"period_type"` // 只返回适用于该周期的模板
+}
+
+type ReportTemplateReq struct {
+	TemplateID string `uri:"template_id" binding:"required"`
+}
+
+type SaveReportTemplateReq struct {
+	Name         string   `json:"name" binding:"required" example:"研发周报"`
+	PeriodTypes  []string `json:"period_types" binding:"required" example:"week,month"`
+	SystemPrompt string   `json:"system_prompt" binding:"required"`
+	Sections     []string `json:"sections" example:"产出,风险,下周计划"` // 必须输出的章节，按顺序输出为二级标题
+}
@@ -0,0 +1,38 @@
+package v1
+
+const (
+	SearchTypeAll    = "all"
+	SearchTypeRecord = "record"
+	SearchTypeReport = "report"
+)
+
+type SearchReq struct {
+	Q          string `form:"q" json:"q" binding:"required" example:"联调"`
+	Type       string `form:"type" json:"type" example:"all"`                    // all/record/report，默认 all
+	StartDate  string `form:"start_date" json:"start_date" example:"2025-12-01"` // 记录日期或报告周期落在该范围内
+	EndDate    string `form:"end_date" json:"end_date" example:"2025-12-31"`
+	PeriodType string `form:"period_type" json:"period_type" example:"week"` // 仅筛选报告
+	Page       int    `form:"page" json:"page" example:"1"`
+	PageSize   int    `form:"page_size" json:"page_size" example:"20"` // 最大 50
+}
+
+type SearchResp struct {
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+	Partial  bool         `json:"partial,omitempty"` // 服务端静态加密的内容超出单次解密上限，结果不完整
+	Items    []SearchItem `json:"items"`
+}
+
+// SearchItem 按相关度倒序；snippet 已做 HTML 转义，命中词以 <mark></mark> 包裹
+type SearchItem struct {
+	Type       string  `json:"type"` // record/report
+	ID         string  `json:"id"`   // record_id 或 report_id
+	Date       string  `json:"date,omitempty"`
+	PeriodType string  `json:"period_type,omitempty"`
+	StartDate  string  `json:"start_date,omitempty"`
+	EndDate    string  `json:"end_date,omitempty"`
+	Title      string  `json:"title,omitempty"`
+	Snippet    string  `json:"snippet"`
+	Score      float64 `json:"score"`
+}
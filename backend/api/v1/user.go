@@ -26,11 +26,36 @@ type LoginResp struct {
 }
 
 type UserSettings struct {
-	UserID              string `json:"user_id" binding:"required"`
-	ReportTemplateWeek  string `json:"report_template_week,omitempty"`  // 用户自定义周报提示词模板
-	ReportTemplateMonth string `json:"report_template_month,omitempty"` // 用户自定义月报提示词模板
-	AutoGenerateWeekly  bool   `json:"auto_generate_weekly"`
-	WeeklyReportTime    string `json:"weekly_report_time"`
+	UserID              string         `json:"user_id" binding:"required"`
+	Timezone            string         `json:"timezone" example:"Asia/Shanghai"` // IANA 时区名，日期校验与自动生成均按该时区计算
+	ReportTemplateWeek  string         `json:"report_template_week,omitempty"`   // 用户自定义周报提示词模板
+	ReportTemplateMonth string         `json:"report_template_month,omitempty"`  // 用户自定义月报提示词模板
+	AutoGenerateWeekly  bool           `json:"auto_generate_weekly"`
+	WeeklyReportTime    string         `json:"weekly_report_time" example:"22:00"` // 周日该时刻自动生成周报，HH:MM
+	AutoGenerateMonthly bool           `json:"auto_generate_monthly"`
+	MonthlyReportTime   string         `json:"monthly_report_time" example:"22:00"`     // 每月最后一天该时刻自动生成月报，HH:MM
+	SprintLengthDays    int            `json:"sprint_length_days" example:"14"`         // 迭代长度（天），默认 14
+	SprintAnchorDate    string         `json:"sprint_anchor_date" example:"2025-01-06"` // 任一迭代的起始日期，迭代按长度由此前后排布；为空时迭代须从周一开始
+	Encryption          *EncryptionKey `json:"encryption,omitempty"`                    // 只读，通过 PUT /user/settings/encryption 设置
+}
+
+const (
+	EncKDFPBKDF2SHA256 = "PBKDF2-SHA256"
+	EncKDFArgon2id     = "Argon2id"
+)
+
+// EncryptionKey 端到端加密密钥的派生参数：客户端用口令 + salt 经 KDF 派生密钥，
+// 其他设备凭相同参数和口令即可派生同一密钥；verifier 为用该密钥加密的固定明文，用于校验口令是否正确
+type EncryptionKey struct {
+	KeyID     string         `json:"key_id" binding:"required" example:"k1"`
+	KDF       string         `json:"kdf" binding:"required" example:"PBKDF2-SHA256"`
+	KDFParams map[string]any `json:"kdf_params" binding:"required"` // 如 {"iterations":600000} 或 {"memory":65536,"iterations":3,"parallelism":1}
+	Salt      string         `json:"salt" binding:"required"`       // base64，至少 16 字节
+	Verifier  string         `json:"verifier" binding:"required"`
+}
+
+type SetEncryptionKeyReq struct {
+	EncryptionKey
 }
 
 type UpdateUserSettingsReq struct {
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"backend/cmd/import/wire"
+	"backend/pkg/config"
+	"backend/pkg/log"
+)
+
+func main() {
+	var envConf = flag.String("conf", "config/local.yml", "config path, eg: -conf ./config/local.yml")
+	var user = flag.String("user", "", "username to import records for")
+	var path = flag.String("path", "", "directory or file (zip/md/markdown/txt) to import")
+	var policy = flag.String("policy", "skip", "conflict policy for existing dates: skip/overwrite/append")
+	var dryRun = flag.Bool("dry-run", false, "print the preview without writing records")
+	flag.Parse()
+	conf := config.NewConfig(*envConf)
+	conf.Set("import.user", *user)
+	conf.Set("import.path", *path)
+	conf.Set("import.policy", *policy)
+	conf.Set("import.dry_run", *dryRun)
+
+	logger := log.NewLog(conf)
+
+	app, cleanup, err := wire.NewWire(conf, logger)
+	defer cleanup()
+	if err != nil {
+		panic(err)
+	}
+	if err = app.Run(context.Background()); err != nil {
+		panic(err)
+	}
+}
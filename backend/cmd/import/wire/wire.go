@@ -0,0 +1,69 @@
+//go:build wireinject
+// +build wireinject
+
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 23:45:52
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 23:45:52
+ */
+
+package wire
+
+import (
+	"backend/internal/repository"
+	"backend/internal/server"
+	"backend/internal/service"
+	"backend/pkg/app"
+	"backend/pkg/jwt"
+	"backend/pkg/keyring"
+	"backend/pkg/log"
+	"backend/pkg/sid"
+
+	"github.com/google/wire"
+	"github.com/spf13/viper"
+)
+
+var repositorySet = wire.NewSet(
+	repository.NewDB,
+	keyring.NewKeyring,
+	repository.NewRepository,
+	repository.NewTransaction,
+	repository.NewUserRepository,
+	repository.NewUserSettingsRepository,
+	repository.NewRecordRepository,
+	repository.NewRecordRevisionRepository,
+	repository.NewRecordImportRepository,
+)
+
+var serviceSet = wire.NewSet(
+	service.NewService,
+	service.NewRecordService,
+	service.NewRecordImportService,
+)
+
+var serverSet = wire.NewSet(
+	server.NewImportServer,
+)
+
+// build App
+func newApp(
+	importServer *server.ImportServer,
+) *app.App {
+	return app.NewApp(
+		app.WithServer(importServer),
+		app.WithName("import"),
+	)
+}
+
+func NewWire(*viper.Viper, *log.Logger) (*app.App, func(), error) {
+	panic(wire.Build(
+		repositorySet,
+		serviceSet,
+		serverSet,
+		sid.NewSid,
+		jwt.NewJwt,
+		newApp,
+	))
+}
@@ -0,0 +1,58 @@
+// Code generated by Wire. DO NOT EDIT.
+
+//go:generate go run -mod=mod github.com/google/wire/cmd/wire
+//go:build !wireinject
+// +build !wireinject
+
+package wire
+
+import (
+	"backend/internal/repository"
+	"backend/internal/server"
+	"backend/internal/service"
+	"backend/pkg/app"
+	"backend/pkg/jwt"
+	"backend/pkg/keyring"
+	"backend/pkg/log"
+	"backend/pkg/sid"
+	"github.com/google/wire"
+	"github.com/spf13/viper"
+)
+
+// Injectors from wire.go:
+
+func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
+	db := repository.NewDB(viperViper, logger)
+	keyringKeyring := keyring.NewKeyring(viperViper)
+	repositoryRepository := repository.NewRepository(logger, db, keyringKeyring)
+	transaction := repository.NewTransaction(repositoryRepository)
+	sidSid := sid.NewSid()
+	jwtJWT := jwt.NewJwt(viperViper)
+	serviceService := service.NewService(transaction, logger, sidSid, jwtJWT)
+	recordRespository := repository.NewRecordRepository(repositoryRepository)
+	userSettingsRepository := repository.NewUserSettingsRepository(repositoryRepository)
+	recordRevisionRepository := repository.NewRecordRevisionRepository(repositoryRepository)
+	recordService := service.NewRecordService(serviceService, recordRespository, userSettingsRepository, recordRevisionRepository)
+	recordImportRepository := repository.NewRecordImportRepository(repositoryRepository)
+	recordImportService := service.NewRecordImportService(serviceService, recordService, recordImportRepository, userSettingsRepository)
+	userRepository := repository.NewUserRepository(repositoryRepository)
+	importServer := server.NewImportServer(viperViper, recordImportService, userRepository, logger)
+	appApp := newApp(importServer)
+	return appApp, func() {
+	}, nil
+}
+
+// wire.go:
+
+var repositorySet = wire.NewSet(repository.NewDB, keyring.NewKeyring, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewUserSettingsRepository, repository.NewRecordRepository, repository.NewRecordRevisionRepository, repository.NewRecordImportRepository)
+
+var serviceSet = wire.NewSet(service.NewService, service.NewRecordService, service.NewRecordImportService)
+
+var serverSet = wire.NewSet(server.NewImportServer)
+
+// build App
+func newApp(
+	importServer *server.ImportServer,
+) *app.App {
+	return app.NewApp(app.WithServer(importServer), app.WithName("import"))
+}
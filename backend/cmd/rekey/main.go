@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"backend/cmd/rekey/wire"
+	"backend/pkg/config"
+	"backend/pkg/log"
+)
+
+func main() {
+	var envConf = flag.String("conf", "config/local.yml", "config path, eg: -conf ./config/local.yml")
+	var rotate = flag.Bool("rotate-data-keys", false, "generate a new data key version for every user before re-encrypting")
+	var batch = flag.Int("batch", 0, "rows per batch, default encryption.rekey.batch or 200")
+	flag.Parse()
+	conf := config.NewConfig(*envConf)
+	if *rotate {
+		conf.Set("encryption.rekey.rotate_data_keys", true)
+	}
+	if *batch > 0 {
+		conf.Set("encryption.rekey.batch", *batch)
+	}
+
+	logger := log.NewLog(conf)
+
+	app, cleanup, err := wire.NewWire(conf, logger)
+	defer cleanup()
+	if err != nil {
+		panic(err)
+	}
+	if err = app.Run(context.Background()); err != nil {
+		panic(err)
+	}
+}
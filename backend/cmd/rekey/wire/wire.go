@@ -0,0 +1,51 @@
+//go:build wireinject
+// +build wireinject
+
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 22:52:14
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 22:52:14
+ */
+
+package wire
+
+import (
+	"backend/internal/repository"
+	"backend/internal/server"
+	"backend/pkg/app"
+	"backend/pkg/keyring"
+	"backend/pkg/log"
+
+	"github.com/google/wire"
+	"github.com/spf13/viper"
+)
+
+var repositorySet = wire.NewSet(
+	repository.NewDB,
+	keyring.NewKeyring,
+	repository.NewRepository,
+	repository.NewRekeyRepository,
+)
+var serverSet = wire.NewSet(
+	server.NewRekeyServer,
+)
+
+// build App
+func newApp(
+	rekeyServer *server.RekeyServer,
+) *app.App {
+	return app.NewApp(
+		app.WithServer(rekeyServer),
+		app.WithName("rekey"),
+	)
+}
+
+func NewWire(*viper.Viper, *log.Logger) (*app.App, func(), error) {
+	panic(wire.Build(
+		repositorySet,
+		serverSet,
+		newApp,
+	))
+}
@@ -0,0 +1,43 @@
+// Code generated by Wire. DO NOT EDIT.
+
+//go:generate go run -mod=mod github.com/google/wire/cmd/wire
+//go:build !wireinject
+// +build !wireinject
+
+package wire
+
+import (
+	"backend/internal/repository"
+	"backend/internal/server"
+	"backend/pkg/app"
+	"backend/pkg/keyring"
+	"backend/pkg/log"
+	"github.com/google/wire"
+	"github.com/spf13/viper"
+)
+
+// Injectors from wire.go:
+
+func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
+	db := repository.NewDB(viperViper, logger)
+	keyringKeyring := keyring.NewKeyring(viperViper)
+	repositoryRepository := repository.NewRepository(logger, db, keyringKeyring)
+	rekeyRepository := repository.NewRekeyRepository(repositoryRepository)
+	rekeyServer := server.NewRekeyServer(viperViper, rekeyRepository, logger)
+	appApp := newApp(rekeyServer)
+	return appApp, func() {
+	}, nil
+}
+
+// wire.go:
+
+var repositorySet = wire.NewSet(repository.NewDB, keyring.NewKeyring, repository.NewRepository, repository.NewRekeyRepository)
+
+var serverSet = wire.NewSet(server.NewRekeyServer)
+
+// build App
+func newApp(
+	rekeyServer *server.RekeyServer,
+) *app.App {
+	return app.NewApp(app.WithServer(rekeyServer), app.WithName("rekey"))
+}
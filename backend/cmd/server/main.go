@@ -38,13 +38,17 @@ func main() {
 	flag.Parse()
 	conf := config.NewConfig(*envConf)
 
+	// MODEL_API_KEY 缺失不阻断启动，依赖 key 的供应商在调用时报错
 	apiKey := os.Getenv("MODEL_API_KEY")
-	if apiKey == "" {
-		panic("MODEL_API_KEY 未配置，禁止启动服务")
+	if apiKey != "" {
+		conf.Set("llm.api_key", apiKey)
 	}
-	conf.Set("llm.openai.api_key", apiKey)
 	logger := log.NewLog(conf)
-	logger.Info("MODEL_API_KEY 已加载", zap.String("masked", maskKey(apiKey)))
+	if apiKey == "" {
+		logger.Warn("MODEL_API_KEY 未配置", zap.String("provider", conf.GetString("llm.provider")))
+	} else {
+		logger.Info("MODEL_API_KEY 已加载", zap.String("masked", maskKey(apiKey)))
+	}
 
 	app, cleanup, err := wire.NewWire(conf, logger)
 	defer cleanup()
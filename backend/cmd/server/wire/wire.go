@@ -21,6 +21,7 @@ import (
 	"backend/internal/service"
 	"backend/pkg/app"
 	"backend/pkg/jwt"
+	"backend/pkg/keyring"
 	"backend/pkg/log"
 	"backend/pkg/server/http"
 	"backend/pkg/sid"
@@ -31,6 +32,7 @@ import (
 
 var repositorySet = wire.NewSet(
 	repository.NewDB,
+	keyring.NewKeyring,
 	//repository.NewRedis,
 	//repository.NewMongo,
 	repository.NewRepository,
@@ -39,6 +41,13 @@ var repositorySet = wire.NewSet(
 	repository.NewUserSettingsRepository,
 	repository.NewRecordRepository,
 	repository.NewReportRepository,
+	repository.NewReportJobRepository,
+	repository.NewReportVersionRepository,
+	repository.NewRecordRevisionRepository,
+	repository.NewSearchRepository,
+	repository.NewRecordImportRepository,
+	repository.NewExportJobRepository,
+	repository.NewReportTemplateRepository,
 )
 
 var serviceSet = wire.NewSet(
@@ -47,7 +56,12 @@ var serviceSet = wire.NewSet(
 	service.NewRecordService,
 	service.NewReportService,
 	service.NewDashboardService,
-	llm.NewOpenAIClient,
+	service.NewSearchService,
+	service.NewRecordImportService,
+	service.NewExportService,
+	service.NewReportExportService,
+	service.NewReportTemplateService,
+	llm.NewProvider,
 )
 
 var handlerSet = wire.NewSet(
@@ -56,6 +70,10 @@ var handlerSet = wire.NewSet(
 	handler.NewRecordHandler,
 	handler.NewReportHandler,
 	handler.NewDashboardHandler,
+	handler.NewSearchHandler,
+	handler.NewRecordImportHandler,
+	handler.NewExportHandler,
+	handler.NewReportTemplateHandler,
 )
 
 var jobSet = wire.NewSet(
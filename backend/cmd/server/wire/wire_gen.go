@@ -16,6 +16,7 @@ import (
 	"backend/internal/service"
 	"backend/pkg/app"
 	"backend/pkg/jwt"
+	"backend/pkg/keyring"
 	"backend/pkg/log"
 	"backend/pkg/server/http"
 	"backend/pkg/sid"
@@ -29,7 +30,8 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 	jwtJWT := jwt.NewJwt(viperViper)
 	handlerHandler := handler.NewHandler(logger)
 	db := repository.NewDB(viperViper, logger)
-	repositoryRepository := repository.NewRepository(logger, db)
+	keyringKeyring := keyring.NewKeyring(viperViper)
+	repositoryRepository := repository.NewRepository(logger, db, keyringKeyring)
 	transaction := repository.NewTransaction(repositoryRepository)
 	sidSid := sid.NewSid()
 	serviceService := service.NewService(transaction, logger, sidSid, jwtJWT)
@@ -38,25 +40,42 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 	userService := service.NewUserService(serviceService, userRepository, userSettingsRepository)
 	userHandler := handler.NewUserHandler(handlerHandler, userService)
 	recordRespository := repository.NewRecordRepository(repositoryRepository)
-	recordService := service.NewRecordService(serviceService, recordRespository)
+	recordRevisionRepository := repository.NewRecordRevisionRepository(repositoryRepository)
+	recordService := service.NewRecordService(serviceService, recordRespository, userSettingsRepository, recordRevisionRepository)
 	recordHandler := handler.NewRecordHandler(handlerHandler, recordService)
 	reportRepository := repository.NewReportRepository(repositoryRepository)
-	openAIClient, err := llm.NewOpenAIClient(viperViper)
-	if err != nil {
-		return nil, nil, err
-	}
-	reportService := service.NewReportService(serviceService, reportRepository, recordService, userSettingsRepository, openAIClient)
-	reportHandler := handler.NewReportHandler(handlerHandler, reportService)
-	dashboardService := service.NewDashboardService(serviceService, recordRespository, reportRepository)
+	reportJobRepository := repository.NewReportJobRepository(repositoryRepository)
+	reportVersionRepository := repository.NewReportVersionRepository(repositoryRepository)
+	reportTemplateRepository := repository.NewReportTemplateRepository(repositoryRepository)
+	provider := llm.NewProvider(viperViper, logger)
+	reportService := service.NewReportService(viperViper, serviceService, reportRepository, reportJobRepository, reportVersionRepository, recordService, userSettingsRepository, reportTemplateRepository, provider)
+	reportExportService := service.NewReportExportService(viperViper, serviceService, reportRepository, userRepository, userSettingsRepository)
+	reportHandler := handler.NewReportHandler(handlerHandler, reportService, reportExportService)
+	dashboardService := service.NewDashboardService(serviceService, recordRespository, reportRepository, userSettingsRepository)
 	dashboardHandler := handler.NewDashboardHandler(handlerHandler, dashboardService)
+	searchRepository := repository.NewSearchRepository(repositoryRepository)
+	searchService := service.NewSearchService(serviceService, searchRepository)
+	searchHandler := handler.NewSearchHandler(handlerHandler, searchService)
+	recordImportRepository := repository.NewRecordImportRepository(repositoryRepository)
+	recordImportService := service.NewRecordImportService(serviceService, recordService, recordImportRepository, userSettingsRepository)
+	recordImportHandler := handler.NewRecordImportHandler(handlerHandler, recordImportService)
+	exportJobRepository := repository.NewExportJobRepository(repositoryRepository)
+	exportService := service.NewExportService(viperViper, serviceService, exportJobRepository, userRepository, userSettingsRepository, reportRepository, recordService)
+	exportHandler := handler.NewExportHandler(handlerHandler, exportService)
+	reportTemplateService := service.NewReportTemplateService(serviceService, reportTemplateRepository)
+	reportTemplateHandler := handler.NewReportTemplateHandler(handlerHandler, reportTemplateService)
 	routerDeps := router.RouterDeps{
-		Logger:           logger,
-		Config:           viperViper,
-		JWT:              jwtJWT,
-		UserHandler:      userHandler,
-		RecordHandler:    recordHandler,
-		ReportHandler:    reportHandler,
-		DashboardHandler: dashboardHandler,
+		Logger:                logger,
+		Config:                viperViper,
+		JWT:                   jwtJWT,
+		UserHandler:           userHandler,
+		RecordHandler:         recordHandler,
+		ReportHandler:         reportHandler,
+		DashboardHandler:      dashboardHandler,
+		SearchHandler:         searchHandler,
+		RecordImportHandler:   recordImportHandler,
+		ExportHandler:         exportHandler,
+		ReportTemplateHandler: reportTemplateHandler,
 	}
 	httpServer := server.NewHTTPServer(routerDeps)
 	jobJob := job.NewJob(transaction, logger, sidSid)
@@ -69,11 +88,11 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 
 // wire.go:
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewUserSettingsRepository, repository.NewRecordRepository, repository.NewReportRepository)
+var repositorySet = wire.NewSet(repository.NewDB, keyring.NewKeyring, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewUserSettingsRepository, repository.NewRecordRepository, repository.NewReportRepository, repository.NewReportJobRepository, repository.NewReportVersionRepository, repository.NewRecordRevisionRepository, repository.NewSearchRepository, repository.NewRecordImportRepository, repository.NewExportJobRepository, repository.NewReportTemplateRepository)
 
-var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewRecordService, service.NewReportService, service.NewDashboardService, llm.NewOpenAIClient)
+var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewRecordService, service.NewReportService, service.NewDashboardService, service.NewSearchService, service.NewRecordImportService, service.NewExportService, service.NewReportExportService, service.NewReportTemplateService, llm.NewProvider)
 
-var handlerSet = wire.NewSet(handler.NewHandler, handler.NewUserHandler, handler.NewRecordHandler, handler.NewReportHandler, handler.NewDashboardHandler)
+var handlerSet = wire.NewSet(handler.NewHandler, handler.NewUserHandler, handler.NewRecordHandler, handler.NewReportHandler, handler.NewDashboardHandler, handler.NewSearchHandler, handler.NewRecordImportHandler, handler.NewExportHandler, handler.NewReportTemplateHandler)
 
 var jobSet = wire.NewSet(job.NewJob, job.NewUserJob)
 
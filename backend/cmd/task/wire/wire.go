@@ -19,6 +19,7 @@ import (
 	"backend/internal/task"
 	"backend/pkg/app"
 	"backend/pkg/jwt"
+	"backend/pkg/keyring"
 	"backend/pkg/log"
 	"backend/pkg/sid"
 
@@ -28,6 +29,7 @@ import (
 
 var repositorySet = wire.NewSet(
 	repository.NewDB,
+	keyring.NewKeyring,
 	//repository.NewRedis,
 	repository.NewRepository,
 	repository.NewTransaction,
@@ -35,19 +37,24 @@ var repositorySet = wire.NewSet(
 	repository.NewUserSettingsRepository,
 	repository.NewRecordRepository,
 	repository.NewReportRepository,
+	repository.NewReportJobRepository,
+	repository.NewReportVersionRepository,
+	repository.NewRecordRevisionRepository,
+	repository.NewReportTemplateRepository,
 )
 
 var serviceSet = wire.NewSet(
 	service.NewService,
 	service.NewRecordService,
 	service.NewReportService,
-	llm.NewOpenAIClient,
+	llm.NewProvider,
 )
 
 var taskSet = wire.NewSet(
 	task.NewTask,
 	task.NewUserTask,
 	task.NewReportTask,
+	task.NewRecordTask,
 )
 var serverSet = wire.NewSet(
 	server.NewTaskServer,
@@ -14,6 +14,7 @@ import (
 	"backend/internal/task"
 	"backend/pkg/app"
 	"backend/pkg/jwt"
+	"backend/pkg/keyring"
 	"backend/pkg/log"
 	"backend/pkg/sid"
 	"github.com/google/wire"
@@ -24,25 +25,28 @@ import (
 
 func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
 	db := repository.NewDB(viperViper, logger)
-	repositoryRepository := repository.NewRepository(logger, db)
+	keyringKeyring := keyring.NewKeyring(viperViper)
+	repositoryRepository := repository.NewRepository(logger, db, keyringKeyring)
 	transaction := repository.NewTransaction(repositoryRepository)
 	sidSid := sid.NewSid()
 	taskTask := task.NewTask(transaction, logger, sidSid)
 	userRepository := repository.NewUserRepository(repositoryRepository)
 	userTask := task.NewUserTask(taskTask, userRepository)
-	reportRepository := repository.NewReportRepository(repositoryRepository)
 	jwtJWT := jwt.NewJwt(viperViper)
 	serviceService := service.NewService(transaction, logger, sidSid, jwtJWT)
+	reportRepository := repository.NewReportRepository(repositoryRepository)
+	reportJobRepository := repository.NewReportJobRepository(repositoryRepository)
+	reportVersionRepository := repository.NewReportVersionRepository(repositoryRepository)
 	recordRespository := repository.NewRecordRepository(repositoryRepository)
-	recordService := service.NewRecordService(serviceService, recordRespository)
 	userSettingsRepository := repository.NewUserSettingsRepository(repositoryRepository)
-	openAIClient, err := llm.NewOpenAIClient(viperViper)
-	if err != nil {
-		return nil, nil, err
-	}
-	reportService := service.NewReportService(serviceService, reportRepository, recordService, userSettingsRepository, openAIClient)
-	reportTask := task.NewReportTask(taskTask, reportRepository, reportService)
-	taskServer := server.NewTaskServer(logger, userTask, reportTask)
+	recordRevisionRepository := repository.NewRecordRevisionRepository(repositoryRepository)
+	recordService := service.NewRecordService(serviceService, recordRespository, userSettingsRepository, recordRevisionRepository)
+	reportTemplateRepository := repository.NewReportTemplateRepository(repositoryRepository)
+	provider := llm.NewProvider(viperViper, logger)
+	reportService := service.NewReportService(viperViper, serviceService, reportRepository, reportJobRepository, reportVersionRepository, recordService, userSettingsRepository, reportTemplateRepository, provider)
+	reportTask := task.NewReportTask(viperViper, taskTask, reportService)
+	recordTask := task.NewRecordTask(viperViper, taskTask, recordService)
+	taskServer := server.NewTaskServer(viperViper, logger, userTask, reportTask, recordTask)
 	appApp := newApp(taskServer)
 	return appApp, func() {
 	}, nil
@@ -50,11 +54,11 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 
 // wire.go:
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewUserSettingsRepository, repository.NewRecordRepository, repository.NewReportRepository)
+var repositorySet = wire.NewSet(repository.NewDB, keyring.NewKeyring, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewUserSettingsRepository, repository.NewRecordRepository, repository.NewReportRepository, repository.NewReportJobRepository, repository.NewReportVersionRepository, repository.NewRecordRevisionRepository, repository.NewReportTemplateRepository)
 
-var serviceSet = wire.NewSet(service.NewService, service.NewRecordService, service.NewReportService, llm.NewOpenAIClient)
+var serviceSet = wire.NewSet(service.NewService, service.NewRecordService, service.NewReportService, llm.NewProvider)
 
-var taskSet = wire.NewSet(task.NewTask, task.NewUserTask, task.NewReportTask)
+var taskSet = wire.NewSet(task.NewTask, task.NewUserTask, task.NewReportTask, task.NewRecordTask)
 
 var serverSet = wire.NewSet(server.NewTaskServer)
 
@@ -26,11 +26,6 @@ const docTemplate = `{
     "paths": {
         "/dashboard/month": {
             "get": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
                 "consumes": [
                     "application/json"
                 ],
@@ -57,38 +52,17 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.Response"
                         }
                     }
-                }
-            }
-        },
-        "/dashboard/summary": {
-            "get": {
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "看板"
-                ],
-                "summary": "获取看板汇总数据",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/v1.Response"
-                        }
-                    }
-                }
+                ]
             }
         },
-        "/login": {
-            "post": {
+        "/dashboard/period": {
+            "get": {
+                "description": "返回 date 所在周期（custom 为 start_date~end_date）的起止日期、记录完成情况、已确认的月报/周报数及该周期的报告状态",
                 "consumes": [
                     "application/json"
                 ],
@@ -96,38 +70,65 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户模块"
+                    "看板"
                 ],
-                "summary": "账号登录",
+                "summary": "获取报告周期看板数据",
                 "parameters": [
                     {
-                        "description": "params",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.LoginReq"
-                        }
+                        "type": "string",
+                        "description": "周期类型：week/month/quarter/half_year/year/sprint/custom",
+                        "name": "period_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "周期内任一天，格式YYYY-MM-DD，默认今天",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "custom 的开始日期",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "custom 的结束日期",
+                        "name": "end_date",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/v1.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/v1.PeriodDashboardResp"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     }
-                }
-            }
-        },
-        "/records": {
-            "get": {
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
-                "description": "date 为空返回当前用户全部记录，传 date 返回单日记录（不存在返回 null）",
+                ]
+            }
+        },
+        "/dashboard/summary": {
+            "get": {
                 "consumes": [
                     "application/json"
                 ],
@@ -135,17 +136,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "工作记录"
-                ],
-                "summary": "查询工作记录（单日或全部）",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "日期，格式 YYYY-MM-DD",
-                        "name": "date",
-                        "in": "query"
-                    }
+                    "看板"
                 ],
+                "summary": "获取看板汇总数据",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -153,15 +146,17 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.Response"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
-                "description": "同一日期多次调用视为更新",
+                ]
+            }
+        },
+        "/export": {
+            "post": {
+                "description": "异步生成 zip：records/ 下每天一个 Markdown，reports/ 下每份报告一个带 YAML front matter 的 Markdown，另含 manifest.json 与 settings.json。已有进行中的导出时返回该任务",
                 "consumes": [
                     "application/json"
                 ],
@@ -169,38 +164,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "工作记录"
-                ],
-                "summary": "创建或更新工作记录",
-                "parameters": [
-                    {
-                        "description": "请求参数",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.UpsertRecordReq"
-                        }
-                    }
+                    "导出"
                 ],
+                "summary": "导出账号全部数据",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.RecordItem"
+                            "$ref": "#/definitions/v1.ExportJob"
                         }
                     }
-                }
-            }
-        },
-        "/records/range": {
-            "get": {
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
-                "description": "start 和 end 需为 YYYY-MM-DD，且 start \u003c end",
+                ]
+            }
+        },
+        "/export/{export_id}": {
+            "get": {
+                "description": "status 为 ready 时返回签名下载链接，链接短时有效，过期后重新查询即可获取新链接",
                 "consumes": [
                     "application/json"
                 ],
@@ -208,22 +192,15 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "工作记录"
+                    "导出"
                 ],
-                "summary": "按时间范围查询工作记录",
+                "summary": "查询导出任务",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "开始日期",
-                        "name": "start",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "结束日期",
-                        "name": "end",
-                        "in": "query",
+                        "description": "导出任务 ID",
+                        "name": "export_id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -231,44 +208,59 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/v1.RecordItem"
-                            }
+                            "$ref": "#/definitions/v1.ExportJob"
                         }
                     }
-                }
-            }
-        },
-        "/records/{record_id}": {
-            "delete": {
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/export/{export_id}/download": {
+            "get": {
+                "description": "使用导出任务返回的签名链接，无需登录态",
                 "produces": [
-                    "application/json"
+                    "application/zip"
                 ],
                 "tags": [
-                    "工作记录"
+                    "导出"
                 ],
-                "summary": "删除工作记录",
+                "summary": "下载导出压缩包",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "记录 ID",
-                        "name": "record_id",
+                        "description": "导出任务 ID",
+                        "name": "export_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "链接过期时间（unix 秒）",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "签名",
+                        "name": "sig",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/v1.Response"
                         }
@@ -276,9 +268,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
+        "/login": {
             "post": {
-                "description": "目前只支持用户名登录",
                 "consumes": [
                     "application/json"
                 ],
@@ -288,7 +279,7 @@ const docTemplate = `{
                 "tags": [
                     "用户模块"
                 ],
-                "summary": "用户注册",
+                "summary": "账号登录",
                 "parameters": [
                     {
                         "description": "params",
@@ -296,7 +287,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.RegisterReq"
+                            "$ref": "#/definitions/v1.LoginReq"
                         }
                     }
                 ],
@@ -310,14 +301,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/reports": {
+        "/records": {
             "get": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "description": "支持按period_type或时间范围筛选",
+                "description": "date 为空返回当前用户全部记录，传 date 返回单日记录（不存在返回 null）",
                 "consumes": [
                     "application/json"
                 ],
@@ -325,27 +311,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "报告"
+                    "工作记录"
                 ],
-                "summary": "获取报告列表",
+                "summary": "查询工作记录（单日或全部）",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "报告类型 week/month/year",
-                        "name": "period_type",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "开始日期",
-                        "name": "start_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "结束日期",
-                        "name": "end_date",
+                        "description": "日期，格式 YYYY-MM-DD",
+                        "name": "date",
                         "in": "query"
                     }
                 ],
@@ -356,17 +329,15 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.Response"
                         }
                     }
-                }
-            }
-        },
-        "/reports/confirm": {
-            "post": {
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
-                "description": "将报告标记为已确认",
+                ]
+            },
+            "post": {
+                "description": "同一日期多次调用视为更新；携带 version（或 If-Match）时，服务端版本不一致返回 409 及 code 2011，data 为服务端当前记录",
                 "consumes": [
                     "application/json"
                 ],
@@ -374,77 +345,99 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "报告"
+                    "工作记录"
                 ],
-                "summary": "确认报告",
+                "summary": "创建或更新工作记录",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "GET 返回的 ETag",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
                     {
                         "description": "请求参数",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.ConfirmReportReq"
+                            "$ref": "#/definitions/v1.UpsertRecordReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.RecordItem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/v1.Response"
                         }
                     }
-                }
-            }
-        },
-        "/reports/edit": {
-            "post": {
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
-                "description": "手动修改报告内容",
+                ]
+            }
+        },
+        "/records/import": {
+            "post": {
+                "description": "上传 zip 或单个 md/markdown/txt 文件；日期取自以日期开头的标题（如 ` + "`" + `## 2024-03-05` + "`" + `），没有日期标题时取自文件名。dry_run=true 只返回预览，否则创建后台导入任务，通过 GET /records/import/{job_id} 查询进度",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "报告"
+                    "工作记录"
                 ],
-                "summary": "编辑报告内容",
+                "summary": "批量导入历史工作记录",
                 "parameters": [
                     {
-                        "description": "请求参数",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.EditReportReq"
-                        }
+                        "type": "file",
+                        "description": "zip 或 md/markdown/txt 文件",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "已有记录的冲突策略：skip（默认）/overwrite/append",
+                        "name": "policy",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只预览不写入",
+                        "name": "dry_run",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "dry_run=true 时为 v1.RecordImportPreview",
                         "schema": {
-                            "$ref": "#/definitions/v1.Response"
+                            "$ref": "#/definitions/v1.RecordImportJob"
                         }
                     }
-                }
-            }
-        },
-        "/reports/generate": {
-            "post": {
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
-                "description": "仅创建/更新报告占位并进入队列",
+                ]
+            }
+        },
+        "/records/import/{job_id}": {
+            "get": {
+                "description": "运行中的任务超过 10 分钟没有进度视为进程中断，状态置为 failed",
                 "consumes": [
                     "application/json"
                 ],
@@ -452,38 +445,82 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "报告"
+                    "工作记录"
                 ],
-                "summary": "生成或重新生成报告",
+                "summary": "查询导入任务进度",
                 "parameters": [
                     {
-                        "description": "请求参数",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.GenReportReq"
-                        }
+                        "type": "string",
+                        "description": "导入任务 ID",
+                        "name": "job_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.Response"
+                            "$ref": "#/definitions/v1.RecordImportJob"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
             }
         },
-        "/reports/{report_id}": {
+        "/records/range": {
             "get": {
+                "description": "start 和 end 需为 YYYY-MM-DD，且 start \u003c end",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "工作记录"
+                ],
+                "summary": "按时间范围查询工作记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "开始日期",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束日期",
+                        "name": "end",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.RecordItem"
+                            }
+                        }
+                    }
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
-                "description": "按报告ID查询",
+                ]
+            }
+        },
+        "/records/{record_id}": {
+            "delete": {
                 "consumes": [
                     "application/json"
                 ],
@@ -491,14 +528,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "报告"
+                    "工作记录"
                 ],
-                "summary": "获取报告详情",
+                "summary": "删除工作记录",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "报告ID",
-                        "name": "report_id",
+                        "description": "记录 ID",
+                        "name": "record_id",
                         "in": "path",
                         "required": true
                     }
@@ -510,16 +547,54 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.Response"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
             }
         },
-        "/user": {
+        "/records/{record_id}/revisions": {
             "get": {
+                "description": "每次覆盖内容前保存的版本，按版本倒序，不含正文",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "工作记录"
+                ],
+                "summary": "查询工作记录历史版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "记录 ID",
+                        "name": "record_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.RecordRevisionsResp"
+                        }
+                    }
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
+                ]
+            }
+        },
+        "/records/{record_id}/revisions/diff": {
+            "get": {
+                "description": "按行对比，to 不传表示与当前版本对比",
                 "consumes": [
                     "application/json"
                 ],
@@ -527,26 +602,93 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户模块"
+                    "工作记录"
+                ],
+                "summary": "对比工作记录的两个版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "记录 ID",
+                        "name": "record_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "起始版本",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "目标版本，默认当前版本",
+                        "name": "to",
+                        "in": "query"
+                    }
                 ],
-                "summary": "获取当前用户信息",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.Response"
+                            "$ref": "#/definitions/v1.RecordRevisionDiffResp"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
             }
         },
-        "/user/settings": {
-            "get": {
+        "/records/{record_id}/revisions/{revision}/restore": {
+            "post": {
+                "description": "当前内容先保存为历史版本，再以所选版本内容生成新版本；已删除的记录会被恢复",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "工作记录"
+                ],
+                "summary": "恢复工作记录到历史版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "记录 ID",
+                        "name": "record_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "历史版本号",
+                        "name": "revision",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.RecordItem"
+                        }
+                    }
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
+                ]
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "目前只支持用户名登录",
                 "consumes": [
                     "application/json"
                 ],
@@ -556,7 +698,18 @@ const docTemplate = `{
                 "tags": [
                     "用户模块"
                 ],
-                "summary": "获取用户配置",
+                "summary": "用户注册",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RegisterReq"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -565,13 +718,48 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/report-templates": {
+            "get": {
+                "description": "内置模板（formal/simple）在前，随后为用户自建模板",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告模板"
+                ],
+                "summary": "获取报告模板列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "只返回适用于该周期的模板",
+                        "name": "period_type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.ReportTemplateItem"
+                            }
+                        }
+                    }
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
+                ]
+            },
+            "post": {
+                "description": "sections 为必须输出的章节，生成时要求按顺序输出为二级标题；生成报告时将返回的 template_id 作为 template 传入",
                 "consumes": [
                     "application/json"
                 ],
@@ -579,9 +767,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户模块"
+                    "报告模板"
                 ],
-                "summary": "更新用户配置",
+                "summary": "新建报告模板",
                 "parameters": [
                     {
                         "description": "params",
@@ -589,7 +777,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.UpdateUserSettingsReq"
+                            "$ref": "#/definitions/v1.SaveReportTemplateReq"
                         }
                     }
                 ],
@@ -597,139 +785,2027 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.Response"
+                            "$ref": "#/definitions/v1.ReportTemplateItem"
                         }
                     }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "v1.ConfirmReportReq": {
-            "type": "object",
-            "required": [
-                "report_id"
-            ],
-            "properties": {
-                "report_id": {
-                    "type": "string"
-                }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
             }
         },
-        "v1.EditReportReq": {
-            "type": "object",
-            "required": [
+        "/report-templates/{template_id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告模板"
+                ],
+                "summary": "获取报告模板详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "模板 ID",
+                        "name": "template_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ReportTemplateItem"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "内置模板只读；修改不影响已生成的报告，重新生成时使用新内容",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告模板"
+                ],
+                "summary": "修改报告模板",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "模板 ID",
+                        "name": "template_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SaveReportTemplateReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ReportTemplateItem"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "内置模板不可删除",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告模板"
+                ],
+                "summary": "删除报告模板",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "模板 ID",
+                        "name": "template_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports": {
+            "get": {
+                "description": "支持按period_type或时间范围筛选",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "获取报告列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "报告类型 week/month/year",
+                        "name": "period_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始日期",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束日期",
+                        "name": "end_date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports/confirm": {
+            "post": {
+                "description": "将报告标记为已确认；携带 version/gen_version（或 If-Match）时，报告已被修改或重新生成则返回 409 及 code 3011，data 为服务端当前报告；未携带时以读取时的版本为条件，期间被修改同样返回 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "确认报告",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "GET 返回的 ETag",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "请求参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ConfirmReportReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports/edit": {
+            "post": {
+                "description": "手动修改报告内容；携带 version/gen_version（或 If-Match）时，报告已被修改或重新生成则返回 409 及 code 3011，data 为服务端当前报告",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "编辑报告内容",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "GET 返回的 ETag",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "请求参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.EditReportReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ReportItem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports/generate": {
+            "post": {
+                "description": "仅创建/更新报告占位并进入队列；compare 为 true 时以上一周期已确认的同类报告为对比基准，上一周期没有已确认报告时返回 400 及 code 3024；报告已确认时需传 overwrite_confirmed，否则返回 409 及 code 3025",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "生成或重新生成报告",
+                "parameters": [
+                    {
+                        "description": "请求参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.GenReportReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports/{report_id}": {
+            "get": {
+                "description": "按报告ID查询",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "获取报告详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "报告ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports/{report_id}/export": {
+            "get": {
+                "description": "仅支持已确认的报告。服务端将 Markdown 渲染为 PDF（使用 PDF 预定义中文字体 STSong-Light，不嵌入，显示取决于阅读器；emoji 等 BMP 之外的字符替换为 ?）/DOCX/独立 HTML，文件头部包含标题、周期、作者与确认日期；branding 指定团队品牌模板（HTML 模板使用 Go html/template 语法，DOCX 模板使用 {{title}}/{{period}}/{{author}}/{{confirmed_at}}/{{content}} 占位符）",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "导出报告",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "报告ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pdf/docx/html/md，默认 md",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "品牌模板名",
+                        "name": "branding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "X-Export-Warning": {
+                                "type": "string",
+                                "description": "渲染限制，逗号分隔：font-not-embedded（PDF 未嵌入字体）、unsupported-chars=N（N 个字符替换为 ?）"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports/{report_id}/jobs": {
+            "get": {
+                "description": "按生成版本倒序返回每次生成的提示词、模型、结果与每次模型调用的耗时、用量、原始响应和错误",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "获取报告生成记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "报告ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports/{report_id}/rewrite": {
+            "post": {
+                "description": "按修改要求（如更简洁、翻译为英文、补充量化）改写 heading 指定的章节或 text 指定的片段，返回替换建议，不修改报告；接受时把返回的 content 连同 version/gen_version 提交到 /reports/edit。定位不到或不唯一返回 400 及 code 3028",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "改写报告的章节或片段",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "报告ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "GET 返回的 ETag",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "请求参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RewriteReportReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.RewriteReportResp"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports/{report_id}/stream": {
+            "get": {
+                "description": "SSE 推送报告正文：delta 为正文增量，reset 表示报告被重新生成需清空已接收内容，done 附带完整报告，error 附带失败原因；\n事件 id 为已下发的正文字符数，断线重连时通过 Last-Event-ID 或 offset 续传。排队中的报告等待 worker 领取后开始推送",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "流式获取报告生成内容",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "报告ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "已接收的正文字符数",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上次收到的事件 id，优先于 offset",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "text/event-stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports/{report_id}/versions": {
+            "get": {
+                "description": "每次生成、编辑、恢复后保存的正文版本，按序号倒序，不含正文",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "查询报告历史版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "报告ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ReportVersionsResp"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports/{report_id}/versions/diff": {
+            "get": {
+                "description": "按行对比，to 不传表示与最新版本对比",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "对比报告的两个版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "报告ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "起始版本序号",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "目标版本序号，默认最新版本",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ReportVersionDiffResp"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/reports/{report_id}/versions/{seq}/restore": {
+            "post": {
+                "description": "以所选版本的正文作为一次编辑写回并生成新版本，报告需重新确认；生成中的报告返回 409 及 code 3007",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "报告"
+                ],
+                "summary": "恢复报告到历史版本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "报告ID",
+                        "name": "report_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "版本序号",
+                        "name": "seq",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ReportItem"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/search": {
+            "get": {
+                "description": "按相关度排序，snippet 中命中词以 \u003cmark\u003e\u003c/mark\u003e 包裹；指定 period_type 时仅搜索报告",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "搜索"
+                ],
+                "summary": "全文搜索工作记录与报告",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "搜索词，多个词以空格分隔",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "all/record/report，默认all",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "开始日期，格式YYYY-MM-DD",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束日期，格式YYYY-MM-DD",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "报告类型：week/month/year",
+                        "name": "period_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码，从1开始",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页条数，默认20，最大50",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.SearchResp"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "获取当前用户信息",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/settings": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "获取用户配置",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "更新用户配置",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateUserSettingsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/settings/encryption": {
+            "put": {
+                "description": "密钥由客户端用口令经 KDF 派生，服务端仅保存 KDF 参数、salt 与校验串，其他设备据此派生同一密钥",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "设置端到端加密密钥参数",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SetEncryptionKeyReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        }
+    },
+    "definitions": {
+        "v1.CitationRecord": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "record_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ConfirmReportReq": {
+            "type": "object",
+            "required": [
+                "report_id"
+            ],
+            "properties": {
+                "gen_version": {
+                    "description": "期间被重新生成同样视为冲突",
+                    "type": "integer",
+                    "example": 2
+                },
+                "report_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "确认所基于的版本，与服务端不一致时返回冲突；也可通过 If-Match 传入 ETag",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "v1.DecryptedRecord": {
+            "type": "object",
+            "required": [
+                "content",
+                "record_id"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "record_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.EditReportReq": {
+            "type": "object",
+            "required": [
+                "content",
+                "report_id"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "gen_version": {
+                    "description": "期间被重新生成同样视为冲突",
+                    "type": "integer",
+                    "example": 2
+                },
+                "report_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "编辑所基于的版本，与服务端不一致时返回冲突；也可通过 If-Match 传入 ETag",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "v1.EncryptionKey": {
+            "type": "object",
+            "required": [
+                "kdf",
+                "kdf_params",
+                "key_id",
+                "salt",
+                "verifier"
+            ],
+            "properties": {
+                "kdf": {
+                    "type": "string",
+                    "example": "PBKDF2-SHA256"
+                },
+                "kdf_params": {
+                    "description": "如 {\"iterations\":600000} 或 {\"memory\":65536,\"iterations\":3,\"parallelism\":1}",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "key_id": {
+                    "type": "string",
+                    "example": "k1"
+                },
+                "salt": {
+                    "description": "base64，至少 16 字节",
+                    "type": "string"
+                },
+                "verifier": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ExportJob": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "download_url": {
+                    "description": "ready 时返回的签名下载链接，无需登录态，短时有效",
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "压缩包保留截止时间",
+                    "type": "string"
+                },
+                "export_id": {
+                    "type": "string",
+                    "example": "exportid_123"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "link_expires_at": {
+                    "description": "下载链接过期时间，过期后重新查询任务获取新链接",
+                    "type": "string"
+                },
+                "records": {
+                    "description": "记录条数，含端到端加密记录",
+                    "type": "integer"
+                },
+                "reports": {
+                    "type": "integer"
+                },
+                "size": {
+                    "description": "压缩包字节数",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "running/ready/failed/expired",
+                    "type": "string",
+                    "example": "ready"
+                }
+            }
+        },
+        "v1.GenReportReq": {
+            "type": "object",
+            "required": [
+                "end_date",
+                "period_type",
+                "start_date",
+                "template"
+            ],
+            "properties": {
+                "compare": {
+                    "description": "与上一周期已确认的同类报告对比，上一周期没有已确认报告时返回错误",
+                    "type": "boolean"
+                },
+                "decrypted_records": {
+                    "description": "周期内有加密记录时，由客户端解密后随请求提交，仅用于本次生成，不落库",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.DecryptedRecord"
+                    }
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "2025-12-31"
+                },
+                "overwrite_confirmed": {
+                    "description": "报告已确认时需显式传 true 才会重新生成，原正文保留在历史版本中",
+                    "type": "boolean"
+                },
+                "period_type": {
+                    "type": "string",
+                    "example": "week"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2025-12-01"
+                },
+                "template": {
+                    "type": "string",
+                    "example": "formal"
+                }
+            }
+        },
+        "v1.LLMAttempt": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "description": "熔断跳过",
+                    "type": "boolean"
+                },
+                "try": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.LoginReq": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "v1.PeriodDashboardResp": {
+            "type": "object",
+            "properties": {
+                "confirmed_months": {
+                    "description": "周期内已确认的月报数",
+                    "type": "integer"
+                },
+                "confirmed_weeks": {
+                    "description": "周期内已确认的周报数",
+                    "type": "integer"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "missing_days": {
+                    "description": "截至今天缺失记录的天数",
+                    "type": "integer"
+                },
+                "period_type": {
+                    "type": "string"
+                },
+                "rate": {
+                    "description": "完成率",
+                    "type": "integer"
+                },
+                "recorded_days": {
+                    "description": "截至今天完成记录的天数",
+                    "type": "integer"
+                },
+                "report": {
+                    "description": "该周期已有的报告",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.PeriodReportState"
+                        }
+                    ]
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "title": {
+                    "description": "生成报告时使用的标题",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.PeriodReportState": {
+            "type": "object",
+            "properties": {
+                "confirmed": {
+                    "type": "boolean"
+                },
+                "report_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.RecordDiffItem": {
+            "type": "object",
+            "properties": {
+                "op": {
+                    "description": "equal/insert/delete",
+                    "type": "string",
+                    "example": "insert"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.RecordEnvelope": {
+            "type": "object",
+            "required": [
+                "alg",
+                "key_id",
+                "nonce"
+            ],
+            "properties": {
+                "alg": {
+                    "type": "string",
+                    "example": "AES-256-GCM"
+                },
+                "key_id": {
+                    "description": "对应用户设置中的加密密钥",
+                    "type": "string",
+                    "example": "k1"
+                },
+                "nonce": {
+                    "description": "base64",
+                    "type": "string",
+                    "example": "3q2+78r+ur7e/w=="
+                }
+            }
+        },
+        "v1.RecordImportError": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.RecordImportJob": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "最多保留前 100 条",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RecordImportError"
+                    }
+                },
+                "failed": {
+                    "description": "含无效条目",
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "job_id": {
+                    "type": "string",
+                    "example": "importid_123"
+                },
+                "policy": {
+                    "type": "string",
+                    "example": "skip"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "notes.zip"
+                },
+                "status": {
+                    "description": "running/done/failed",
+                    "type": "string",
+                    "example": "running"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "description": "覆盖与追加",
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.RecordItem": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "工作内容",
+                    "type": "string",
+                    "example": "完成接口定义与联调"
+                },
+                "date": {
+                    "description": "日期，格式 YYYY-MM-DD",
+                    "type": "string",
+                    "example": "2025-12-11"
+                },
+                "encrypted": {
+                    "description": "为 true 时 content 为 base64 密文",
+                    "type": "boolean"
+                },
+                "envelope": {
+                    "description": "加密参数，客户端解密使用",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.RecordEnvelope"
+                        }
+                    ]
+                },
+                "record_id": {
+                    "description": "唯一标识",
+                    "type": "string",
+                    "example": "rec_123"
+                },
+                "updatedAt": {
+                    "description": "最近更新时间",
+                    "type": "string",
+                    "example": "2025-12-11T10:00:00Z"
+                },
+                "version": {
+                    "description": "版本计数",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "v1.RecordRevisionDiffResp": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "description": "新增行数",
+                    "type": "integer"
+                },
+                "diffs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RecordDiffItem"
+                    }
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "removed": {
+                    "description": "删除行数",
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.RecordRevisionItem": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "被覆盖的时间",
+                    "type": "string",
+                    "example": "2025-12-11T10:00:00Z"
+                },
+                "revision": {
+                    "description": "版本号，对应被覆盖时的 version",
+                    "type": "integer",
+                    "example": 3
+                },
+                "word_count": {
+                    "description": "字数",
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "v1.RecordRevisionsResp": {
+            "type": "object",
+            "properties": {
+                "current_version": {
+                    "description": "当前版本号，可作为 diff 的 to",
+                    "type": "integer"
+                },
+                "record_id": {
+                    "type": "string"
+                },
+                "revisions": {
+                    "description": "按版本倒序",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RecordRevisionItem"
+                    }
+                }
+            }
+        },
+        "v1.RegisterReq": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "v1.ReportBaseline": {
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "type": "string"
+                },
+                "period_type": {
+                    "type": "string"
+                },
+                "report_id": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "生成时上期报告的编辑版本号",
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.ReportCitation": {
+            "type": "object",
+            "properties": {
+                "line": {
+                    "description": "正文行号，从 1 开始",
+                    "type": "integer"
+                },
+                "records": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.CitationRecord"
+                    }
+                },
+                "text": {
+                    "description": "要点文本（不含列表标记）",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ReportClaim": {
+            "type": "object",
+            "properties": {
+                "claim": {
+                    "description": "声明片段",
+                    "type": "string"
+                },
+                "context": {
+                    "description": "所在要点文本",
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "number、date、term、person",
+                    "type": "string"
+                },
+                "line": {
+                    "description": "正文行号，从 1 开始",
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ReportItem": {
+            "type": "object",
+            "required": [
                 "content",
-                "report_id"
+                "end_date",
+                "period_type",
+                "report_id",
+                "start_date",
+                "title"
             ],
             "properties": {
-                "content": {
+                "abstract": {
+                    "type": "string"
+                },
+                "citations": {
+                    "description": "要点到原始记录的引用",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ReportCitation"
+                    }
+                },
+                "comparison": {
+                    "description": "对比生成时的上期报告",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ReportBaseline"
+                        }
+                    ]
+                },
+                "confirmed": {
+                    "type": "boolean"
+                },
+                "confirmed_at": {
+                    "description": "确认时间",
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "failed_reason": {
+                    "type": "string"
+                },
+                "gen_version": {
+                    "description": "生成版本号",
+                    "type": "integer"
+                },
+                "llm_attempts": {
+                    "description": "最近一次生成的调用记录",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.LLMAttempt"
+                    }
+                },
+                "llm_model": {
+                    "description": "最终应答的模型",
+                    "type": "string"
+                },
+                "period_type": {
+                    "type": "string"
+                },
+                "report_id": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "summary": {
+                    "description": "结构化摘要，旧报告或提取失败时为空",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ReportSummary"
+                        }
+                    ]
+                },
+                "template": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "verification": {
+                    "description": "事实核对结果，确认前应提示用户复核 flagged",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ReportVerification"
+                        }
+                    ]
+                },
+                "version": {
+                    "description": "手工编辑版本号",
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.ReportMetric": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ReportSummary": {
+            "type": "object",
+            "properties": {
+                "comparison": {
+                    "description": "与上期对比，仅对比生成的报告",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ReportSummaryComparison"
+                        }
+                    ]
+                },
+                "key_outputs": {
+                    "description": "关键产出",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "metrics": {
+                    "description": "量化数据",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ReportMetric"
+                    }
+                },
+                "next_steps": {
+                    "description": "后续计划",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "projects": {
+                    "description": "涉及的项目",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "risks": {
+                    "description": "问题与风险",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "summary": {
+                    "description": "一句话概述",
                     "type": "string"
+                }
+            }
+        },
+        "v1.ReportSummaryComparison": {
+            "type": "object",
+            "properties": {
+                "continued": {
+                    "description": "持续推进",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "report_id": {
+                "dropped": {
+                    "description": "未延续事项",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "started": {
+                    "description": "新增事项",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "trends": {
+                    "description": "变化趋势",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "v1.ReportTemplateItem": {
+            "type": "object",
+            "properties": {
+                "builtin": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "period_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "system_prompt": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "v1.GenReportReq": {
+        "v1.ReportVerification": {
             "type": "object",
-            "required": [
-                "end_date",
-                "period_type",
-                "start_date",
-                "template"
-            ],
             "properties": {
-                "end_date": {
+                "checked": {
+                    "description": "核对的事实声明数",
+                    "type": "integer"
+                },
+                "flagged": {
+                    "description": "输入记录中找不到依据的声明",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ReportClaim"
+                    }
+                },
+                "mode": {
+                    "description": "lexical 或 llm",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ReportVersionDiffResp": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "description": "新增行数",
+                    "type": "integer"
+                },
+                "diffs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RecordDiffItem"
+                    }
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "removed": {
+                    "description": "删除行数",
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.ReportVersionItem": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "generate/edit/restore",
                     "type": "string",
-                    "example": "2025-12-31"
+                    "example": "generate"
                 },
-                "period_type": {
+                "author": {
+                    "description": "llm/user",
                     "type": "string",
-                    "example": "week"
+                    "example": "llm"
                 },
-                "start_date": {
+                "created_at": {
                     "type": "string",
-                    "example": "2025-12-01"
+                    "example": "2025-12-11T10:00:00Z"
+                },
+                "gen_version": {
+                    "description": "写入时报告的生成版本号",
+                    "type": "integer"
+                },
+                "llm_model": {
+                    "description": "生成所用模型",
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer",
+                    "example": 3
                 },
                 "template": {
-                    "type": "string",
-                    "example": "formal"
+                    "description": "生成所用模板",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "写入时报告的编辑版本号",
+                    "type": "integer"
                 }
             }
         },
-        "v1.LoginReq": {
+        "v1.ReportVersionsResp": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "description": "与当前正文一致的版本序号，报告正在生成或尚无版本时为 0",
+                    "type": "integer"
+                },
+                "report_id": {
+                    "type": "string"
+                },
+                "versions": {
+                    "description": "按序号倒序",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ReportVersionItem"
+                    }
+                }
+            }
+        },
+        "v1.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {},
+                "msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.RewriteReportReq": {
             "type": "object",
             "required": [
-                "password",
-                "username"
+                "instruction"
             ],
             "properties": {
-                "password": {
+                "gen_version": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "heading": {
+                    "description": "按标题定位，改写该标题及其下级内容，可不带 #",
                     "type": "string",
-                    "example": "123456"
+                    "example": "## 本周工作"
                 },
-                "username": {
+                "instruction": {
                     "type": "string",
-                    "example": "alice"
+                    "maxLength": 500,
+                    "example": "更简洁一些"
+                },
+                "text": {
+                    "description": "按原文片段定位",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "改写所基于的版本，与服务端不一致时返回冲突",
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "v1.RecordItem": {
+        "v1.RewriteReportResp": {
             "type": "object",
             "properties": {
                 "content": {
-                    "description": "工作内容",
-                    "type": "string",
-                    "example": "完成接口定义与联调"
+                    "description": "替换后的完整正文",
+                    "type": "string"
                 },
-                "date": {
-                    "description": "日期，格式 YYYY-MM-DD",
-                    "type": "string",
-                    "example": "2025-12-11"
+                "end_line": {
+                    "type": "integer"
                 },
-                "record_id": {
-                    "description": "唯一标识",
-                    "type": "string",
-                    "example": "rec_123"
+                "gen_version": {
+                    "type": "integer"
                 },
-                "updatedAt": {
-                    "description": "最近更新时间",
-                    "type": "string",
-                    "example": "2025-12-11T10:00:00Z"
+                "llm_model": {
+                    "type": "string"
+                },
+                "original": {
+                    "type": "string"
+                },
+                "replacement": {
+                    "type": "string"
+                },
+                "report_id": {
+                    "type": "string"
+                },
+                "start_line": {
+                    "description": "被替换内容在当前正文中的起止行号，从 1 开始",
+                    "type": "integer"
                 },
                 "version": {
-                    "description": "版本计数",
-                    "type": "integer",
-                    "example": 1
+                    "type": "integer"
                 }
             }
         },
-        "v1.RegisterReq": {
+        "v1.SaveReportTemplateReq": {
             "type": "object",
             "required": [
-                "password",
-                "username"
+                "name",
+                "period_types",
+                "system_prompt"
             ],
             "properties": {
-                "password": {
+                "name": {
                     "type": "string",
-                    "example": "123456"
+                    "example": "研发周报"
                 },
-                "username": {
-                    "type": "string",
-                    "example": "alice"
+                "period_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "week",
+                        "month"
+                    ]
+                },
+                "sections": {
+                    "description": "必须输出的章节，按顺序输出为二级标题",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "产出",
+                        "风险",
+                        "下周计划"
+                    ]
+                },
+                "system_prompt": {
+                    "type": "string"
                 }
             }
         },
-        "v1.Response": {
+        "v1.SearchItem": {
             "type": "object",
             "properties": {
-                "code": {
+                "date": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "record_id 或 report_id",
+                    "type": "string"
+                },
+                "period_type": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "record/report",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.SearchResp": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SearchItem"
+                    }
+                },
+                "page": {
                     "type": "integer"
                 },
-                "data": {},
-                "msg": {
+                "page_size": {
+                    "type": "integer"
+                },
+                "partial": {
+                    "description": "服务端静态加密的内容超出单次解密上限，结果不完整",
+                    "type": "boolean"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.SetEncryptionKeyReq": {
+            "type": "object",
+            "required": [
+                "kdf",
+                "kdf_params",
+                "key_id",
+                "salt",
+                "verifier"
+            ],
+            "properties": {
+                "kdf": {
+                    "type": "string",
+                    "example": "PBKDF2-SHA256"
+                },
+                "kdf_params": {
+                    "description": "如 {\"iterations\":600000} 或 {\"memory\":65536,\"iterations\":3,\"parallelism\":1}",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "key_id": {
+                    "type": "string",
+                    "example": "k1"
+                },
+                "salt": {
+                    "description": "base64，至少 16 字节",
+                    "type": "string"
+                },
+                "verifier": {
                     "type": "string"
                 }
             }
@@ -740,9 +2816,25 @@ const docTemplate = `{
                 "user_id"
             ],
             "properties": {
+                "auto_generate_monthly": {
+                    "type": "boolean"
+                },
                 "auto_generate_weekly": {
                     "type": "boolean"
                 },
+                "encryption": {
+                    "description": "只读，通过 PUT /user/settings/encryption 设置",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.EncryptionKey"
+                        }
+                    ]
+                },
+                "monthly_report_time": {
+                    "description": "每月最后一天该时刻自动生成月报，HH:MM",
+                    "type": "string",
+                    "example": "22:00"
+                },
                 "report_template_month": {
                     "description": "用户自定义月报提示词模板",
                     "type": "string"
@@ -751,11 +2843,28 @@ const docTemplate = `{
                     "description": "用户自定义周报提示词模板",
                     "type": "string"
                 },
+                "sprint_anchor_date": {
+                    "description": "任一迭代的起始日期，迭代按长度由此前后排布；为空时迭代须从周一开始",
+                    "type": "string",
+                    "example": "2025-01-06"
+                },
+                "sprint_length_days": {
+                    "description": "迭代长度（天），默认 14",
+                    "type": "integer",
+                    "example": 14
+                },
+                "timezone": {
+                    "description": "IANA 时区名，日期校验与自动生成均按该时区计算",
+                    "type": "string",
+                    "example": "Asia/Shanghai"
+                },
                 "user_id": {
                     "type": "string"
                 },
                 "weekly_report_time": {
-                    "type": "string"
+                    "description": "周日该时刻自动生成周报，HH:MM",
+                    "type": "string",
+                    "example": "22:00"
                 }
             }
         },
@@ -775,9 +2884,21 @@ const docTemplate = `{
                     "type": "string",
                     "example": "2025-12-11"
                 },
+                "encrypted": {
+                    "description": "为 true 时 content 为 base64 密文，须同时传 envelope",
+                    "type": "boolean"
+                },
+                "envelope": {
+                    "$ref": "#/definitions/v1.RecordEnvelope"
+                },
                 "meta": {
                     "type": "object",
                     "additionalProperties": {}
+                },
+                "version": {
+                    "description": "编辑所基于的版本号，与服务端不一致时返回冲突；也可通过 If-Match 传入 ETag",
+                    "type": "integer",
+                    "example": 3
                 }
             }
         }
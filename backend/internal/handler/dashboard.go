@@ -56,6 +56,45 @@ func (h *DashboardHandler) GetMonth(ctx *gin.Context) {
 	v1.HandleSuccess(ctx, resp)
 }
 
+// GetPeriod godoc
+// @Summary 获取报告周期看板数据
+// @Description 返回 date 所在周期（custom 为 start_date~end_date）的起止日期、记录完成情况、已确认的月报/周报数及该周期的报告状态
+// @Schemes
+// @Tags 看板
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param period_type query string true "周期类型：week/month/quarter/half_year/year/sprint/custom"
+// @Param date query string false "周期内任一天，格式YYYY-MM-DD，默认今天"
+// @Param start_date query string false "custom 的开始日期"
+// @Param end_date query string false "custom 的结束日期"
+// @Success 200 {object} v1.Response{data=v1.PeriodDashboardResp}
+// @Router /dashboard/period [get]
+func (h *DashboardHandler) GetPeriod(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.PeriodDashboardReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	resp, err := h.dashboardService.GetPeriod(ctx, userId, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, v1.ErrInvalidDate) || errors.Is(err, v1.ErrInvalidReportPeriod) {
+			status = http.StatusBadRequest
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, resp)
+}
+
 // GetSummary godoc
 // @Summary 获取看板汇总数据
 // @Schemes
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag 以版本号作为 ETag，如记录 "3"、报告 "2.1"（gen_version.version）
+func setETag(ctx *gin.Context, versions ...int) {
+	parts := make([]string, 0, len(versions))
+	for _, v := range versions {
+		parts = append(parts, strconv.Itoa(v))
+	}
+	ctx.Header("ETag", `"`+strings.Join(parts, ".")+`"`)
+}
+
+// ifMatch 解析 If-Match 中的版本号，格式需与 setETag 一致；未传、为 * 或格式不符时返回 false
+func ifMatch(ctx *gin.Context, n int) ([]int, bool) {
+	value := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	value = strings.TrimPrefix(value, "W/")
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return nil, false
+	}
+	parts := strings.Split(value[1:len(value)-1], ".")
+	if len(parts) != n {
+		return nil, false
+	}
+	versions := make([]int, 0, n)
+	for _, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		versions = append(versions, v)
+	}
+	return versions, true
+}
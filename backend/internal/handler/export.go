@@ -0,0 +1,136 @@
+package handler
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	*Handler
+	exportService service.ExportService
+}
+
+func NewExportHandler(handler *Handler, exportService service.ExportService) *ExportHandler {
+	return &ExportHandler{
+		Handler:       handler,
+		exportService: exportService,
+	}
+}
+
+// StartExport godoc
+// @Summary 导出账号全部数据
+// @Schemes
+// @Description 异步生成 zip：records/ 下每天一个 Markdown，reports/ 下每份报告一个带 YAML front matter 的 Markdown，另含 manifest.json 与 settings.json。已有进行中的导出时返回该任务
+// @Tags 导出
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.ExportJob
+// @Router /export [post]
+func (h *ExportHandler) StartExport(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	job, err := h.exportService.StartExport(ctx, userId)
+	if err != nil {
+		v1.HandleError(ctx, exportErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, withDownloadBase(ctx, job))
+}
+
+// GetExport godoc
+// @Summary 查询导出任务
+// @Schemes
+// @Description status 为 ready 时返回签名下载链接，链接短时有效，过期后重新查询即可获取新链接
+// @Tags 导出
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param export_id path string true "导出任务 ID"
+// @Success 200 {object} v1.ExportJob
+// @Router /export/{export_id} [get]
+func (h *ExportHandler) GetExport(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.ExportJobReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	job, err := h.exportService.GetExport(ctx, userId, req.ExportID)
+	if err != nil {
+		v1.HandleError(ctx, exportErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, withDownloadBase(ctx, job))
+}
+
+// DownloadExport godoc
+// @Summary 下载导出压缩包
+// @Schemes
+// @Description 使用导出任务返回的签名链接，无需登录态
+// @Tags 导出
+// @Produce application/zip
+// @Param export_id path string true "导出任务 ID"
+// @Param expires query int true "链接过期时间（unix 秒）"
+// @Param sig query string true "签名"
+// @Success 200 {file} file
+// @Failure 403 {object} v1.Response
+// @Router /export/{export_id}/download [get]
+func (h *ExportHandler) DownloadExport(ctx *gin.Context) {
+	var req v1.ExportDownloadReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	path, name, err := h.exportService.OpenDownload(ctx, &req)
+	if err != nil {
+		v1.HandleError(ctx, exportErrorStatus(err), err, nil)
+		return
+	}
+	ctx.FileAttachment(path, name)
+}
+
+// withDownloadBase 下载链接补全 API 前缀（如 /v1）
+func withDownloadBase(ctx *gin.Context, job v1.ExportJob) v1.ExportJob {
+	if job.DownloadURL != "" {
+		fullPath := ctx.FullPath()
+		if i := strings.Index(fullPath, "/export"); i > 0 {
+			job.DownloadURL = fullPath[:i] + job.DownloadURL
+		}
+	}
+	return job
+}
+
+func exportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrExportNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrExportLinkInvalid):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrExportExpired):
+		return http.StatusGone
+	case errors.Is(err, v1.ErrExportNotReady):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
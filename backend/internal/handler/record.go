@@ -55,6 +55,7 @@ func (h *RecordHandler) QueryRecords(ctx *gin.Context) {
 			v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
 			return
 		}
+		setETag(ctx, record.Version)
 		v1.HandleSuccess(ctx, record)
 		return
 	}
@@ -107,13 +108,15 @@ func (h *RecordHandler) QueryRecordsByRange(ctx *gin.Context) {
 // UpsertRecord godoc
 // @Summary 创建或更新工作记录
 // @Schemes
-// @Description 同一日期多次调用视为更新
+// @Description 同一日期多次调用视为更新；携带 version（或 If-Match）时，服务端版本不一致返回 409 及 code 2011，data 为服务端当前记录
 // @Tags 工作记录
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param If-Match header string false "GET 返回的 ETag"
 // @Param request body v1.UpsertRecordReq true "请求参数"
 // @Success 200 {object} v1.RecordItem
+// @Failure 409 {object} v1.Response
 // @Router /records [post]
 func (h *RecordHandler) UpsertRecord(ctx *gin.Context) {
 	userId := GetUserIdFromCtx(ctx)
@@ -128,9 +131,19 @@ func (h *RecordHandler) UpsertRecord(ctx *gin.Context) {
 		return
 	}
 
+	if req.Version == nil {
+		if versions, ok := ifMatch(ctx, 1); ok {
+			req.Version = &versions[0]
+		}
+	}
+
 	if err := h.recordService.UpsertUserRecord(ctx, userId, &req); err != nil {
+		if errors.Is(err, v1.ErrRecordConflict) {
+			h.recordConflict(ctx, userId, req.Date)
+			return
+		}
 		status := http.StatusInternalServerError
-		if errors.Is(err, v1.ErrBadRequest) {
+		if errors.Is(err, v1.ErrBadRequest) || errors.Is(err, v1.ErrInvalidEnvelope) {
 			status = http.StatusBadRequest
 		}
 		v1.HandleError(ctx, status, err, nil)
@@ -142,9 +155,21 @@ func (h *RecordHandler) UpsertRecord(ctx *gin.Context) {
 		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
 		return
 	}
+	setETag(ctx, record.Version)
 	v1.HandleSuccess(ctx, record)
 }
 
+// recordConflict 返回冲突及服务端当前记录，供客户端合并
+func (h *RecordHandler) recordConflict(ctx *gin.Context, userId string, date string) {
+	current, err := h.recordService.QueryUserRecordsByDate(ctx, userId, date)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusConflict, v1.ErrRecordConflict, nil)
+		return
+	}
+	setETag(ctx, current.Version)
+	v1.HandleError(ctx, http.StatusConflict, v1.ErrRecordConflict, current)
+}
+
 // DeleteRecord godoc
 // @Summary 删除工作记录
 // @Schemes
@@ -178,3 +203,120 @@ func (h *RecordHandler) DeleteRecord(ctx *gin.Context) {
 	}
 	v1.HandleSuccess(ctx, nil)
 }
+
+// ListRevisions godoc
+// @Summary 查询工作记录历史版本
+// @Schemes
+// @Description 每次覆盖内容前保存的版本，按版本倒序，不含正文
+// @Tags 工作记录
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param record_id path string true "记录 ID"
+// @Success 200 {object} v1.RecordRevisionsResp
+// @Router /records/{record_id}/revisions [get]
+func (h *RecordHandler) ListRevisions(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.RecordRevisionsReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	resp, err := h.recordService.ListRevisions(ctx, userId, req.RecordID)
+	if err != nil {
+		v1.HandleError(ctx, revisionErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, resp)
+}
+
+// DiffRevisions godoc
+// @Summary 对比工作记录的两个版本
+// @Schemes
+// @Description 按行对比，to 不传表示与当前版本对比
+// @Tags 工作记录
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param record_id path string true "记录 ID"
+// @Param from query int true "起始版本"
+// @Param to query int false "目标版本，默认当前版本"
+// @Success 200 {object} v1.RecordRevisionDiffResp
+// @Router /records/{record_id}/revisions/diff [get]
+func (h *RecordHandler) DiffRevisions(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.RecordRevisionDiffReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	resp, err := h.recordService.DiffRevisions(ctx, userId, &req)
+	if err != nil {
+		v1.HandleError(ctx, revisionErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, resp)
+}
+
+// RestoreRevision godoc
+// @Summary 恢复工作记录到历史版本
+// @Schemes
+// @Description 当前内容先保存为历史版本，再以所选版本内容生成新版本；已删除的记录会被恢复
+// @Tags 工作记录
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param record_id path string true "记录 ID"
+// @Param revision path int true "历史版本号"
+// @Success 200 {object} v1.RecordItem
+// @Router /records/{record_id}/revisions/{revision}/restore [post]
+func (h *RecordHandler) RestoreRevision(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.RestoreRecordRevisionReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	record, err := h.recordService.RestoreRevision(ctx, userId, req.RecordID, req.Revision)
+	if err != nil {
+		v1.HandleError(ctx, revisionErrorStatus(err), err, nil)
+		return
+	}
+	setETag(ctx, record.Version)
+	v1.HandleSuccess(ctx, record)
+}
+
+func revisionErrorStatus(err error) int {
+	if errors.Is(err, v1.ErrRecordNotExist) || errors.Is(err, v1.ErrRevisionNotExist) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, v1.ErrRecordConflict) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, v1.ErrRecordEncrypted) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/service"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxImportUploadSize = 32 << 20
+
+type RecordImportHandler struct {
+	*Handler
+	importService service.RecordImportService
+}
+
+func NewRecordImportHandler(handler *Handler, importService service.RecordImportService) *RecordImportHandler {
+	return &RecordImportHandler{
+		Handler:       handler,
+		importService: importService,
+	}
+}
+
+// ImportRecords godoc
+// @Summary 批量导入历史工作记录
+// @Schemes
+// @Description 上传 zip 或单个 md/markdown/txt 文件；日期取自以日期开头的标题（如 `## 2024-03-05`），没有日期标题时取自文件名。dry_run=true 只返回预览，否则创建后台导入任务，通过 GET /records/import/{job_id} 查询进度
+// @Tags 工作记录
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param file formData file true "zip 或 md/markdown/txt 文件"
+// @Param policy formData string false "已有记录的冲突策略：skip（默认）/overwrite/append"
+// @Param dry_run formData bool false "只预览不写入"
+// @Success 200 {object} v1.RecordImportJob "dry_run=true 时为 v1.RecordImportPreview"
+// @Router /records/import [post]
+func (h *RecordImportHandler) ImportRecords(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.ImportRecordsReq
+	if err := ctx.ShouldBind(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if header.Size > maxImportUploadSize {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrImportTooLarge, nil)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	sources := []service.ImportSource{{Name: header.Filename, Data: data}}
+
+	if req.DryRun {
+		preview, err := h.importService.PreviewImport(ctx, userId, &req, sources)
+		if err != nil {
+			v1.HandleError(ctx, importErrorStatus(err), err, nil)
+			return
+		}
+		v1.HandleSuccess(ctx, preview)
+		return
+	}
+	job, err := h.importService.StartImport(ctx, userId, &req, header.Filename, sources)
+	if err != nil {
+		v1.HandleError(ctx, importErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, job)
+}
+
+// GetImportJob godoc
+// @Summary 查询导入任务进度
+// @Schemes
+// @Description 运行中的任务超过 10 分钟没有进度视为进程中断，状态置为 failed
+// @Tags 工作记录
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param job_id path string true "导入任务 ID"
+// @Success 200 {object} v1.RecordImportJob
+// @Router /records/import/{job_id} [get]
+func (h *RecordImportHandler) GetImportJob(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.RecordImportJobReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	job, err := h.importService.GetImportJob(ctx, userId, req.JobID)
+	if err != nil {
+		v1.HandleError(ctx, importErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, job)
+}
+
+func importErrorStatus(err error) int {
+	if errors.Is(err, v1.ErrImportJobNotExist) {
+		return http.StatusNotFound
+	}
+	for _, target := range []error{v1.ErrInvalidImportFile, v1.ErrImportTooLarge, v1.ErrImportEmpty, v1.ErrInvalidImportPolicy} {
+		if errors.Is(err, target) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
@@ -5,19 +5,26 @@ import (
 	"backend/internal/service"
 	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 type ReportHandler struct {
 	*Handler
-	reportService service.ReportService
+	reportService       service.ReportService
+	reportExportService service.ReportExportService
 }
 
-func NewReportHandler(handler *Handler, reportService service.ReportService) *ReportHandler {
+func NewReportHandler(handler *Handler, reportService service.ReportService, reportExportService service.ReportExportService) *ReportHandler {
 	return &ReportHandler{
-		Handler:       handler,
-		reportService: reportService,
+		Handler:             handler,
+		reportService:       reportService,
+		reportExportService: reportExportService,
 	}
 }
 
@@ -54,9 +61,116 @@ func (h *ReportHandler) GetReportByID(ctx *gin.Context) {
 		v1.HandleError(ctx, status, err, nil)
 		return
 	}
+	setETag(ctx, report.GenVersion, report.Version)
 	v1.HandleSuccess(ctx, report)
 }
 
+// GetReportJobs godoc
+// @Summary 获取报告生成记录
+// @Schemes
+// @Description 按生成版本倒序返回每次生成的提示词、模型、结果与每次模型调用的耗时、用量、原始响应和错误
+// @Tags 报告
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param report_id path string true "报告ID"
+// @Success 200 {object} v1.Response
+// @Router /reports/{report_id}/jobs [get]
+func (h *ReportHandler) GetReportJobs(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.GetReportJobsReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	jobs, err := h.reportService.GetReportJobs(ctx, userId, req.ReportID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, v1.ErrReportNotExist) {
+			status = http.StatusNotFound
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, jobs)
+}
+
+// StreamReport godoc
+// @Summary 流式获取报告生成内容
+// @Schemes
+// @Description SSE 推送报告正文：delta 为正文增量，reset 表示报告被重新生成需清空已接收内容，done 附带完整报告，error 附带失败原因；
+// @Description 事件 id 为已下发的正文字符数，断线重连时通过 Last-Event-ID 或 offset 续传。排队中的报告等待 worker 领取后开始推送
+// @Tags 报告
+// @Produce text/event-stream
+// @Security Bearer
+// @Param report_id path string true "报告ID"
+// @Param offset query int false "已接收的正文字符数"
+// @Param Last-Event-ID header string false "上次收到的事件 id，优先于 offset"
+// @Success 200 {string} string "text/event-stream"
+// @Router /reports/{report_id}/stream [get]
+func (h *ReportHandler) StreamReport(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.StreamReportReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if lastEventID := ctx.GetHeader("Last-Event-ID"); lastEventID != "" {
+		offset, err := strconv.Atoi(lastEventID)
+		if err != nil {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+			return
+		}
+		req.Offset = offset
+	}
+
+	// 首个事件发出前才写入 SSE 响应头，之前的错误仍按普通 JSON 返回
+	started := false
+	err := h.reportService.StreamReport(ctx.Request.Context(), userId, &req, func(event v1.ReportStreamEvent) error {
+		if !started {
+			started = true
+			ctx.Header("Cache-Control", "no-cache")
+			ctx.Header("Connection", "keep-alive")
+			ctx.Header("X-Accel-Buffering", "no")
+			ctx.Status(http.StatusOK)
+		}
+		ctx.Render(-1, sse.Event{
+			Id:    strconv.Itoa(event.Offset),
+			Event: event.Event,
+			Data:  event.Data,
+		})
+		ctx.Writer.Flush()
+		return ctx.Request.Context().Err()
+	})
+	if err == nil {
+		return
+	}
+	if started {
+		h.logger.WithContext(ctx).Warn("stream report interrupted", zap.Error(err))
+		return
+	}
+	status := http.StatusInternalServerError
+	if errors.Is(err, v1.ErrReportNotExist) {
+		status = http.StatusNotFound
+	}
+	v1.HandleError(ctx, status, err, nil)
+}
+
 // GetReports godoc
 // @Summary 获取报告列表
 // @Schemes
@@ -94,7 +208,7 @@ func (h *ReportHandler) GetReports(ctx *gin.Context) {
 // GenerateReport godoc
 // @Summary 生成或重新生成报告
 // @Schemes
-// @Description 仅创建/更新报告占位并进入队列
+// @Description 仅创建/更新报告占位并进入队列；compare 为 true 时以上一周期已确认的同类报告为对比基准，上一周期没有已确认报告时返回 400 及 code 3024；报告已确认时需传 overwrite_confirmed，否则返回 409 及 code 3025
 // @Tags 报告
 // @Accept json
 // @Produce json
@@ -118,9 +232,13 @@ func (h *ReportHandler) GenerateReport(ctx *gin.Context) {
 	report, err := h.reportService.GenerateReport(ctx, userId, &req)
 	if err != nil {
 		status := http.StatusInternalServerError
-		if errors.Is(err, v1.ErrInvalidReportPeriod) || errors.Is(err, v1.ErrInvalidReportTemplate) || errors.Is(err, v1.ErrInvalidDate) {
+		if errors.Is(err, v1.ErrInvalidReportPeriod) || errors.Is(err, v1.ErrInvalidReportTemplate) || errors.Is(err, v1.ErrReportTemplatePeriod) || errors.Is(err, v1.ErrInvalidDate) ||
+			errors.Is(err, v1.ErrBadRequest) || errors.Is(err, v1.ErrEncryptedRecords) || errors.Is(err, v1.ErrNoComparisonBaseline) {
 			status = http.StatusBadRequest
 		}
+		if errors.Is(err, v1.ErrReportConfirmed) {
+			status = http.StatusConflict
+		}
 		v1.HandleError(ctx, status, err, nil)
 		return
 	}
@@ -130,13 +248,15 @@ func (h *ReportHandler) GenerateReport(ctx *gin.Context) {
 // EditReport godoc
 // @Summary 编辑报告内容
 // @Schemes
-// @Description 手动修改报告内容
+// @Description 手动修改报告内容；携带 version/gen_version（或 If-Match）时，报告已被修改或重新生成则返回 409 及 code 3011，data 为服务端当前报告
 // @Tags 报告
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param If-Match header string false "GET 返回的 ETag"
 // @Param request body v1.EditReportReq true "请求参数"
-// @Success 200 {object} v1.Response
+// @Success 200 {object} v1.ReportItem
+// @Failure 409 {object} v1.Response
 // @Router /reports/edit [post]
 func (h *ReportHandler) EditReport(ctx *gin.Context) {
 	userId := GetUserIdFromCtx(ctx)
@@ -151,7 +271,17 @@ func (h *ReportHandler) EditReport(ctx *gin.Context) {
 		return
 	}
 
+	if req.Version == nil && req.GenVersion == nil {
+		if versions, ok := ifMatch(ctx, 2); ok {
+			req.GenVersion, req.Version = &versions[0], &versions[1]
+		}
+	}
+
 	if err := h.reportService.EditReport(ctx, userId, &req); err != nil {
+		if errors.Is(err, v1.ErrReportConflict) {
+			h.reportConflict(ctx, userId, req.ReportID)
+			return
+		}
 		status := http.StatusInternalServerError
 		if errors.Is(err, v1.ErrReportNotExist) {
 			status = http.StatusNotFound
@@ -162,19 +292,39 @@ func (h *ReportHandler) EditReport(ctx *gin.Context) {
 		v1.HandleError(ctx, status, err, nil)
 		return
 	}
-	v1.HandleSuccess(ctx, nil)
+
+	report, err := h.reportService.GetReportByID(ctx, userId, req.ReportID)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+	setETag(ctx, report.GenVersion, report.Version)
+	v1.HandleSuccess(ctx, report)
+}
+
+// reportConflict 返回冲突及服务端当前报告，供客户端合并
+func (h *ReportHandler) reportConflict(ctx *gin.Context, userId string, reportID string) {
+	current, err := h.reportService.GetReportByID(ctx, userId, reportID)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusConflict, v1.ErrReportConflict, nil)
+		return
+	}
+	setETag(ctx, current.GenVersion, current.Version)
+	v1.HandleError(ctx, http.StatusConflict, v1.ErrReportConflict, current)
 }
 
 // ConfirmReport godoc
 // @Summary 确认报告
 // @Schemes
-// @Description 将报告标记为已确认
+// @Description 将报告标记为已确认；携带 version/gen_version（或 If-Match）时，报告已被修改或重新生成则返回 409 及 code 3011，data 为服务端当前报告；未携带时以读取时的版本为条件，期间被修改同样返回 409
 // @Tags 报告
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param If-Match header string false "GET 返回的 ETag"
 // @Param request body v1.ConfirmReportReq true "请求参数"
 // @Success 200 {object} v1.Response
+// @Failure 409 {object} v1.Response
 // @Router /reports/confirm [post]
 func (h *ReportHandler) ConfirmReport(ctx *gin.Context) {
 	userId := GetUserIdFromCtx(ctx)
@@ -189,7 +339,17 @@ func (h *ReportHandler) ConfirmReport(ctx *gin.Context) {
 		return
 	}
 
+	if req.Version == nil && req.GenVersion == nil {
+		if versions, ok := ifMatch(ctx, 2); ok {
+			req.GenVersion, req.Version = &versions[0], &versions[1]
+		}
+	}
+
 	if err := h.reportService.ConfirmReport(ctx, userId, &req); err != nil {
+		if errors.Is(err, v1.ErrReportConflict) {
+			h.reportConflict(ctx, userId, req.ReportID)
+			return
+		}
 		status := http.StatusInternalServerError
 		if errors.Is(err, v1.ErrReportNotExist) {
 			status = http.StatusNotFound
@@ -202,3 +362,227 @@ func (h *ReportHandler) ConfirmReport(ctx *gin.Context) {
 	}
 	v1.HandleSuccess(ctx, nil)
 }
+
+// ExportReport godoc
+// @Summary 导出报告
+// @Schemes
+// @Description 仅支持已确认的报告。服务端将 Markdown 渲染为 PDF（使用 PDF 预定义中文字体 STSong-Light，不嵌入，显示取决于阅读器；emoji 等 BMP 之外的字符替换为 ?）/DOCX/独立 HTML，文件头部包含标题、周期、作者与确认日期；branding 指定团队品牌模板（HTML 模板使用 Go html/template 语法，DOCX 模板使用 {{title}}/{{period}}/{{author}}/{{confirmed_at}}/{{content}} 占位符）
+// @Tags 报告
+// @Produce application/octet-stream
+// @Security Bearer
+// @Param report_id path string true "报告ID"
+// @Param format query string false "pdf/docx/html/md，默认 md"
+// @Param branding query string false "品牌模板名"
+// @Success 200 {file} file
+// @Header 200 {string} X-Export-Warning "渲染限制，逗号分隔：font-not-embedded（PDF 未嵌入字体）、unsupported-chars=N（N 个字符替换为 ?）"
+// @Failure 400 {object} v1.Response
+// @Router /reports/{report_id}/export [get]
+func (h *ReportHandler) ExportReport(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.ExportReportReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	file, err := h.reportExportService.ExportReport(ctx, userId, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, v1.ErrReportNotExist):
+			status = http.StatusNotFound
+		case errors.Is(err, v1.ErrReportNotReady), errors.Is(err, v1.ErrReportNotConfirmed):
+			status = http.StatusConflict
+		case errors.Is(err, v1.ErrInvalidExportFormat), errors.Is(err, v1.ErrInvalidBranding):
+			status = http.StatusBadRequest
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+	// 文件名含中文，按 RFC 5987 编码
+	ctx.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(file.Name))
+	if len(file.Warnings) > 0 {
+		ctx.Header("X-Export-Warning", strings.Join(file.Warnings, ", "))
+	}
+	ctx.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+// ListVersions godoc
+// @Summary 查询报告历史版本
+// @Schemes
+// @Description 每次生成、编辑、恢复后保存的正文版本，按序号倒序，不含正文
+// @Tags 报告
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param report_id path string true "报告ID"
+// @Success 200 {object} v1.ReportVersionsResp
+// @Router /reports/{report_id}/versions [get]
+func (h *ReportHandler) ListVersions(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.ReportVersionsReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	resp, err := h.reportService.ListVersions(ctx, userId, req.ReportID)
+	if err != nil {
+		v1.HandleError(ctx, versionErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, resp)
+}
+
+// DiffVersions godoc
+// @Summary 对比报告的两个版本
+// @Schemes
+// @Description 按行对比，to 不传表示与最新版本对比
+// @Tags 报告
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param report_id path string true "报告ID"
+// @Param from query int true "起始版本序号"
+// @Param to query int false "目标版本序号，默认最新版本"
+// @Success 200 {object} v1.ReportVersionDiffResp
+// @Router /reports/{report_id}/versions/diff [get]
+func (h *ReportHandler) DiffVersions(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.ReportVersionDiffReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	resp, err := h.reportService.DiffVersions(ctx, userId, &req)
+	if err != nil {
+		v1.HandleError(ctx, versionErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, resp)
+}
+
+// RestoreVersion godoc
+// @Summary 恢复报告到历史版本
+// @Schemes
+// @Description 以所选版本的正文作为一次编辑写回并生成新版本，报告需重新确认；生成中的报告返回 409 及 code 3007
+// @Tags 报告
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param report_id path string true "报告ID"
+// @Param seq path int true "版本序号"
+// @Success 200 {object} v1.ReportItem
+// @Router /reports/{report_id}/versions/{seq}/restore [post]
+func (h *ReportHandler) RestoreVersion(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.RestoreReportVersionReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	report, err := h.reportService.RestoreVersion(ctx, userId, req.ReportID, req.Seq)
+	if err != nil {
+		if errors.Is(err, v1.ErrReportConflict) {
+			h.reportConflict(ctx, userId, req.ReportID)
+			return
+		}
+		v1.HandleError(ctx, versionErrorStatus(err), err, nil)
+		return
+	}
+	setETag(ctx, report.GenVersion, report.Version)
+	v1.HandleSuccess(ctx, report)
+}
+
+// RewriteReport godoc
+// @Summary 改写报告的章节或片段
+// @Schemes
+// @Description 按修改要求（如更简洁、翻译为英文、补充量化）改写 heading 指定的章节或 text 指定的片段，返回替换建议，不修改报告；接受时把返回的 content 连同 version/gen_version 提交到 /reports/edit。定位不到或不唯一返回 400 及 code 3028
+// @Tags 报告
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param report_id path string true "报告ID"
+// @Param If-Match header string false "GET 返回的 ETag"
+// @Param request body v1.RewriteReportReq true "请求参数"
+// @Success 200 {object} v1.RewriteReportResp
+// @Failure 409 {object} v1.Response
+// @Router /reports/{report_id}/rewrite [post]
+func (h *ReportHandler) RewriteReport(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.RewriteReportReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if req.Version == nil && req.GenVersion == nil {
+		if versions, ok := ifMatch(ctx, 2); ok {
+			req.GenVersion, req.Version = &versions[0], &versions[1]
+		}
+	}
+
+	resp, err := h.reportService.RewriteReport(ctx, userId, &req)
+	if err != nil {
+		if errors.Is(err, v1.ErrReportConflict) {
+			h.reportConflict(ctx, userId, req.ReportID)
+			return
+		}
+		status := versionErrorStatus(err)
+		if errors.Is(err, v1.ErrInvalidRewriteTarget) || errors.Is(err, v1.ErrBadRequest) {
+			status = http.StatusBadRequest
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+	setETag(ctx, resp.GenVersion, resp.Version)
+	v1.HandleSuccess(ctx, resp)
+}
+
+func versionErrorStatus(err error) int {
+	if errors.Is(err, v1.ErrReportNotExist) || errors.Is(err, v1.ErrReportVersionNotExist) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, v1.ErrReportNotReady) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
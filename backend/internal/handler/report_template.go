@@ -0,0 +1,200 @@
+package handler
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportTemplateHandler struct {
+	*Handler
+	templateService service.ReportTemplateService
+}
+
+func NewReportTemplateHandler(handler *Handler, templateService service.ReportTemplateService) *ReportTemplateHandler {
+	return &ReportTemplateHandler{
+		Handler:         handler,
+		templateService: templateService,
+	}
+}
+
+// ListTemplates godoc
+// @Summary 获取报告模板列表
+// @Schemes
+// @Description 内置模板（formal/simple）在前，随后为用户自建模板
+// @Tags 报告模板
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param period_type query string false "只返回适用于该周期的模板"
+// @Success 200 {array} v1.ReportTemplateItem
+// @Router /report-templates [get]
+func (h *ReportTemplateHandler) ListTemplates(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.GetReportTemplatesReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	items, err := h.templateService.ListTemplates(ctx, userId, &req)
+	if err != nil {
+		v1.HandleError(ctx, templateErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, items)
+}
+
+// GetTemplate godoc
+// @Summary 获取报告模板详情
+// @Schemes
+// @Tags 报告模板
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param template_id path string true "模板 ID"
+// @Success 200 {object} v1.ReportTemplateItem
+// @Router /report-templates/{template_id} [get]
+func (h *ReportTemplateHandler) GetTemplate(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.ReportTemplateReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	item, err := h.templateService.GetTemplate(ctx, userId, req.TemplateID)
+	if err != nil {
+		v1.HandleError(ctx, templateErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, item)
+}
+
+// CreateTemplate godoc
+// @Summary 新建报告模板
+// @Schemes
+// @Description sections 为必须输出的章节，生成时要求按顺序输出为二级标题；生成报告时将返回的 template_id 作为 template 传入
+// @Tags 报告模板
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.SaveReportTemplateReq true "params"
+// @Success 200 {object} v1.ReportTemplateItem
+// @Router /report-templates [post]
+func (h *ReportTemplateHandler) CreateTemplate(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.SaveReportTemplateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	item, err := h.templateService.CreateTemplate(ctx, userId, &req)
+	if err != nil {
+		v1.HandleError(ctx, templateErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, item)
+}
+
+// UpdateTemplate godoc
+// @Summary 修改报告模板
+// @Schemes
+// @Description 内置模板只读；修改不影响已生成的报告，重新生成时使用新内容
+// @Tags 报告模板
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param template_id path string true "模板 ID"
+// @Param request body v1.SaveReportTemplateReq true "params"
+// @Success 200 {object} v1.ReportTemplateItem
+// @Router /report-templates/{template_id} [put]
+func (h *ReportTemplateHandler) UpdateTemplate(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var uri v1.ReportTemplateReq
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	var req v1.SaveReportTemplateReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	item, err := h.templateService.UpdateTemplate(ctx, userId, uri.TemplateID, &req)
+	if err != nil {
+		v1.HandleError(ctx, templateErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, item)
+}
+
+// DeleteTemplate godoc
+// @Summary 删除报告模板
+// @Schemes
+// @Description 内置模板不可删除
+// @Tags 报告模板
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param template_id path string true "模板 ID"
+// @Success 200 {object} v1.Response
+// @Router /report-templates/{template_id} [delete]
+func (h *ReportTemplateHandler) DeleteTemplate(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.ReportTemplateReq
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.templateService.DeleteTemplate(ctx, userId, req.TemplateID); err != nil {
+		v1.HandleError(ctx, templateErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+func templateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrReportTemplateNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrReportTemplateReadOnly):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrReportTemplateNameExists), errors.Is(err, v1.ErrReportTemplateLimit):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrInvalidReportTemplateArg):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	*Handler
+	searchService service.SearchService
+}
+
+func NewSearchHandler(handler *Handler, searchService service.SearchService) *SearchHandler {
+	return &SearchHandler{
+		Handler:       handler,
+		searchService: searchService,
+	}
+}
+
+// Search godoc
+// @Summary 全文搜索工作记录与报告
+// @Description 按相关度排序，snippet 中命中词以 <mark></mark> 包裹；指定 period_type 时仅搜索报告
+// @Schemes
+// @Tags 搜索
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param q query string true "搜索词，多个词以空格分隔"
+// @Param type query string false "all/record/report，默认all"
+// @Param start_date query string false "开始日期，格式YYYY-MM-DD"
+// @Param end_date query string false "结束日期，格式YYYY-MM-DD"
+// @Param period_type query string false "报告类型：week/month/year"
+// @Param page query int false "页码，从1开始"
+// @Param page_size query int false "每页条数，默认20，最大50"
+// @Success 200 {object} v1.SearchResp
+// @Router /search [get]
+func (h *SearchHandler) Search(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.SearchReq
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	resp, err := h.searchService.Search(ctx, userId, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, v1.ErrBadRequest) || errors.Is(err, v1.ErrInvalidSearchType) ||
+			errors.Is(err, v1.ErrInvalidReportPeriod) || errors.Is(err, v1.ErrInvalidDate) {
+			status = http.StatusBadRequest
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, resp)
+}
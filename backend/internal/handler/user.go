@@ -52,6 +52,42 @@ func (h *UserHandler) Register(ctx *gin.Context) {
 	v1.HandleSuccess(ctx, nil)
 }
 
+// SetEncryptionKey godoc
+// @Summary 设置端到端加密密钥参数
+// @Schemes
+// @Description 密钥由客户端用口令经 KDF 派生，服务端仅保存 KDF 参数、salt 与校验串，其他设备据此派生同一密钥
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.SetEncryptionKeyReq true "params"
+// @Success 200 {object} v1.Response
+// @Router /user/settings/encryption [put]
+func (h *UserHandler) SetEncryptionKey(ctx *gin.Context) {
+	userId := GetUserIdFromCtx(ctx)
+	if userId == "" {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.SetEncryptionKeyReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.userService.SetEncryptionKey(ctx, userId, &req); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, v1.ErrInvalidEncryptionKey) {
+			status = http.StatusBadRequest
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
 // Login godoc
 // @Summary 账号登录
 // @Schemes
@@ -162,7 +198,11 @@ func (h *UserHandler) UpdateUserSettings(ctx *gin.Context) {
 	}
 
 	if err := h.userService.UpdateUserSettings(ctx, userId, &req); err != nil {
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		status := http.StatusInternalServerError
+		if errors.Is(err, v1.ErrInvalidReportTime) || errors.Is(err, v1.ErrInvalidTimezone) || errors.Is(err, v1.ErrInvalidSprint) {
+			status = http.StatusBadRequest
+		}
+		v1.HandleError(ctx, status, err, nil)
 		return
 	}
 
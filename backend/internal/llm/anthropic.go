@@ -0,0 +1,206 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 09:41:05
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 09:41:05
+ */
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const anthropicVersion = "2023-06-01"
+
+func init() {
+	Register("anthropic", func(conf *viper.Viper) (Provider, error) {
+		return NewAnthropicClient(conf)
+	})
+}
+
+// AnthropicClient Anthropic Messages API 协议
+type AnthropicClient struct {
+	client    *http.Client
+	baseURL   string
+	apiKey    string
+	model     string
+	maxTokens int
+	window    int
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicReq struct {
+	Model      string               `json:"model"`
+	MaxTokens  int                  `json:"max_tokens"`
+	System     string               `json:"system,omitempty"`
+	Messages   []anthropicMessage   `json:"messages"`
+	Tools      []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice *anthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type anthropicResp struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input"` // type=tool_use 时为工具入参
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func NewAnthropicClient(conf *viper.Viper) (*AnthropicClient, error) {
+	apiKey := apiKey(conf, "anthropic")
+	if apiKey == "" {
+		return nil, errors.New("MODEL_API_KEY 未配置")
+	}
+	baseURL := conf.GetString("llm.anthropic.base_url")
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
+	}
+	model := conf.GetString("llm.anthropic.model")
+	if model == "" {
+		return nil, errors.New("llm.anthropic.model 未配置")
+	}
+	maxTokens := conf.GetInt("llm.anthropic.max_tokens")
+	if maxTokens <= 0 {
+		maxTokens = 4096
+	}
+	return &AnthropicClient{
+		client:    newHTTPClient(),
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		apiKey:    apiKey,
+		model:     model,
+		maxTokens: maxTokens,
+		window:    contextWindow(conf, "anthropic", model),
+	}, nil
+}
+
+func (c *AnthropicClient) ContextWindow() int {
+	return c.window
+}
+
+func (c *AnthropicClient) Name() string {
+	return "anthropic"
+}
+
+func (c *AnthropicClient) Model() string {
+	return c.model
+}
+
+func (c *AnthropicClient) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*Completion, error) {
+	var resp anthropicResp
+	raw, err := postJSON(ctx, c.client, c.baseURL+"/v1/messages", map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": anthropicVersion,
+	}, anthropicReq{
+		Model:     c.model,
+		MaxTokens: c.maxTokens,
+		System:    systemPrompt,
+		Messages: []anthropicMessage{
+			{Role: "user", Content: userPrompt},
+		},
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("call llm model failed: %w", err)
+	}
+
+	var builder strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			builder.WriteString(block.Text)
+		}
+	}
+	if builder.Len() == 0 {
+		return nil, errors.New("llm model returned empty response")
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = c.model
+	}
+	return &Completion{
+		Content: builder.String(),
+		Model:   model,
+		Usage: Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+		Raw: raw,
+	}, nil
+}
+
+// CompleteJSON 以强制调用单个工具的方式获取结构化输出，工具入参即结果
+func (c *AnthropicClient) CompleteJSON(ctx context.Context, systemPrompt string, userPrompt string, schema *Schema) (*Completion, error) {
+	var resp anthropicResp
+	raw, err := postJSON(ctx, c.client, c.baseURL+"/v1/messages", map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": anthropicVersion,
+	}, anthropicReq{
+		Model:     c.model,
+		MaxTokens: c.maxTokens,
+		System:    systemPrompt,
+		Messages: []anthropicMessage{
+			{Role: "user", Content: userPrompt},
+		},
+		Tools: []anthropicTool{
+			{Name: schema.Name, Description: schema.Description, InputSchema: schema.Schema},
+		},
+		ToolChoice: &anthropicToolChoice{Type: "tool", Name: schema.Name},
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("call llm model failed: %w", err)
+	}
+
+	var content string
+	for _, block := range resp.Content {
+		if block.Type == "tool_use" && len(block.Input) > 0 {
+			content = string(block.Input)
+			break
+		}
+	}
+	if content == "" {
+		return nil, errors.New("llm model returned empty response")
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = c.model
+	}
+	return &Completion{
+		Content: content,
+		Model:   model,
+		Usage: Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+		Raw: raw,
+	}, nil
+}
@@ -0,0 +1,74 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 16:02:19
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 16:02:19
+ */
+package llm
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultContextWindow 未配置且无法按模型名推断时的上下文窗口（token）
+const DefaultContextWindow = 8192
+
+// ContextLimiter 声明上下文窗口的供应商，用于按 token 预算切分输入
+type ContextLimiter interface {
+	ContextWindow() int
+}
+
+// 常见模型的上下文窗口，按模型名前缀匹配，先匹配者优先
+var knownContextWindows = []struct {
+	prefix string
+	window int
+}{
+	{"qwen3-max", 262144},
+	{"qwen-plus", 131072},
+	{"qwen-turbo", 131072},
+	{"qwen-max", 32768},
+	{"deepseek", 65536},
+	{"gpt-4o", 128000},
+	{"gpt-4.1", 1047576},
+	{"claude", 200000},
+}
+
+// contextWindow 读取 llm.<provider>.context_window，未配置时按模型名推断
+func contextWindow(conf *viper.Viper, name string, model string) int {
+	if window := conf.GetInt("llm." + name + ".context_window"); window > 0 {
+		return window
+	}
+	model = strings.ToLower(model)
+	for _, known := range knownContextWindows {
+		if strings.HasPrefix(model, known.prefix) {
+			return known.window
+		}
+	}
+	return DefaultContextWindow
+}
+
+// ContextWindow 返回供应商的上下文窗口，未声明时为 DefaultContextWindow
+func ContextWindow(p Provider) int {
+	if limiter, ok := p.(ContextLimiter); ok {
+		if window := limiter.ContextWindow(); window > 0 {
+			return window
+		}
+	}
+	return DefaultContextWindow
+}
+
+// EstimateTokens 粗略估算 token 数：中日韩等宽字符约 1 字 1 token，其余约 4 字符 1 token，宁多勿少
+func EstimateTokens(text string) int {
+	wide, other := 0, 0
+	for _, r := range text {
+		if r >= 0x2E80 {
+			wide++
+		} else {
+			other++
+		}
+	}
+	return wide + (other+3)/4
+}
@@ -0,0 +1,417 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 14:05:31
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 14:05:31
+ */
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultMaxAttempts      = 3
+	defaultStreamTimeout    = 2 * time.Minute
+	defaultBaseBackoff      = time.Second
+	defaultMaxBackoff       = 10 * time.Second
+	defaultFailureThreshold = 5
+	defaultBreakerCooldown  = time.Minute
+)
+
+// Attempt 单次模型调用记录
+type Attempt struct {
+	Provider  string `json:"provider"`
+	Model     string `json:"model"`
+	Try       int    `json:"try"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Skipped   bool   `json:"skipped,omitempty"` // 熔断打开，未实际调用
+
+	Usage Usage  `json:"-"` // 成功时的用量与原始响应，落库到 report_job_attempt
+	Raw   string `json:"-"`
+}
+
+// ChainError 调用链全部失败
+type ChainError struct {
+	Attempts []Attempt
+	Last     error
+}
+
+func (e *ChainError) Error() string {
+	if e.Last == nil {
+		return "all llm providers failed"
+	}
+	return "all llm providers failed: " + e.Last.Error()
+}
+
+func (e *ChainError) Unwrap() error {
+	return e.Last
+}
+
+// Reason 面向用户的失败原因，写入 report.failed_reason
+func (e *ChainError) Reason() string {
+	if len(e.Attempts) == 0 {
+		return "生成失败：未配置可用模型"
+	}
+	last := e.Attempts[len(e.Attempts)-1]
+	cause := "调用失败"
+	switch {
+	case last.Skipped:
+		cause = "熔断中"
+	case errors.Is(e.Last, context.DeadlineExceeded):
+		cause = "请求超时"
+	default:
+		if code := statusCode(e.Last); code == http.StatusTooManyRequests {
+			cause = "限流(429)"
+		} else if code >= 500 {
+			cause = fmt.Sprintf("服务异常(%d)", code)
+		} else if code > 0 {
+			cause = fmt.Sprintf("请求被拒绝(%d)", code)
+		}
+	}
+	return fmt.Sprintf("生成失败：模型 %s %s，共尝试 %d 次", last.Model, cause, len(e.Attempts))
+}
+
+type RetryPolicy struct {
+	MaxAttempts    int           // 单个供应商最多尝试次数
+	AttemptTimeout time.Duration // 单次调用超时
+	StreamTimeout  time.Duration // 单次流式调用超时，流式输出耗时更长
+	BaseBackoff    time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Chain 按顺序尝试多个供应商：可重试错误（429/5xx/超时）在同一供应商内指数退避重试，
+// 其余错误或重试耗尽后切换到下一个；连续失败的供应商由熔断器跳过一段时间
+type Chain struct {
+	entries []*chainEntry
+	retry   RetryPolicy
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+type chainEntry struct {
+	provider Provider
+	breaker  *breaker
+}
+
+func NewChain(providers []Provider, retry RetryPolicy, threshold int, cooldown time.Duration) *Chain {
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = defaultMaxAttempts
+	}
+	if retry.AttemptTimeout <= 0 {
+		retry.AttemptTimeout = DefaultReqTimeout
+	}
+	if retry.StreamTimeout <= 0 {
+		retry.StreamTimeout = defaultStreamTimeout
+	}
+	if retry.BaseBackoff <= 0 {
+		retry.BaseBackoff = defaultBaseBackoff
+	}
+	if retry.MaxBackoff <= 0 {
+		retry.MaxBackoff = defaultMaxBackoff
+	}
+	if threshold <= 0 {
+		threshold = defaultFailureThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+
+	entries := make([]*chainEntry, 0, len(providers))
+	for _, p := range providers {
+		entries = append(entries, &chainEntry{
+			provider: p,
+			breaker:  &breaker{threshold: threshold, cooldown: cooldown},
+		})
+	}
+	return &Chain{
+		entries: entries,
+		retry:   retry,
+		sleep:   sleepCtx,
+	}
+}
+
+// NewChainFromConfig 读取 llm.fallback / llm.retry / llm.breaker；未配置 fallback 时仅包含 llm.provider
+func NewChainFromConfig(conf *viper.Viper) (*Chain, error) {
+	var items []map[string]any
+	if err := conf.UnmarshalKey("llm.fallback", &items); err != nil {
+		return nil, fmt.Errorf("llm.fallback 配置错误: %w", err)
+	}
+	if len(items) == 0 {
+		name := conf.GetString("llm.provider")
+		if name == "" {
+			name = DefaultProvider
+		}
+		items = append(items, map[string]any{"provider": name})
+	}
+
+	providers := make([]Provider, 0, len(items))
+	var errs []error
+	for _, item := range items {
+		name, _ := item["provider"].(string)
+		if name == "" {
+			return nil, errors.New("llm.fallback 缺少 provider")
+		}
+		p, err := Open(name, overrideConf(conf, name, item))
+		if err != nil {
+			// 单个供应商不可用不影响其余链路
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		providers = append(providers, p)
+	}
+	if len(providers) == 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return NewChain(providers, RetryPolicy{
+		MaxAttempts:    conf.GetInt("llm.retry.max_attempts"),
+		AttemptTimeout: conf.GetDuration("llm.retry.attempt_timeout"),
+		StreamTimeout:  conf.GetDuration("llm.retry.stream_timeout"),
+		BaseBackoff:    conf.GetDuration("llm.retry.base_backoff"),
+		MaxBackoff:     conf.GetDuration("llm.retry.max_backoff"),
+	}, conf.GetInt("llm.breaker.failure_threshold"), conf.GetDuration("llm.breaker.cooldown")), nil
+}
+
+// overrideConf 复制配置，并用链路条目中的字段覆盖 llm.<provider>.*，实现同一供应商多模型
+func overrideConf(conf *viper.Viper, name string, item map[string]any) *viper.Viper {
+	c := viper.New()
+	_ = c.MergeConfigMap(conf.AllSettings())
+	for k, v := range item {
+		if k == "provider" {
+			continue
+		}
+		c.Set("llm."+name+"."+k, v)
+	}
+	return c
+}
+
+func (c *Chain) Name() string {
+	if len(c.entries) == 0 {
+		return ""
+	}
+	return c.entries[0].provider.Name()
+}
+
+func (c *Chain) Model() string {
+	if len(c.entries) == 0 {
+		return ""
+	}
+	return c.entries[0].provider.Model()
+}
+
+// ContextWindow 取链路中最小的上下文窗口，切换到备用模型时输入仍不超限
+func (c *Chain) ContextWindow() int {
+	window := 0
+	for _, entry := range c.entries {
+		if w := ContextWindow(entry.provider); window == 0 || w < window {
+			window = w
+		}
+	}
+	if window == 0 {
+		return DefaultContextWindow
+	}
+	return window
+}
+
+func (c *Chain) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*Completion, error) {
+	return c.run(ctx, c.retry.AttemptTimeout, func(ctx context.Context, p Provider) (*Completion, bool, error) {
+		completion, err := p.Complete(ctx, systemPrompt, userPrompt)
+		return completion, false, err
+	})
+}
+
+// Stream 流式调用；一旦已向调用方输出内容，失败后不再重试或切换供应商，避免内容拼接错乱
+func (c *Chain) Stream(ctx context.Context, systemPrompt string, userPrompt string, onDelta func(delta string)) (*Completion, error) {
+	return c.run(ctx, c.retry.StreamTimeout, func(ctx context.Context, p Provider) (*Completion, bool, error) {
+		emitted := false
+		completion, err := Stream(ctx, p, systemPrompt, userPrompt, func(delta string) {
+			emitted = true
+			onDelta(delta)
+		})
+		return completion, emitted, err
+	})
+}
+
+// CompleteJSON 结构化输出，重试与切换策略同 Complete
+func (c *Chain) CompleteJSON(ctx context.Context, systemPrompt string, userPrompt string, schema *Schema) (*Completion, error) {
+	return c.run(ctx, c.retry.AttemptTimeout, func(ctx context.Context, p Provider) (*Completion, bool, error) {
+		completion, err := CompleteJSON(ctx, p, systemPrompt, userPrompt, schema)
+		return completion, false, err
+	})
+}
+
+type attemptFunc func(ctx context.Context, p Provider) (completion *Completion, emitted bool, err error)
+
+func (c *Chain) run(ctx context.Context, timeout time.Duration, call attemptFunc) (*Completion, error) {
+	attempts := make([]Attempt, 0, len(c.entries)*c.retry.MaxAttempts)
+	var lastErr error
+
+	for _, entry := range c.entries {
+		p := entry.provider
+		if !entry.breaker.allow() {
+			attempts = append(attempts, Attempt{Provider: p.Name(), Model: p.Model(), Skipped: true})
+			lastErr = fmt.Errorf("%s circuit open", p.Name())
+			continue
+		}
+
+		for try := 1; try <= c.retry.MaxAttempts; try++ {
+			start := time.Now()
+			attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+			completion, emitted, err := call(attemptCtx, p)
+			cancel()
+
+			attempt := Attempt{
+				Provider:  p.Name(),
+				Model:     p.Model(),
+				Try:       try,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			if err == nil {
+				entry.breaker.success()
+				attempt.Usage = completion.Usage
+				attempt.Raw = completion.Raw
+				attempts = append(attempts, attempt)
+				completion.Attempts = attempts
+				return completion, nil
+			}
+
+			attempt.Error = err.Error()
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) {
+				attempt.Raw = statusErr.Body
+			}
+			attempts = append(attempts, attempt)
+			lastErr = err
+
+			// 外部取消（客户端断开、请求超时）不计入供应商失败，直接返回，不再切换供应商
+			if ctx.Err() != nil {
+				entry.breaker.release()
+				return nil, &ChainError{Attempts: attempts, Last: ctx.Err()}
+			}
+			entry.breaker.failure()
+			if emitted {
+				return nil, &ChainError{Attempts: attempts, Last: err}
+			}
+			// 熔断已打开（含半开试探失败）时不再重试，直接切换供应商
+			if !retryable(err) || try == c.retry.MaxAttempts || entry.breaker.open() {
+				break
+			}
+			if err := c.sleep(ctx, c.backoff(try)); err != nil {
+				return nil, &ChainError{Attempts: attempts, Last: err}
+			}
+		}
+	}
+	return nil, &ChainError{Attempts: attempts, Last: lastErr}
+}
+
+func (c *Chain) backoff(try int) time.Duration {
+	d := c.retry.BaseBackoff << (try - 1)
+	if d <= 0 || d > c.retry.MaxBackoff {
+		d = c.retry.MaxBackoff
+	}
+	// 叠加 0~20% 抖动，避免多个 worker 同时重试
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+// retryable 429、5xx、超时与网络错误可重试，其余（鉴权失败、参数错误等）直接切换供应商
+func retryable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if code := statusCode(err); code > 0 {
+		return code == http.StatusTooManyRequests || code >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func statusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// breaker 连续失败达到阈值后打开，冷却期结束放行一次试探请求（半开），试探结果返回前拒绝其余请求
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool // 半开状态下已放行试探请求，等待 success/failure
+}
+
+// allow 每个供应商每次调用只检查一次：放行即占用试探名额，调用方须以 success、failure 或 release 结束
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return false
+	}
+	// 半开：只放行一个试探请求，失败会再次打开
+	b.probing = true
+	return true
+}
+
+// open 只读判断是否处于打开或半开状态，用于重试前决定是否继续
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+// release 调用被外部取消时归还试探名额，不影响失败计数
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
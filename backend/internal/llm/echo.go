@@ -0,0 +1,144 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 09:52:47
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 09:52:47
+ */
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const echoModel = "echo"
+
+func init() {
+	Register("echo", func(conf *viper.Viper) (Provider, error) {
+		return NewEchoClient(conf)
+	})
+}
+
+// EchoClient 离线确定性实现：配置了 fixture 时原样返回文件内容，否则回显用户提示词。
+// 用于本地开发、测试与无网环境，不产生任何外部调用
+type EchoClient struct {
+	fixture string
+	window  int
+}
+
+func NewEchoClient(conf *viper.Viper) (*EchoClient, error) {
+	c := &EchoClient{window: contextWindow(conf, "echo", echoModel)}
+	if path := conf.GetString("llm.echo.fixture"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		c.fixture = string(b)
+	}
+	return c, nil
+}
+
+func (c *EchoClient) ContextWindow() int {
+	return c.window
+}
+
+func (c *EchoClient) Name() string {
+	return "echo"
+}
+
+func (c *EchoClient) Model() string {
+	return echoModel
+}
+
+func (c *EchoClient) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*Completion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	content := c.fixture
+	if content == "" {
+		content = "# 离线报告\n\n" + strings.TrimSpace(userPrompt) + "\n"
+	}
+
+	return echoCompletion(systemPrompt, userPrompt, content), nil
+}
+
+// CompleteJSON 按 Schema 从用户提示词确定性地填充字段：字符串取首个正文行，
+// 第一个字符串数组字段取列表项，其余为空
+func (c *EchoClient) CompleteJSON(ctx context.Context, systemPrompt string, userPrompt string, schema *Schema) (*Completion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var text string
+	items := []string{}
+	for _, line := range strings.Split(userPrompt, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "", strings.HasPrefix(trimmed, "#"):
+		case strings.HasPrefix(trimmed, "- "), strings.HasPrefix(trimmed, "* "):
+			items = append(items, strings.TrimSpace(trimmed[2:]))
+		case text == "":
+			text = trimmed
+		}
+	}
+
+	out := make(map[string]any)
+	properties, _ := schema.Schema["properties"].(map[string]any)
+	required, _ := schema.Schema["required"].([]string)
+	filled := false
+	for _, name := range required {
+		prop, _ := properties[name].(map[string]any)
+		switch prop["type"] {
+		case "string":
+			out[name] = text
+		case "array":
+			itemSchema, _ := prop["items"].(map[string]any)
+			if !filled && itemSchema["type"] == "string" {
+				out[name] = items
+				filled = true
+			} else {
+				out[name] = []any{}
+			}
+		}
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	return echoCompletion(systemPrompt, userPrompt, string(b)), nil
+}
+
+func echoCompletion(systemPrompt string, userPrompt string, content string) *Completion {
+	promptTokens := len([]rune(systemPrompt)) + len([]rune(userPrompt))
+	completionTokens := len([]rune(content))
+	return &Completion{
+		Content: content,
+		Model:   echoModel,
+		Usage: Usage{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      promptTokens + completionTokens,
+		},
+		Raw: content,
+	}
+}
+
+// Stream 按行切分输出，模拟流式返回
+func (c *EchoClient) Stream(ctx context.Context, systemPrompt string, userPrompt string, onDelta func(delta string)) (*Completion, error) {
+	completion, err := c.Complete(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.SplitAfter(completion.Content, "\n") {
+		if line != "" {
+			onDelta(line)
+		}
+	}
+	return completion, nil
+}
@@ -0,0 +1,85 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 09:21:36
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 09:21:36
+ */
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// StatusError 供应商返回的非 2xx 响应
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("llm http status %d: %s", e.StatusCode, e.Body)
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 60 * time.Second,
+	}
+}
+
+// postJSON 发送 JSON 请求并解析响应，返回原始响应体
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body any, out any) (string, error) {
+	resp, err := doPost(ctx, client, url, headers, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return string(raw), &StatusError{StatusCode: resp.StatusCode, Body: string(raw)}
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return string(raw), fmt.Errorf("decode llm response failed: %w", err)
+	}
+	return string(raw), nil
+}
+
+// postStream 发送 JSON 请求，2xx 时由调用方负责读取并关闭响应体
+func postStream(ctx context.Context, client *http.Client, url string, headers map[string]string, body any) (*http.Response, error) {
+	resp, err := doPost(ctx, client, url, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(raw)}
+	}
+	return resp, nil
+}
+
+func doPost(ctx context.Context, client *http.Client, url string, headers map[string]string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,217 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 09:30:12
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 09:30:12
+ */
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	Register("ollama", func(conf *viper.Viper) (Provider, error) {
+		return NewOllamaClient(conf)
+	})
+}
+
+// OllamaClient 本地模型（Ollama /api/chat 协议），无需 api key
+type OllamaClient struct {
+	client  *http.Client
+	baseURL string
+	model   string
+	window  int
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaChatReq struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   map[string]any  `json:"format,omitempty"` // 结构化输出的 JSON Schema
+	Options  ollamaOptions   `json:"options"`
+}
+
+// ollamaOptions 显式设置 num_ctx，避免服务端默认窗口较小时静默截断提示词
+type ollamaOptions struct {
+	NumCtx int `json:"num_ctx"`
+}
+
+type ollamaChatResp struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+func NewOllamaClient(conf *viper.Viper) (*OllamaClient, error) {
+	baseURL := conf.GetString("llm.ollama.base_url")
+	if baseURL == "" {
+		baseURL = "http://127.0.0.1:11434"
+	}
+	model := conf.GetString("llm.ollama.model")
+	if model == "" {
+		return nil, errors.New("llm.ollama.model 未配置")
+	}
+	return &OllamaClient{
+		client:  newHTTPClient(),
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		window:  contextWindow(conf, "ollama", model),
+	}, nil
+}
+
+func (c *OllamaClient) ContextWindow() int {
+	return c.window
+}
+
+func (c *OllamaClient) Name() string {
+	return "ollama"
+}
+
+func (c *OllamaClient) Model() string {
+	return c.model
+}
+
+func (c *OllamaClient) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*Completion, error) {
+	var resp ollamaChatResp
+	raw, err := postJSON(ctx, c.client, c.baseURL+"/api/chat", nil, ollamaChatReq{
+		Model: c.model,
+		Messages: []ollamaMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Stream:  false,
+		Options: ollamaOptions{NumCtx: c.window},
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("call llm model failed: %w", err)
+	}
+	if resp.Message.Content == "" {
+		return nil, errors.New("llm model returned empty response")
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = c.model
+	}
+	return &Completion{
+		Content: resp.Message.Content,
+		Model:   model,
+		Usage: Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+			TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+		},
+		Raw: raw,
+	}, nil
+}
+
+func (c *OllamaClient) CompleteJSON(ctx context.Context, systemPrompt string, userPrompt string, schema *Schema) (*Completion, error) {
+	var resp ollamaChatResp
+	raw, err := postJSON(ctx, c.client, c.baseURL+"/api/chat", nil, ollamaChatReq{
+		Model: c.model,
+		Messages: []ollamaMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Stream:  false,
+		Options: ollamaOptions{NumCtx: c.window},
+		Format:  schema.Schema,
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("call llm model failed: %w", err)
+	}
+	if resp.Message.Content == "" {
+		return nil, errors.New("llm model returned empty response")
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = c.model
+	}
+	return &Completion{
+		Content: resp.Message.Content,
+		Model:   model,
+		Usage: Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+			TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+		},
+		Raw: raw,
+	}, nil
+}
+
+func (c *OllamaClient) Stream(ctx context.Context, systemPrompt string, userPrompt string, onDelta func(delta string)) (*Completion, error) {
+	resp, err := postStream(ctx, c.client, c.baseURL+"/api/chat", nil, ollamaChatReq{
+		Model: c.model,
+		Messages: []ollamaMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Stream:  true,
+		Options: ollamaOptions{NumCtx: c.window},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("call llm model failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 流式响应为逐行 JSON，最后一行 done=true 携带用量
+	var builder strings.Builder
+	var last ollamaChatResp
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChatResp
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return nil, fmt.Errorf("decode llm response failed: %w", err)
+		}
+		if chunk.Message.Content != "" {
+			builder.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+		last = chunk
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("call llm model failed: %w", err)
+	}
+	if builder.Len() == 0 {
+		return nil, errors.New("llm model returned empty response")
+	}
+
+	model := last.Model
+	if model == "" {
+		model = c.model
+	}
+	raw, _ := json.Marshal(last)
+	return &Completion{
+		Content: builder.String(),
+		Model:   model,
+		Usage: Usage{
+			PromptTokens:     last.PromptEvalCount,
+			CompletionTokens: last.EvalCount,
+			TotalTokens:      last.PromptEvalCount + last.EvalCount,
+		},
+		Raw: string(raw),
+	}, nil
+}
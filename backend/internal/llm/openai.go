@@ -12,9 +12,10 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/openai/openai-go"
@@ -24,13 +25,22 @@ import (
 
 const DefaultReqTimeout = 30 * time.Second
 
+func init() {
+	Register("openai", func(conf *viper.Viper) (Provider, error) {
+		return NewOpenAIClient(conf)
+	})
+}
+
+// OpenAIClient OpenAI 兼容协议（DashScope、DeepSeek、vLLM 等）
 type OpenAIClient struct {
-	client openai.Client
-	model  string
+	client        openai.Client
+	model         string
+	jsonMode      string
+	contextWindow int
 }
 
 func NewOpenAIClient(conf *viper.Viper) (*OpenAIClient, error) {
-	apiKey := conf.GetString("llm.openai.api_key")
+	apiKey := apiKey(conf, "openai")
 	if apiKey == "" {
 		return nil, errors.New("MODEL_API_KEY 未配置")
 	}
@@ -46,24 +56,47 @@ func NewOpenAIClient(conf *viper.Viper) (*OpenAIClient, error) {
 	client := openai.NewClient(
 		option.WithAPIKey(apiKey),
 		option.WithBaseURL(baseURL),
+		// 重试由 Chain 统一处理
+		option.WithMaxRetries(0),
 		option.WithHTTPClient(&http.Client{
 			Timeout: 60 * time.Second,
 		}),
 	)
 
+	// 部分兼容服务不支持 json_schema，可配置为 json_object，此时 Schema 写入系统提示词
+	jsonMode := conf.GetString("llm.openai.json_mode")
+	if jsonMode == "" {
+		jsonMode = "json_schema"
+	}
+	if jsonMode != "json_schema" && jsonMode != "json_object" {
+		return nil, fmt.Errorf("llm.openai.json_mode 仅支持 json_schema/json_object，当前为 %q", jsonMode)
+	}
+
 	return &OpenAIClient{
-		client: client,
-		model:  model,
+		client:        client,
+		model:         model,
+		jsonMode:      jsonMode,
+		contextWindow: contextWindow(conf, "openai", model),
 	}, nil
 }
 
-func (c *OpenAIClient) GenerateReport(ctx context.Context, systemPrompt string, userPrompt string) (string, string, error) {
+func (c *OpenAIClient) ContextWindow() int {
+	return c.contextWindow
+}
+
+func (c *OpenAIClient) Name() string {
+	return "openai"
+}
+
+func (c *OpenAIClient) Model() string {
+	return c.model
+}
+
+func (c *OpenAIClient) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*Completion, error) {
 	if c == nil {
-		return "", "", errors.New("llm client not initialized")
+		return nil, errors.New("llm client not initialized")
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, DefaultReqTimeout)
-	defer cancel()
 	resp, err := c.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
 		Model: openai.ChatModel(c.model),
 		Messages: []openai.ChatCompletionMessageParamUnion{
@@ -72,30 +105,135 @@ func (c *OpenAIClient) GenerateReport(ctx context.Context, systemPrompt string,
 		},
 	})
 	if err != nil {
-		return "", "", errors.New("call llm model failed: " + err.Error())
+		return nil, fmt.Errorf("call llm model failed: %w", err)
 	}
 	if len(resp.Choices) == 0 {
-		return "", "", errors.New("llm model returned empty response")
+		return nil, errors.New("llm model returned empty response")
 	}
 
-	raw := resp.Choices[0].Message.Content
-	content := raw
-	abstract := buildAbstract(raw)
-	return content, abstract, nil
+	model := resp.Model
+	if model == "" {
+		model = c.model
+	}
+	return &Completion{
+		Content: resp.Choices[0].Message.Content,
+		Model:   model,
+		Usage: Usage{
+			PromptTokens:     int(resp.Usage.PromptTokens),
+			CompletionTokens: int(resp.Usage.CompletionTokens),
+			TotalTokens:      int(resp.Usage.TotalTokens),
+		},
+		Raw: resp.RawJSON(),
+	}, nil
 }
 
-func buildAbstract(content string) string {
-	// 简单截取前 2 行作为摘要，避免额外 LLM 调用
-	lines := strings.Split(content, "\n")
-	result := []string{}
-	for _, l := range lines {
-		trimmed := strings.TrimSpace(l)
-		if trimmed != "" {
-			result = append(result, trimmed)
+func (c *OpenAIClient) CompleteJSON(ctx context.Context, systemPrompt string, userPrompt string, schema *Schema) (*Completion, error) {
+	if c == nil {
+		return nil, errors.New("llm client not initialized")
+	}
+
+	var format openai.ChatCompletionNewParamsResponseFormatUnion
+	if c.jsonMode == "json_object" {
+		b, err := json.Marshal(schema.Schema)
+		if err != nil {
+			return nil, err
 		}
-		if len(result) >= 2 {
-			break
+		systemPrompt += "\n\n仅输出一个符合以下 JSON Schema 的 JSON 对象：\n" + string(b)
+		format.OfJSONObject = &openai.ResponseFormatJSONObjectParam{}
+	} else {
+		format.OfJSONSchema = &openai.ResponseFormatJSONSchemaParam{
+			JSONSchema: openai.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:        schema.Name,
+				Description: openai.String(schema.Description),
+				Schema:      schema.Schema,
+				Strict:      openai.Bool(true),
+			},
 		}
 	}
-	return strings.Join(result, " ")
+
+	resp, err := c.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Model: openai.ChatModel(c.model),
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(systemPrompt),
+			openai.UserMessage(userPrompt),
+		},
+		ResponseFormat: format,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("call llm model failed: %w", err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return nil, errors.New("llm model returned empty response")
+	}
+
+	model := resp.Model
+	if model == "" {
+		model = c.model
+	}
+	return &Completion{
+		Content: resp.Choices[0].Message.Content,
+		Model:   model,
+		Usage: Usage{
+			PromptTokens:     int(resp.Usage.PromptTokens),
+			CompletionTokens: int(resp.Usage.CompletionTokens),
+			TotalTokens:      int(resp.Usage.TotalTokens),
+		},
+		Raw: resp.RawJSON(),
+	}, nil
+}
+
+func (c *OpenAIClient) Stream(ctx context.Context, systemPrompt string, userPrompt string, onDelta func(delta string)) (*Completion, error) {
+	if c == nil {
+		return nil, errors.New("llm client not initialized")
+	}
+
+	stream := c.client.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
+		Model: openai.ChatModel(c.model),
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(systemPrompt),
+			openai.UserMessage(userPrompt),
+		},
+		StreamOptions: openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: openai.Bool(true),
+		},
+	})
+	defer stream.Close()
+
+	acc := openai.ChatCompletionAccumulator{}
+	for stream.Next() {
+		chunk := stream.Current()
+		acc.AddChunk(chunk)
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fmt.Errorf("call llm model failed: %w", err)
+	}
+	if len(acc.Choices) == 0 || acc.Choices[0].Message.Content == "" {
+		return nil, errors.New("llm model returned empty response")
+	}
+
+	model := acc.Model
+	if model == "" {
+		model = c.model
+	}
+	raw, _ := json.Marshal(acc.ChatCompletion)
+	return &Completion{
+		Content: acc.Choices[0].Message.Content,
+		Model:   model,
+		Usage: Usage{
+			PromptTokens:     int(acc.Usage.PromptTokens),
+			CompletionTokens: int(acc.Usage.CompletionTokens),
+			TotalTokens:      int(acc.Usage.TotalTokens),
+		},
+		Raw: string(raw),
+	}, nil
+}
+
+func (c *OpenAIClient) GenerateReport(ctx context.Context, systemPrompt string, userPrompt string) (string, string, error) {
+	if c == nil {
+		return "", "", errors.New("llm client not initialized")
+	}
+	return GenerateReport(ctx, c, systemPrompt, userPrompt)
 }
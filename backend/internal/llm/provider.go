@@ -0,0 +1,150 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 09:12:40
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 09:12:40
+ */
+package llm
+
+import (
+	"backend/pkg/log"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const DefaultProvider = "openai"
+
+// Provider 大模型供应商抽象，业务层只依赖该接口，便于切换本地模型/其他厂商/离线桩
+type Provider interface {
+	// Name 供应商名称，对应配置 llm.provider
+	Name() string
+	// Model 实际调用的模型名
+	Model() string
+	// Complete 发起一次对话补全，只负责拿到模型原始输出
+	Complete(ctx context.Context, systemPrompt string, userPrompt string) (*Completion, error)
+}
+
+// Completion 一次模型调用的结果
+type Completion struct {
+	Content string // 模型输出正文
+	Model   string // 实际应答的模型（以厂商返回为准）
+	Usage   Usage
+	Raw     string // 厂商原始响应，便于排查
+
+	Attempts []Attempt // 经调用链时记录每次尝试
+}
+
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// Factory 根据配置构造供应商实例
+type Factory func(conf *viper.Viper) (Provider, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register 注册供应商实现，一般在各实现文件的 init 中调用
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if factory == nil {
+		panic("llm: register nil factory for " + name)
+	}
+	if _, ok := factories[name]; ok {
+		panic("llm: provider already registered: " + name)
+	}
+	factories[name] = factory
+}
+
+// Providers 返回已注册的供应商名称
+func Providers() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open 按名称构造供应商
+func Open(name string, conf *viper.Viper) (Provider, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[name]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("llm provider %q 未注册，可选：%s", name, strings.Join(Providers(), "/"))
+	}
+	return factory(conf)
+}
+
+// NewProvider 按 llm.provider / llm.fallback 构造带重试与熔断的调用链；初始化失败时不阻断服务启动，
+// 返回一个调用即报错的占位实现，由报告生成流程标记失败
+func NewProvider(conf *viper.Viper, logger *log.Logger) Provider {
+	chain, err := NewChainFromConfig(conf)
+	if err != nil {
+		name := conf.GetString("llm.provider")
+		logger.Error("init llm provider failed, report generation disabled", zap.String("provider", name), zap.Error(err))
+		return &unavailableProvider{name: name, err: err}
+	}
+	for _, entry := range chain.entries {
+		logger.Info("llm provider loaded", zap.String("provider", entry.provider.Name()), zap.String("model", entry.provider.Model()))
+	}
+	return chain
+}
+
+// GenerateReport 调用模型生成报告，返回「正文 + 摘要」；摘要提取失败时为空，不影响正文
+func GenerateReport(ctx context.Context, p Provider, systemPrompt string, userPrompt string) (string, string, error) {
+	if p == nil {
+		return "", "", errors.New("llm client not initialized")
+	}
+
+	completion, err := p.Complete(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return "", "", err
+	}
+	summary, _, err := Summarize(ctx, p, completion.Content)
+	if err != nil {
+		return completion.Content, "", nil
+	}
+	return completion.Content, summary.Text(), nil
+}
+
+// apiKey 读取供应商专属 key，未配置时回退到公共的 llm.api_key（MODEL_API_KEY）
+func apiKey(conf *viper.Viper, name string) string {
+	if key := conf.GetString("llm." + name + ".api_key"); key != "" {
+		return key
+	}
+	return conf.GetString("llm.api_key")
+}
+
+type unavailableProvider struct {
+	name string
+	err  error
+}
+
+func (p *unavailableProvider) Name() string {
+	return p.name
+}
+
+func (p *unavailableProvider) Model() string {
+	return ""
+}
+
+func (p *unavailableProvider) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*Completion, error) {
+	return nil, errors.New("llm provider unavailable: " + p.err.Error())
+}
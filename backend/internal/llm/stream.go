@@ -0,0 +1,34 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 16:20:08
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 16:20:08
+ */
+package llm
+
+import (
+	"context"
+	"errors"
+)
+
+// Streamer 支持流式输出的供应商，onDelta 按模型产出顺序回调增量文本
+type Streamer interface {
+	Stream(ctx context.Context, systemPrompt string, userPrompt string, onDelta func(delta string)) (*Completion, error)
+}
+
+// Stream 优先流式调用；供应商不支持时退化为一次性输出整段内容
+func Stream(ctx context.Context, p Provider, systemPrompt string, userPrompt string, onDelta func(delta string)) (*Completion, error) {
+	if p == nil {
+		return nil, errors.New("llm client not initialized")
+	}
+	if streamer, ok := p.(Streamer); ok {
+		return streamer.Stream(ctx, systemPrompt, userPrompt, onDelta)
+	}
+	completion, err := p.Complete(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+	onDelta(completion.Content)
+	return completion, nil
+}
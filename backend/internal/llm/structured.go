@@ -0,0 +1,58 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 10:32:15
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 10:32:15
+ */
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Schema 结构化输出的 JSON Schema；Name 作为 OpenAI response_format 名称与 Anthropic 工具名
+type Schema struct {
+	Name        string
+	Description string
+	Schema      map[string]any
+}
+
+// StructuredCompleter 支持按 JSON Schema 约束输出的供应商（OpenAI json_schema / Anthropic 工具调用 / Ollama format），
+// Completion.Content 为 JSON 文本
+type StructuredCompleter interface {
+	CompleteJSON(ctx context.Context, systemPrompt string, userPrompt string, schema *Schema) (*Completion, error)
+}
+
+// CompleteJSON 优先使用供应商的结构化输出能力；不支持时把 Schema 写入系统提示词，并从输出中截取 JSON 对象
+func CompleteJSON(ctx context.Context, p Provider, systemPrompt string, userPrompt string, schema *Schema) (*Completion, error) {
+	if p == nil {
+		return nil, errors.New("llm client not initialized")
+	}
+	if structured, ok := p.(StructuredCompleter); ok {
+		return structured.CompleteJSON(ctx, systemPrompt, userPrompt, schema)
+	}
+	b, err := json.Marshal(schema.Schema)
+	if err != nil {
+		return nil, err
+	}
+	completion, err := p.Complete(ctx, systemPrompt+"\n\n仅输出一个符合以下 JSON Schema 的 JSON 对象，不要输出代码块或其他说明：\n"+string(b), userPrompt)
+	if err != nil {
+		return nil, err
+	}
+	completion.Content = extractJSON(completion.Content)
+	return completion, nil
+}
+
+// extractJSON 去掉模型附带的代码块或说明文字，取第一个 { 到最后一个 } 之间的内容
+func extractJSON(content string) string {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return strings.TrimSpace(content)
+	}
+	return content[start : end+1]
+}
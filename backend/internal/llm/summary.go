@@ -0,0 +1,248 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 10:46:02
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 10:46:02
+ */
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	maxSummaryItems = 10
+	maxSummaryRunes = 200
+)
+
+var ErrEmptySummary = errors.New("llm summary is empty")
+
+const summarySystemPrompt = `你是工作报告摘要助手。请阅读用户提供的 Markdown 报告，提取结构化摘要。
+
+要求：
+1. 仅基于报告原文，不允许编造事实、数据或项目。
+2. summary：1-2 句概述本周期核心进展。
+3. key_outputs：关键产出，每条一句话，最多 10 条。
+4. metrics：仅收录报告中明确出现的量化数据，name 为指标名称，value 保留原文数值与单位。
+5. risks：问题与风险；next_steps：后续计划；projects：报告提到的项目、产品或系统名称。
+6. 没有对应内容的字段返回空数组，不要填写“暂无”。`
+
+const comparisonSummaryRule = `
+7. comparison：仅依据报告中「与上期对比」一节填写，continued 为上期延续至本期的工作，started 为本期新增事项，dropped 为上期有而本期未延续的事项，trends 为数据或工作重心的变化趋势。`
+
+// ReportSummary 报告结构化摘要，由模型按 JSON Schema 输出
+type ReportSummary struct {
+	Summary    string   `json:"summary"`     // 一句话概述
+	KeyOutputs []string `json:"key_outputs"` // 关键产出
+	Metrics    []Metric `json:"metrics"`     // 量化数据
+	Risks      []string `json:"risks"`       // 问题与风险
+	NextSteps  []string `json:"next_steps"`  // 后续计划
+	Projects   []string `json:"projects"`    // 涉及的项目
+	// Comparison 与上期对比，仅对比生成的报告提取
+	Comparison *Comparison `json:"comparison,omitempty"`
+}
+
+// Comparison 与上一周期报告的对比
+type Comparison struct {
+	Continued []string `json:"continued"` // 持续推进
+	Started   []string `json:"started"`   // 新增事项
+	Dropped   []string `json:"dropped"`   // 未延续事项
+	Trends    []string `json:"trends"`    // 变化趋势
+}
+
+type Metric struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// summarySchema 按 OpenAI strict 模式要求：所有字段必填且不允许额外字段
+var summarySchema = &Schema{
+	Name:        "report_summary",
+	Description: "工作报告的结构化摘要",
+	Schema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"summary":     map[string]any{"type": "string", "description": "1-2 句概述本周期核心进展"},
+			"key_outputs": stringArraySchema("关键产出"),
+			"metrics": map[string]any{
+				"type":        "array",
+				"description": "报告中明确出现的量化数据",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"name":  map[string]any{"type": "string"},
+						"value": map[string]any{"type": "string"},
+					},
+					"required":             []string{"name", "value"},
+					"additionalProperties": false,
+				},
+			},
+			"risks":      stringArraySchema("问题与风险"),
+			"next_steps": stringArraySchema("后续计划"),
+			"projects":   stringArraySchema("涉及的项目、产品或系统"),
+		},
+		"required":             []string{"summary", "key_outputs", "metrics", "risks", "next_steps", "projects"},
+		"additionalProperties": false,
+	},
+}
+
+// comparisonSummarySchema 在 summarySchema 基础上增加必填的 comparison 字段
+var comparisonSummarySchema = func() *Schema {
+	properties := make(map[string]any)
+	for name, prop := range summarySchema.Schema["properties"].(map[string]any) {
+		properties[name] = prop
+	}
+	properties["comparison"] = map[string]any{
+		"type":        "object",
+		"description": "与上期对比",
+		"properties": map[string]any{
+			"continued": stringArraySchema("上期延续至本期的工作"),
+			"started":   stringArraySchema("本期新增事项"),
+			"dropped":   stringArraySchema("上期有而本期未延续的事项"),
+			"trends":    stringArraySchema("数据或工作重心的变化趋势"),
+		},
+		"required":             []string{"continued", "started", "dropped", "trends"},
+		"additionalProperties": false,
+	}
+	return &Schema{
+		Name:        "report_summary_comparison",
+		Description: "含上期对比的工作报告结构化摘要",
+		Schema: map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             append(append([]string{}, summarySchema.Schema["required"].([]string)...), "comparison"),
+			"additionalProperties": false,
+		},
+	}
+}()
+
+func stringArraySchema(description string) map[string]any {
+	return map[string]any{
+		"type":        "array",
+		"description": description,
+		"items":       map[string]any{"type": "string"},
+	}
+}
+
+// Summarize 从报告正文提取结构化摘要；输出不符合 Schema 时返回错误，Completion 仍返回便于记录调用
+func Summarize(ctx context.Context, p Provider, content string) (*ReportSummary, *Completion, error) {
+	return summarize(ctx, p, summarySystemPrompt, content, summarySchema)
+}
+
+// SummarizeComparison 提取结构化摘要，并从「与上期对比」一节提取 comparison
+func SummarizeComparison(ctx context.Context, p Provider, content string) (*ReportSummary, *Completion, error) {
+	return summarize(ctx, p, summarySystemPrompt+comparisonSummaryRule, content, comparisonSummarySchema)
+}
+
+func summarize(ctx context.Context, p Provider, systemPrompt string, content string, schema *Schema) (*ReportSummary, *Completion, error) {
+	completion, err := CompleteJSON(ctx, p, systemPrompt, content, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	summary, err := ParseReportSummary(completion.Content)
+	if err != nil {
+		return nil, completion, err
+	}
+	return summary, completion, nil
+}
+
+// ParseReportSummary 校验并规整模型输出：去除空白与重复条目，超长内容截断
+func ParseReportSummary(raw string) (*ReportSummary, error) {
+	var summary ReportSummary
+	if err := json.Unmarshal([]byte(extractJSON(raw)), &summary); err != nil {
+		return nil, fmt.Errorf("decode llm summary failed: %w", err)
+	}
+	summary.Summary = clipRunes(strings.TrimSpace(summary.Summary), maxSummaryRunes)
+	summary.KeyOutputs = normalizeItems(summary.KeyOutputs)
+	summary.Risks = normalizeItems(summary.Risks)
+	summary.NextSteps = normalizeItems(summary.NextSteps)
+	summary.Projects = normalizeItems(summary.Projects)
+	metrics := make([]Metric, 0, len(summary.Metrics))
+	for _, m := range summary.Metrics {
+		m.Name = clipRunes(strings.TrimSpace(m.Name), maxSummaryRunes)
+		m.Value = clipRunes(strings.TrimSpace(m.Value), maxSummaryRunes)
+		if m.Name == "" || m.Value == "" || len(metrics) >= maxSummaryItems {
+			continue
+		}
+		metrics = append(metrics, m)
+	}
+	summary.Metrics = metrics
+	if c := summary.Comparison; c != nil {
+		c.Continued = normalizeItems(c.Continued)
+		c.Started = normalizeItems(c.Started)
+		c.Dropped = normalizeItems(c.Dropped)
+		c.Trends = normalizeItems(c.Trends)
+		if c.Empty() {
+			summary.Comparison = nil
+		}
+	}
+	if summary.Empty() {
+		return nil, ErrEmptySummary
+	}
+	return &summary, nil
+}
+
+func (s *ReportSummary) Empty() bool {
+	return s.Summary == "" && len(s.KeyOutputs) == 0 && len(s.Metrics) == 0 &&
+		len(s.Risks) == 0 && len(s.NextSteps) == 0 && len(s.Projects) == 0
+}
+
+func (c *Comparison) Empty() bool {
+	return len(c.Continued) == 0 && len(c.Started) == 0 && len(c.Dropped) == 0 && len(c.Trends) == 0
+}
+
+// Text 渲染为纯文本，用作报告摘要与年报素材
+func (s *ReportSummary) Text() string {
+	var lines []string
+	if s.Summary != "" {
+		lines = append(lines, s.Summary)
+	}
+	add := func(label string, items []string) {
+		if len(items) > 0 {
+			lines = append(lines, label+"："+strings.Join(items, "；"))
+		}
+	}
+	add("关键产出", s.KeyOutputs)
+	metrics := make([]string, 0, len(s.Metrics))
+	for _, m := range s.Metrics {
+		metrics = append(metrics, m.Name+" "+m.Value)
+	}
+	add("关键数据", metrics)
+	add("问题与风险", s.Risks)
+	add("后续计划", s.NextSteps)
+	add("涉及项目", s.Projects)
+	if s.Comparison != nil {
+		add("较上期持续推进", s.Comparison.Continued)
+		add("较上期新增", s.Comparison.Started)
+		add("较上期未延续", s.Comparison.Dropped)
+		add("变化趋势", s.Comparison.Trends)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func normalizeItems(items []string) []string {
+	result := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		item = clipRunes(strings.TrimSpace(item), maxSummaryRunes)
+		if item == "" || seen[item] || len(result) >= maxSummaryItems {
+			continue
+		}
+		seen[item] = true
+		result = append(result, item)
+	}
+	return result
+}
+
+func clipRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "…"
+}
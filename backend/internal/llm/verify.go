@@ -0,0 +1,92 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 14:42:18
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 14:42:18
+ */
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 事实声明类型
+const (
+	ClaimNumber = "number"
+	ClaimDate   = "date"
+	ClaimTerm   = "term"   // 功能、产品、系统等名称
+	ClaimPerson = "person" // 人员
+)
+
+const verifySystemPrompt = `你是工作报告审核助手。用户会提供「原始记录」与根据记录生成的「报告」。
+请找出报告中的事实性声明：数字（数量、比例、耗时等）、日期、功能/产品/系统名称、人员，逐条判断能否由原始记录直接支持。
+
+要求：
+1. quote 必须是报告原文中连续出现的片段，尽量短，只包含该声明本身（如“降低 30%”“支付网关”“张三”）。
+2. kind 取值：number、date、term、person。
+3. 原始记录中有明确依据时 supported 为 true；记录中没有出现、或与记录不一致时为 false。由记录合理归纳的概括性表述不算事实声明，不要列出。
+4. 报告标题、周期日期来自系统输入，视为有依据。`
+
+// ClaimCheck 单条事实声明的核对结果
+type ClaimCheck struct {
+	Quote     string `json:"quote"`
+	Kind      string `json:"kind"`
+	Supported bool   `json:"supported"`
+}
+
+var verifySchema = &Schema{
+	Name:        "report_claims",
+	Description: "报告事实声明核对结果",
+	Schema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"claims": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"quote":     map[string]any{"type": "string", "description": "报告原文片段"},
+						"kind":      map[string]any{"type": "string", "enum": []string{ClaimNumber, ClaimDate, ClaimTerm, ClaimPerson}},
+						"supported": map[string]any{"type": "boolean"},
+					},
+					"required":             []string{"quote", "kind", "supported"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"claims"},
+		"additionalProperties": false,
+	},
+}
+
+// VerifyClaims 由模型抽取报告中的事实声明并对照原始记录核对
+func VerifyClaims(ctx context.Context, p Provider, content string, sources string) ([]ClaimCheck, *Completion, error) {
+	userPrompt := "原始记录：\n" + sources + "\n\n报告：\n" + content
+	completion, err := CompleteJSON(ctx, p, verifySystemPrompt, userPrompt, verifySchema)
+	if err != nil {
+		return nil, nil, err
+	}
+	var result struct {
+		Claims []ClaimCheck `json:"claims"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(completion.Content)), &result); err != nil {
+		return nil, completion, fmt.Errorf("decode llm claims failed: %w", err)
+	}
+	claims := make([]ClaimCheck, 0, len(result.Claims))
+	for _, c := range result.Claims {
+		c.Quote = strings.TrimSpace(c.Quote)
+		switch c.Kind {
+		case ClaimNumber, ClaimDate, ClaimTerm, ClaimPerson:
+		default:
+			continue
+		}
+		if c.Quote != "" {
+			claims = append(claims, c)
+		}
+	}
+	return claims, completion, nil
+}
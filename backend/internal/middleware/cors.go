@@ -10,6 +10,7 @@ func CORSMiddleware() gin.HandlerFunc {
 		method := c.Request.Method
 		c.Header("Access-Control-Allow-Origin", c.GetHeader("Origin"))
 		c.Header("Access-Control-Allow-Credentials", "true")
+		c.Header("Access-Control-Expose-Headers", "ETag, X-Export-Warning") // ETag 编辑时通过 If-Match 回传版本；X-Export-Warning 为导出渲染限制
 
 		if method == "OPTIONS" {
 			c.Header("Access-Control-Allow-Methods", c.GetHeader("Access-Control-Request-Method"))
@@ -0,0 +1,30 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 23:52:33
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 23:52:33
+ */
+package model
+
+import "time"
+
+// ExportJob 账号数据导出任务，压缩包写入生成进程的本地目录，超过保留时长后删除
+type ExportJob struct {
+	ExportID   string     `gorm:"primaryKey;size:40" json:"export_id"`
+	UserID     string     `gorm:"index;size:32;not null" json:"-"`
+	Status     string     `gorm:"size:20;default:'running'" json:"status"` // running/ready/failed/expired
+	FilePath   string     `gorm:"size:512" json:"-"`
+	Size       int64      `json:"size"`
+	Records    int        `json:"records"`
+	Reports    int        `json:"reports"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at,omitempty"`
+}
+
+func (ExportJob) TableName() string {
+	return "export_job"
+}
@@ -21,9 +21,13 @@ type Record struct {
 	UserID      string            `gorm:"uniqueIndex:uid_record_date,priority:1;size:32;not null" json:"user_id"` // 与 date 组成唯一约束
 	Date        string            `gorm:"size:10;uniqueIndex:uid_record_date,priority:2;not null" json:"date"`
 	Content     string            `gorm:"type:longtext;not null" json:"content"`
+	KeyVersion  int               `gorm:"default:0" json:"-"` // 服务端静态加密所用数据密钥版本，0 为明文
 	WordCount   int               `gorm:"default:0" json:"word_count"`
 	Meta        datatypes.JSONMap `gorm:"type:json" json:"meta,omitempty"`
-	IsEncrypted bool              `gorm:"default:false" json:"is_encrypted"`
+	IsEncrypted bool              `gorm:"default:false" json:"is_encrypted"`   // 端到端加密，content 为 base64 密文，服务端不解密
+	EncAlg      string            `gorm:"size:32" json:"enc_alg,omitempty"`    // 加密算法，如 AES-256-GCM
+	EncNonce    string            `gorm:"size:64" json:"enc_nonce,omitempty"`  // base64 nonce
+	EncKeyID    string            `gorm:"size:64" json:"enc_key_id,omitempty"` // 加密所用密钥标识
 	Version     int               `gorm:"default:1" json:"version"`
 	IsDeleted   bool              `gorm:"default:false" json:"is_deleted"`
 	CreatedAt   time.Time         `gorm:"autoCreateTime" json:"created_at"`
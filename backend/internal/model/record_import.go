@@ -0,0 +1,38 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 23:10:36
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 23:10:36
+ */
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// RecordImportJob 历史记录批量导入任务，文件解析后由接收请求的进程在后台逐条写入并更新进度
+type RecordImportJob struct {
+	ImportJobID string         `gorm:"primaryKey;size:40" json:"job_id"`
+	UserID      string         `gorm:"index;size:32;not null" json:"-"`
+	Source      string         `gorm:"size:256" json:"source"` // 上传的文件名或 CLI 导入路径
+	Policy      string         `gorm:"size:20;not null" json:"policy"`
+	Status      string         `gorm:"size:20;default:'running'" json:"status"` // running/done/failed
+	Total       int            `json:"total"`
+	Processed   int            `json:"processed"`
+	Created     int            `json:"created"`
+	Updated     int            `json:"updated"`
+	Skipped     int            `json:"skipped"`
+	Failed      int            `json:"failed"`
+	Errors      datatypes.JSON `gorm:"type:json" json:"errors,omitempty"` // []v1.RecordImportError
+	Error       string         `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	FinishedAt  *time.Time     `json:"finished_at,omitempty"`
+}
+
+func (RecordImportJob) TableName() string {
+	return "record_import_job"
+}
@@ -0,0 +1,37 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 20:05:14
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 20:05:14
+ */
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// RecordRevision 工作记录的历史版本，记录每次被覆盖前的内容
+type RecordRevision struct {
+	ID         uint              `gorm:"primaryKey;autoIncrement" json:"-"`
+	RecordID   string            `gorm:"uniqueIndex:uid_record_revision,priority:1;size:32;not null" json:"record_id"`
+	Revision   int               `gorm:"uniqueIndex:uid_record_revision,priority:2;not null" json:"revision"` // 被覆盖时记录的 version
+	UserID     string            `gorm:"size:32;not null" json:"-"`
+	Date       string            `gorm:"size:10;not null" json:"date"`
+	Content    string            `gorm:"type:longtext;not null" json:"content"`
+	KeyVersion int               `gorm:"default:0" json:"-"` // 服务端静态加密所用数据密钥版本，0 为明文
+	WordCount  int               `gorm:"default:0" json:"word_count"`
+	Meta       datatypes.JSONMap `gorm:"type:json" json:"meta,omitempty"`
+	// 加密信息随版本保存，恢复时一并还原
+	IsEncrypted bool      `gorm:"default:false" json:"is_encrypted"`
+	EncAlg      string    `gorm:"size:32" json:"enc_alg,omitempty"`
+	EncNonce    string    `gorm:"size:64" json:"enc_nonce,omitempty"`
+	EncKeyID    string    `gorm:"size:64" json:"enc_key_id,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime;index" json:"created_at"` // 被覆盖的时间
+}
+
+func (RecordRevision) TableName() string {
+	return "record_revision"
+}
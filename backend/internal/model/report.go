@@ -21,15 +21,22 @@ type Report struct {
 	StartDate    string            `gorm:"size:10;uniqueIndex:uid_report_period,priority:3;not null" json:"start_date"`
 	EndDate      string            `gorm:"size:10;uniqueIndex:uid_report_period,priority:4;not null" json:"end_date"`
 	Title        string            `gorm:"size:256;not null" json:"title"`
-	Content      string            `gorm:"type:longtext;not null" json:"content"` //报告内容
-	Template     string            `gorm:"size:20;default:'formal'" json:"template"`
-	Abstract     string            `gorm:"type:text" json:"abstract,omitempty"`      //报告结构化摘要
+	Content      string            `gorm:"type:longtext;not null" json:"content"`    //报告内容
+	KeyVersion   int               `gorm:"default:0" json:"-"`                       // 服务端静态加密所用数据密钥版本，0 为明文
+	Template     string            `gorm:"size:32;default:'formal'" json:"template"` // 报告模板 ID，内置 formal/simple
+	Abstract     string            `gorm:"type:text" json:"abstract,omitempty"`      //报告摘要，由结构化摘要渲染的纯文本
+	Summary      string            `gorm:"type:text" json:"summary,omitempty"`       // 结构化摘要 JSON，与正文同一 key_version 加密
 	FailedReason string            `gorm:"type:text" json:"failed_reason,omitempty"` //记录处理失败的原因
 	Confirmed    bool              `gorm:"default:false" json:"confirmed"`
+	ConfirmedAt  *time.Time        `json:"confirmed_at,omitempty"`                 // 最近一次确认时间，编辑或重新生成后清空
 	Status       string            `gorm:"size:20;default:'queued'" json:"status"` // queued/ready/processing/failed
+	LLMModel     string            `gorm:"size:64" json:"llm_model,omitempty"`     // 最终应答的模型
 	Meta         datatypes.JSONMap `gorm:"type:json" json:"meta,omitempty"`        // 扩展预留
 	Version      int               `gorm:"default:0" json:"version"`               //手工生成版本号记录
 	GenVersion   int               `gorm:"default:0" json:"gen_version"`           //llm自动生成版本号记录
+	LeaseOwner   string            `gorm:"size:64" json:"-"`                       // 持有处理租约的进程
+	LeaseExpires *time.Time        `gorm:"index" json:"-"`                         // 租约到期时间，处理中靠心跳续期
+	ClaimCount   int               `gorm:"default:0" json:"-"`                     // 当前生成版本被领取的次数
 	CreatedAt    time.Time         `gorm:"autoCreateTime;index:idx_created_desc" json:"created_at"`
 	UpdatedAt    time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt    gorm.DeletedAt    `gorm:"index" json:"-"`
@@ -0,0 +1,61 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 17:02:45
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 17:02:45
+ */
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ReportJob 报告生成任务，每次发起生成（对应一个 GenVersion）一条，重新生成不覆盖历史
+type ReportJob struct {
+	ReportJobID  string            `gorm:"primaryKey;size:40" json:"report_job_id"`
+	ReportID     string            `gorm:"uniqueIndex:uid_report_job_version,priority:1;size:32;not null" json:"report_id"`
+	GenVersion   int               `gorm:"uniqueIndex:uid_report_job_version,priority:2;not null" json:"gen_version"`
+	UserID       string            `gorm:"index:idx_status_created,priority:1;size:32;not null" json:"-"`
+	PeriodType   string            `gorm:"size:20;not null" json:"period_type"`
+	StartDate    string            `gorm:"size:10;not null" json:"start_date"`
+	EndDate      string            `gorm:"size:10;not null" json:"end_date"`
+	Template     string            `gorm:"size:32;not null" json:"template"`
+	Status       string            `gorm:"size:20;index:idx_status_created,priority:2;default:'queued'" json:"status"` // queued/processing/ready/failed
+	LLMModel     string            `gorm:"size:64" json:"llm_model"`                                                   // 最终应答的模型
+	SystemPrompt string            `gorm:"type:longtext" json:"system_prompt"`
+	Prompt       string            `gorm:"type:longtext" json:"prompt"`
+	Result       string            `gorm:"type:longtext" json:"result,omitempty"`
+	KeyVersion   int               `gorm:"default:0" json:"-"` // prompt 与 result 静态加密所用数据密钥版本，0 为明文
+	Error        string            `gorm:"type:text" json:"error,omitempty"`
+	Meta         datatypes.JSONMap `gorm:"type:json" json:"meta,omitempty"` // 扩展预留
+	CreatedAt    time.Time         `gorm:"autoCreateTime;index:idx_status_created,priority:3" json:"created_at"`
+	UpdatedAt    time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (ReportJob) TableName() string {
+	return "report_job"
+}
+
+// ReportJobAttempt 单次模型调用记录，含重试与供应商切换
+type ReportJobAttempt struct {
+	ID               uint      `gorm:"primaryKey" json:"-"`
+	ReportJobID      string    `gorm:"index;size:40;not null" json:"report_job_id"`
+	Provider         string    `gorm:"size:32" json:"provider"`
+	Model            string    `gorm:"size:64" json:"model"`
+	Try              int       `json:"try"`
+	LatencyMs        int64     `json:"latency_ms"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	Raw              string    `gorm:"type:longtext" json:"raw,omitempty"` // 厂商原始响应，开启静态加密时不保存
+	Error            string    `gorm:"type:text" json:"error,omitempty"`
+	Skipped          bool      `gorm:"default:false" json:"skipped"` // 熔断跳过，未实际调用
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (ReportJobAttempt) TableName() string {
+	return "report_job_attempt"
+}
@@ -0,0 +1,31 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 10:05:12
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 10:05:12
+ */
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ReportTemplate 报告模板：系统提示词 + 必须输出的章节大纲；内置模板 user_id 为空，由迁移时从内嵌提示词写入
+type ReportTemplate struct {
+	TemplateID   string         `gorm:"primaryKey;size:32" json:"template_id"`
+	UserID       string         `gorm:"uniqueIndex:uid_template_name,priority:1;size:32;not null;default:''" json:"-"`
+	Name         string         `gorm:"uniqueIndex:uid_template_name,priority:2;size:64;not null" json:"name"`
+	PeriodTypes  string         `gorm:"size:128;not null" json:"period_types"` // 适用的报告周期，逗号分隔
+	SystemPrompt string         `gorm:"type:text;not null" json:"system_prompt"`
+	Sections     datatypes.JSON `gorm:"type:json" json:"sections"` // 章节大纲 []string，生成时要求按顺序输出为二级标题
+	Builtin      bool           `gorm:"default:false" json:"builtin"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (ReportTemplate) TableName() string {
+	return "report_templates"
+}
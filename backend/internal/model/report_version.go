@@ -0,0 +1,31 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 18:21:40
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 18:21:40
+ */
+package model
+
+import "time"
+
+// ReportVersion 报告正文的历史版本，每次生成、编辑、恢复后写入一条，写入后不再修改
+type ReportVersion struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	ReportID   string    `gorm:"uniqueIndex:uid_report_version,priority:1;size:32;not null" json:"report_id"`
+	Seq        int       `gorm:"uniqueIndex:uid_report_version,priority:2;not null" json:"seq"` // 报告内递增的版本序号
+	UserID     string    `gorm:"size:32;not null" json:"-"`
+	Author     string    `gorm:"size:10;not null" json:"author"` // llm/user
+	Action     string    `gorm:"size:10;not null" json:"action"` // generate/edit/restore
+	Version    int       `gorm:"default:0" json:"version"`       // 写入时报告的编辑版本号
+	GenVersion int       `gorm:"default:0" json:"gen_version"`   // 写入时报告的生成版本号
+	LLMModel   string    `gorm:"size:64" json:"llm_model,omitempty"`
+	Template   string    `gorm:"size:32" json:"template"`
+	Content    string    `gorm:"type:longtext;not null" json:"content"`
+	KeyVersion int       `gorm:"default:0" json:"-"` // 服务端静态加密所用数据密钥版本，0 为明文
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (ReportVersion) TableName() string {
+	return "report_versions"
+}
@@ -0,0 +1,25 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 22:05:31
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 22:05:31
+ */
+package model
+
+import "time"
+
+// UserDataKey 用户数据密钥，由主密钥包装后存储；同一用户 version 递增，新写入使用最新版本
+type UserDataKey struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	UserID      string    `gorm:"uniqueIndex:uid_user_data_key,priority:1;size:32;not null" json:"user_id"`
+	Version     int       `gorm:"uniqueIndex:uid_user_data_key,priority:2;not null" json:"version"`
+	MasterKeyID string    `gorm:"size:64;index;not null" json:"master_key_id"` // 包装所用主密钥
+	WrappedKey  string    `gorm:"type:text;not null" json:"-"`                 // base64(nonce||密文)
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (UserDataKey) TableName() string {
+	return "user_data_key"
+}
@@ -1,17 +1,31 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
 
 // 用户配置
 type UserSettings struct {
-	UserID              string    `gorm:"primaryKey;size:32" json:"user_id"` // 与 users.user_id 对齐
-	Timezone            string    `gorm:"size:64;default:'Asia/Shanghai'" json:"timezone"`
-	ReportTemplateWeek  string    `gorm:"type:text" json:"report_template_week,omitempty"`  // 用户自定义周报提示词模板
-	ReportTemplateMonth string    `gorm:"type:text" json:"report_template_month,omitempty"` // 用户自定义月报提示词模板
-	AutoGenerateWeekly  bool      `gorm:"default:false" json:"auto_generate_weekly"`
-	WeeklyReportTime    string    `gorm:"size:8;default:'22:00'" json:"weekly_report_time"`
-	CreatedAt           time.Time `gorm:"autoCreateTime" json:"-"`
-	UpdatedAt           time.Time `gorm:"autoUpdateTime" json:"-"`
+	UserID              string `gorm:"primaryKey;size:32" json:"user_id"` // 与 users.user_id 对齐
+	Timezone            string `gorm:"size:64;default:'Asia/Shanghai'" json:"timezone"`
+	ReportTemplateWeek  string `gorm:"type:text" json:"report_template_week,omitempty"`  // 用户自定义周报提示词模板
+	ReportTemplateMonth string `gorm:"type:text" json:"report_template_month,omitempty"` // 用户自定义月报提示词模板
+	AutoGenerateWeekly  bool   `gorm:"default:false" json:"auto_generate_weekly"`
+	WeeklyReportTime    string `gorm:"size:8;default:'22:00'" json:"weekly_report_time"` // 每周日该时刻（用户时区）自动生成本周周报
+	AutoGenerateMonthly bool   `gorm:"default:false" json:"auto_generate_monthly"`
+	MonthlyReportTime   string `gorm:"size:8;default:'22:00'" json:"monthly_report_time"` // 每月最后一天该时刻（用户时区）自动生成本月月报
+	SprintLengthDays    int    `gorm:"default:14" json:"sprint_length_days"`              // 迭代长度（天）
+	SprintAnchorDate    string `gorm:"size:10" json:"sprint_anchor_date"`                 // 任一迭代的起始日期，为空时迭代须从周一开始
+	// 端到端加密密钥参数：密钥由客户端用口令经 KDF 派生，服务端只保存派生参数与校验串，不接触口令和密钥
+	EncKeyID     string            `gorm:"size:64" json:"enc_key_id,omitempty"`
+	EncKDF       string            `gorm:"size:32" json:"enc_kdf,omitempty"` // PBKDF2-SHA256 / Argon2id
+	EncKDFParams datatypes.JSONMap `gorm:"type:json" json:"enc_kdf_params,omitempty"`
+	EncSalt      string            `gorm:"size:64" json:"enc_salt,omitempty"`       // base64
+	EncVerifier  string            `gorm:"type:text" json:"enc_verifier,omitempty"` // 客户端用该密钥加密的固定明文，用于校验口令
+	CreatedAt    time.Time         `gorm:"autoCreateTime" json:"-"`
+	UpdatedAt    time.Time         `gorm:"autoUpdateTime" json:"-"`
 }
 
 func (u *UserSettings) TableName() string {
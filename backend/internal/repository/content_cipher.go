@@ -0,0 +1,212 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 22:12:40
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 22:12:40
+ */
+package repository
+
+import (
+	"backend/internal/model"
+	"backend/pkg/keyring"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 记录、历史版本与报告正文（含结构化摘要）的服务端静态加密（信封加密）：每个用户一把数据密钥，由主密钥包装后存于 user_data_key，
+// 正文以数据密钥 AES-256-GCM 加密后 base64 存储，key_version 标记所用数据密钥版本（0 为明文）。
+// 加解密只发生在 repository 内，上层读写的始终是明文
+
+// withSealed 以密文写入 content，写入后恢复调用方持有的明文
+func (r *Repository) withSealed(ctx context.Context, userID string, content *string, keyVersion *int, write func() error) error {
+	plain := *content
+	sealed, version, err := r.sealContent(ctx, userID, plain)
+	if err != nil {
+		return err
+	}
+	*content, *keyVersion = sealed, version
+	err = write()
+	*content = plain
+	return err
+}
+
+// withSealedReport 正文与结构化摘要以同一版本数据密钥加密；加密时由结构化摘要渲染的摘要文本不落明文
+func (r *Repository) withSealedReport(ctx context.Context, report *model.Report, write func() error) error {
+	content, summary, abstract := report.Content, report.Summary, report.Abstract
+	sealed, version, err := r.sealContent(ctx, report.UserID, content)
+	if err != nil {
+		return err
+	}
+	sealedSummary, err := r.sealWithVersion(ctx, report.UserID, version, summary)
+	if err != nil {
+		return err
+	}
+	report.Content, report.Summary, report.KeyVersion = sealed, sealedSummary, version
+	if version > 0 {
+		report.Abstract = ""
+	}
+	err = write()
+	report.Content, report.Summary, report.Abstract = content, summary, abstract
+	return err
+}
+
+// sealContent 未开启加密或内容为空时原样返回，版本为 0
+func (r *Repository) sealContent(ctx context.Context, userID string, content string) (string, int, error) {
+	if !r.keyring.Enabled() || content == "" {
+		return content, 0, nil
+	}
+	version, key, err := r.currentDataKey(ctx, userID)
+	if err != nil {
+		return "", 0, err
+	}
+	sealed, err := keyring.Seal(key, []byte(content), []byte(userID))
+	if err != nil {
+		return "", 0, err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), version, nil
+}
+
+// sealWithVersion 以指定版本的数据密钥加密，用于与正文共用 key_version 的列；版本为 0 时原样返回
+func (r *Repository) sealWithVersion(ctx context.Context, userID string, version int, content string) (string, error) {
+	if version == 0 || content == "" {
+		return content, nil
+	}
+	key, err := r.dataKey(ctx, userID, version)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := keyring.Seal(key, []byte(content), []byte(userID))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openContent 按 key_version 解密；关闭加密后仍可读取存量密文
+func (r *Repository) openContent(ctx context.Context, userID string, content string, version int) (string, error) {
+	if version == 0 {
+		return content, nil
+	}
+	key, err := r.dataKey(ctx, userID, version)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return "", fmt.Errorf("decode sealed content: %w", err)
+	}
+	plain, err := keyring.Open(key, sealed, []byte(userID))
+	if err != nil {
+		return "", fmt.Errorf("open sealed content (user %s, key version %d): %w", userID, version, err)
+	}
+	return string(plain), nil
+}
+
+func (r *Repository) openRecord(ctx context.Context, record *model.Record) error {
+	content, err := r.openContent(ctx, record.UserID, record.Content, record.KeyVersion)
+	if err != nil {
+		return err
+	}
+	record.Content = content
+	return nil
+}
+
+func (r *Repository) openReport(ctx context.Context, report *model.Report) error {
+	content, err := r.openContent(ctx, report.UserID, report.Content, report.KeyVersion)
+	if err != nil {
+		return err
+	}
+	report.Content = content
+	if report.Summary != "" {
+		summary, err := r.openContent(ctx, report.UserID, report.Summary, report.KeyVersion)
+		if err != nil {
+			return err
+		}
+		report.Summary = summary
+	}
+	return nil
+}
+
+func (r *Repository) openReports(ctx context.Context, reports []*model.Report) error {
+	for _, report := range reports {
+		if err := r.openReport(ctx, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// currentDataKey 返回用户最新版本的数据密钥，首次写入时创建；并发创建时以先写入者为准
+func (r *Repository) currentDataKey(ctx context.Context, userID string) (int, []byte, error) {
+	var row model.UserDataKey
+	err := r.DB(ctx).Where("user_id = ?", userID).Order("version desc").First(&row).Error
+	if err == nil {
+		key, err := r.unwrapDataKey(&row)
+		return row.Version, key, err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil, err
+	}
+	if err := r.createDataKey(ctx, userID, 1); err != nil {
+		return 0, nil, err
+	}
+	if err := r.DB(ctx).Where("user_id = ?", userID).Order("version desc").First(&row).Error; err != nil {
+		return 0, nil, err
+	}
+	key, err := r.unwrapDataKey(&row)
+	return row.Version, key, err
+}
+
+// createDataKey 生成并以当前主密钥包装一把数据密钥，版本已存在时忽略
+func (r *Repository) createDataKey(ctx context.Context, userID string, version int) error {
+	raw, err := keyring.NewDataKey()
+	if err != nil {
+		return err
+	}
+	masterKeyID, wrapped, err := r.keyring.Wrap(raw)
+	if err != nil {
+		return err
+	}
+	return r.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserDataKey{
+		UserID:      userID,
+		Version:     version,
+		MasterKeyID: masterKeyID,
+		WrappedKey:  base64.StdEncoding.EncodeToString(wrapped),
+	}).Error
+}
+
+func (r *Repository) dataKey(ctx context.Context, userID string, version int) ([]byte, error) {
+	cacheKey := fmt.Sprintf("%s#%d", userID, version)
+	if key, ok := r.dataKeys.Load(cacheKey); ok {
+		return key.([]byte), nil
+	}
+	var row model.UserDataKey
+	if err := r.DB(ctx).Where("user_id = ? AND version = ?", userID, version).First(&row).Error; err != nil {
+		return nil, fmt.Errorf("load data key (user %s, version %d): %w", userID, version, err)
+	}
+	return r.unwrapDataKey(&row)
+}
+
+// unwrapDataKey 解包后按 user_id#version 缓存；重新包装不改变数据密钥本身，缓存无需失效
+func (r *Repository) unwrapDataKey(row *model.UserDataKey) ([]byte, error) {
+	cacheKey := fmt.Sprintf("%s#%d", row.UserID, row.Version)
+	if key, ok := r.dataKeys.Load(cacheKey); ok {
+		return key.([]byte), nil
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(row.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode wrapped data key: %w", err)
+	}
+	key, err := r.keyring.Unwrap(row.MasterKeyID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key (user %s, version %d): %w", row.UserID, row.Version, err)
+	}
+	r.dataKeys.Store(cacheKey, key)
+	return key, nil
+}
@@ -0,0 +1,131 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 23:54:10
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 23:54:10
+ */
+package repository
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExportJobRepository interface {
+	Create(ctx context.Context, job *model.ExportJob) error
+	GetByID(ctx context.Context, userID string, exportID string) (*model.ExportJob, error)
+	GetByExportID(ctx context.Context, exportID string) (*model.ExportJob, error)
+	GetRunning(ctx context.Context, userID string) (*model.ExportJob, error)
+	Touch(ctx context.Context, exportID string) (bool, error)
+	Finish(ctx context.Context, job *model.ExportJob) (bool, error)
+	ListExpired(ctx context.Context, userID string, now time.Time) ([]*model.ExportJob, error)
+	MarkExpired(ctx context.Context, exportID string) error
+}
+
+func NewExportJobRepository(r *Repository) ExportJobRepository {
+	return &exportJobRepository{
+		Repository: r,
+	}
+}
+
+type exportJobRepository struct {
+	*Repository
+}
+
+func (r *exportJobRepository) Create(ctx context.Context, job *model.ExportJob) error {
+	if err := r.DB(ctx).Create(job).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *exportJobRepository) GetByID(ctx context.Context, userID string, exportID string) (*model.ExportJob, error) {
+	var job model.ExportJob
+	if err := r.DB(ctx).Where("user_id = ? AND export_id = ?", userID, exportID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetByExportID 供签名下载使用，调用方已校验签名
+func (r *exportJobRepository) GetByExportID(ctx context.Context, exportID string) (*model.ExportJob, error) {
+	var job model.ExportJob
+	if err := r.DB(ctx).Where("export_id = ?", exportID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *exportJobRepository) GetRunning(ctx context.Context, userID string) (*model.ExportJob, error) {
+	var job model.ExportJob
+	if err := r.DB(ctx).Where("user_id = ? AND status = ?", userID, v1.ExportStatusRunning).Order("created_at desc").First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Touch 刷新进行中导出的 updated_at，返回 false 表示导出已结束（如被判定为中断）
+func (r *exportJobRepository) Touch(ctx context.Context, exportID string) (bool, error) {
+	result := r.DB(ctx).Model(&model.ExportJob{}).
+		Where("export_id = ? AND status = ?", exportID, v1.ExportStatusRunning).
+		Update("updated_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Finish 仅结束仍在进行中的导出，返回 false 表示已被其他请求结束
+func (r *exportJobRepository) Finish(ctx context.Context, job *model.ExportJob) (bool, error) {
+	result := r.DB(ctx).Model(&model.ExportJob{}).
+		Where("export_id = ? AND status = ?", job.ExportID, v1.ExportStatusRunning).
+		Updates(map[string]interface{}{
+			"status":      job.Status,
+			"file_path":   job.FilePath,
+			"size":        job.Size,
+			"records":     job.Records,
+			"reports":     job.Reports,
+			"error":       job.Error,
+			"finished_at": job.FinishedAt,
+			"expires_at":  job.ExpiresAt,
+			"updated_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListExpired 用户已过保留时长、压缩包尚未清理的导出
+func (r *exportJobRepository) ListExpired(ctx context.Context, userID string, now time.Time) ([]*model.ExportJob, error) {
+	var jobs []*model.ExportJob
+	if err := r.DB(ctx).Where("user_id = ? AND status = ? AND expires_at < ?", userID, v1.ExportStatusReady, now).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *exportJobRepository) MarkExpired(ctx context.Context, exportID string) error {
+	return r.DB(ctx).Model(&model.ExportJob{}).
+		Where("export_id = ?", exportID).
+		Updates(map[string]interface{}{
+			"status":     v1.ExportStatusExpired,
+			"file_path":  "",
+			"updated_at": time.Now(),
+		}).Error
+}
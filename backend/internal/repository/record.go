@@ -12,6 +12,7 @@ import (
 	"backend/internal/model"
 	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -19,6 +20,7 @@ import (
 type RecordRespository interface {
 	Create(ctx context.Context, record *model.Record) error
 	Update(ctx context.Context, record *model.Record) error
+	UpdateIfVersion(ctx context.Context, record *model.Record, version int) (bool, error)
 	GetByID(ctx context.Context, userID string, recordID string) (*model.Record, error)
 	GetByUserID(ctx context.Context, userID string, date string) ([]*model.Record, error)
 	GetByDateRange(ctx context.Context, userID string, startDate string, endDate string) ([]*model.Record, error)
@@ -35,17 +37,44 @@ type recordRepository struct {
 }
 
 func (r *recordRepository) Create(ctx context.Context, record *model.Record) error {
-	if err := r.DB(ctx).Create(record).Error; err != nil {
-		return err
-	}
-	return nil
+	return r.withSealed(ctx, record.UserID, &record.Content, &record.KeyVersion, func() error {
+		return r.DB(ctx).Create(record).Error
+	})
 }
 
 func (r *recordRepository) Update(ctx context.Context, record *model.Record) error {
-	if err := r.DB(ctx).Save(record).Error; err != nil {
-		return err
+	return r.withSealed(ctx, record.UserID, &record.Content, &record.KeyVersion, func() error {
+		return r.DB(ctx).Save(record).Error
+	})
+}
+
+// UpdateIfVersion 仅当记录仍为 version 时写入，返回 false 表示期间已被其他端修改
+func (r *recordRepository) UpdateIfVersion(ctx context.Context, record *model.Record, version int) (bool, error) {
+	content, keyVersion, err := r.sealContent(ctx, record.UserID, record.Content)
+	if err != nil {
+		return false, err
 	}
-	return nil
+	now := time.Now()
+	result := r.DB(ctx).Model(&model.Record{}).
+		Where("record_id = ? AND version = ?", record.RecordID, version).
+		Updates(map[string]interface{}{
+			"content":     content,
+			"key_version": keyVersion,
+			"word_count":  record.WordCount,
+			"meta":        record.Meta,
+			"version":     record.Version,
+			"is_deleted":  record.IsDeleted,
+			"updated_at":  now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return false, nil
+	}
+	record.KeyVersion = keyVersion
+	record.UpdatedAt = now
+	return true, nil
 }
 
 func (r *recordRepository) GetByID(ctx context.Context, userID string, recordID string) (*model.Record, error) {
@@ -56,6 +85,9 @@ func (r *recordRepository) GetByID(ctx context.Context, userID string, recordID
 		}
 		return nil, err
 	}
+	if err := r.openRecord(ctx, &record); err != nil {
+		return nil, err
+	}
 	return &record, nil
 }
 
@@ -74,6 +106,9 @@ func (r *recordRepository) GetByUserID(ctx context.Context, userId string, date
 		}
 		return nil, err
 	}
+	if err := r.openRecords(ctx, records); err != nil {
+		return nil, err
+	}
 	return records, nil
 }
 
@@ -87,5 +122,17 @@ func (r *recordRepository) GetByDateRange(ctx context.Context, userId string, st
 		}
 		return nil, err
 	}
+	if err := r.openRecords(ctx, records); err != nil {
+		return nil, err
+	}
 	return records, nil
 }
+
+func (r *recordRepository) openRecords(ctx context.Context, records []*model.Record) error {
+	for _, record := range records {
+		if err := r.openRecord(ctx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 23:14:02
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 23:14:02
+ */
+package repository
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RecordImportRepository interface {
+	Create(ctx context.Context, job *model.RecordImportJob) error
+	GetByID(ctx context.Context, userID string, jobID string) (*model.RecordImportJob, error)
+	UpdateProgress(ctx context.Context, job *model.RecordImportJob) (bool, error)
+	Touch(ctx context.Context, jobID string) (bool, error)
+	FailStale(ctx context.Context, jobID string, before time.Time, errMsg string) (bool, error)
+}
+
+func NewRecordImportRepository(r *Repository) RecordImportRepository {
+	return &recordImportRepository{
+		Repository: r,
+	}
+}
+
+type recordImportRepository struct {
+	*Repository
+}
+
+func (r *recordImportRepository) Create(ctx context.Context, job *model.RecordImportJob) error {
+	if err := r.DB(ctx).Create(job).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *recordImportRepository) GetByID(ctx context.Context, userID string, jobID string) (*model.RecordImportJob, error) {
+	var job model.RecordImportJob
+	if err := r.DB(ctx).Where("user_id = ? AND import_job_id = ?", userID, jobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// UpdateProgress 写入计数、错误明细与状态，updated_at 同时作为心跳；仅更新运行中的任务，
+// 返回 false 表示任务已被结束（如被判定为中断）
+func (r *recordImportRepository) UpdateProgress(ctx context.Context, job *model.RecordImportJob) (bool, error) {
+	result := r.DB(ctx).Model(&model.RecordImportJob{}).
+		Where("import_job_id = ? AND status = ?", job.ImportJobID, v1.RecordImportStatusRunning).
+		Updates(map[string]interface{}{
+			"status":      job.Status,
+			"processed":   job.Processed,
+			"created":     job.Created,
+			"updated":     job.Updated,
+			"skipped":     job.Skipped,
+			"failed":      job.Failed,
+			"errors":      job.Errors,
+			"error":       job.Error,
+			"finished_at": job.FinishedAt,
+			"updated_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Touch 仅刷新运行中任务的 updated_at，返回 false 表示任务已被结束
+func (r *recordImportRepository) Touch(ctx context.Context, jobID string) (bool, error) {
+	result := r.DB(ctx).Model(&model.RecordImportJob{}).
+		Where("import_job_id = ? AND status = ?", jobID, v1.RecordImportStatusRunning).
+		Update("updated_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// FailStale 进度长时间未更新的运行中任务视为进程中断，标记失败
+func (r *recordImportRepository) FailStale(ctx context.Context, jobID string, before time.Time, errMsg string) (bool, error) {
+	now := time.Now()
+	result := r.DB(ctx).Model(&model.RecordImportJob{}).
+		Where("import_job_id = ? AND status = ? AND updated_at < ?", jobID, v1.RecordImportStatusRunning, before).
+		Updates(map[string]interface{}{
+			"status":      v1.RecordImportStatusFailed,
+			"error":       errMsg,
+			"finished_at": &now,
+			"updated_at":  now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,98 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 20:09:37
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 20:09:37
+ */
+package repository
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RecordRevisionRepository interface {
+	Create(ctx context.Context, revision *model.RecordRevision) error
+	ListByRecord(ctx context.Context, recordID string) ([]*model.RecordRevision, error)
+	GetByRevision(ctx context.Context, recordID string, revision int) (*model.RecordRevision, error)
+	Prune(ctx context.Context, keep int, before time.Time, limit int) (int64, error)
+}
+
+func NewRecordRevisionRepository(r *Repository) RecordRevisionRepository {
+	return &recordRevisionRepository{
+		Repository: r,
+	}
+}
+
+type recordRevisionRepository struct {
+	*Repository
+}
+
+func (r *recordRevisionRepository) Create(ctx context.Context, revision *model.RecordRevision) error {
+	return r.withSealed(ctx, revision.UserID, &revision.Content, &revision.KeyVersion, func() error {
+		return r.DB(ctx).Create(revision).Error
+	})
+}
+
+// ListByRecord 按版本倒序返回，不含正文
+func (r *recordRevisionRepository) ListByRecord(ctx context.Context, recordID string) ([]*model.RecordRevision, error) {
+	var revisions []*model.RecordRevision
+	if err := r.DB(ctx).
+		Select("id", "record_id", "revision", "user_id", "date", "word_count", "created_at").
+		Where("record_id = ?", recordID).
+		Order("revision desc").
+		Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *recordRevisionRepository) GetByRevision(ctx context.Context, recordID string, revision int) (*model.RecordRevision, error) {
+	var rev model.RecordRevision
+	if err := r.DB(ctx).Where("record_id = ? AND revision = ?", recordID, revision).First(&rev).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	content, err := r.openContent(ctx, rev.UserID, rev.Content, rev.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+	rev.Content = content
+	return &rev, nil
+}
+
+// Prune 删除每条记录最新 keep 个版本之外、或早于 before 的历史版本，单次最多删除 limit 条；
+// keep <= 0 表示不限个数，before 为零值表示不限时间
+func (r *recordRevisionRepository) Prune(ctx context.Context, keep int, before time.Time, limit int) (int64, error) {
+	if keep <= 0 && before.IsZero() {
+		return 0, nil
+	}
+	query := r.DB(ctx).Table("(?) AS ranked", r.DB(ctx).Model(&model.RecordRevision{}).
+		Select("id, created_at, ROW_NUMBER() OVER (PARTITION BY record_id ORDER BY revision DESC) AS rn"))
+	switch {
+	case keep > 0 && !before.IsZero():
+		query = query.Where("rn > ? OR created_at < ?", keep, before)
+	case keep > 0:
+		query = query.Where("rn > ?", keep)
+	default:
+		query = query.Where("created_at < ?", before)
+	}
+
+	var ids []uint
+	if err := query.Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := r.DB(ctx).Where("id IN ?", ids).Delete(&model.RecordRevision{})
+	return result.RowsAffected, result.Error
+}
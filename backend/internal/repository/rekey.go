@@ -0,0 +1,302 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 22:31:18
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 22:31:18
+ */
+package repository
+
+import (
+	"backend/internal/model"
+	"backend/pkg/keyring"
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// RekeyRepository 密钥轮换：重新包装数据密钥、生成新版本数据密钥，并把存量正文迁移到当前密钥（未开启加密时还原为明文）
+type RekeyRepository interface {
+	RewrapDataKeys(ctx context.Context, batch int) (int, error)
+	RotateDataKeys(ctx context.Context, batch int) (int, error)
+	ReencryptRecords(ctx context.Context, batch int) (int, error)
+	ReencryptRevisions(ctx context.Context, batch int) (int, error)
+	ReencryptReports(ctx context.Context, batch int) (int, error)
+	ReencryptReportVersions(ctx context.Context, batch int) (int, error)
+	ReencryptReportJobs(ctx context.Context, batch int) (int, error)
+}
+
+func NewRekeyRepository(r *Repository) RekeyRepository {
+	return &rekeyRepository{
+		Repository: r,
+	}
+}
+
+type rekeyRepository struct {
+	*Repository
+}
+
+// RewrapDataKeys 用当前主密钥重新包装仍由旧主密钥包装的数据密钥，数据密钥本身不变，正文无需重新加密
+func (r *rekeyRepository) RewrapDataKeys(ctx context.Context, batch int) (int, error) {
+	current := r.keyring.CurrentKeyID()
+	if current == "" {
+		return 0, keyring.ErrNoMasterKey
+	}
+	rewrapped := 0
+	var rows []*model.UserDataKey
+	err := r.DB(ctx).Where("master_key_id <> ?", current).FindInBatches(&rows, batch, func(tx *gorm.DB, _ int) error {
+		for _, row := range rows {
+			key, err := r.unwrapDataKey(row)
+			if err != nil {
+				return err
+			}
+			masterKeyID, wrapped, err := r.keyring.Wrap(key)
+			if err != nil {
+				return err
+			}
+			result := r.DB(ctx).Model(&model.UserDataKey{}).
+				Where("id = ? AND master_key_id = ?", row.ID, row.MasterKeyID).
+				Updates(map[string]interface{}{
+					"master_key_id": masterKeyID,
+					"wrapped_key":   base64.StdEncoding.EncodeToString(wrapped),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			rewrapped += int(result.RowsAffected)
+		}
+		return nil
+	}).Error
+	return rewrapped, err
+}
+
+// RotateDataKeys 为每个已有数据密钥的用户生成下一版本，之后的写入使用新版本，存量正文由 Reencrypt* 迁移
+func (r *rekeyRepository) RotateDataKeys(ctx context.Context, batch int) (int, error) {
+	type latest struct {
+		UserID  string
+		Version int
+	}
+	rotated := 0
+	cursor := ""
+	for {
+		var rows []latest
+		if err := r.DB(ctx).Model(&model.UserDataKey{}).
+			Select("user_id, MAX(version) AS version").
+			Where("user_id > ?", cursor).
+			Group("user_id").
+			Order("user_id").
+			Limit(batch).
+			Scan(&rows).Error; err != nil {
+			return rotated, err
+		}
+		for _, row := range rows {
+			if err := r.createDataKey(ctx, row.UserID, row.Version+1); err != nil {
+				return rotated, err
+			}
+			rotated += 1
+		}
+		if len(rows) < batch {
+			return rotated, nil
+		}
+		cursor = rows[len(rows)-1].UserID
+	}
+}
+
+func (r *rekeyRepository) ReencryptRecords(ctx context.Context, batch int) (int, error) {
+	changed := 0
+	targets := make(map[string]int)
+	var rows []*model.Record
+	err := r.DB(ctx).Unscoped().Select("record_id", "user_id", "content", "key_version").
+		FindInBatches(&rows, batch, func(tx *gorm.DB, _ int) error {
+			for _, row := range rows {
+				ok, err := r.reencrypt(ctx, targets, sealedRow{table: "record", pk: "record_id", id: row.RecordID, userID: row.UserID,
+					keyVersion: row.KeyVersion, column: "content", value: row.Content})
+				if err != nil {
+					return err
+				}
+				if ok {
+					changed += 1
+				}
+			}
+			return nil
+		}).Error
+	return changed, err
+}
+
+func (r *rekeyRepository) ReencryptRevisions(ctx context.Context, batch int) (int, error) {
+	changed := 0
+	targets := make(map[string]int)
+	var rows []*model.RecordRevision
+	err := r.DB(ctx).Select("id", "user_id", "content", "key_version").
+		FindInBatches(&rows, batch, func(tx *gorm.DB, _ int) error {
+			for _, row := range rows {
+				ok, err := r.reencrypt(ctx, targets, sealedRow{table: "record_revision", pk: "id", id: row.ID, userID: row.UserID,
+					keyVersion: row.KeyVersion, column: "content", value: row.Content})
+				if err != nil {
+					return err
+				}
+				if ok {
+					changed += 1
+				}
+			}
+			return nil
+		}).Error
+	return changed, err
+}
+
+// ReencryptReports 结构化摘要与正文共用 key_version；由摘要渲染的 abstract 在迁移到加密时清空，与业务写入一致
+func (r *rekeyRepository) ReencryptReports(ctx context.Context, batch int) (int, error) {
+	changed := 0
+	targets := make(map[string]int)
+	var rows []*model.Report
+	err := r.DB(ctx).Unscoped().Select("report_id", "user_id", "content", "summary", "abstract", "key_version").
+		FindInBatches(&rows, batch, func(tx *gorm.DB, _ int) error {
+			for _, row := range rows {
+				ok, err := r.reencrypt(ctx, targets, sealedRow{table: "report", pk: "report_id", id: row.ReportID, userID: row.UserID,
+					keyVersion: row.KeyVersion, column: "content", value: row.Content,
+					extra: map[string]string{"summary": row.Summary}, derived: map[string]string{"abstract": row.Abstract}})
+				if err != nil {
+					return err
+				}
+				if ok {
+					changed += 1
+				}
+			}
+			return nil
+		}).Error
+	return changed, err
+}
+
+func (r *rekeyRepository) ReencryptReportVersions(ctx context.Context, batch int) (int, error) {
+	changed := 0
+	targets := make(map[string]int)
+	var rows []*model.ReportVersion
+	err := r.DB(ctx).Select("id", "user_id", "content", "key_version").
+		FindInBatches(&rows, batch, func(tx *gorm.DB, _ int) error {
+			for _, row := range rows {
+				ok, err := r.reencrypt(ctx, targets, sealedRow{table: "report_versions", pk: "id", id: row.ID, userID: row.UserID,
+					keyVersion: row.KeyVersion, column: "content", value: row.Content})
+				if err != nil {
+					return err
+				}
+				if ok {
+					changed += 1
+				}
+			}
+			return nil
+		}).Error
+	return changed, err
+}
+
+// ReencryptReportJobs 生成任务的提示词与生成结果共用 key_version，以提示词为准判定与条件更新
+func (r *rekeyRepository) ReencryptReportJobs(ctx context.Context, batch int) (int, error) {
+	changed := 0
+	targets := make(map[string]int)
+	var rows []*model.ReportJob
+	err := r.DB(ctx).Select("report_job_id", "user_id", "prompt", "result", "key_version").
+		FindInBatches(&rows, batch, func(tx *gorm.DB, _ int) error {
+			for _, row := range rows {
+				ok, err := r.reencrypt(ctx, targets, sealedRow{table: "report_job", pk: "report_job_id", id: row.ReportJobID, userID: row.UserID,
+					keyVersion: row.KeyVersion, column: "prompt", value: row.Prompt, extra: map[string]string{"result": row.Result}})
+				if err != nil {
+					return err
+				}
+				if ok {
+					changed += 1
+				}
+			}
+			return nil
+		}).Error
+	return changed, err
+}
+
+// sealedRow 一行中以同一 key_version 加密的列。column 为主列，为空的行跳过，并以读取时的密文作为条件更新；
+// extra 为共用 key_version 的其他加密列；derived 为由正文派生的明文列，迁移到加密时清空
+type sealedRow struct {
+	table      string
+	pk         string
+	id         any
+	userID     string
+	keyVersion int
+	column     string
+	value      string
+	extra      map[string]string
+	derived    map[string]string
+}
+
+// reencrypt 把一行正文迁移到用户当前的数据密钥版本；以读取时的密文为条件更新，
+// 期间被业务写入覆盖（业务写入总是使用当前版本）的行跳过
+func (r *rekeyRepository) reencrypt(ctx context.Context, targets map[string]int, row sealedRow) (bool, error) {
+	if row.value == "" {
+		return false, nil
+	}
+	userID, keyVersion := row.userID, row.keyVersion
+	target, ok := targets[userID]
+	if !ok {
+		var err error
+		target, err = r.targetVersion(ctx, userID)
+		if err != nil {
+			return false, err
+		}
+		targets[userID] = target
+	}
+	if keyVersion == target {
+		return false, nil
+	}
+
+	plain, err := r.openContent(ctx, userID, row.value, keyVersion)
+	if err != nil {
+		return false, err
+	}
+	sealed, version, err := r.sealContent(ctx, userID, plain)
+	if err != nil {
+		return false, err
+	}
+	targets[userID] = version
+	updates := map[string]interface{}{
+		row.column:    sealed,
+		"key_version": version,
+	}
+	for column, value := range row.extra {
+		if value == "" {
+			continue
+		}
+		plain, err := r.openContent(ctx, userID, value, keyVersion)
+		if err != nil {
+			return false, err
+		}
+		if updates[column], err = r.sealWithVersion(ctx, userID, version, plain); err != nil {
+			return false, err
+		}
+	}
+	query := r.DB(ctx).Table(row.table).Where(row.pk+" = ? AND key_version = ? AND "+row.column+" = ?", row.id, keyVersion, row.value)
+	if version > 0 {
+		for column, value := range row.derived {
+			updates[column] = ""
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	result := query.UpdateColumns(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// targetVersion 开启加密时为用户最新的数据密钥版本（尚无数据密钥时为 -1，首次加密时创建），未开启时为 0 即明文
+func (r *rekeyRepository) targetVersion(ctx context.Context, userID string) (int, error) {
+	if !r.keyring.Enabled() {
+		return 0, nil
+	}
+	var row model.UserDataKey
+	err := r.DB(ctx).Where("user_id = ?", userID).Order("version desc").First(&row).Error
+	if err == nil {
+		return row.Version, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return -1, nil
+	}
+	return 0, err
+}
@@ -14,12 +14,16 @@ import (
 	"errors"
 	"time"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ReportRepository interface {
 	Create(ctx context.Context, report *model.Report) error
 	Update(ctx context.Context, report *model.Report) error
+	UpdateContentIfVersion(ctx context.Context, report *model.Report, version int, genVersion int) (bool, error)
+	ConfirmIfVersion(ctx context.Context, reportID string, version int, genVersion int, confirmedAt time.Time) (bool, error)
 	GetByID(ctx context.Context, userID string, reportID string) (*model.Report, error)
 	GetByReportID(ctx context.Context, reportID string) (*model.Report, error)
 	GetByUnique(ctx context.Context, userID string, periodType string, startDate string, endDate string) (*model.Report, error)
@@ -27,11 +31,17 @@ type ReportRepository interface {
 	GetByPeriodType(ctx context.Context, userID string, periodType string) ([]*model.Report, error)
 	GetAll(ctx context.Context, userID string) ([]*model.Report, error)
 
-	ListByStatus(ctx context.Context, status string, limit int) ([]*model.Report, error)
 	ListConfirmedByPeriod(ctx context.Context, userID string, periodType string, start string, end string) ([]*model.Report, error)
-	TryMarkProcessing(ctx context.Context, reportID string, genVersion int) (bool, error)
-	UpdateGenerated(ctx context.Context, reportID string, genVersion int, content string, abstract string) error
-	UpdateFailed(ctx context.Context, reportID string, genVersion int, reason string) error
+	TryMarkProcessing(ctx context.Context, reportID string, genVersion int, owner string, lease time.Duration) (bool, error)
+	ClaimNext(ctx context.Context, owner string, lease time.Duration, perUserLimit int) (*model.Report, error)
+	RenewLease(ctx context.Context, reportID string, genVersion int, owner string, lease time.Duration) (bool, error)
+	ListExpiredLeases(ctx context.Context, now time.Time, limit int) ([]*model.Report, error)
+	RequeueExpired(ctx context.Context, reportID string, genVersion int, now time.Time) (bool, error)
+	FailExpired(ctx context.Context, reportID string, genVersion int, now time.Time, reason string) (bool, error)
+	UpdatePartial(ctx context.Context, reportID string, genVersion int, owner string, content string) error
+	UpdateGenerated(ctx context.Context, reportID string, genVersion int, owner string, content string, abstract string, summary string, llmModel string, meta datatypes.JSONMap) (bool, error)
+	UpdateSummary(ctx context.Context, report *model.Report) (bool, error)
+	UpdateFailed(ctx context.Context, reportID string, genVersion int, owner string, reason string, meta datatypes.JSONMap) error
 }
 
 func NewReportRepository(r *Repository) ReportRepository {
@@ -45,17 +55,70 @@ type reportRepository struct {
 }
 
 func (r *reportRepository) Create(ctx context.Context, report *model.Report) error {
-	if err := r.DB(ctx).Create(report).Error; err != nil {
-		return err
-	}
-	return nil
+	return r.withSealedReport(ctx, report, func() error {
+		return r.DB(ctx).Create(report).Error
+	})
 }
 
 func (r *reportRepository) Update(ctx context.Context, report *model.Report) error {
-	if err := r.DB(ctx).Save(report).Error; err != nil {
-		return err
+	return r.withSealedReport(ctx, report, func() error {
+		return r.DB(ctx).Save(report).Error
+	})
+}
+
+// UpdateContentIfVersion 仅当报告仍为 version/genVersion 且已生成完成时写入编辑内容（含摘要），
+// 返回 false 表示期间已被其他端编辑或重新生成
+func (r *reportRepository) UpdateContentIfVersion(ctx context.Context, report *model.Report, version int, genVersion int) (bool, error) {
+	content, keyVersion, err := r.sealContent(ctx, report.UserID, report.Content)
+	if err != nil {
+		return false, err
 	}
-	return nil
+	summary, err := r.sealWithVersion(ctx, report.UserID, keyVersion, report.Summary)
+	if err != nil {
+		return false, err
+	}
+	abstract := report.Abstract
+	if keyVersion > 0 {
+		abstract = ""
+	}
+	now := time.Now()
+	result := r.DB(ctx).Model(&model.Report{}).
+		Where("report_id = ? AND version = ? AND gen_version = ? AND status = ?", report.ReportID, version, genVersion, v1.ReportStatusReady).
+		Updates(map[string]interface{}{
+			"content":      content,
+			"key_version":  keyVersion,
+			"summary":      summary,
+			"abstract":     abstract,
+			"version":      report.Version,
+			"confirmed":    report.Confirmed,
+			"confirmed_at": report.ConfirmedAt,
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return false, nil
+	}
+	report.KeyVersion = keyVersion
+	report.UpdatedAt = now
+	return true, nil
+}
+
+// ConfirmIfVersion 仅当报告仍为 version/genVersion 且已生成完成时标记确认，只写确认相关的列；
+// 返回 false 表示期间已被编辑或重新生成
+func (r *reportRepository) ConfirmIfVersion(ctx context.Context, reportID string, version int, genVersion int, confirmedAt time.Time) (bool, error) {
+	result := r.DB(ctx).Model(&model.Report{}).
+		Where("report_id = ? AND version = ? AND gen_version = ? AND status = ?", reportID, version, genVersion, v1.ReportStatusReady).
+		Updates(map[string]interface{}{
+			"confirmed":    true,
+			"confirmed_at": confirmedAt,
+			"updated_at":   confirmedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
 }
 
 func (r *reportRepository) GetByID(ctx context.Context, userID string, reportID string) (*model.Report, error) {
@@ -66,6 +129,9 @@ func (r *reportRepository) GetByID(ctx context.Context, userID string, reportID
 		}
 		return nil, err
 	}
+	if err := r.openReport(ctx, &report); err != nil {
+		return nil, err
+	}
 	return &report, nil
 }
 
@@ -77,6 +143,9 @@ func (r *reportRepository) GetByReportID(ctx context.Context, reportID string) (
 		}
 		return nil, err
 	}
+	if err := r.openReport(ctx, &report); err != nil {
+		return nil, err
+	}
 	return &report, nil
 }
 
@@ -94,6 +163,9 @@ func (r *reportRepository) GetByUnique(ctx context.Context, userID string, perio
 		}
 		return nil, err
 	}
+	if err := r.openReport(ctx, &report); err != nil {
+		return nil, err
+	}
 	return &report, nil
 }
 
@@ -105,6 +177,9 @@ func (r *reportRepository) GetByDateRange(ctx context.Context, userID string, pe
 		}
 		return nil, err
 	}
+	if err := r.openReports(ctx, reports); err != nil {
+		return nil, err
+	}
 	return reports, nil
 }
 
@@ -116,6 +191,9 @@ func (r *reportRepository) GetByPeriodType(ctx context.Context, userID string, p
 		}
 		return nil, err
 	}
+	if err := r.openReports(ctx, reports); err != nil {
+		return nil, err
+	}
 	return reports, nil
 }
 
@@ -127,12 +205,7 @@ func (r *reportRepository) GetAll(ctx context.Context, userID string) ([]*model.
 		}
 		return nil, err
 	}
-	return reports, nil
-}
-
-func (r *reportRepository) ListByStatus(ctx context.Context, status string, limit int) ([]*model.Report, error) {
-	var reports []*model.Report
-	if err := r.DB(ctx).Where("status = ?", status).Order("updated_at asc").Limit(limit).Find(&reports).Error; err != nil {
+	if err := r.openReports(ctx, reports); err != nil {
 		return nil, err
 	}
 	return reports, nil
@@ -146,15 +219,23 @@ func (r *reportRepository) ListConfirmedByPeriod(ctx context.Context, userID str
 		Find(&reports).Error; err != nil {
 		return nil, err
 	}
+	if err := r.openReports(ctx, reports); err != nil {
+		return nil, err
+	}
 	return reports, nil
 }
 
-func (r *reportRepository) TryMarkProcessing(ctx context.Context, reportID string, genVersion int) (bool, error) {
+// TryMarkProcessing 领取排队中的报告并持有租约，租约到期未续期视为处理进程已退出
+func (r *reportRepository) TryMarkProcessing(ctx context.Context, reportID string, genVersion int, owner string, lease time.Duration) (bool, error) {
+	now := time.Now()
 	result := r.DB(ctx).Model(&model.Report{}).
 		Where("report_id = ? AND status = ? AND gen_version = ?", reportID, v1.ReportStatusQueued, genVersion).
 		Updates(map[string]interface{}{
-			"status":     v1.ReportStatusProcessing,
-			"updated_at": time.Now(),
+			"status":        v1.ReportStatusProcessing,
+			"lease_owner":   owner,
+			"lease_expires": now.Add(lease),
+			"claim_count":   gorm.Expr("claim_count + 1"),
+			"updated_at":    now,
 		})
 	if result.Error != nil {
 		return false, result.Error
@@ -162,32 +243,231 @@ func (r *reportRepository) TryMarkProcessing(ctx context.Context, reportID strin
 	return result.RowsAffected == 1, nil
 }
 
-func (r *reportRepository) UpdateGenerated(ctx context.Context, reportID string, genVersion int, content string, abstract string) error {
+// claimCandidates 每轮领取时考察的候选数
+const claimCandidates = 10
+
+// ClaimNext 为 owner 领取下一条排队中的报告，无可领取时返回 ErrNotFound。
+// 候选按「用户处理中数量 + 用户内排队序号」排序，各用户轮流领取，已有 perUserLimit 条在处理中的用户跳过，
+// 避免单个用户的大量积压（如年报）占满所有 worker；多实例下 MySQL/Postgres 通过 FOR UPDATE SKIP LOCKED
+// 跳过其他实例正在领取的行，SQLite 只有单写者，依靠带状态条件的更新保证只被领取一次
+func (r *reportRepository) ClaimNext(ctx context.Context, owner string, lease time.Duration, perUserLimit int) (*model.Report, error) {
+	queued := r.DB(ctx).Model(&model.Report{}).
+		Select("report_id, user_id, updated_at, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY updated_at, report_id) AS rn").
+		Where("status = ?", v1.ReportStatusQueued)
+	busy := r.DB(ctx).Model(&model.Report{}).
+		Select("user_id, COUNT(*) AS busy").
+		Where("status = ?", v1.ReportStatusProcessing).
+		Group("user_id")
+	query := r.DB(ctx).Table("(?) AS q", queued).
+		Joins("LEFT JOIN (?) AS p ON p.user_id = q.user_id", busy)
+	if perUserLimit > 0 {
+		query = query.Where("COALESCE(p.busy, 0) < ?", perUserLimit)
+	}
+	var candidates []string
+	if err := query.Order("COALESCE(p.busy, 0) + q.rn, q.updated_at").Limit(claimCandidates).Pluck("q.report_id", &candidates).Error; err != nil {
+		return nil, err
+	}
+
+	skipLocked := r.db.Dialector.Name() != "sqlite"
+	for _, reportID := range candidates {
+		var claimed *model.Report
+		err := r.Transaction(ctx, func(ctx context.Context) error {
+			tx := r.DB(ctx).Where("report_id = ? AND status = ?", reportID, v1.ReportStatusQueued)
+			if skipLocked {
+				tx = tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+			}
+			var report model.Report
+			if err := tx.First(&report).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					// 已被其他实例领取或锁定
+					return nil
+				}
+				return err
+			}
+			ok, err := r.TryMarkProcessing(ctx, report.ReportID, report.GenVersion, owner, lease)
+			if err != nil || !ok {
+				return err
+			}
+			report.Status = string(v1.ReportStatusProcessing)
+			report.LeaseOwner = owner
+			claimed = &report
+			return r.openReport(ctx, claimed)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if claimed != nil {
+			return claimed, nil
+		}
+	}
+	return nil, v1.ErrNotFound
+}
+
+// RenewLease 心跳续期，返回 false 表示租约已丢失（被回收或报告已重新生成）
+func (r *reportRepository) RenewLease(ctx context.Context, reportID string, genVersion int, owner string, lease time.Duration) (bool, error) {
+	result := r.DB(ctx).Model(&model.Report{}).
+		Where("report_id = ? AND gen_version = ? AND status = ? AND lease_owner = ?", reportID, genVersion, v1.ReportStatusProcessing, owner).
+		Update("lease_expires", time.Now().Add(lease))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ListExpiredLeases 处理中但租约已过期的报告，未设置租约的是租约机制上线前遗留的处理中报告
+func (r *reportRepository) ListExpiredLeases(ctx context.Context, now time.Time, limit int) ([]*model.Report, error) {
+	var reports []*model.Report
+	if err := r.DB(ctx).Where("status = ? AND (lease_expires < ? OR lease_expires IS NULL)", v1.ReportStatusProcessing, now).
+		Order("lease_expires asc").Limit(limit).Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	if err := r.openReports(ctx, reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// RequeueExpired 租约过期的报告放回队列，条件中再次校验过期避免与心跳竞争
+func (r *reportRepository) RequeueExpired(ctx context.Context, reportID string, genVersion int, now time.Time) (bool, error) {
 	result := r.DB(ctx).Model(&model.Report{}).
-		Where("report_id = ? AND gen_version = ?", reportID, genVersion).
+		Where("report_id = ? AND gen_version = ? AND status = ? AND (lease_expires < ? OR lease_expires IS NULL)", reportID, genVersion, v1.ReportStatusProcessing, now).
 		Updates(map[string]interface{}{
-			"status":        v1.ReportStatusReady,
-			"content":       content,
-			"abstract":      abstract,
-			"failed_reason": "",
-			"updated_at":    time.Now(),
+			"status":        v1.ReportStatusQueued,
+			"lease_owner":   "",
+			"lease_expires": nil,
+			"updated_at":    now,
 		})
 	if result.Error != nil {
-		return result.Error
+		return false, result.Error
 	}
-	return nil
+	return result.RowsAffected == 1, nil
 }
 
-func (r *reportRepository) UpdateFailed(ctx context.Context, reportID string, genVersion int, reason string) error {
+// FailExpired 租约过期且领取次数耗尽，直接置为失败
+func (r *reportRepository) FailExpired(ctx context.Context, reportID string, genVersion int, now time.Time, reason string) (bool, error) {
 	result := r.DB(ctx).Model(&model.Report{}).
-		Where("report_id = ? AND gen_version = ?", reportID, genVersion).
+		Where("report_id = ? AND gen_version = ? AND status = ? AND (lease_expires < ? OR lease_expires IS NULL)", reportID, genVersion, v1.ReportStatusProcessing, now).
 		Updates(map[string]interface{}{
 			"status":        v1.ReportStatusFailed,
 			"failed_reason": reason,
-			"updated_at":    time.Now(),
+			"lease_owner":   "",
+			"lease_expires": nil,
+			"updated_at":    now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdatePartial 生成过程中定期落盘已输出的正文，仅对仍持有租约的同一轮生成生效
+func (r *reportRepository) UpdatePartial(ctx context.Context, reportID string, genVersion int, owner string, content string) error {
+	_, content, keyVersion, err := r.sealReportContent(ctx, reportID, content)
+	if err != nil {
+		return err
+	}
+	result := r.DB(ctx).Model(&model.Report{}).
+		Where("report_id = ? AND gen_version = ? AND status = ? AND lease_owner = ?", reportID, genVersion, v1.ReportStatusProcessing, owner).
+		Updates(map[string]interface{}{
+			"content":     content,
+			"key_version": keyVersion,
+			"updated_at":  time.Now(),
 		})
 	if result.Error != nil {
 		return result.Error
 	}
 	return nil
 }
+
+// UpdateGenerated summary 为结构化摘要 JSON，与正文同一版本加密；加密时 abstract 不落明文。
+// 返回 false 表示租约已丢失，未写入
+func (r *reportRepository) UpdateGenerated(ctx context.Context, reportID string, genVersion int, owner string, content string, abstract string, summary string, llmModel string, meta datatypes.JSONMap) (bool, error) {
+	userID, content, keyVersion, err := r.sealReportContent(ctx, reportID, content)
+	if err != nil {
+		return false, err
+	}
+	if summary, err = r.sealWithVersion(ctx, userID, keyVersion, summary); err != nil {
+		return false, err
+	}
+	if keyVersion > 0 {
+		abstract = ""
+	}
+	updates := map[string]interface{}{
+		"status":        v1.ReportStatusReady,
+		"content":       content,
+		"key_version":   keyVersion,
+		"abstract":      abstract,
+		"summary":       summary,
+		"llm_model":     llmModel,
+		"failed_reason": "",
+		"lease_owner":   "",
+		"lease_expires": nil,
+		"updated_at":    time.Now(),
+	}
+	if meta != nil {
+		updates["meta"] = meta
+	}
+	result := r.DB(ctx).Model(&model.Report{}).
+		Where("report_id = ? AND gen_version = ? AND lease_owner = ?", reportID, genVersion, owner).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateFailed meta 为空时保留原值；写回结果均要求仍持有租约，避免被回收后的旧进程覆盖
+func (r *reportRepository) UpdateFailed(ctx context.Context, reportID string, genVersion int, owner string, reason string, meta datatypes.JSONMap) error {
+	updates := map[string]interface{}{
+		"status":        v1.ReportStatusFailed,
+		"failed_reason": reason,
+		"lease_owner":   "",
+		"lease_expires": nil,
+		"updated_at":    time.Now(),
+	}
+	if meta != nil {
+		updates["meta"] = meta
+	}
+	result := r.DB(ctx).Model(&model.Report{}).
+		Where("report_id = ? AND gen_version = ? AND lease_owner = ?", reportID, genVersion, owner).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// sealReportContent 只按 report_id 写入正文时，先查出归属用户再加密；未加密时不查询，返回的 user_id 为空
+func (r *reportRepository) sealReportContent(ctx context.Context, reportID string, content string) (string, string, int, error) {
+	if !r.keyring.Enabled() || content == "" {
+		return "", content, 0, nil
+	}
+	var report model.Report
+	if err := r.DB(ctx).Select("user_id").Where("report_id = ?", reportID).First(&report).Error; err != nil {
+		return "", "", 0, err
+	}
+	sealed, keyVersion, err := r.sealContent(ctx, report.UserID, content)
+	return report.UserID, sealed, keyVersion, err
+}
+
+// UpdateSummary 补写结构化摘要，要求正文未被编辑或重新生成，并沿用正文的 key_version
+func (r *reportRepository) UpdateSummary(ctx context.Context, report *model.Report) (bool, error) {
+	summary, err := r.sealWithVersion(ctx, report.UserID, report.KeyVersion, report.Summary)
+	if err != nil {
+		return false, err
+	}
+	abstract := report.Abstract
+	if report.KeyVersion > 0 {
+		abstract = ""
+	}
+	result := r.DB(ctx).Model(&model.Report{}).
+		Where("report_id = ? AND version = ? AND gen_version = ? AND key_version = ?", report.ReportID, report.Version, report.GenVersion, report.KeyVersion).
+		UpdateColumns(map[string]interface{}{
+			"summary":  summary,
+			"abstract": abstract,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
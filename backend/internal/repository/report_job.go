@@ -0,0 +1,168 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 17:10:26
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 17:10:26
+ */
+package repository
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const finishRetries = 3
+
+type ReportJobRepository interface {
+	Create(ctx context.Context, job *model.ReportJob) error
+	GetByReportVersion(ctx context.Context, reportID string, genVersion int) (*model.ReportJob, error)
+	ListByReport(ctx context.Context, userID string, reportID string) ([]*model.ReportJob, error)
+	MarkProcessing(ctx context.Context, job *model.ReportJob, systemPrompt string, prompt string) error
+	Finish(ctx context.Context, job *model.ReportJob, status string, llmModel string, result string, errMsg string) error
+	CreateAttempts(ctx context.Context, attempts []*model.ReportJobAttempt) error
+	ListAttempts(ctx context.Context, reportJobIDs []string) ([]*model.ReportJobAttempt, error)
+}
+
+func NewReportJobRepository(r *Repository) ReportJobRepository {
+	return &reportJobRepository{
+		Repository: r,
+	}
+}
+
+type reportJobRepository struct {
+	*Repository
+}
+
+func (r *reportJobRepository) Create(ctx context.Context, job *model.ReportJob) error {
+	if err := r.DB(ctx).Create(job).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *reportJobRepository) GetByReportVersion(ctx context.Context, reportID string, genVersion int) (*model.ReportJob, error) {
+	var job model.ReportJob
+	if err := r.DB(ctx).Where("report_id = ? AND gen_version = ?", reportID, genVersion).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	if err := r.openJob(ctx, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListByReport 按生成版本倒序
+func (r *reportJobRepository) ListByReport(ctx context.Context, userID string, reportID string) ([]*model.ReportJob, error) {
+	var jobs []*model.ReportJob
+	if err := r.DB(ctx).Where("user_id = ? AND report_id = ?", userID, reportID).Order("gen_version desc").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if err := r.openJob(ctx, job); err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
+
+// MarkProcessing 记录本次实际使用的提示词，重复处理时覆盖为最新一次；提示词含周期内全部记录，与正文一样静态加密
+func (r *reportJobRepository) MarkProcessing(ctx context.Context, job *model.ReportJob, systemPrompt string, prompt string) error {
+	var keyVersion int
+	return r.withSealed(ctx, job.UserID, &prompt, &keyVersion, func() error {
+		return r.DB(ctx).Model(&model.ReportJob{}).
+			Where("report_job_id = ?", job.ReportJobID).
+			Updates(map[string]interface{}{
+				"status":        v1.ReportStatusProcessing,
+				"system_prompt": systemPrompt,
+				"prompt":        prompt,
+				"key_version":   keyVersion,
+				"updated_at":    time.Now(),
+			}).Error
+	})
+}
+
+// Finish 生成结果与提示词共用 key_version：提示词已加密时以同一版本加密结果，尚无提示词时以当前版本加密；
+// 以读取时的 key_version 为条件更新，期间被 rekey 迁移时重试
+func (r *reportJobRepository) Finish(ctx context.Context, job *model.ReportJob, status string, llmModel string, result string, errMsg string) error {
+	updates := map[string]interface{}{
+		"status":     status,
+		"llm_model":  llmModel,
+		"result":     result,
+		"error":      errMsg,
+		"updated_at": time.Now(),
+	}
+	if result == "" {
+		return r.DB(ctx).Model(&model.ReportJob{}).Where("report_job_id = ?", job.ReportJobID).Updates(updates).Error
+	}
+	for i := 0; i < finishRetries; i++ {
+		var row model.ReportJob
+		if err := r.DB(ctx).Select("key_version", "prompt").Where("report_job_id = ?", job.ReportJobID).First(&row).Error; err != nil {
+			return err
+		}
+		keyVersion := row.KeyVersion
+		var err error
+		if keyVersion == 0 && row.Prompt == "" {
+			updates["result"], keyVersion, err = r.sealContent(ctx, job.UserID, result)
+		} else {
+			updates["result"], err = r.sealWithVersion(ctx, job.UserID, keyVersion, result)
+		}
+		if err != nil {
+			return err
+		}
+		updates["key_version"] = keyVersion
+		tx := r.DB(ctx).Model(&model.ReportJob{}).
+			Where("report_job_id = ? AND key_version = ?", job.ReportJobID, row.KeyVersion).
+			Updates(updates)
+		if tx.Error != nil || tx.RowsAffected == 1 {
+			return tx.Error
+		}
+	}
+	return errors.New("report job key version changed during finish")
+}
+
+// CreateAttempts 厂商原始响应含生成的正文，开启静态加密时不保存
+func (r *reportJobRepository) CreateAttempts(ctx context.Context, attempts []*model.ReportJobAttempt) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+	if r.keyring.Enabled() {
+		for _, attempt := range attempts {
+			attempt.Raw = ""
+		}
+	}
+	return r.DB(ctx).Create(&attempts).Error
+}
+
+func (r *reportJobRepository) ListAttempts(ctx context.Context, reportJobIDs []string) ([]*model.ReportJobAttempt, error) {
+	var attempts []*model.ReportJobAttempt
+	if len(reportJobIDs) == 0 {
+		return attempts, nil
+	}
+	if err := r.DB(ctx).Where("report_job_id IN ?", reportJobIDs).Order("id asc").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (r *reportJobRepository) openJob(ctx context.Context, job *model.ReportJob) error {
+	for _, column := range []*string{&job.Prompt, &job.Result} {
+		if *column == "" {
+			continue
+		}
+		plain, err := r.openContent(ctx, job.UserID, *column, job.KeyVersion)
+		if err != nil {
+			return err
+		}
+		*column = plain
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 10:08:47
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 10:08:47
+ */
+package repository
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReportTemplateRepository interface {
+	Create(ctx context.Context, tpl *model.ReportTemplate) error
+	Update(ctx context.Context, tpl *model.ReportTemplate) (bool, error)
+	Delete(ctx context.Context, userID string, templateID string) (bool, error)
+	GetByID(ctx context.Context, userID string, templateID string) (*model.ReportTemplate, error)
+	List(ctx context.Context, userID string) ([]*model.ReportTemplate, error)
+	CountByUser(ctx context.Context, userID string) (int64, error)
+	NameExists(ctx context.Context, userID string, name string, excludeID string) (bool, error)
+}
+
+func NewReportTemplateRepository(r *Repository) ReportTemplateRepository {
+	return &reportTemplateRepository{
+		Repository: r,
+	}
+}
+
+type reportTemplateRepository struct {
+	*Repository
+}
+
+func (r *reportTemplateRepository) Create(ctx context.Context, tpl *model.ReportTemplate) error {
+	if err := r.DB(ctx).Create(tpl).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// Update 只更新用户自己的模板，内置模板不可修改
+func (r *reportTemplateRepository) Update(ctx context.Context, tpl *model.ReportTemplate) (bool, error) {
+	result := r.DB(ctx).Model(&model.ReportTemplate{}).
+		Where("template_id = ? AND user_id = ? AND builtin = ?", tpl.TemplateID, tpl.UserID, false).
+		Updates(map[string]interface{}{
+			"name":          tpl.Name,
+			"period_types":  tpl.PeriodTypes,
+			"system_prompt": tpl.SystemPrompt,
+			"sections":      tpl.Sections,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *reportTemplateRepository) Delete(ctx context.Context, userID string, templateID string) (bool, error) {
+	result := r.DB(ctx).Where("template_id = ? AND user_id = ? AND builtin = ?", templateID, userID, false).Delete(&model.ReportTemplate{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetByID 用户可见的模板：自己的或内置的
+func (r *reportTemplateRepository) GetByID(ctx context.Context, userID string, templateID string) (*model.ReportTemplate, error) {
+	var tpl model.ReportTemplate
+	if err := r.DB(ctx).Where("template_id = ? AND (user_id = ? OR builtin = ?)", templateID, userID, true).First(&tpl).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &tpl, nil
+}
+
+// List 内置模板在前，自定义模板按创建时间排序
+func (r *reportTemplateRepository) List(ctx context.Context, userID string) ([]*model.ReportTemplate, error) {
+	var tpls []*model.ReportTemplate
+	if err := r.DB(ctx).Where("user_id = ? OR builtin = ?", userID, true).
+		Order("builtin desc").Order("created_at asc").Find(&tpls).Error; err != nil {
+		return nil, err
+	}
+	return tpls, nil
+}
+
+func (r *reportTemplateRepository) CountByUser(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	if err := r.DB(ctx).Model(&model.ReportTemplate{}).Where("user_id = ? AND builtin = ?", userID, false).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *reportTemplateRepository) NameExists(ctx context.Context, userID string, name string, excludeID string) (bool, error) {
+	var count int64
+	if err := r.DB(ctx).Model(&model.ReportTemplate{}).
+		Where("user_id = ? AND builtin = ? AND name = ? AND template_id <> ?", userID, false, name, excludeID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SeedReportTemplates 写入内置模板，需在 AutoMigrate 之后执行，可重复执行：已存在时以内嵌提示词为准覆盖
+func SeedReportTemplates(db *gorm.DB, tpls []*model.ReportTemplate) error {
+	if len(tpls) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "template_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "period_types", "system_prompt", "sections", "builtin", "updated_at"}),
+	}).Create(&tpls).Error
+}
@@ -0,0 +1,95 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 18:26:03
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 18:26:03
+ */
+package repository
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ReportVersionRepository 报告历史版本只追加，不提供修改与删除
+type ReportVersionRepository interface {
+	Create(ctx context.Context, version *model.ReportVersion) error
+	ListByReport(ctx context.Context, reportID string) ([]*model.ReportVersion, error)
+	GetBySeq(ctx context.Context, reportID string, seq int) (*model.ReportVersion, error)
+	GetLatest(ctx context.Context, reportID string) (*model.ReportVersion, error)
+}
+
+func NewReportVersionRepository(r *Repository) ReportVersionRepository {
+	return &reportVersionRepository{
+		Repository: r,
+	}
+}
+
+type reportVersionRepository struct {
+	*Repository
+}
+
+// Create 序号取该报告当前最大序号加一，并发写入时由唯一索引拒绝
+func (r *reportVersionRepository) Create(ctx context.Context, version *model.ReportVersion) error {
+	var last int
+	if err := r.DB(ctx).Model(&model.ReportVersion{}).
+		Where("report_id = ?", version.ReportID).
+		Select("COALESCE(MAX(seq), 0)").
+		Scan(&last).Error; err != nil {
+		return err
+	}
+	version.Seq = last + 1
+	return r.withSealed(ctx, version.UserID, &version.Content, &version.KeyVersion, func() error {
+		return r.DB(ctx).Create(version).Error
+	})
+}
+
+// ListByReport 按序号倒序返回，不含正文
+func (r *reportVersionRepository) ListByReport(ctx context.Context, reportID string) ([]*model.ReportVersion, error) {
+	var versions []*model.ReportVersion
+	if err := r.DB(ctx).
+		Select("id", "report_id", "seq", "user_id", "author", "action", "version", "gen_version", "llm_model", "template", "created_at").
+		Where("report_id = ?", reportID).
+		Order("seq desc").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *reportVersionRepository) GetBySeq(ctx context.Context, reportID string, seq int) (*model.ReportVersion, error) {
+	var version model.ReportVersion
+	if err := r.DB(ctx).Where("report_id = ? AND seq = ?", reportID, seq).First(&version).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	content, err := r.openContent(ctx, version.UserID, version.Content, version.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+	version.Content = content
+	return &version, nil
+}
+
+// GetLatest 最新版本，不含正文
+func (r *reportVersionRepository) GetLatest(ctx context.Context, reportID string) (*model.ReportVersion, error) {
+	var version model.ReportVersion
+	if err := r.DB(ctx).
+		Select("id", "report_id", "seq", "user_id", "author", "action", "version", "gen_version", "llm_model", "template", "created_at").
+		Where("report_id = ?", reportID).
+		Order("seq desc").
+		First(&version).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &version, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/glebarez/sqlite"
+	"backend/pkg/keyring"
 	"backend/pkg/log"
 	"backend/pkg/zapgorm2"
 	"github.com/redis/go-redis/v9"
@@ -13,6 +14,7 @@ import (
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"sync"
 	"time"
 )
 
@@ -22,12 +24,15 @@ type Repository struct {
 	db *gorm.DB
 	//rdb    *redis.Client
 	//mongo  *mongo.Client
-	logger *log.Logger
+	logger   *log.Logger
+	keyring  *keyring.Keyring
+	dataKeys sync.Map // userID#version -> 已解包的数据密钥
 }
 
 func NewRepository(
 	logger *log.Logger,
 	db *gorm.DB,
+	keyring *keyring.Keyring,
 	// rdb *redis.Client,
 	//
 	//	mongo *mongo.Client,
@@ -36,7 +41,8 @@ func NewRepository(
 		db: db,
 		//rdb:    rdb,
 		//mongo:  mongo,
-		logger: logger,
+		logger:  logger,
+		keyring: keyring,
 	}
 }
 
@@ -0,0 +1,469 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 19:12:40
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 19:12:40
+ */
+package repository
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const (
+	searchDocRecord = "record"
+	searchDocReport = "report"
+
+	pgSearchConfig   = "simple" // 中文分词需安装 zhparser 等扩展后改为对应配置，并重建索引
+	sqliteFTSTable   = "search_fts"
+	sqliteTrigramLen = 3 // trigram 分词下少于 3 个字符的查询无法命中索引，退化为 LIKE
+
+	// searchScanLimit 静态加密的行无法建索引，搜索时在服务端解密匹配；每类最多解密的行数，
+	// 同时也是与之合并排序的索引命中数上限，超出时结果不完整
+	searchScanLimit = 5000
+)
+
+// SearchFilter 全文搜索条件；Types 为空表示记录与报告都搜索
+type SearchFilter struct {
+	UserID     string
+	Query      string
+	Types      []string
+	StartDate  string
+	EndDate    string
+	PeriodType string
+	Offset     int
+	Limit      int
+}
+
+// SearchHit 搜索命中，记录的 StartDate/EndDate 均为记录日期
+type SearchHit struct {
+	DocType    string
+	DocID      string
+	StartDate  string
+	EndDate    string
+	PeriodType string
+	Title      string
+	Content    string
+	Abstract   string
+	Score      float64
+	UpdatedAt  time.Time
+}
+
+// SearchResult Partial 表示静态加密内容超出解密上限，结果不完整
+type SearchResult struct {
+	Hits    []*SearchHit
+	Total   int64
+	Partial bool
+}
+
+type SearchRepository interface {
+	Search(ctx context.Context, filter *SearchFilter) (*SearchResult, error)
+}
+
+func NewSearchRepository(r *Repository) SearchRepository {
+	return &searchRepository{
+		Repository: r,
+	}
+}
+
+type searchRepository struct {
+	*Repository
+}
+
+// searchSource 某一方言下单表的检索片段，参数按 SELECT、FROM、WHERE 的顺序拼接
+type searchSource struct {
+	score     string
+	scoreArgs []any
+	from      string
+	fromArgs  []any
+	cond      string
+	condArgs  []any
+}
+
+// Search 记录与报告合并后按相关度排序分页；已删除、客户端加密的记录及未生成完成的报告不参与搜索。
+// 明文走全文索引；服务端静态加密的行解密后逐词匹配，存在时与索引命中合并，统一按命中次数重新计算相关度
+func (r *searchRepository) Search(ctx context.Context, filter *SearchFilter) (*SearchResult, error) {
+	sealed, partial, err := r.searchSealed(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) == 0 && !partial {
+		hits, total, err := r.searchIndexed(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		return &SearchResult{Hits: hits, Total: total}, nil
+	}
+
+	indexed := *filter
+	indexed.Offset, indexed.Limit = 0, searchScanLimit
+	hits, total, err := r.searchIndexed(ctx, &indexed)
+	if err != nil {
+		return nil, err
+	}
+	if total > int64(len(hits)) {
+		partial = true
+	}
+	terms := strings.Fields(strings.ToLower(filter.Query))
+	for _, hit := range hits {
+		hit.Score = sealedScore(hit, terms)
+	}
+	hits = append(hits, sealed...)
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].StartDate != hits[j].StartDate {
+			return hits[i].StartDate > hits[j].StartDate
+		}
+		return hits[i].DocID < hits[j].DocID
+	})
+	result := &SearchResult{Total: total + int64(len(sealed)), Partial: partial}
+	if filter.Offset < len(hits) {
+		end := filter.Offset + filter.Limit
+		if end > len(hits) {
+			end = len(hits)
+		}
+		result.Hits = hits[filter.Offset:end]
+	}
+	return result, nil
+}
+
+// searchSealed 解密该用户静态加密（key_version > 0）的记录与报告并匹配全部查询词；返回 true 表示超出解密上限
+func (r *searchRepository) searchSealed(ctx context.Context, filter *SearchFilter) ([]*SearchHit, bool, error) {
+	terms := strings.Fields(strings.ToLower(filter.Query))
+	var hits []*SearchHit
+	partial := false
+	if searchType(filter, searchDocRecord) {
+		query := r.DB(ctx).Where("user_id = ? AND is_deleted = ? AND is_encrypted = ? AND key_version > 0", filter.UserID, false, false)
+		if filter.StartDate != "" {
+			query = query.Where("date >= ?", filter.StartDate)
+		}
+		if filter.EndDate != "" {
+			query = query.Where("date <= ?", filter.EndDate)
+		}
+		var records []*model.Record
+		if err := query.Order("date desc").Limit(searchScanLimit + 1).Find(&records).Error; err != nil {
+			return nil, false, err
+		}
+		if len(records) > searchScanLimit {
+			records, partial = records[:searchScanLimit], true
+		}
+		for _, record := range records {
+			if err := r.openRecord(ctx, record); err != nil {
+				return nil, false, err
+			}
+			hit := &SearchHit{DocType: searchDocRecord, DocID: record.RecordID, StartDate: record.Date, EndDate: record.Date,
+				Content: record.Content, UpdatedAt: record.UpdatedAt}
+			if hit.Score = sealedScore(hit, terms); hit.Score > 0 {
+				hits = append(hits, hit)
+			}
+		}
+	}
+	if searchType(filter, searchDocReport) {
+		query := r.DB(ctx).Where("user_id = ? AND status = ? AND key_version > 0", filter.UserID, v1.ReportStatusReady)
+		if filter.StartDate != "" {
+			query = query.Where("end_date >= ?", filter.StartDate)
+		}
+		if filter.EndDate != "" {
+			query = query.Where("start_date <= ?", filter.EndDate)
+		}
+		if filter.PeriodType != "" {
+			query = query.Where("period_type = ?", filter.PeriodType)
+		}
+		var reports []*model.Report
+		if err := query.Order("start_date desc").Limit(searchScanLimit + 1).Find(&reports).Error; err != nil {
+			return nil, false, err
+		}
+		if len(reports) > searchScanLimit {
+			reports, partial = reports[:searchScanLimit], true
+		}
+		for _, report := range reports {
+			if err := r.openReport(ctx, report); err != nil {
+				return nil, false, err
+			}
+			hit := &SearchHit{DocType: searchDocReport, DocID: report.ReportID, StartDate: report.StartDate, EndDate: report.EndDate,
+				PeriodType: report.PeriodType, Title: report.Title, Content: report.Content, Abstract: report.Abstract, UpdatedAt: report.UpdatedAt}
+			if hit.Score = sealedScore(hit, terms); hit.Score > 0 {
+				hits = append(hits, hit)
+			}
+		}
+	}
+	return hits, partial, nil
+}
+
+// sealedScore 全部查询词都出现时返回总命中次数，否则为 0；terms 须为小写
+func sealedScore(hit *SearchHit, terms []string) float64 {
+	text := strings.ToLower(hit.Title + "\n" + hit.Content + "\n" + hit.Abstract)
+	score := 0
+	for _, term := range terms {
+		n := strings.Count(text, term)
+		if n == 0 {
+			return 0
+		}
+		score += n
+	}
+	return float64(score)
+}
+
+// searchIndexed 明文行走全文索引，在数据库中排序分页
+func (r *searchRepository) searchIndexed(ctx context.Context, filter *SearchFilter) ([]*SearchHit, int64, error) {
+	db := r.DB(ctx)
+	dialect := db.Dialector.Name()
+
+	var parts []string
+	var args []any
+	if searchType(filter, searchDocRecord) {
+		src := searchSourceFor(dialect, searchDocRecord, filter.Query)
+		sql := fmt.Sprintf("SELECT 'record' AS doc_type, record.record_id AS doc_id, record.date AS start_date, record.date AS end_date, '' AS period_type, '' AS title, record.content AS content, '' AS abstract, %s AS score, record.updated_at AS updated_at FROM %s WHERE record.user_id = ? AND record.deleted_at IS NULL AND record.is_deleted = ? AND record.is_encrypted = ? AND record.key_version = 0 AND %s",
+			src.score, src.from, src.cond)
+		args = append(args, src.scoreArgs...)
+		args = append(args, src.fromArgs...)
+		args = append(args, filter.UserID, false, false)
+		args = append(args, src.condArgs...)
+		if filter.StartDate != "" {
+			sql += " AND record.date >= ?"
+			args = append(args, filter.StartDate)
+		}
+		if filter.EndDate != "" {
+			sql += " AND record.date <= ?"
+			args = append(args, filter.EndDate)
+		}
+		parts = append(parts, sql)
+	}
+	if searchType(filter, searchDocReport) {
+		src := searchSourceFor(dialect, searchDocReport, filter.Query)
+		sql := fmt.Sprintf("SELECT 'report' AS doc_type, report.report_id AS doc_id, report.start_date AS start_date, report.end_date AS end_date, report.period_type AS period_type, report.title AS title, report.content AS content, report.abstract AS abstract, %s AS score, report.updated_at AS updated_at FROM %s WHERE report.user_id = ? AND report.deleted_at IS NULL AND report.status = ? AND report.key_version = 0 AND %s",
+			src.score, src.from, src.cond)
+		args = append(args, src.scoreArgs...)
+		args = append(args, src.fromArgs...)
+		args = append(args, filter.UserID, "ready")
+		args = append(args, src.condArgs...)
+		// 报告周期与筛选范围有交集即命中
+		if filter.StartDate != "" {
+			sql += " AND report.end_date >= ?"
+			args = append(args, filter.StartDate)
+		}
+		if filter.EndDate != "" {
+			sql += " AND report.start_date <= ?"
+			args = append(args, filter.EndDate)
+		}
+		if filter.PeriodType != "" {
+			sql += " AND report.period_type = ?"
+			args = append(args, filter.PeriodType)
+		}
+		parts = append(parts, sql)
+	}
+	if len(parts) == 0 {
+		return nil, 0, nil
+	}
+	union := strings.Join(parts, " UNION ALL ")
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM ("+union+") AS hits", args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if total == 0 || int64(filter.Offset) >= total {
+		return nil, total, nil
+	}
+
+	var hits []*SearchHit
+	pageArgs := append(append([]any{}, args...), filter.Limit, filter.Offset)
+	if err := db.Raw("SELECT * FROM ("+union+") AS hits ORDER BY score DESC, start_date DESC, doc_id LIMIT ? OFFSET ?", pageArgs...).Scan(&hits).Error; err != nil {
+		return nil, 0, err
+	}
+	return hits, total, nil
+}
+
+func searchType(filter *SearchFilter, docType string) bool {
+	if len(filter.Types) == 0 {
+		return true
+	}
+	for _, t := range filter.Types {
+		if t == docType {
+			return true
+		}
+	}
+	return false
+}
+
+func searchSourceFor(dialect string, docType string, query string) searchSource {
+	switch dialect {
+	case "mysql":
+		cols := "record.content"
+		if docType == searchDocReport {
+			cols = "report.title, report.content, report.abstract"
+		}
+		match := fmt.Sprintf("MATCH(%s) AGAINST (? IN NATURAL LANGUAGE MODE)", cols)
+		return searchSource{
+			score: match, scoreArgs: []any{query},
+			from: docType,
+			cond: match, condArgs: []any{query},
+		}
+	case "postgres":
+		vector := pgSearchVector(docType, docType+".")
+		tsQuery := fmt.Sprintf("plainto_tsquery('%s', ?)", pgSearchConfig)
+		return searchSource{
+			score: fmt.Sprintf("ts_rank(%s, %s)", vector, tsQuery), scoreArgs: []any{query},
+			from: docType,
+			cond: fmt.Sprintf("%s @@ %s", vector, tsQuery), condArgs: []any{query},
+		}
+	default:
+		return sqliteSearchSource(docType, query)
+	}
+}
+
+// pgSearchVector 与 GIN 表达式索引保持一致，否则查询无法走索引
+func pgSearchVector(docType string, prefix string) string {
+	if docType == searchDocReport {
+		return fmt.Sprintf("to_tsvector('%s', coalesce(%stitle, '') || ' ' || coalesce(%scontent, '') || ' ' || coalesce(%sabstract, ''))",
+			pgSearchConfig, prefix, prefix, prefix)
+	}
+	return fmt.Sprintf("to_tsvector('%s', coalesce(%scontent, ''))", pgSearchConfig, prefix)
+}
+
+func sqliteSearchSource(docType string, query string) searchSource {
+	fields := strings.Fields(query)
+	for _, f := range fields {
+		if utf8.RuneCountInString(f) < sqliteTrigramLen {
+			return sqliteLikeSource(docType, fields)
+		}
+	}
+	// bm25 越小越相关，取负数与其他方言保持"越大越相关"
+	return searchSource{
+		score: "fts.rank",
+		from: fmt.Sprintf("%s JOIN (SELECT doc_id, -bm25(%s) AS rank FROM %s WHERE %s MATCH ? AND doc_type = '%s') AS fts ON fts.doc_id = %s.%s_id",
+			docType, sqliteFTSTable, sqliteFTSTable, sqliteFTSTable, docType, docType, docType),
+		fromArgs: []any{ftsPhrase(fields)},
+		cond:     "1 = 1",
+	}
+}
+
+// sqliteLikeSource 短词无法走 trigram 索引，逐词 LIKE 匹配，不计算相关度
+func sqliteLikeSource(docType string, fields []string) searchSource {
+	cols := []string{"record.content"}
+	if docType == searchDocReport {
+		cols = []string{"report.title", "report.content", "report.abstract"}
+	}
+	conds := make([]string, 0, len(fields))
+	var args []any
+	for _, f := range fields {
+		like := "%" + escapeLike(f) + "%"
+		ors := make([]string, 0, len(cols))
+		for _, col := range cols {
+			ors = append(ors, col+` LIKE ? ESCAPE '\'`)
+			args = append(args, like)
+		}
+		conds = append(conds, "("+strings.Join(ors, " OR ")+")")
+	}
+	return searchSource{score: "0", from: docType, cond: strings.Join(conds, " AND "), condArgs: args}
+}
+
+// ftsPhrase 每个词作为一个短语（AND 关系），双引号转义后避免被解析为 FTS5 语法
+func ftsPhrase(fields []string) string {
+	phrases := make([]string, 0, len(fields))
+	for _, f := range fields {
+		phrases = append(phrases, `"`+strings.ReplaceAll(f, `"`, `""`)+`"`)
+	}
+	return strings.Join(phrases, " ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// EnsureSearchIndex 创建全文索引，需在 AutoMigrate 之后执行，可重复执行：
+// MySQL 使用 ngram 分词的 FULLTEXT 索引，Postgres 使用 tsvector 表达式 GIN 索引，
+// SQLite 使用触发器维护的 FTS5 trigram 虚拟表
+func EnsureSearchIndex(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "mysql":
+		indexes := []struct {
+			table string
+			name  string
+			cols  string
+		}{
+			{"record", "ft_record_content", "content"},
+			{"report", "ft_report_text", "title, content, abstract"},
+		}
+		for _, idx := range indexes {
+			if db.Migrator().HasIndex(idx.table, idx.name) {
+				continue
+			}
+			sql := fmt.Sprintf("CREATE FULLTEXT INDEX %s ON %s (%s) WITH PARSER ngram", idx.name, idx.table, idx.cols)
+			if err := db.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	case "postgres":
+		for _, docType := range []string{searchDocRecord, searchDocReport} {
+			sql := fmt.Sprintf("CREATE INDEX IF NOT EXISTS ft_%s_text ON %s USING GIN (%s)", docType, docType, pgSearchVector(docType, ""))
+			if err := db.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	case "sqlite":
+		return ensureSQLiteSearchIndex(db)
+	default:
+		return nil
+	}
+}
+
+// sqliteSearchTriggers 记录与报告写入时同步 FTS 表；报告正文生成中会频繁更新，仅在相关列变化时重建
+var sqliteSearchTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS record_fts_ai AFTER INSERT ON record BEGIN
+		INSERT INTO search_fts (doc_type, doc_id, body) VALUES ('record', new.record_id, new.content);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS record_fts_au AFTER UPDATE OF content ON record BEGIN
+		DELETE FROM search_fts WHERE doc_type = 'record' AND doc_id = old.record_id;
+		INSERT INTO search_fts (doc_type, doc_id, body) VALUES ('record', new.record_id, new.content);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS record_fts_ad AFTER DELETE ON record BEGIN
+		DELETE FROM search_fts WHERE doc_type = 'record' AND doc_id = old.record_id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS report_fts_ai AFTER INSERT ON report BEGIN
+		INSERT INTO search_fts (doc_type, doc_id, body) VALUES ('report', new.report_id, coalesce(new.title, '') || ' ' || coalesce(new.content, '') || ' ' || coalesce(new.abstract, ''));
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS report_fts_au AFTER UPDATE OF title, content, abstract ON report BEGIN
+		DELETE FROM search_fts WHERE doc_type = 'report' AND doc_id = old.report_id;
+		INSERT INTO search_fts (doc_type, doc_id, body) VALUES ('report', new.report_id, coalesce(new.title, '') || ' ' || coalesce(new.content, '') || ' ' || coalesce(new.abstract, ''));
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS report_fts_ad AFTER DELETE ON report BEGIN
+		DELETE FROM search_fts WHERE doc_type = 'report' AND doc_id = old.report_id;
+	END`,
+}
+
+func ensureSQLiteSearchIndex(db *gorm.DB) error {
+	if db.Migrator().HasTable(sqliteFTSTable) {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		stmts := []string{
+			`CREATE VIRTUAL TABLE search_fts USING fts5(doc_type UNINDEXED, doc_id UNINDEXED, body, tokenize = 'trigram')`,
+		}
+		stmts = append(stmts, sqliteSearchTriggers...)
+		// 回填建索引之前已有的数据
+		stmts = append(stmts,
+			`INSERT INTO search_fts (doc_type, doc_id, body) SELECT 'record', record_id, content FROM record`,
+			`INSERT INTO search_fts (doc_type, doc_id, body) SELECT 'report', report_id, coalesce(title, '') || ' ' || coalesce(content, '') || ' ' || coalesce(abstract, '') FROM report`,
+		)
+		for _, stmt := range stmts {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
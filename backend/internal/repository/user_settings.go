@@ -20,6 +20,7 @@ type UserSettingsRepository interface {
 	Create(ctx context.Context, userSettings *model.UserSettings) error
 	Update(ctx context.Context, userSettings *model.UserSettings) error
 	GetByID(ctx context.Context, userId string) (*model.UserSettings, error)
+	ListAutoGenerate(ctx context.Context, afterUserID string, limit int) ([]*model.UserSettings, error)
 }
 
 func NewUserSettingsRepository(
@@ -58,3 +59,14 @@ func (r *userSettings) GetByID(ctx context.Context, userId string) (*model.UserS
 	}
 	return &userSettings, nil
 }
+
+// ListAutoGenerate 开启自动生成周报/月报的用户，按 user_id 游标分页
+func (r *userSettings) ListAutoGenerate(ctx context.Context, afterUserID string, limit int) ([]*model.UserSettings, error) {
+	var settings []*model.UserSettings
+	if err := r.DB(ctx).
+		Where("user_id > ? AND (auto_generate_weekly = ? OR auto_generate_monthly = ?)", afterUserID, true, true).
+		Order("user_id asc").Limit(limit).Find(&settings).Error; err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
@@ -21,5 +21,6 @@ func InitDashboardRouter(
 	{
 		strictAuthRouter.GET("/dashboard/month", deps.DashboardHandler.GetMonth)
 		strictAuthRouter.GET("/dashboard/summary", deps.DashboardHandler.GetSummary)
+		strictAuthRouter.GET("/dashboard/period", deps.DashboardHandler.GetPeriod)
 	}
 }
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitExportRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	// 签名下载链接，不校验登录态
+	noAuthRouter := r.Group("/")
+	{
+		noAuthRouter.GET("/export/:export_id/download", deps.ExportHandler.DownloadExport)
+	}
+	strictAuthRouter := r.Group("/").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.POST("/export", deps.ExportHandler.StartExport)
+		strictAuthRouter.GET("/export/:export_id", deps.ExportHandler.GetExport)
+	}
+}
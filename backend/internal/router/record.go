@@ -25,5 +25,10 @@ func InitRecordRouter(
 		strictAuthRouter.GET("/records/range", deps.RecordHandler.QueryRecordsByRange)
 		strictAuthRouter.POST("/records", deps.RecordHandler.UpsertRecord)
 		strictAuthRouter.DELETE("/records/:record_id", deps.RecordHandler.DeleteRecord)
+		strictAuthRouter.GET("/records/:record_id/revisions", deps.RecordHandler.ListRevisions)
+		strictAuthRouter.GET("/records/:record_id/revisions/diff", deps.RecordHandler.DiffRevisions)
+		strictAuthRouter.POST("/records/:record_id/revisions/:revision/restore", deps.RecordHandler.RestoreRevision)
+		strictAuthRouter.POST("/records/import", deps.RecordImportHandler.ImportRecords)
+		strictAuthRouter.GET("/records/import/:job_id", deps.RecordImportHandler.GetImportJob)
 	}
 }
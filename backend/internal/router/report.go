@@ -21,6 +21,13 @@ func InitReportRouter(
 	{
 		strictAuthRouter.GET("/reports", deps.ReportHandler.GetReports)
 		strictAuthRouter.GET("/reports/:report_id", deps.ReportHandler.GetReportByID)
+		strictAuthRouter.GET("/reports/:report_id/jobs", deps.ReportHandler.GetReportJobs)
+		strictAuthRouter.GET("/reports/:report_id/stream", deps.ReportHandler.StreamReport)
+		strictAuthRouter.GET("/reports/:report_id/export", deps.ReportHandler.ExportReport)
+		strictAuthRouter.GET("/reports/:report_id/versions", deps.ReportHandler.ListVersions)
+		strictAuthRouter.GET("/reports/:report_id/versions/diff", deps.ReportHandler.DiffVersions)
+		strictAuthRouter.POST("/reports/:report_id/versions/:seq/restore", deps.ReportHandler.RestoreVersion)
+		strictAuthRouter.POST("/reports/:report_id/rewrite", deps.ReportHandler.RewriteReport)
 		strictAuthRouter.POST("/reports/generate", deps.ReportHandler.GenerateReport)
 		strictAuthRouter.POST("/reports/edit", deps.ReportHandler.EditReport)
 		strictAuthRouter.POST("/reports/confirm", deps.ReportHandler.ConfirmReport)
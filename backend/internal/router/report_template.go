@@ -0,0 +1,21 @@
+package router
+
+import (
+	"backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitReportTemplateRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("/report-templates", deps.ReportTemplateHandler.ListTemplates)
+		strictAuthRouter.POST("/report-templates", deps.ReportTemplateHandler.CreateTemplate)
+		strictAuthRouter.GET("/report-templates/:template_id", deps.ReportTemplateHandler.GetTemplate)
+		strictAuthRouter.PUT("/report-templates/:template_id", deps.ReportTemplateHandler.UpdateTemplate)
+		strictAuthRouter.DELETE("/report-templates/:template_id", deps.ReportTemplateHandler.DeleteTemplate)
+	}
+}
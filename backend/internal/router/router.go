@@ -23,4 +23,8 @@ type RouterDeps struct {
 	RecordHandler *handler.RecordHandler
 	ReportHandler *handler.ReportHandler
 	DashboardHandler *handler.DashboardHandler
+	SearchHandler    *handler.SearchHandler
+	RecordImportHandler *handler.RecordImportHandler
+	ExportHandler       *handler.ExportHandler
+	ReportTemplateHandler *handler.ReportTemplateHandler
 }
@@ -0,0 +1,24 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 19:41:26
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 19:41:26
+ */
+package router
+
+import (
+	"backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitSearchRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("/search", deps.SearchHandler.Search)
+	}
+}
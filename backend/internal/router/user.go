@@ -29,5 +29,6 @@ func InitUserRouter(
 		strictAuthRouter.GET("/user", deps.UserHandler.GetProfile)
 		strictAuthRouter.GET("/user/settings", deps.UserHandler.GetUserSettings)
 		strictAuthRouter.PUT("/user/settings", deps.UserHandler.UpdateUserSettings)
+		strictAuthRouter.PUT("/user/settings/encryption", deps.UserHandler.SetEncryptionKey)
 	}
 }
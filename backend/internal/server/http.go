@@ -59,6 +59,9 @@ func NewHTTPServer(
 	router.InitRecordRouter(deps, v1)
 	router.InitReportRouter(deps, v1)
 	router.InitDashboardRouter(deps, v1)
+	router.InitSearchRouter(deps, v1)
+	router.InitExportRouter(deps, v1)
+	router.InitReportTemplateRouter(deps, v1)
 
 	return s
 }
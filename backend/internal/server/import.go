@@ -0,0 +1,148 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 23:41:27
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 23:41:27
+ */
+package server
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/pkg/log"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// ImportServer 历史记录导入工具：读取目录或单个文件（zip/md/markdown/txt），按用户名导入，
+// 与 POST /records/import 使用同一套解析与冲突策略，dry_run 时只输出预览
+type ImportServer struct {
+	importSvc service.RecordImportService
+	userRepo  repository.UserRepository
+	log       *log.Logger
+	username  string
+	path      string
+	req       v1.ImportRecordsReq
+}
+
+func NewImportServer(conf *viper.Viper, importSvc service.RecordImportService, userRepo repository.UserRepository, log *log.Logger) *ImportServer {
+	return &ImportServer{
+		importSvc: importSvc,
+		userRepo:  userRepo,
+		log:       log,
+		username:  conf.GetString("import.user"),
+		path:      conf.GetString("import.path"),
+		req: v1.ImportRecordsReq{
+			Policy: conf.GetString("import.policy"),
+			DryRun: conf.GetBool("import.dry_run"),
+		},
+	}
+}
+
+func (m *ImportServer) Start(ctx context.Context) error {
+	if m.username == "" || m.path == "" {
+		return errors.New("import: -user and -path are required")
+	}
+	user, err := m.userRepo.GetByUsername(ctx, m.username)
+	if err != nil {
+		m.log.Error("get import user error", zap.String("username", m.username), zap.Error(err))
+		return err
+	}
+	sources, err := readImportSources(m.path)
+	if err != nil {
+		m.log.Error("read import path error", zap.String("path", m.path), zap.Error(err))
+		return err
+	}
+
+	if m.req.DryRun {
+		preview, err := m.importSvc.PreviewImport(ctx, user.UserID, &m.req, sources)
+		if err != nil {
+			m.log.Error("preview import error", zap.Error(err))
+			return err
+		}
+		for _, entry := range preview.Entries {
+			m.log.Info("import preview", zap.String("date", entry.Date), zap.String("action", entry.Action),
+				zap.String("source", entry.Source), zap.Int("word_count", entry.WordCount), zap.String("reason", entry.Reason))
+		}
+		m.log.Info("import preview done", zap.Int("total", preview.Total), zap.Int("create", preview.Create),
+			zap.Int("overwrite", preview.Overwrite), zap.Int("append", preview.Append),
+			zap.Int("skip", preview.Skip), zap.Int("invalid", preview.Invalid))
+		os.Exit(0)
+		return nil
+	}
+
+	job, err := m.importSvc.RunImport(ctx, user.UserID, &m.req, m.path, sources)
+	if err != nil {
+		m.log.Error("import records error", zap.Error(err))
+		return err
+	}
+	for _, item := range job.Errors {
+		m.log.Warn("import record failed", zap.String("date", item.Date), zap.String("source", item.Source), zap.String("reason", item.Reason))
+	}
+	m.log.Info("Import success", zap.String("job_id", job.JobID), zap.Int("total", job.Total),
+		zap.Int("created", job.Created), zap.Int("updated", job.Updated),
+		zap.Int("skipped", job.Skipped), zap.Int("failed", job.Failed))
+	os.Exit(0)
+	return nil
+}
+
+func (m *ImportServer) Stop(ctx context.Context) error {
+	m.log.Info("Import stop")
+	return nil
+}
+
+// readImportSources 目录按相对路径递归读取文本与 zip 文件，其余文件忽略
+func readImportSources(root string) ([]service.ImportSource, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(root)
+		if err != nil {
+			return nil, err
+		}
+		return []service.ImportSource{{Name: filepath.Base(root), Data: data}}, nil
+	}
+
+	var sources []service.ImportSource
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != root {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".md", ".markdown", ".txt", ".zip":
+		default:
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, service.ImportSource{Name: filepath.ToSlash(rel), Data: data})
+		return nil
+	})
+	return sources, err
+}
@@ -8,7 +8,10 @@
 package server
 
 import (
+	"backend/internal/llm"
 	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
 	"backend/pkg/log"
 	"context"
 	"os"
@@ -33,11 +36,27 @@ func (m *MigrateServer) Start(ctx context.Context) error {
 		&model.User{},
 		&model.UserSettings{},
 		&model.Record{},
+		&model.RecordRevision{},
+		&model.RecordImportJob{},
+		&model.ExportJob{},
+		&model.UserDataKey{},
 		&model.Report{},
+		&model.ReportVersion{},
+		&model.ReportJob{},
+		&model.ReportJobAttempt{},
+		&model.ReportTemplate{},
 	); err != nil {
 		m.log.Error("user migrate error", zap.Error(err))
 		return err
 	}
+	if err := repository.EnsureSearchIndex(m.db); err != nil {
+		m.log.Error("search index migrate error", zap.Error(err))
+		return err
+	}
+	if err := repository.SeedReportTemplates(m.db, service.BuiltinReportTemplates(llm.LoadPrompts(m.log))); err != nil {
+		m.log.Error("seed report templates error", zap.Error(err))
+		return err
+	}
 	m.log.Info("AutoMigrate success")
 	os.Exit(0)
 	return nil
@@ -0,0 +1,86 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 22:48:05
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 22:48:05
+ */
+package server
+
+import (
+	"backend/internal/repository"
+	"backend/pkg/log"
+	"context"
+	"os"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const defaultRekeyBatch = 200
+
+// RekeyServer 密钥轮换工具：先用当前主密钥重新包装数据密钥，可选为每个用户生成新版本数据密钥，
+// 再分批把记录、历史版本、报告正文、报告历史版本及生成任务的提示词与结果迁移到当前密钥。可重复执行，已是目标状态的数据会被跳过
+type RekeyServer struct {
+	rekeyRepo      repository.RekeyRepository
+	log            *log.Logger
+	batch          int
+	rotateDataKeys bool
+}
+
+func NewRekeyServer(conf *viper.Viper, rekeyRepo repository.RekeyRepository, log *log.Logger) *RekeyServer {
+	batch := conf.GetInt("encryption.rekey.batch")
+	if batch <= 0 {
+		batch = defaultRekeyBatch
+	}
+	return &RekeyServer{
+		rekeyRepo:      rekeyRepo,
+		log:            log,
+		batch:          batch,
+		rotateDataKeys: conf.GetBool("encryption.rekey.rotate_data_keys"),
+	}
+}
+
+func (m *RekeyServer) Start(ctx context.Context) error {
+	rewrapped, err := m.rekeyRepo.RewrapDataKeys(ctx, m.batch)
+	if err != nil {
+		m.log.Error("rewrap data keys error", zap.Int("rewrapped", rewrapped), zap.Error(err))
+		return err
+	}
+	m.log.Info("rewrap data keys done", zap.Int("rewrapped", rewrapped))
+
+	if m.rotateDataKeys {
+		rotated, err := m.rekeyRepo.RotateDataKeys(ctx, m.batch)
+		if err != nil {
+			m.log.Error("rotate data keys error", zap.Int("rotated", rotated), zap.Error(err))
+			return err
+		}
+		m.log.Info("rotate data keys done", zap.Int("rotated", rotated))
+	}
+
+	for _, step := range []struct {
+		name string
+		run  func(ctx context.Context, batch int) (int, error)
+	}{
+		{"record", m.rekeyRepo.ReencryptRecords},
+		{"record_revision", m.rekeyRepo.ReencryptRevisions},
+		{"report", m.rekeyRepo.ReencryptReports},
+		{"report_versions", m.rekeyRepo.ReencryptReportVersions},
+		{"report_job", m.rekeyRepo.ReencryptReportJobs},
+	} {
+		changed, err := step.run(ctx, m.batch)
+		if err != nil {
+			m.log.Error("reencrypt content error", zap.String("table", step.name), zap.Int("changed", changed), zap.Error(err))
+			return err
+		}
+		m.log.Info("reencrypt content done", zap.String("table", step.name), zap.Int("changed", changed))
+	}
+	m.log.Info("Rekey success")
+	os.Exit(0)
+	return nil
+}
+
+func (m *RekeyServer) Stop(ctx context.Context) error {
+	m.log.Info("Rekey stop")
+	return nil
+}
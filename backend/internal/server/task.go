@@ -14,25 +14,34 @@ import (
 	"time"
 
 	"github.com/go-co-op/gocron"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
+const defaultPruneRevisionsCron = "0 30 3 * * *"
+
 type TaskServer struct {
 	log        *log.Logger
+	conf       *viper.Viper
 	scheduler  *gocron.Scheduler
 	userTask   task.UserTask
 	reportTask task.ReportTask
+	recordTask task.RecordTask
 }
 
 func NewTaskServer(
+	conf *viper.Viper,
 	log *log.Logger,
 	userTask task.UserTask,
 	reportTask task.ReportTask,
+	recordTask task.RecordTask,
 ) *TaskServer {
 	return &TaskServer{
 		log:        log,
+		conf:       conf,
 		userTask:   userTask,
 		reportTask: reportTask,
+		recordTask: recordTask,
 	}
 }
 func (t *TaskServer) Start(ctx context.Context) error {
@@ -44,11 +53,8 @@ func (t *TaskServer) Start(ctx context.Context) error {
 		t.log.Error("start report task failed", zap.Error(err))
 	}
 
-	loc, err := time.LoadLocation("Asia/Shanghai")
-	if err != nil {
-		return err
-	}
-	t.scheduler = gocron.NewScheduler(loc)
+	// 以下除历史版本清理外均为固定间隔任务，与时区无关；按用户时区的调度（自动周报/月报）在任务内部计算
+	t.scheduler = gocron.NewScheduler(time.UTC)
 
 	// _, err := t.scheduler.CronWithSeconds("0/30 * * * * *").Do(func() {
 	// 	err := t.userTask.CheckUser(ctx)
@@ -60,7 +66,7 @@ func (t *TaskServer) Start(ctx context.Context) error {
 	// 	t.log.Error("检查用户任务失败", zap.Error(err))
 	// }
 
-	_, err = t.scheduler.CronWithSeconds("0/5 * * * * *").Do(func() {
+	_, err := t.scheduler.CronWithSeconds("0/5 * * * * *").Do(func() {
 		err := t.reportTask.ProcessReportQueue(ctx)
 		if err != nil {
 			t.log.Error("report task failed", zap.Error(err))
@@ -70,9 +76,57 @@ func (t *TaskServer) Start(ctx context.Context) error {
 		t.log.Error("report task failed", zap.Error(err))
 	}
 
+	_, err = t.scheduler.CronWithSeconds("15/30 * * * * *").Do(func() {
+		err := t.reportTask.RecoverExpiredReports(ctx)
+		if err != nil {
+			t.log.Error("recover expired reports failed", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("recover expired reports failed", zap.Error(err))
+	}
+
+	_, err = t.scheduler.CronWithSeconds("0 * * * * *").Do(func() {
+		err := t.reportTask.AutoGenerateReports(ctx)
+		if err != nil {
+			t.log.Error("auto generate reports failed", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("auto generate reports failed", zap.Error(err))
+	}
+
+	_, err = t.scheduler.CronWithSeconds(t.pruneRevisionsCron()).Do(func() {
+		err := t.recordTask.PruneRevisions(ctx)
+		if err != nil {
+			t.log.Error("prune record revisions failed", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("prune record revisions failed", zap.Error(err))
+	}
+
 	t.scheduler.StartBlocking()
 	return nil
 }
+
+// pruneRevisionsCron 历史版本清理时刻与时区由 task.prune_revisions 配置，默认 UTC 03:30
+func (t *TaskServer) pruneRevisionsCron() string {
+	spec := t.conf.GetString("task.prune_revisions.cron")
+	if spec == "" {
+		spec = defaultPruneRevisionsCron
+	}
+	timezone := t.conf.GetString("task.prune_revisions.timezone")
+	if timezone == "" {
+		return spec
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		t.log.Error("invalid prune revisions timezone, fallback to UTC", zap.String("timezone", timezone), zap.Error(err))
+		return spec
+	}
+	return "CRON_TZ=" + timezone + " " + spec
+}
+
 func (t *TaskServer) Stop(ctx context.Context) error {
 	t.scheduler.Stop()
 	if err := t.reportTask.Stop(ctx); err != nil {
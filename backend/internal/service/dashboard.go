@@ -4,6 +4,7 @@ import (
 	v1 "backend/api/v1"
 	"backend/internal/repository"
 	"context"
+	"errors"
 	"time"
 
 	"go.uber.org/zap"
@@ -12,24 +13,28 @@ import (
 type DashboardService interface {
 	GetMonth(ctx context.Context, userId string, month string) (*v1.MonthDashboardResp, error)
 	GetSummary(ctx context.Context, userId string) (*v1.DashboardSummaryResp, error)
+	GetPeriod(ctx context.Context, userId string, req *v1.PeriodDashboardReq) (*v1.PeriodDashboardResp, error)
 }
 
 func NewDashboardService(
 	service *Service,
 	recordRepo repository.RecordRespository,
 	reportRepo repository.ReportRepository,
+	userSettingsRepo repository.UserSettingsRepository,
 ) DashboardService {
 	return &dashboardService{
-		Service:    service,
-		recordRepo: recordRepo,
-		reportRepo: reportRepo,
+		Service:          service,
+		recordRepo:       recordRepo,
+		reportRepo:       reportRepo,
+		userSettingsRepo: userSettingsRepo,
 	}
 }
 
 type dashboardService struct {
 	*Service
-	recordRepo repository.RecordRespository
-	reportRepo repository.ReportRepository
+	recordRepo       repository.RecordRespository
+	reportRepo       repository.ReportRepository
+	userSettingsRepo repository.UserSettingsRepository
 }
 
 func (s *dashboardService) GetMonth(ctx context.Context, userId string, month string) (*v1.MonthDashboardResp, error) {
@@ -38,7 +43,13 @@ func (s *dashboardService) GetMonth(ctx context.Context, userId string, month st
 		s.logger.Error("parse month failed", zap.String("user_id", userId), zap.String("month", month))
 		return nil, v1.ErrInvalidDate
 	}
-	now := time.Now()
+	loc, err := userLocation(ctx, s.userSettingsRepo, userId)
+	if err != nil {
+		s.logger.Error("get user settings failed", zap.String("user_id", userId), zap.Error(err))
+		return nil, v1.ErrGetDashboardFailed
+	}
+	// 统计截止到用户时区的今天
+	now := localToday(time.Now(), loc)
 	if monthTime.After(now) {
 		return nil, v1.ErrInvalidDate
 	}
@@ -49,6 +60,103 @@ func (s *dashboardService) GetMonth(ctx context.Context, userId string, month st
 		end = now
 	}
 
+	coverage, err := s.coverage(ctx, userId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.MonthDashboardResp{
+		RecordedDays: coverage.RecordedDays,
+		MissingDays:  coverage.MissingDays,
+		Rate:         coverage.Rate,
+		Days:         coverage.Days,
+	}, nil
+}
+
+// GetPeriod 报告周期的记录完成情况、已确认的月报/周报数与该周期报告状态
+func (s *dashboardService) GetPeriod(ctx context.Context, userId string, req *v1.PeriodDashboardReq) (*v1.PeriodDashboardResp, error) {
+	if err := validateReportPeriod(req.PeriodType); err != nil {
+		return nil, err
+	}
+	settings, err := s.userSettingsRepo.GetByID(ctx, userId)
+	if err != nil && !errors.Is(err, v1.ErrNotFound) {
+		s.logger.Error("get user settings failed", zap.String("user_id", userId), zap.Error(err))
+		return nil, v1.ErrGetDashboardFailed
+	}
+	loc := loadLocation("")
+	if settings != nil {
+		loc = loadLocation(settings.Timezone)
+	}
+	now := localToday(time.Now(), loc)
+
+	var start, end time.Time
+	if req.PeriodType == string(v1.ReportPeriodCustom) {
+		if start, err = time.Parse(reportDateLayout, req.StartDate); err != nil {
+			return nil, v1.ErrInvalidDate
+		}
+		if end, err = time.Parse(reportDateLayout, req.EndDate); err != nil {
+			return nil, v1.ErrInvalidDate
+		}
+		if err := validateDateRange(req.PeriodType, start, end, sprintOf(settings)); err != nil {
+			return nil, err
+		}
+	} else {
+		date := now
+		if req.Date != "" {
+			if date, err = time.Parse(reportDateLayout, req.Date); err != nil {
+				return nil, v1.ErrInvalidDate
+			}
+		}
+		if start, end, err = periodRange(req.PeriodType, date, sprintOf(settings)); err != nil {
+			return nil, err
+		}
+	}
+	if start.After(now) {
+		return nil, v1.ErrInvalidDate
+	}
+	startDate, endDate := start.Format(reportDateLayout), end.Format(reportDateLayout)
+
+	// 记录完成情况统计截止到用户时区的今天
+	coverage, err := s.coverage(ctx, userId, start, minDate(end, now))
+	if err != nil {
+		return nil, err
+	}
+	resp := &v1.PeriodDashboardResp{
+		PeriodType:   req.PeriodType,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Title:        buildReportTitle(req.PeriodType, startDate, endDate),
+		RecordedDays: coverage.RecordedDays,
+		MissingDays:  coverage.MissingDays,
+		Rate:         coverage.Rate,
+	}
+	if usesRollup(req.PeriodType, startDate, endDate) {
+		months, err := s.reportRepo.ListConfirmedByPeriod(ctx, userId, string(v1.ReportPeriodMonth), startDate, endDate)
+		if err != nil {
+			s.logger.Error("list confirmed month reports failed", zap.String("user_id", userId), zap.Error(err))
+			return nil, v1.ErrGetDashboardFailed
+		}
+		weeks, err := s.reportRepo.ListConfirmedByPeriod(ctx, userId, string(v1.ReportPeriodWeek), startDate, endDate)
+		if err != nil {
+			s.logger.Error("list confirmed week reports failed", zap.String("user_id", userId), zap.Error(err))
+			return nil, v1.ErrGetDashboardFailed
+		}
+		resp.ConfirmedMonths = len(months)
+		resp.ConfirmedWeeks = len(weeks)
+	}
+
+	report, err := s.reportRepo.GetByUnique(ctx, userId, req.PeriodType, startDate, endDate)
+	if err != nil && !errors.Is(err, v1.ErrNotFound) {
+		s.logger.Error("get report for dashboard failed", zap.String("user_id", userId), zap.Error(err))
+		return nil, v1.ErrGetDashboardFailed
+	}
+	if report != nil {
+		resp.Report = &v1.PeriodReportState{ReportID: report.ReportID, Status: report.Status, Confirmed: report.Confirmed}
+	}
+	return resp, nil
+}
+
+// coverage 统计 [start, end] 内每天是否有记录
+func (s *dashboardService) coverage(ctx context.Context, userId string, start time.Time, end time.Time) (*v1.MonthDashboardResp, error) {
 	records, err := s.recordRepo.GetByDateRange(ctx, userId, start.Format(reportDateLayout), end.Format(reportDateLayout))
 	if err != nil && err != v1.ErrNotFound {
 		s.logger.Error("get records for dashboard failed", zap.String("user_id", userId), zap.Error(err))
@@ -92,6 +200,13 @@ func (s *dashboardService) GetMonth(ctx context.Context, userId string, month st
 	}, nil
 }
 
+func minDate(a time.Time, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
 func (s *dashboardService) GetSummary(ctx context.Context, userId string) (*v1.DashboardSummaryResp, error) {
 	records, err := s.recordRepo.GetByUserID(ctx, userId, "")
 	if err != nil && err != v1.ErrNotFound {
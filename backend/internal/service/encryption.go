@@ -0,0 +1,133 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 21:02:45
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 21:02:45
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"encoding/base64"
+)
+
+const (
+	encTagSize     = 16 // AEAD 认证标签长度，密文至少包含标签
+	encMinSaltSize = 16
+	encMaxKeyIDLen = 64
+	encMaxVerifier = 1024
+
+	minPBKDF2Iterations = 100000
+)
+
+var encNonceSize = map[string]int{
+	v1.EncAlgAES256GCM:         12,
+	v1.EncAlgXChaCha20Poly1305: 24,
+}
+
+// recordEncryption 记录的加密状态，明文记录为零值
+type recordEncryption struct {
+	encrypted bool
+	alg       string
+	nonce     string
+	keyID     string
+}
+
+func encryptionOfRecord(record *model.Record) recordEncryption {
+	return recordEncryption{encrypted: record.IsEncrypted, alg: record.EncAlg, nonce: record.EncNonce, keyID: record.EncKeyID}
+}
+
+func encryptionOfRevision(rev *model.RecordRevision) recordEncryption {
+	return recordEncryption{encrypted: rev.IsEncrypted, alg: rev.EncAlg, nonce: rev.EncNonce, keyID: rev.EncKeyID}
+}
+
+func (e recordEncryption) applyTo(record *model.Record) {
+	record.IsEncrypted = e.encrypted
+	record.EncAlg = e.alg
+	record.EncNonce = e.nonce
+	record.EncKeyID = e.keyID
+}
+
+func (e recordEncryption) envelope() *v1.RecordEnvelope {
+	if !e.encrypted {
+		return nil
+	}
+	return &v1.RecordEnvelope{Alg: e.alg, Nonce: e.nonce, KeyID: e.keyID}
+}
+
+// validateEnvelope 校验密文与加密参数格式；服务端不持有密钥，无法也不尝试解密
+func validateEnvelope(content string, envelope *v1.RecordEnvelope) (recordEncryption, error) {
+	if envelope == nil {
+		return recordEncryption{}, v1.ErrInvalidEnvelope
+	}
+	size, ok := encNonceSize[envelope.Alg]
+	if !ok {
+		return recordEncryption{}, v1.ErrInvalidEnvelope
+	}
+	nonce, err := base64.StdEncoding.DecodeString(envelope.Nonce)
+	if err != nil || len(nonce) != size {
+		return recordEncryption{}, v1.ErrInvalidEnvelope
+	}
+	if envelope.KeyID == "" || len(envelope.KeyID) > encMaxKeyIDLen {
+		return recordEncryption{}, v1.ErrInvalidEnvelope
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(content)
+	if err != nil || len(ciphertext) < encTagSize {
+		return recordEncryption{}, v1.ErrInvalidEnvelope
+	}
+	return recordEncryption{encrypted: true, alg: envelope.Alg, nonce: envelope.Nonce, keyID: envelope.KeyID}, nil
+}
+
+// validateEncryptionKey 校验客户端上报的密钥派生参数，拒绝明显过弱的配置
+func validateEncryptionKey(key *v1.EncryptionKey) error {
+	if key.KeyID == "" || len(key.KeyID) > encMaxKeyIDLen {
+		return v1.ErrInvalidEncryptionKey
+	}
+	salt, err := base64.StdEncoding.DecodeString(key.Salt)
+	if err != nil || len(salt) < encMinSaltSize || len(key.Salt) > 64 {
+		return v1.ErrInvalidEncryptionKey
+	}
+	if key.Verifier == "" || len(key.Verifier) > encMaxVerifier {
+		return v1.ErrInvalidEncryptionKey
+	}
+	switch key.KDF {
+	case v1.EncKDFPBKDF2SHA256:
+		if kdfParam(key.KDFParams, "iterations") < minPBKDF2Iterations {
+			return v1.ErrInvalidEncryptionKey
+		}
+	case v1.EncKDFArgon2id:
+		if kdfParam(key.KDFParams, "memory") <= 0 || kdfParam(key.KDFParams, "iterations") <= 0 || kdfParam(key.KDFParams, "parallelism") <= 0 {
+			return v1.ErrInvalidEncryptionKey
+		}
+	default:
+		return v1.ErrInvalidEncryptionKey
+	}
+	return nil
+}
+
+// kdfParam JSON 解码后数字为 float64
+func kdfParam(params map[string]any, name string) int {
+	switch v := params[name].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+func toEncryptionKey(settings *model.UserSettings) *v1.EncryptionKey {
+	if settings.EncKeyID == "" {
+		return nil
+	}
+	return &v1.EncryptionKey{
+		KeyID:     settings.EncKeyID,
+		KDF:       settings.EncKDF,
+		KDFParams: settings.EncKDFParams,
+		Salt:      settings.EncSalt,
+		Verifier:  settings.EncVerifier,
+	}
+}
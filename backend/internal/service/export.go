@@ -0,0 +1,493 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 23:58:41
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 23:58:41
+ */
+package service
+
+import (
+	"archive/zip"
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	ExportPrefix string = "exportid_"
+
+	defaultExportDir       = "storage/exports"
+	defaultExportLinkTTL   = 15 * time.Minute
+	defaultExportRetention = 24 * time.Hour
+	exportStaleAfter       = 10 * time.Minute
+	exportHeartbeat        = 2 * time.Minute // 生成期间刷新 updated_at 的间隔，须明显小于 exportStaleAfter
+	exportInterrupted      = "导出进程中断，请重新导出"
+)
+
+// ExportService 账号数据导出：异步生成 zip（每天一个记录 Markdown、每份报告一个带 front matter 的 Markdown、
+// manifest.json 与 settings.json），通过短时有效的签名链接下载；记录文件可直接用 POST /records/import 导回
+type ExportService interface {
+	StartExport(ctx context.Context, userId string) (v1.ExportJob, error)
+	GetExport(ctx context.Context, userId string, exportId string) (v1.ExportJob, error)
+	OpenDownload(ctx context.Context, req *v1.ExportDownloadReq) (string, string, error)
+}
+
+func NewExportService(
+	conf *viper.Viper,
+	service *Service,
+	exportRepo repository.ExportJobRepository,
+	userRepo repository.UserRepository,
+	userSettingsRepo repository.UserSettingsRepository,
+	reportRepo repository.ReportRepository,
+	recordSvr RecordService,
+) ExportService {
+	dir := conf.GetString("export.dir")
+	if dir == "" {
+		dir = defaultExportDir
+	}
+	linkTTL := conf.GetDuration("export.link_ttl")
+	if linkTTL <= 0 {
+		linkTTL = defaultExportLinkTTL
+	}
+	retention := conf.GetDuration("export.retention")
+	if retention <= 0 {
+		retention = defaultExportRetention
+	}
+	signKey := conf.GetString("export.sign_key")
+	if signKey == "" {
+		signKey = conf.GetString("security.jwt.key")
+	}
+	return &exportService{
+		Service:          service,
+		exportRepo:       exportRepo,
+		userRepo:         userRepo,
+		userSettingsRepo: userSettingsRepo,
+		reportRepo:       reportRepo,
+		recordSvr:        recordSvr,
+		dir:              dir,
+		linkTTL:          linkTTL,
+		retention:        retention,
+		signKey:          []byte(signKey),
+	}
+}
+
+type exportService struct {
+	*Service
+	exportRepo       repository.ExportJobRepository
+	userRepo         repository.UserRepository
+	userSettingsRepo repository.UserSettingsRepository
+	reportRepo       repository.ReportRepository
+	recordSvr        RecordService
+	dir              string
+	linkTTL          time.Duration
+	retention        time.Duration
+	signKey          []byte
+}
+
+// StartExport 同一用户已有进行中的导出时直接返回该任务
+func (s *exportService) StartExport(ctx context.Context, userId string) (v1.ExportJob, error) {
+	s.pruneExpired(ctx, userId)
+
+	running, err := s.exportRepo.GetRunning(ctx, userId)
+	if err == nil {
+		if time.Since(running.UpdatedAt) < exportStaleAfter {
+			return s.toExportJob(running), nil
+		}
+		s.finish(ctx, running, errors.New(exportInterrupted))
+	} else if !errors.Is(err, v1.ErrNotFound) {
+		s.logger.Error("get running export failed", zap.String("user_id", userId), zap.Error(err))
+		return v1.ExportJob{}, v1.ErrExportFailed
+	}
+
+	exportId, err := s.sid.GenString()
+	if err != nil {
+		return v1.ExportJob{}, v1.ErrJWTGenFailed
+	}
+	job := &model.ExportJob{
+		ExportID: ExportPrefix + exportId,
+		UserID:   userId,
+		Status:   string(v1.ExportStatusRunning),
+	}
+	if err := s.exportRepo.Create(ctx, job); err != nil {
+		s.logger.Error("create export job failed", zap.String("user_id", userId), zap.Error(err))
+		return v1.ExportJob{}, v1.ErrExportFailed
+	}
+	item := s.toExportJob(job)
+	go func() {
+		ctx := context.Background()
+		stop := s.heartbeat(ctx, job.ExportID)
+		err := s.buildArchive(ctx, job)
+		stop()
+		s.finish(ctx, job, err)
+	}()
+	return item, nil
+}
+
+func (s *exportService) GetExport(ctx context.Context, userId string, exportId string) (v1.ExportJob, error) {
+	job, err := s.exportRepo.GetByID(ctx, userId, exportId)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return v1.ExportJob{}, v1.ErrExportNotExist
+		}
+		s.logger.Error("get export job failed", zap.String("user_id", userId), zap.String("export_id", exportId), zap.Error(err))
+		return v1.ExportJob{}, v1.ErrExportFailed
+	}
+	switch {
+	case job.Status == string(v1.ExportStatusRunning) && time.Since(job.UpdatedAt) > exportStaleAfter:
+		s.finish(ctx, job, errors.New(exportInterrupted))
+	case job.Status == string(v1.ExportStatusReady) && job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt):
+		s.expire(ctx, job)
+	}
+	return s.toExportJob(job), nil
+}
+
+// OpenDownload 校验签名与有效期，返回压缩包路径与下载文件名
+func (s *exportService) OpenDownload(ctx context.Context, req *v1.ExportDownloadReq) (string, string, error) {
+	if time.Now().Unix() > req.Expires || !hmac.Equal([]byte(req.Sig), []byte(s.sign(req.ExportID, req.Expires))) {
+		return "", "", v1.ErrExportLinkInvalid
+	}
+	job, err := s.exportRepo.GetByExportID(ctx, req.ExportID)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return "", "", v1.ErrExportNotExist
+		}
+		return "", "", v1.ErrExportFailed
+	}
+	switch job.Status {
+	case string(v1.ExportStatusReady):
+	case string(v1.ExportStatusExpired):
+		return "", "", v1.ErrExportExpired
+	default:
+		return "", "", v1.ErrExportNotReady
+	}
+	if job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		s.expire(ctx, job)
+		return "", "", v1.ErrExportExpired
+	}
+	if _, err := os.Stat(job.FilePath); err != nil {
+		// 压缩包只写在生成它的实例上
+		s.logger.Error("export archive missing", zap.String("export_id", job.ExportID), zap.String("path", job.FilePath), zap.Error(err))
+		return "", "", v1.ErrExportExpired
+	}
+	return job.FilePath, fmt.Sprintf("thinking-calendar-export-%s.zip", job.CreatedAt.Format("20060102")), nil
+}
+
+func (s *exportService) finish(ctx context.Context, job *model.ExportJob, cause error) {
+	now := time.Now()
+	job.FinishedAt = &now
+	if cause != nil {
+		job.Status = string(v1.ExportStatusFailed)
+		job.Error = cause.Error()
+		if job.FilePath != "" {
+			_ = os.Remove(job.FilePath)
+			job.FilePath = ""
+		}
+		s.logger.Error("export failed", zap.String("export_id", job.ExportID), zap.String("user_id", job.UserID), zap.Error(cause))
+	} else {
+		expires := now.Add(s.retention)
+		job.Status = string(v1.ExportStatusReady)
+		job.ExpiresAt = &expires
+	}
+	ok, err := s.exportRepo.Finish(ctx, job)
+	if err != nil {
+		s.logger.Error("update export job failed", zap.String("export_id", job.ExportID), zap.Error(err))
+		return
+	}
+	if ok {
+		return
+	}
+	// 已被其他请求结束（如判定为中断），丢弃本次结果并以库中状态为准
+	s.logger.Warn("export already finished", zap.String("export_id", job.ExportID))
+	if cause == nil && job.FilePath != "" {
+		_ = os.Remove(job.FilePath)
+	}
+	if latest, err := s.exportRepo.GetByExportID(ctx, job.ExportID); err == nil {
+		*job = *latest
+	}
+}
+
+// heartbeat 生成期间定期刷新 updated_at，避免耗时较长的导出被判定为中断；返回的函数停止刷新
+func (s *exportService) heartbeat(ctx context.Context, exportId string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(exportHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ok, err := s.exportRepo.Touch(ctx, exportId)
+				if err != nil {
+					s.logger.Warn("touch export job failed", zap.String("export_id", exportId), zap.Error(err))
+					continue
+				}
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (s *exportService) expire(ctx context.Context, job *model.ExportJob) {
+	if job.FilePath != "" {
+		if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+			s.logger.Warn("remove export archive failed", zap.String("export_id", job.ExportID), zap.Error(err))
+			return
+		}
+	}
+	if err := s.exportRepo.MarkExpired(ctx, job.ExportID); err != nil {
+		s.logger.Warn("mark export expired failed", zap.String("export_id", job.ExportID), zap.Error(err))
+		return
+	}
+	job.Status = string(v1.ExportStatusExpired)
+	job.FilePath = ""
+}
+
+// pruneExpired 清理该用户已过保留时长的压缩包
+func (s *exportService) pruneExpired(ctx context.Context, userId string) {
+	jobs, err := s.exportRepo.ListExpired(ctx, userId, time.Now())
+	if err != nil {
+		s.logger.Warn("list expired exports failed", zap.String("user_id", userId), zap.Error(err))
+		return
+	}
+	for _, job := range jobs {
+		s.expire(ctx, job)
+	}
+}
+
+func (s *exportService) sign(exportId string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signKey)
+	mac.Write([]byte(exportId + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *exportService) toExportJob(job *model.ExportJob) v1.ExportJob {
+	item := v1.ExportJob{
+		ExportID:  job.ExportID,
+		Status:    job.Status,
+		Records:   job.Records,
+		Reports:   job.Reports,
+		Size:      job.Size,
+		Error:     job.Error,
+		CreatedAt: formatTime(&job.CreatedAt),
+	}
+	if job.FinishedAt != nil {
+		item.FinishedAt = formatTime(job.FinishedAt)
+	}
+	if job.ExpiresAt != nil {
+		item.ExpiresAt = formatTime(job.ExpiresAt)
+	}
+	if job.Status == string(v1.ExportStatusReady) {
+		linkExpires := time.Now().Add(s.linkTTL)
+		if job.ExpiresAt != nil && job.ExpiresAt.Before(linkExpires) {
+			linkExpires = *job.ExpiresAt
+		}
+		// 相对 API 前缀的路径，由 handler 补全
+		item.DownloadURL = fmt.Sprintf("/export/%s/download?expires=%d&sig=%s", job.ExportID, linkExpires.Unix(), s.sign(job.ExportID, linkExpires.Unix()))
+		item.LinkExpiresAt = formatTime(&linkExpires)
+	}
+	return item
+}
+
+// buildArchive 先写临时文件，完成后改名，下载时不会读到半个压缩包
+func (s *exportService) buildArchive(ctx context.Context, job *model.ExportJob) error {
+	user, err := s.userRepo.GetByID(ctx, job.UserID)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	settings, err := s.userSettingsRepo.GetByID(ctx, job.UserID)
+	if err != nil {
+		if !errors.Is(err, v1.ErrNotFound) {
+			return fmt.Errorf("get user settings: %w", err)
+		}
+		settings = &model.UserSettings{UserID: job.UserID}
+	}
+	records, err := s.recordSvr.GetAllUserRecords(ctx, job.UserID)
+	if err != nil {
+		return fmt.Errorf("get records: %w", err)
+	}
+	reports, err := s.reportRepo.GetAll(ctx, job.UserID)
+	if err != nil && !errors.Is(err, v1.ErrNotFound) {
+		return fmt.Errorf("get reports: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, job.ExportID+".zip")
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	archive := &exportArchive{zw: zip.NewWriter(file)}
+	manifest := writeExportArchive(archive, time.Now(), user, settings, records, reports)
+	if err := archive.err; err != nil {
+		file.Close()
+		return err
+	}
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	archive.add("manifest.json", "", data)
+	if err := archive.err; err != nil {
+		file.Close()
+		return err
+	}
+	if err := archive.zw.Close(); err != nil {
+		file.Close()
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	job.FilePath = path
+	job.Size = info.Size()
+	job.Records = len(records)
+	job.Reports = len(reports)
+	return nil
+}
+
+type exportArchive struct {
+	zw    *zip.Writer
+	files []v1.ExportManifestFile
+	err   error
+}
+
+// add 写入一个文件；kind 非空时记入 manifest
+func (a *exportArchive) add(path string, kind string, data []byte) {
+	if a.err != nil {
+		return
+	}
+	w, err := a.zw.Create(path)
+	if err != nil {
+		a.err = err
+		return
+	}
+	if _, err := w.Write(data); err != nil {
+		a.err = err
+		return
+	}
+	if kind != "" {
+		sum := sha256.Sum256(data)
+		a.files = append(a.files, v1.ExportManifestFile{Path: path, Kind: kind, Size: len(data), SHA256: hex.EncodeToString(sum[:])})
+	}
+}
+
+// writeExportArchive 端到端加密的记录服务端无法转成 Markdown，连同加密参数原样写入 records/encrypted.json
+func writeExportArchive(a *exportArchive, exportedAt time.Time, user *model.User, settings *model.UserSettings, records []v1.RecordItem, reports []*model.Report) v1.ExportManifest {
+	sort.Slice(records, func(i, j int) bool { return records[i].Date < records[j].Date })
+	var encrypted []v1.RecordItem
+	for _, record := range records {
+		if record.Encrypted {
+			encrypted = append(encrypted, record)
+			continue
+		}
+		a.add("records/"+record.Date+".md", "record", []byte(renderExportRecord(record)))
+	}
+	if len(encrypted) > 0 {
+		data, _ := json.MarshalIndent(encrypted, "", "  ")
+		a.add("records/encrypted.json", "encrypted_records", data)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].PeriodType != reports[j].PeriodType {
+			return reports[i].PeriodType < reports[j].PeriodType
+		}
+		return reports[i].StartDate < reports[j].StartDate
+	})
+	for _, report := range reports {
+		a.add(fmt.Sprintf("reports/%s/%s_%s.md", report.PeriodType, report.StartDate, report.EndDate), "report", []byte(renderExportReport(report)))
+	}
+
+	data, _ := json.MarshalIndent(settings, "", "  ")
+	a.add("settings.json", "settings", data)
+
+	return v1.ExportManifest{
+		Format:           v1.ExportFormat,
+		FormatVersion:    v1.ExportFormatVersion,
+		ExportedAt:       formatTime(&exportedAt),
+		UserID:           user.UserID,
+		Username:         user.Username,
+		Records:          len(records) - len(encrypted),
+		EncryptedRecords: len(encrypted),
+		Reports:          len(reports),
+		Files:            a.files,
+	}
+}
+
+// renderExportRecord front matter 中的 date 优先于正文中的日期标题，导入时整篇归属该日期
+func renderExportRecord(record v1.RecordItem) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString("kind: record\n")
+	b.WriteString("date: " + record.Date + "\n")
+	b.WriteString("version: " + strconv.Itoa(record.Version) + "\n")
+	b.WriteString("updated_at: " + strconv.Quote(record.UpdatedAt) + "\n")
+	b.WriteString("---\n\n")
+	b.WriteString(record.Content)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// renderExportReport kind: report 的文件导入时忽略
+func renderExportReport(report *model.Report) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString("kind: report\n")
+	b.WriteString("report_id: " + report.ReportID + "\n")
+	b.WriteString("title: " + strconv.Quote(report.Title) + "\n")
+	b.WriteString("period_type: " + report.PeriodType + "\n")
+	b.WriteString("start_date: " + report.StartDate + "\n")
+	b.WriteString("end_date: " + report.EndDate + "\n")
+	b.WriteString("template: " + report.Template + "\n")
+	b.WriteString("status: " + report.Status + "\n")
+	b.WriteString("confirmed: " + strconv.FormatBool(report.Confirmed) + "\n")
+	if report.ConfirmedAt != nil {
+		b.WriteString("confirmed_at: " + strconv.Quote(formatTime(report.ConfirmedAt)) + "\n")
+	}
+	b.WriteString("version: " + strconv.Itoa(report.Version) + "\n")
+	b.WriteString("gen_version: " + strconv.Itoa(report.GenVersion) + "\n")
+	if report.LLMModel != "" {
+		b.WriteString("llm_model: " + strconv.Quote(report.LLMModel) + "\n")
+	}
+	b.WriteString("created_at: " + strconv.Quote(formatTime(&report.CreatedAt)) + "\n")
+	b.WriteString("updated_at: " + strconv.Quote(formatTime(&report.UpdatedAt)) + "\n")
+	b.WriteString("---\n\n")
+	b.WriteString(report.Content)
+	b.WriteString("\n")
+	return b.String()
+}
@@ -30,21 +30,31 @@ type RecordService interface {
 	QueryUserRecordsByDate(ctx context.Context, userId string, date string) (v1.RecordItem, error)
 	QueryUserRecordsByDateRange(ctx context.Context, userId string, startDate string, endDate string) ([]v1.RecordItem, error)
 	GetAllUserRecords(ctx context.Context, userId string) ([]v1.RecordItem, error)
+	ListRevisions(ctx context.Context, userId string, recordId string) (*v1.RecordRevisionsResp, error)
+	DiffRevisions(ctx context.Context, userId string, req *v1.RecordRevisionDiffReq) (*v1.RecordRevisionDiffResp, error)
+	RestoreRevision(ctx context.Context, userId string, recordId string, revision int) (v1.RecordItem, error)
+	PruneRevisions(ctx context.Context, keep int, maxAge time.Duration) (int64, error)
 }
 
 func NewRecordService(
 	service *Service,
 	recordRepo repository.RecordRespository,
+	userSettingsRepo repository.UserSettingsRepository,
+	revisionRepo repository.RecordRevisionRepository,
 ) RecordService {
 	return &recordService{
-		Service:    service,
-		recordRepo: recordRepo,
+		Service:          service,
+		recordRepo:       recordRepo,
+		userSettingsRepo: userSettingsRepo,
+		revisionRepo:     revisionRepo,
 	}
 }
 
 type recordService struct {
 	*Service
-	recordRepo repository.RecordRespository
+	recordRepo       repository.RecordRespository
+	userSettingsRepo repository.UserSettingsRepository
+	revisionRepo     repository.RecordRevisionRepository
 }
 
 func (s *recordService) UpsertUserRecord(ctx context.Context, userId string, req *v1.UpsertRecordReq) error {
@@ -53,10 +63,21 @@ func (s *recordService) UpsertUserRecord(ctx context.Context, userId string, req
 		s.logger.Error("fmt date error", zap.String("user_id", userId), zap.String("date", req.Date))
 		return v1.ErrInvalidDate
 	}
-	if parsedDate.After(time.Now()) {
+	loc, err := userLocation(ctx, s.userSettingsRepo, userId)
+	if err != nil {
+		s.logger.Error("get user settings failed.", zap.String("user_id", userId), zap.Error(err))
+		return v1.ErrGetUserSettingsFailed
+	}
+	if parsedDate.After(localToday(time.Now(), loc)) {
 		s.logger.Error("future date not allowed", zap.String("user_id", userId), zap.String("date", req.Date))
 		return v1.ErrInvalidDate
 	}
+	var enc recordEncryption
+	if req.Encrypted {
+		if enc, err = validateEnvelope(req.Content, req.Envelope); err != nil {
+			return err
+		}
+	}
 	recordListPtr, err := s.recordRepo.GetByUserID(ctx, userId, req.Date)
 	if err != nil {
 		s.logger.Error("get records failed.", zap.String("user_id", userId))
@@ -75,10 +96,15 @@ func (s *recordService) UpsertUserRecord(ctx context.Context, userId string, req
 			UserID:    userId,
 			Date:      req.Date,
 			Content:   req.Content,
-			WordCount: utf8.RuneCountInString(req.Content),
+			WordCount: wordCount(req.Content, enc),
 			Meta:      req.Meta,
 		}
+		enc.applyTo(record)
 		if err := s.recordRepo.Create(ctx, record); err != nil {
+			// 其他端同时创建了同一天的记录
+			if existed, getErr := s.recordRepo.GetByUserID(ctx, userId, req.Date); getErr == nil && len(existed) > 0 {
+				return v1.ErrRecordConflict
+			}
 			s.logger.Error("create record failed.", zap.String("user_id", userId), zap.Error(err))
 			return v1.ErrCreateRecordFailed
 		}
@@ -89,13 +115,14 @@ func (s *recordService) UpsertUserRecord(ctx context.Context, userId string, req
 			return v1.ErrTooManyRecords
 		}
 		existedRecord := recordListPtr[0]
-		existedRecord.Date = req.Date
-		existedRecord.Content = req.Content
-		existedRecord.WordCount = utf8.RuneCountInString(req.Content)
-		existedRecord.Meta = req.Meta
-		existedRecord.Version = existedRecord.Version + 1
-		existedRecord.IsDeleted = false
-		if err := s.recordRepo.Update(ctx, existedRecord); err != nil {
+		// 未携带版本号的旧客户端保持覆盖写入
+		if req.Version != nil && *req.Version != existedRecord.Version {
+			return v1.ErrRecordConflict
+		}
+		if err := s.overwrite(ctx, existedRecord, req.Content, req.Meta, enc); err != nil {
+			if errors.Is(err, v1.ErrRecordConflict) {
+				return err
+			}
 			s.logger.Error("update record failed.", zap.String("user_id", userId), zap.String("record_id", existedRecord.RecordID), zap.String("date", req.Date), zap.Error(err))
 			return v1.ErrUpdateRecordFailed
 		}
@@ -187,7 +214,17 @@ func (s *recordService) toRecordItem(record *model.Record) v1.RecordItem {
 		Content:   record.Content,
 		UpdatedAt: formatTime(&record.UpdatedAt),
 		Version:   record.Version,
+		Encrypted: record.IsEncrypted,
+		Envelope:  encryptionOfRecord(record).envelope(),
+	}
+}
+
+// wordCount 密文无法统计字数，记为 0
+func wordCount(content string, enc recordEncryption) int {
+	if enc.encrypted {
+		return 0
 	}
+	return utf8.RuneCountInString(content)
 }
 
 func (s *recordService) toRecordItems(records []*model.Record) []v1.RecordItem {
@@ -0,0 +1,356 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 23:26:15
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 23:26:15
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	ImportJobPrefix string = "importid_"
+
+	importProgressEvery = 20
+	importMaxErrors     = 100
+	importStaleAfter    = 10 * time.Minute
+	importHeartbeat     = 2 * time.Minute // 写入期间刷新 updated_at 的间隔，须明显小于 importStaleAfter
+	importInterrupted   = "导入进程中断，可重新导入，已写入的记录会按冲突策略跳过"
+)
+
+// RecordImportService 批量导入历史记录（Markdown/Obsidian/纯文本），解析日期后经 RecordService 逐条写入
+type RecordImportService interface {
+	PreviewImport(ctx context.Context, userId string, req *v1.ImportRecordsReq, sources []ImportSource) (*v1.RecordImportPreview, error)
+	StartImport(ctx context.Context, userId string, req *v1.ImportRecordsReq, source string, sources []ImportSource) (v1.RecordImportJob, error)
+	RunImport(ctx context.Context, userId string, req *v1.ImportRecordsReq, source string, sources []ImportSource) (v1.RecordImportJob, error)
+	GetImportJob(ctx context.Context, userId string, jobId string) (v1.RecordImportJob, error)
+}
+
+func NewRecordImportService(
+	service *Service,
+	recordSvr RecordService,
+	importRepo repository.RecordImportRepository,
+	userSettingsRepo repository.UserSettingsRepository,
+) RecordImportService {
+	return &recordImportService{
+		Service:          service,
+		recordSvr:        recordSvr,
+		importRepo:       importRepo,
+		userSettingsRepo: userSettingsRepo,
+	}
+}
+
+type recordImportService struct {
+	*Service
+	recordSvr        RecordService
+	importRepo       repository.RecordImportRepository
+	userSettingsRepo repository.UserSettingsRepository
+}
+
+// importPlan 单条记录的写入计划，version 为预览时读到的版本，写入时以此做并发校验
+type importPlan struct {
+	entry   *importEntry
+	action  string
+	reason  string
+	content string
+	version *int
+}
+
+func (s *recordImportService) PreviewImport(ctx context.Context, userId string, req *v1.ImportRecordsReq, sources []ImportSource) (*v1.RecordImportPreview, error) {
+	plans, err := s.plan(ctx, userId, req, sources)
+	if err != nil {
+		return nil, err
+	}
+	preview := &v1.RecordImportPreview{Entries: make([]v1.RecordImportEntry, 0, len(plans))}
+	for _, p := range plans {
+		preview.Total += 1
+		switch p.action {
+		case v1.RecordImportActionCreate:
+			preview.Create += 1
+		case v1.RecordImportActionOverwrite:
+			preview.Overwrite += 1
+		case v1.RecordImportActionAppend:
+			preview.Append += 1
+		case v1.RecordImportActionSkip:
+			preview.Skip += 1
+		default:
+			preview.Invalid += 1
+		}
+		preview.Entries = append(preview.Entries, v1.RecordImportEntry{
+			Date:      p.entry.date,
+			Source:    p.entry.source(),
+			WordCount: utf8.RuneCountInString(p.entry.content),
+			Action:    p.action,
+			Reason:    p.reason,
+		})
+	}
+	return preview, nil
+}
+
+// StartImport 解析与冲突判断在请求内完成，写入在本进程后台执行，通过 GetImportJob 查询进度
+func (s *recordImportService) StartImport(ctx context.Context, userId string, req *v1.ImportRecordsReq, source string, sources []ImportSource) (v1.RecordImportJob, error) {
+	job, plans, err := s.createJob(ctx, userId, req, source, sources)
+	if err != nil {
+		return v1.RecordImportJob{}, err
+	}
+	item := toRecordImportJob(job)
+	go s.execute(context.Background(), job, plans)
+	return item, nil
+}
+
+// RunImport 同步执行，供 CLI 使用
+func (s *recordImportService) RunImport(ctx context.Context, userId string, req *v1.ImportRecordsReq, source string, sources []ImportSource) (v1.RecordImportJob, error) {
+	job, plans, err := s.createJob(ctx, userId, req, source, sources)
+	if err != nil {
+		return v1.RecordImportJob{}, err
+	}
+	s.execute(ctx, job, plans)
+	return toRecordImportJob(job), nil
+}
+
+func (s *recordImportService) GetImportJob(ctx context.Context, userId string, jobId string) (v1.RecordImportJob, error) {
+	job, err := s.importRepo.GetByID(ctx, userId, jobId)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return v1.RecordImportJob{}, v1.ErrImportJobNotExist
+		}
+		s.logger.Error("get import job failed", zap.String("user_id", userId), zap.String("job_id", jobId), zap.Error(err))
+		return v1.RecordImportJob{}, v1.ErrImportFailed
+	}
+	if job.Status == string(v1.RecordImportStatusRunning) && time.Since(job.UpdatedAt) > importStaleAfter {
+		if ok, err := s.importRepo.FailStale(ctx, job.ImportJobID, time.Now().Add(-importStaleAfter), importInterrupted); err == nil && ok {
+			job.Status = string(v1.RecordImportStatusFailed)
+			job.Error = importInterrupted
+		}
+	}
+	return toRecordImportJob(job), nil
+}
+
+func (s *recordImportService) createJob(ctx context.Context, userId string, req *v1.ImportRecordsReq, source string, sources []ImportSource) (*model.RecordImportJob, []importPlan, error) {
+	plans, err := s.plan(ctx, userId, req, sources)
+	if err != nil {
+		return nil, nil, err
+	}
+	if utf8.RuneCountInString(source) > 256 {
+		source = string([]rune(source)[:256])
+	}
+	jobId, err := s.sid.GenString()
+	if err != nil {
+		return nil, nil, v1.ErrJWTGenFailed
+	}
+	job := &model.RecordImportJob{
+		ImportJobID: ImportJobPrefix + jobId,
+		UserID:      userId,
+		Source:      source,
+		Policy:      string(importPolicy(req)),
+		Status:      string(v1.RecordImportStatusRunning),
+		Total:       len(plans),
+	}
+	if err := s.importRepo.Create(ctx, job); err != nil {
+		s.logger.Error("create import job failed", zap.String("user_id", userId), zap.Error(err))
+		return nil, nil, v1.ErrImportFailed
+	}
+	return job, plans, nil
+}
+
+// plan 读取用户现有记录并按冲突策略决定每条的写入方式；端到端加密的记录服务端无法合并，总是跳过
+func (s *recordImportService) plan(ctx context.Context, userId string, req *v1.ImportRecordsReq, sources []ImportSource) ([]importPlan, error) {
+	policy := importPolicy(req)
+	if policy != v1.RecordImportSkip && policy != v1.RecordImportOverwrite && policy != v1.RecordImportAppend {
+		return nil, v1.ErrInvalidImportPolicy
+	}
+	entries, err := parseImportSources(sources)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 || entries[0].reason != "" {
+		return nil, v1.ErrImportEmpty
+	}
+
+	loc, err := userLocation(ctx, s.userSettingsRepo, userId)
+	if err != nil {
+		s.logger.Error("get user settings failed.", zap.String("user_id", userId), zap.Error(err))
+		return nil, v1.ErrGetUserSettingsFailed
+	}
+	today := localToday(time.Now(), loc).Format(dateLayout)
+	records, err := s.recordSvr.GetAllUserRecords(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]v1.RecordItem, len(records))
+	for _, record := range records {
+		existing[record.Date] = record
+	}
+
+	plans := make([]importPlan, 0, len(entries))
+	for _, entry := range entries {
+		p := importPlan{entry: entry, content: entry.content}
+		record, ok := existing[entry.date]
+		switch {
+		case entry.reason != "":
+			p.action, p.reason = v1.RecordImportActionInvalid, entry.reason
+		case entry.date > today:
+			p.action, p.reason = v1.RecordImportActionInvalid, "日期晚于今天"
+		case !ok:
+			p.action = v1.RecordImportActionCreate
+		case record.Encrypted:
+			p.action, p.reason = v1.RecordImportActionSkip, "已有端到端加密记录"
+		case strings.TrimSpace(record.Content) == entry.content:
+			p.action, p.reason = v1.RecordImportActionSkip, "内容相同"
+		case policy == v1.RecordImportSkip:
+			p.action, p.reason = v1.RecordImportActionSkip, "已有记录"
+		case policy == v1.RecordImportOverwrite:
+			p.action, p.version = v1.RecordImportActionOverwrite, &record.Version
+		case strings.Contains(record.Content, entry.content):
+			// 重复导入同一批文件时不重复追加
+			p.action, p.reason = v1.RecordImportActionSkip, "已包含导入内容"
+		default:
+			p.action, p.version = v1.RecordImportActionAppend, &record.Version
+			p.content = strings.TrimRight(record.Content, "\n") + "\n\n" + entry.content
+		}
+		plans = append(plans, p)
+	}
+	return plans, nil
+}
+
+// execute 逐条写入，每 importProgressEvery 条落一次进度；单条失败记入错误明细后继续。
+// 任务被判定为中断后停止写入，不再覆盖其状态
+func (s *recordImportService) execute(ctx context.Context, job *model.RecordImportJob, plans []importPlan) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.keepAlive(ctx, cancel, job.ImportJobID)
+
+	var errs []v1.RecordImportError
+	fail := func(p importPlan, reason string) {
+		job.Failed += 1
+		if len(errs) < importMaxErrors {
+			errs = append(errs, v1.RecordImportError{Date: p.entry.date, Source: p.entry.source(), Reason: reason})
+		}
+	}
+	for i, p := range plans {
+		if ctx.Err() != nil {
+			s.logger.Warn("import records stopped", zap.String("job_id", job.ImportJobID), zap.Int("processed", job.Processed), zap.Error(ctx.Err()))
+			return
+		}
+		switch p.action {
+		case v1.RecordImportActionInvalid:
+			fail(p, p.reason)
+		case v1.RecordImportActionSkip:
+			job.Skipped += 1
+		default:
+			err := s.recordSvr.UpsertUserRecord(ctx, job.UserID, &v1.UpsertRecordReq{
+				Date:    p.entry.date,
+				Content: p.content,
+				Meta:    map[string]any{"import_job_id": job.ImportJobID, "import_source": p.entry.source()},
+				Version: p.version,
+			})
+			switch {
+			case err == nil && p.action == v1.RecordImportActionCreate:
+				job.Created += 1
+			case err == nil:
+				job.Updated += 1
+			default:
+				fail(p, err.Error())
+			}
+		}
+		job.Processed = i + 1
+		if job.Processed%importProgressEvery == 0 && job.Processed < len(plans) && !s.saveProgress(ctx, job, errs) {
+			s.logger.Warn("import job already finished, stop writing", zap.String("job_id", job.ImportJobID), zap.Int("processed", job.Processed))
+			return
+		}
+	}
+	now := time.Now()
+	job.Status = string(v1.RecordImportStatusDone)
+	job.FinishedAt = &now
+	if !s.saveProgress(ctx, job, errs) {
+		s.logger.Warn("import job already finished, discard result", zap.String("job_id", job.ImportJobID))
+		return
+	}
+	s.logger.Info("import records done", zap.String("job_id", job.ImportJobID), zap.String("user_id", job.UserID),
+		zap.Int("total", job.Total), zap.Int("created", job.Created), zap.Int("updated", job.Updated),
+		zap.Int("skipped", job.Skipped), zap.Int("failed", job.Failed))
+}
+
+// saveProgress 返回 false 表示任务已被结束；写入失败时按仍在运行处理，由下次进度或心跳重试
+func (s *recordImportService) saveProgress(ctx context.Context, job *model.RecordImportJob, errs []v1.RecordImportError) bool {
+	if len(errs) > 0 {
+		job.Errors, _ = json.Marshal(errs)
+	}
+	ok, err := s.importRepo.UpdateProgress(ctx, job)
+	if err != nil {
+		s.logger.Warn("update import progress failed", zap.String("job_id", job.ImportJobID), zap.Error(err))
+		return true
+	}
+	return ok
+}
+
+// keepAlive 写入期间定期刷新心跳，避免单条写入较慢时被判定为中断；任务已被结束时取消写入
+func (s *recordImportService) keepAlive(ctx context.Context, cancel context.CancelFunc, jobID string) {
+	ticker := time.NewTicker(importHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		alive, err := s.importRepo.Touch(ctx, jobID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.logger.Warn("touch import job failed", zap.String("job_id", jobID), zap.Error(err))
+			continue
+		}
+		if !alive {
+			s.logger.Warn("import job ended, cancel writing", zap.String("job_id", jobID))
+			cancel()
+			return
+		}
+	}
+}
+
+func importPolicy(req *v1.ImportRecordsReq) v1.RecordImportPolicy {
+	if req.Policy == "" {
+		return v1.RecordImportSkip
+	}
+	return v1.RecordImportPolicy(req.Policy)
+}
+
+func toRecordImportJob(job *model.RecordImportJob) v1.RecordImportJob {
+	item := v1.RecordImportJob{
+		JobID:     job.ImportJobID,
+		Status:    job.Status,
+		Source:    job.Source,
+		Policy:    job.Policy,
+		Total:     job.Total,
+		Processed: job.Processed,
+		Created:   job.Created,
+		Updated:   job.Updated,
+		Skipped:   job.Skipped,
+		Failed:    job.Failed,
+		Errors:    []v1.RecordImportError{},
+		Error:     job.Error,
+		CreatedAt: formatTime(&job.CreatedAt),
+	}
+	if len(job.Errors) > 0 {
+		_ = json.Unmarshal(job.Errors, &item.Errors)
+	}
+	if job.FinishedAt != nil {
+		item.FinishedAt = formatTime(job.FinishedAt)
+	}
+	return item
+}
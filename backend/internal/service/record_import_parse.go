@@ -0,0 +1,276 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 23:18:40
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 23:18:40
+ */
+package service
+
+import (
+	"archive/zip"
+	v1 "backend/api/v1"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxImportBytes   = 32 << 20 // 解压后的文本总量
+	maxImportEntries = 5000
+)
+
+var (
+	// 2024-03-05、2024_03_05、2024.03.05、2024/03/05、2024年3月5日
+	importDatePattern = regexp.MustCompile(`(\d{4})[-_./年](\d{1,2})[-_./月](\d{1,2})日?`)
+	// 20240305
+	importCompactDatePattern = regexp.MustCompile(`(?:^|\D)(\d{4})(\d{2})(\d{2})(?:\D|$)`)
+	importHeadingPattern     = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*\s*$`)
+	importTextExts           = map[string]bool{".md": true, ".markdown": true, ".txt": true}
+)
+
+// ImportSource 待导入的单个文件，zip 会在解析时展开
+type ImportSource struct {
+	Name string
+	Data []byte
+}
+
+// importEntry 按日期合并后的一条待导入记录
+type importEntry struct {
+	date    string
+	sources []string
+	content string
+	reason  string // 非空表示无效
+}
+
+func (e *importEntry) source() string {
+	return strings.Join(e.sources, ",")
+}
+
+// parseImportSources 解析文件中的日期与正文：front matter 有 date 时整篇归属该日期；文件内有以日期开头的标题
+// （如 `## 2024-03-05`）时按标题切分，首个日期标题之前的内容忽略；否则整篇归属文件名中的日期。
+// 同一日期出现多次时按出现顺序合并，front matter 中 kind: report 的文件（导出的报告）跳过
+func parseImportSources(sources []ImportSource) ([]*importEntry, error) {
+	files, err := expandImportSources(sources)
+	if err != nil {
+		return nil, err
+	}
+	byDate := make(map[string]*importEntry)
+	var invalid []*importEntry
+	add := func(date string, source string, content string) {
+		content = strings.TrimSpace(content)
+		if content == "" {
+			return
+		}
+		entry, ok := byDate[date]
+		if !ok {
+			byDate[date] = &importEntry{date: date, sources: []string{source}, content: content}
+			return
+		}
+		if entry.sources[len(entry.sources)-1] != source {
+			entry.sources = append(entry.sources, source)
+		}
+		entry.content += "\n\n" + content
+	}
+
+	for _, file := range files {
+		if !utf8.Valid(file.Data) {
+			invalid = append(invalid, &importEntry{sources: []string{file.Name}, reason: "文件不是 UTF-8 编码"})
+			continue
+		}
+		text, front := normalizeImportText(string(file.Data))
+		if front["kind"] == "report" {
+			// 导出压缩包中的报告，不作为记录导入
+			continue
+		}
+		if date, ok := parseLeadingImportDate(front["date"]); ok {
+			add(date, file.Name, text)
+			continue
+		}
+		sections := splitByDateHeadings(text)
+		if len(sections) > 0 {
+			for _, section := range sections {
+				add(section.date, file.Name, section.content)
+			}
+			continue
+		}
+		date, ok := parseImportDate(path.Base(file.Name))
+		if !ok {
+			invalid = append(invalid, &importEntry{sources: []string{file.Name}, reason: "文件名与标题中没有日期"})
+			continue
+		}
+		add(date, file.Name, text)
+	}
+
+	entries := make([]*importEntry, 0, len(byDate)+len(invalid))
+	for _, entry := range byDate {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].date < entries[j].date })
+	entries = append(entries, invalid...)
+	if len(entries) > maxImportEntries {
+		return nil, v1.ErrImportTooLarge
+	}
+	return entries, nil
+}
+
+// expandImportSources 展开 zip，只保留文本文件；隐藏文件、__MACOSX 与附件忽略
+func expandImportSources(sources []ImportSource) ([]ImportSource, error) {
+	var files []ImportSource
+	total := 0
+	for _, source := range sources {
+		ext := strings.ToLower(path.Ext(source.Name))
+		if ext != ".zip" {
+			if !importTextExts[ext] {
+				return nil, v1.ErrInvalidImportFile
+			}
+			total += len(source.Data)
+			if total > maxImportBytes {
+				return nil, v1.ErrImportTooLarge
+			}
+			files = append(files, source)
+			continue
+		}
+
+		reader, err := zip.NewReader(bytes.NewReader(source.Data), int64(len(source.Data)))
+		if err != nil {
+			return nil, v1.ErrInvalidImportFile
+		}
+		for _, f := range reader.File {
+			name := strings.ReplaceAll(f.Name, "\\", "/")
+			if f.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".") {
+				continue
+			}
+			if !importTextExts[strings.ToLower(path.Ext(name))] {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, v1.ErrInvalidImportFile
+			}
+			// 按实际解压字节数限制，不信任 zip 头中声明的大小
+			data, err := io.ReadAll(io.LimitReader(rc, int64(maxImportBytes-total+1)))
+			rc.Close()
+			if err != nil {
+				return nil, v1.ErrInvalidImportFile
+			}
+			total += len(data)
+			if total > maxImportBytes {
+				return nil, v1.ErrImportTooLarge
+			}
+			files = append(files, ImportSource{Name: name, Data: data})
+		}
+	}
+	if len(files) == 0 {
+		return nil, v1.ErrImportEmpty
+	}
+	return files, nil
+}
+
+// normalizeImportText 去掉 BOM、统一换行，并拆出 YAML front matter（Obsidian 属性、导出文件的元数据），
+// front matter 只解析顶层的 key: value
+func normalizeImportText(text string) (string, map[string]string) {
+	text = strings.TrimPrefix(text, "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	front := make(map[string]string)
+	if !strings.HasPrefix(text, "---\n") {
+		return text, front
+	}
+	end := strings.Index(text[4:], "\n---")
+	if end < 0 {
+		return text, front
+	}
+	rest := text[4+end+4:]
+	if rest != "" && rest[0] != '\n' {
+		return text, front
+	}
+	for _, line := range strings.Split(text[4:4+end], "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, " ") {
+			continue
+		}
+		front[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return rest, front
+}
+
+type importSection struct {
+	date    string
+	content string
+}
+
+// splitByDateHeadings 只有以日期开头的标题作为切分点，其余标题保留在正文中
+func splitByDateHeadings(text string) []importSection {
+	var sections []importSection
+	var current *importSection
+	var body strings.Builder
+	flush := func() {
+		if current != nil {
+			current.content = body.String()
+			sections = append(sections, *current)
+		}
+		body.Reset()
+	}
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if !inFence {
+			if m := importHeadingPattern.FindStringSubmatch(line); m != nil {
+				if date, ok := parseLeadingImportDate(m[1]); ok {
+					flush()
+					current = &importSection{date: date}
+					continue
+				}
+			}
+		}
+		if current != nil {
+			body.WriteString(line)
+			body.WriteByte('\n')
+		}
+	}
+	flush()
+	return sections
+}
+
+// parseImportDate 在文件名任意位置查找日期
+func parseImportDate(s string) (string, bool) {
+	if m := importDatePattern.FindStringSubmatch(s); m != nil {
+		return formatImportDate(m[1], m[2], m[3])
+	}
+	if m := importCompactDatePattern.FindStringSubmatch(s); m != nil {
+		return formatImportDate(m[1], m[2], m[3])
+	}
+	return "", false
+}
+
+// parseLeadingImportDate 标题须以日期开头，如 `2024-03-05 周二`
+func parseLeadingImportDate(s string) (string, bool) {
+	if loc := importDatePattern.FindStringSubmatchIndex(s); loc != nil && loc[0] == 0 {
+		return formatImportDate(s[loc[2]:loc[3]], s[loc[4]:loc[5]], s[loc[6]:loc[7]])
+	}
+	if loc := importCompactDatePattern.FindStringSubmatchIndex(s); loc != nil && loc[0] == 0 {
+		return formatImportDate(s[loc[2]:loc[3]], s[loc[4]:loc[5]], s[loc[6]:loc[7]])
+	}
+	return "", false
+}
+
+func formatImportDate(year, month, day string) (string, bool) {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	date := fmt.Sprintf("%04d-%02d-%02d", y, m, d)
+	// time.Parse 会拒绝 2 月 30 日等不存在的日期
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return "", false
+	}
+	return date, true
+}
@@ -0,0 +1,218 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 20:16:52
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 20:16:52
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"go.uber.org/zap"
+)
+
+const revisionPruneBatch = 500
+
+// overwrite 以读取时的版本号为条件覆盖记录内容，并保存被覆盖的版本，两步在同一事务内完成；
+// 期间记录已被修改时返回 ErrRecordConflict
+func (s *recordService) overwrite(ctx context.Context, record *model.Record, content string, meta map[string]any, enc recordEncryption) error {
+	revision := &model.RecordRevision{
+		RecordID:    record.RecordID,
+		Revision:    record.Version,
+		UserID:      record.UserID,
+		Date:        record.Date,
+		Content:     record.Content,
+		WordCount:   record.WordCount,
+		Meta:        record.Meta,
+		IsEncrypted: record.IsEncrypted,
+		EncAlg:      record.EncAlg,
+		EncNonce:    record.EncNonce,
+		EncKeyID:    record.EncKeyID,
+	}
+	updated := *record
+	updated.Content = content
+	updated.WordCount = wordCount(content, enc)
+	updated.Meta = meta
+	enc.applyTo(&updated)
+	updated.Version = record.Version + 1
+	updated.IsDeleted = false
+
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		ok, err := s.recordRepo.UpdateIfVersion(ctx, &updated, record.Version)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return v1.ErrRecordConflict
+		}
+		return s.revisionRepo.Create(ctx, revision)
+	})
+	if err != nil {
+		return err
+	}
+	*record = updated
+	return nil
+}
+
+func (s *recordService) getRecord(ctx context.Context, userId string, recordId string) (*model.Record, error) {
+	record, err := s.recordRepo.GetByID(ctx, userId, recordId)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return nil, v1.ErrRecordNotExist
+		}
+		s.logger.Error("get record failed.", zap.String("user_id", userId), zap.String("record_id", recordId), zap.Error(err))
+		return nil, v1.ErrGetRecordsFailed
+	}
+	return record, nil
+}
+
+func (s *recordService) ListRevisions(ctx context.Context, userId string, recordId string) (*v1.RecordRevisionsResp, error) {
+	record, err := s.getRecord(ctx, userId, recordId)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := s.revisionRepo.ListByRecord(ctx, record.RecordID)
+	if err != nil {
+		s.logger.Error("list record revisions failed.", zap.String("record_id", recordId), zap.Error(err))
+		return nil, v1.ErrGetRevisionsFailed
+	}
+
+	items := make([]v1.RecordRevisionItem, 0, len(revisions))
+	for _, rev := range revisions {
+		items = append(items, v1.RecordRevisionItem{
+			Revision:  rev.Revision,
+			WordCount: rev.WordCount,
+			CreatedAt: formatTime(&rev.CreatedAt),
+		})
+	}
+	return &v1.RecordRevisionsResp{
+		RecordID:       record.RecordID,
+		CurrentVersion: record.Version,
+		Revisions:      items,
+	}, nil
+}
+
+func (s *recordService) DiffRevisions(ctx context.Context, userId string, req *v1.RecordRevisionDiffReq) (*v1.RecordRevisionDiffResp, error) {
+	record, err := s.getRecord(ctx, userId, req.RecordID)
+	if err != nil {
+		return nil, err
+	}
+	to := req.To
+	if to <= 0 {
+		to = record.Version
+	}
+	from, err := s.revisionContent(ctx, record, req.From)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.revisionContent(ctx, record, to)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &v1.RecordRevisionDiffResp{From: req.From, To: to, Diffs: DiffLines(from, target)}
+	for _, d := range resp.Diffs {
+		lines := strings.Count(strings.TrimSuffix(d.Text, "\n"), "\n") + 1
+		switch d.Op {
+		case v1.RecordDiffInsert:
+			resp.Added += lines
+		case v1.RecordDiffDelete:
+			resp.Removed += lines
+		}
+	}
+	return resp, nil
+}
+
+// revisionContent 当前版本直接取记录正文，其余从历史版本读取；密文无法对比
+func (s *recordService) revisionContent(ctx context.Context, record *model.Record, revision int) (string, error) {
+	if revision == record.Version {
+		if record.IsEncrypted {
+			return "", v1.ErrRecordEncrypted
+		}
+		return record.Content, nil
+	}
+	rev, err := s.revisionRepo.GetByRevision(ctx, record.RecordID, revision)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return "", v1.ErrRevisionNotExist
+		}
+		s.logger.Error("get record revision failed.", zap.String("record_id", record.RecordID), zap.Int("revision", revision), zap.Error(err))
+		return "", v1.ErrGetRevisionsFailed
+	}
+	if rev.IsEncrypted {
+		return "", v1.ErrRecordEncrypted
+	}
+	return rev.Content, nil
+}
+
+// RestoreRevision 用历史版本覆盖当前内容并生成新版本；已删除的记录恢复后重新可见
+func (s *recordService) RestoreRevision(ctx context.Context, userId string, recordId string, revision int) (v1.RecordItem, error) {
+	record, err := s.getRecord(ctx, userId, recordId)
+	if err != nil {
+		return v1.RecordItem{}, err
+	}
+	rev, err := s.revisionRepo.GetByRevision(ctx, record.RecordID, revision)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return v1.RecordItem{}, v1.ErrRevisionNotExist
+		}
+		s.logger.Error("get record revision failed.", zap.String("record_id", recordId), zap.Int("revision", revision), zap.Error(err))
+		return v1.RecordItem{}, v1.ErrGetRevisionsFailed
+	}
+	if err := s.overwrite(ctx, record, rev.Content, rev.Meta, encryptionOfRevision(rev)); err != nil {
+		if errors.Is(err, v1.ErrRecordConflict) {
+			return v1.RecordItem{}, err
+		}
+		s.logger.Error("restore record revision failed.", zap.String("record_id", recordId), zap.Int("revision", revision), zap.Error(err))
+		return v1.RecordItem{}, v1.ErrRestoreRecordFailed
+	}
+	return s.toRecordItem(record), nil
+}
+
+// PruneRevisions 按保留策略分批清理历史版本，返回删除条数
+func (s *recordService) PruneRevisions(ctx context.Context, keep int, maxAge time.Duration) (int64, error) {
+	var before time.Time
+	if maxAge > 0 {
+		before = time.Now().Add(-maxAge)
+	}
+	var total int64
+	for {
+		deleted, err := s.revisionRepo.Prune(ctx, keep, before, revisionPruneBatch)
+		if err != nil {
+			s.logger.Error("prune record revisions failed.", zap.Int64("deleted", total), zap.Error(err))
+			return total, err
+		}
+		total += deleted
+		if deleted < revisionPruneBatch {
+			return total, nil
+		}
+	}
+}
+
+// DiffLines 按行对比两段文本
+func DiffLines(from string, to string) []v1.RecordDiffItem {
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(from, to)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	items := make([]v1.RecordDiffItem, 0, len(diffs))
+	for _, d := range diffs {
+		op := v1.RecordDiffEqual
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			op = v1.RecordDiffInsert
+		case diffmatchpatch.DiffDelete:
+			op = v1.RecordDiffDelete
+		}
+		items = append(items, v1.RecordDiffItem{Op: op, Text: d.Text})
+	}
+	return items
+}
@@ -13,44 +13,99 @@ import (
 	"backend/internal/model"
 	"backend/internal/repository"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"gorm.io/datatypes"
 )
 
 type ReportService interface {
 	GenerateReport(ctx context.Context, userId string, req *v1.GenReportReq) (string, error)
 	GetReportByID(ctx context.Context, userId string, reportID string) (v1.ReportItem, error)
+	GetReportJobs(ctx context.Context, userId string, reportID string) ([]v1.ReportJobItem, error)
 	GetReports(ctx context.Context, userId string, req *v1.GetReportsReq) ([]v1.ReportItem, error)
 	EditReport(ctx context.Context, userId string, req *v1.EditReportReq) error
 	ConfirmReport(ctx context.Context, userId string, req *v1.ConfirmReportReq) error
-	ProcessReport(ctx context.Context, reportID string, genVersion int) error
-	ProcessQueuedReports(ctx context.Context, limit int) (int, error)
+	ProcessNextReport(ctx context.Context, workerID string) (bool, error)
+	StreamReport(ctx context.Context, userId string, req *v1.StreamReportReq, emit func(event v1.ReportStreamEvent) error) error
+	RecoverExpiredReports(ctx context.Context, limit int) (int, error)
+	AutoGenerateReports(ctx context.Context, now time.Time) (int, error)
+	ListVersions(ctx context.Context, userId string, reportID string) (*v1.ReportVersionsResp, error)
+	DiffVersions(ctx context.Context, userId string, req *v1.ReportVersionDiffReq) (*v1.ReportVersionDiffResp, error)
+	RestoreVersion(ctx context.Context, userId string, reportID string, seq int) (v1.ReportItem, error)
+	RewriteReport(ctx context.Context, userId string, req *v1.RewriteReportReq) (*v1.RewriteReportResp, error)
 }
 
 type reportPrompt struct {
-	system string
-	user   string
+	system  string
+	user    string
+	refs    map[string]citationSource // 记录编号，用于解析引用
+	sources string                    // 事实核对依据，为空时使用 user；分段汇总后为原始素材
 }
 
 func NewReportService(
+	conf *viper.Viper,
 	service *Service,
 	reportRepo repository.ReportRepository,
+	reportJobRepo repository.ReportJobRepository,
+	reportVersionRepo repository.ReportVersionRepository,
 	recordSvr RecordService,
 	userSettingsRepo repository.UserSettingsRepository,
-	openAIClient *llm.OpenAIClient,
+	templateRepo repository.ReportTemplateRepository,
+	llmProvider llm.Provider,
 ) ReportService {
+	leaseTTL := conf.GetDuration("report.lease.ttl")
+	if leaseTTL <= 0 {
+		leaseTTL = defaultLeaseTTL
+	}
+	maxClaims := conf.GetInt("report.lease.max_attempts")
+	if maxClaims <= 0 {
+		maxClaims = defaultMaxClaims
+	}
+	perUserLimit := conf.GetInt("report.worker.per_user_limit")
+	if perUserLimit <= 0 {
+		perUserLimit = defaultPerUserLimit
+	}
+	chunkMaxTokens := conf.GetInt("report.chunk.max_tokens")
+	if chunkMaxTokens <= 0 {
+		chunkMaxTokens = defaultChunkMaxTokens
+	}
+	chunkCacheSize := conf.GetInt("report.chunk.cache_size")
+	if chunkCacheSize <= 0 {
+		chunkCacheSize = defaultChunkCacheSize
+	}
+	verifyMode := conf.GetString("report.verify.mode")
+	switch verifyMode {
+	case verifyModeOff, verifyModeLLM:
+	default:
+		verifyMode = verifyModeLexical
+	}
+	promptSet := llm.LoadPrompts(service.logger)
 	return &reportService{
 		Service:          service,
 		recordSvr:        recordSvr,
 		reportRepo:       reportRepo,
+		reportJobRepo:    reportJobRepo,
+		versionRepo:      reportVersionRepo,
 		userSettingsRepo: userSettingsRepo,
-		openAIClient:     openAIClient,
-		promptSet:        llm.LoadPrompts(service.logger),
+		llmProvider:      llmProvider,
+		templateRepo:     templateRepo,
+		promptSet:        promptSet,
+		builtinTemplates: BuiltinReportTemplates(promptSet),
+		leaseOwner:       InstanceID(conf),
+		leaseTTL:         leaseTTL,
+		maxClaims:        maxClaims,
+		perUserLimit:     perUserLimit,
+		verifyMode:       verifyMode,
+		chunkMaxTokens:   chunkMaxTokens,
+		chunkCache:       newChunkCache(chunkCacheSize),
 	}
 }
 
@@ -58,13 +113,27 @@ type reportService struct {
 	*Service
 	recordSvr        RecordService
 	reportRepo       repository.ReportRepository
+	reportJobRepo    repository.ReportJobRepository
+	versionRepo      repository.ReportVersionRepository
 	userSettingsRepo repository.UserSettingsRepository
-	openAIClient     *llm.OpenAIClient
+	templateRepo     repository.ReportTemplateRepository
+	llmProvider      llm.Provider
 	promptSet        llm.PromptSet
+	builtinTemplates []*model.ReportTemplate
+	lives            sync.Map // reportID#genVersion -> *liveReport，本进程正在生成的报告
+	payloads         sync.Map // reportID#genVersion -> 客户端解密的记录明文，仅在本进程生成期间保留
+	leaseOwner       string   // 本进程标识，写入领取的报告
+	leaseTTL         time.Duration
+	maxClaims        int    // 同一生成版本最多领取次数，超过后不再重新排队
+	perUserLimit     int    // 单个用户同时处理中的报告上限
+	verifyMode       string // 生成后事实核对方式：off、lexical、llm
+	chunkMaxTokens   int    // 分段汇总时每段的 token 上限
+	chunkCache       *chunkCache
 }
 
 const (
 	ReportPrefix     = "reportid_"
+	ReportJobPrefix  = "jobid_"
 	reportDateLayout = "2006-01-02"
 )
 
@@ -72,7 +141,7 @@ func (s *reportService) GenerateReport(ctx context.Context, userId string, req *
 	if err := validateReportPeriod(req.PeriodType); err != nil {
 		return "", err
 	}
-	if err := validateReportTemplate(req.Template); err != nil {
+	if err := s.validateReportTemplate(ctx, userId, req.Template, req.PeriodType); err != nil {
 		return "", err
 	}
 	start, err := time.Parse(reportDateLayout, req.StartDate)
@@ -83,7 +152,16 @@ func (s *reportService) GenerateReport(ctx context.Context, userId string, req *
 	if err != nil {
 		return "", v1.ErrInvalidDate
 	}
-	today := time.Now()
+	settings, err := s.userSettingsRepo.GetByID(ctx, userId)
+	if err != nil && !errors.Is(err, v1.ErrNotFound) {
+		s.logger.Error("get user settings failed", zap.String("user_id", userId), zap.Error(err))
+		return "", v1.ErrGetUserSettingsFailed
+	}
+	loc := loadLocation("")
+	if settings != nil {
+		loc = loadLocation(settings.Timezone)
+	}
+	today := localToday(time.Now(), loc)
 	if start.After(today) {
 		return "", v1.ErrInvalidDate
 	}
@@ -92,10 +170,20 @@ func (s *reportService) GenerateReport(ctx context.Context, userId string, req *
 	}
 	// 仅在生产环境进行严格的时间范围校验
 	if gin.Mode() == gin.ReleaseMode {
-		if err := validateDateRange(req.PeriodType, start, end); err != nil {
+		if err := validateDateRange(req.PeriodType, start, end, sprintOf(settings)); err != nil {
+			return "", err
+		}
+	}
+	var baseline *reportBaseline
+	if req.Compare {
+		if baseline, err = s.comparisonBaseline(ctx, userId, req, start, end, sprintOf(settings)); err != nil {
 			return "", err
 		}
 	}
+	decrypted, err := s.decryptedRecords(ctx, userId, req)
+	if err != nil {
+		return "", err
+	}
 
 	report, err := s.reportRepo.GetByUnique(ctx, userId, req.PeriodType, req.StartDate, req.EndDate)
 	if err != nil && !errors.Is(err, v1.ErrNotFound) {
@@ -123,26 +211,73 @@ func (s *reportService) GenerateReport(ctx context.Context, userId string, req *
 			Version:      0,
 			GenVersion:   1,
 			Confirmed:    false,
+			Meta:         withComparisonMeta(nil, baseline),
+		}
+		if len(decrypted) > 0 {
+			s.claimDecrypted(report)
 		}
-		if err := s.reportRepo.Create(ctx, report); err != nil {
+		err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+			if err := s.reportRepo.Create(ctx, report); err != nil {
+				return err
+			}
+			return s.createReportJob(ctx, report)
+		})
+		if err != nil {
 			s.logger.Error("create report placeholder failed", zap.String("user_id", userId), zap.Error(err))
 			return "", v1.ErrCreateReportFailed
 		}
+		if len(decrypted) > 0 {
+			s.runDecrypted(report, decrypted)
+		}
 		return report.ReportID, nil
 	}
 
+	// 已确认的报告需显式确认才重新生成；正文清空前确保已保存为历史版本
+	if report.Confirmed && !req.OverwriteConfirmed {
+		return "", v1.ErrReportConfirmed
+	}
+	legacy, err := s.legacyVersion(ctx, report)
+	if err != nil {
+		s.logger.Error("get report versions failed", zap.String("user_id", userId), zap.String("report_id", report.ReportID), zap.Error(err))
+		return "", v1.ErrGetReportVersionsFailed
+	}
+
 	report.Template = req.Template
 	report.Title = buildReportTitle(req.PeriodType, req.StartDate, req.EndDate)
 	report.Status = string(v1.ReportStatusQueued)
 	report.Confirmed = false
+	report.ConfirmedAt = nil
 	report.Abstract = ""
+	report.Summary = ""
 	report.FailedReason = ""
 	report.Content = ""
+	report.LLMModel = ""
+	report.LeaseOwner = ""
+	report.LeaseExpires = nil
+	report.ClaimCount = 0
 	report.GenVersion = report.GenVersion + 1
-	if err := s.reportRepo.Update(ctx, report); err != nil {
+	report.Meta = withComparisonMeta(report.Meta, baseline)
+	if len(decrypted) > 0 {
+		s.claimDecrypted(report)
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if legacy != nil {
+			if err := s.versionRepo.Create(ctx, legacy); err != nil {
+				return err
+			}
+		}
+		if err := s.reportRepo.Update(ctx, report); err != nil {
+			return err
+		}
+		return s.createReportJob(ctx, report)
+	})
+	if err != nil {
 		s.logger.Error("update report placeholder failed", zap.String("user_id", userId), zap.String("report_id", report.ReportID), zap.Error(err))
 		return "", v1.ErrUpdateReportFailed
 	}
+	if len(decrypted) > 0 {
+		s.runDecrypted(report, decrypted)
+	}
 	return report.ReportID, nil
 }
 
@@ -158,6 +293,65 @@ func (s *reportService) GetReportByID(ctx context.Context, userId string, report
 	return s.toReportItem(report), nil
 }
 
+func (s *reportService) GetReportJobs(ctx context.Context, userId string, reportID string) ([]v1.ReportJobItem, error) {
+	if _, err := s.reportRepo.GetByID(ctx, userId, reportID); err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return nil, v1.ErrReportNotExist
+		}
+		s.logger.Error("get report failed", zap.String("user_id", userId), zap.String("report_id", reportID), zap.Error(err))
+		return nil, v1.ErrGetReportsFailed
+	}
+	jobs, err := s.reportJobRepo.ListByReport(ctx, userId, reportID)
+	if err != nil {
+		s.logger.Error("list report jobs failed", zap.String("user_id", userId), zap.String("report_id", reportID), zap.Error(err))
+		return nil, v1.ErrGetReportJobsFailed
+	}
+	jobIDs := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		jobIDs = append(jobIDs, job.ReportJobID)
+	}
+	attempts, err := s.reportJobRepo.ListAttempts(ctx, jobIDs)
+	if err != nil {
+		s.logger.Error("list report job attempts failed", zap.String("user_id", userId), zap.String("report_id", reportID), zap.Error(err))
+		return nil, v1.ErrGetReportJobsFailed
+	}
+	attemptMap := make(map[string][]v1.ReportJobAttemptItem, len(jobs))
+	for _, attempt := range attempts {
+		attemptMap[attempt.ReportJobID] = append(attemptMap[attempt.ReportJobID], v1.ReportJobAttemptItem{
+			Provider:         attempt.Provider,
+			Model:            attempt.Model,
+			Try:              attempt.Try,
+			LatencyMs:        attempt.LatencyMs,
+			PromptTokens:     attempt.PromptTokens,
+			CompletionTokens: attempt.CompletionTokens,
+			TotalTokens:      attempt.TotalTokens,
+			Raw:              attempt.Raw,
+			Error:            attempt.Error,
+			Skipped:          attempt.Skipped,
+			CreatedAt:        formatTime(&attempt.CreatedAt),
+		})
+	}
+
+	items := make([]v1.ReportJobItem, 0, len(jobs))
+	for _, job := range jobs {
+		items = append(items, v1.ReportJobItem{
+			ReportJobID:  job.ReportJobID,
+			GenVersion:   job.GenVersion,
+			Template:     job.Template,
+			Status:       job.Status,
+			LLMModel:     job.LLMModel,
+			SystemPrompt: job.SystemPrompt,
+			Prompt:       job.Prompt,
+			Result:       job.Result,
+			Error:        job.Error,
+			Attempts:     attemptMap[job.ReportJobID],
+			CreatedAt:    formatTime(&job.CreatedAt),
+			UpdatedAt:    formatTime(&job.UpdatedAt),
+		})
+	}
+	return items, nil
+}
+
 func (s *reportService) GetReports(ctx context.Context, userId string, req *v1.GetReportsReq) ([]v1.ReportItem, error) {
 	var reports []*model.Report
 	var err error
@@ -193,10 +387,14 @@ func (s *reportService) EditReport(ctx context.Context, userId string, req *v1.E
 	if report.Status != string(v1.ReportStatusReady) {
 		return v1.ErrReportNotReady
 	}
-	report.Content = req.Content
-	report.Version = report.Version + 1
-	report.Confirmed = false
-	if err := s.reportRepo.Update(ctx, report); err != nil {
+	// 未携带版本号的旧客户端以读取时的版本为准
+	if (req.Version != nil && *req.Version != report.Version) || (req.GenVersion != nil && *req.GenVersion != report.GenVersion) {
+		return v1.ErrReportConflict
+	}
+	if err := s.overwriteContent(ctx, report, req.Content, v1.ReportVersionEdit); err != nil {
+		if errors.Is(err, v1.ErrReportConflict) {
+			return err
+		}
 		s.logger.Error("update report failed", zap.String("user_id", userId), zap.String("report_id", req.ReportID), zap.Error(err))
 		return v1.ErrUpdateReportFailed
 	}
@@ -215,47 +413,41 @@ func (s *reportService) ConfirmReport(ctx context.Context, userId string, req *v
 	if report.Status != string(v1.ReportStatusReady) {
 		return v1.ErrReportNotReady
 	}
-	report.Confirmed = true
-	if err := s.reportRepo.Update(ctx, report); err != nil {
+	// 未携带版本号时以读取时的版本为准，确认的始终是读取到的内容
+	if (req.Version != nil && *req.Version != report.Version) || (req.GenVersion != nil && *req.GenVersion != report.GenVersion) {
+		return v1.ErrReportConflict
+	}
+	ok, err := s.reportRepo.ConfirmIfVersion(ctx, report.ReportID, report.Version, report.GenVersion, time.Now())
+	if err != nil {
 		s.logger.Error("confirm report failed", zap.String("user_id", userId), zap.String("report_id", req.ReportID), zap.Error(err))
 		return v1.ErrUpdateReportFailed
 	}
+	if !ok {
+		return v1.ErrReportConflict
+	}
 	return nil
 }
 
-func (s *reportService) ProcessQueuedReports(ctx context.Context, limit int) (int, error) {
-	if limit <= 0 {
-		return 0, nil
-	}
-	reports, err := s.reportRepo.ListByStatus(ctx, string(v1.ReportStatusQueued), limit)
+// ProcessNextReport 由 worker 从数据库领取下一条报告并处理，没有可领取的报告时返回 false
+func (s *reportService) ProcessNextReport(ctx context.Context, workerID string) (bool, error) {
+	report, err := s.reportRepo.ClaimNext(ctx, workerID, s.leaseTTL, s.perUserLimit)
 	if err != nil {
-		s.logger.Error("scan queued reports failed", zap.Error(err))
-		return 0, err
-	}
-	processed := 0
-	for _, report := range reports {
-		select {
-		case <-ctx.Done():
-			return processed, ctx.Err()
-		default:
-		}
-		if err := s.ProcessReport(ctx, report.ReportID, report.GenVersion); err != nil {
-			s.logger.Error("process report failed", zap.String("report_id", report.ReportID), zap.Int("gen_version", report.GenVersion), zap.Error(err))
-			continue
+		if errors.Is(err, v1.ErrNotFound) {
+			return false, nil
 		}
-		processed += 1
+		s.logger.Error("claim report failed", zap.String("worker_id", workerID), zap.Error(err))
+		return false, err
 	}
-	return processed, nil
+	s.logger.Info("report claimed", zap.String("worker_id", workerID), zap.String("report_id", report.ReportID), zap.String("user_id", report.UserID), zap.Int("gen_version", report.GenVersion))
+	return true, s.processClaimed(ctx, report.ReportID, report.GenVersion, workerID)
 }
 
-func (s *reportService) ProcessReport(ctx context.Context, reportID string, genVersion int) error {
-	claimed, err := s.reportRepo.TryMarkProcessing(ctx, reportID, genVersion)
-	if err != nil {
-		return err
-	}
-	if !claimed {
-		return nil
-	}
+// processClaimed 处理已被 owner 领取（queued -> processing）的报告，处理期间持续续租
+func (s *reportService) processClaimed(ctx context.Context, reportID string, genVersion int, owner string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer s.payloads.Delete(liveKey(reportID, genVersion))
+	go s.keepLease(ctx, cancel, reportID, genVersion, owner)
 
 	report, err := s.reportRepo.GetByReportID(ctx, reportID)
 	if err != nil {
@@ -264,51 +456,53 @@ func (s *reportService) ProcessReport(ctx context.Context, reportID string, genV
 		}
 		return err
 	}
-	if report.GenVersion != genVersion {
+	// 读取前租约已丢失（被回收或重新生成）
+	if report.GenVersion != genVersion || report.LeaseOwner != owner {
 		return nil
 	}
+	job, err := s.getOrCreateReportJob(ctx, report)
+	if err != nil {
+		return err
+	}
 
-	if report.PeriodType == string(v1.ReportPeriodYear) {
-		return s.processYearReport(ctx, report, genVersion)
+	if usesRollup(report.PeriodType, report.StartDate, report.EndDate) {
+		return s.processRollupReport(ctx, report, job)
 	}
 
 	records, err := s.recordSvr.QueryUserRecordsByDateRange(ctx, report.UserID, report.StartDate, report.EndDate)
 	if err != nil {
-		if updateErr := s.reportRepo.UpdateFailed(ctx, reportID, genVersion, "获取记录失败"); updateErr != nil {
+		if updateErr := s.markFailed(ctx, report, job, "获取记录失败", err, nil); updateErr != nil {
 			s.logger.Error("mark report failed status error", zap.String("report_id", reportID), zap.Int("gen_version", genVersion), zap.Error(updateErr))
 			return updateErr
 		}
 		return err
 	}
+	if missing := s.withDecrypted(reportID, genVersion, records); missing > 0 {
+		return s.failEncrypted(ctx, report, job, missing)
+	}
 
 	userSettings, err := s.userSettingsRepo.GetByID(ctx, report.UserID)
 	if err != nil && !errors.Is(err, v1.ErrNotFound) {
-		if updateErr := s.reportRepo.UpdateFailed(ctx, reportID, genVersion, "获取用户设置失败"); updateErr != nil {
+		if updateErr := s.markFailed(ctx, report, job, "获取用户设置失败", err, nil); updateErr != nil {
 			s.logger.Error("mark report failed status error", zap.String("report_id", reportID), zap.Int("gen_version", genVersion), zap.Error(updateErr))
 		}
 		return v1.ErrGetUserSettingsFailed
 	}
 
-	prompt := s.buildUserPrompt(report.PeriodType, report.Template, userSettings, records, report.Title)
-
-	content, abstract, err := s.callModel(ctx, prompt.system, prompt.user)
-	if err != nil {
-		if updateErr := s.reportRepo.UpdateFailed(ctx, reportID, genVersion, "生成失败"); updateErr != nil {
-			s.logger.Error("mark report failed status error", zap.String("report_id", reportID), zap.Int("gen_version", genVersion), zap.Error(updateErr))
-			return v1.ErrGenReportFailed
+	prompt := s.buildUserPrompt(report.PeriodType, s.reportTemplate(ctx, report), userSettings, records, report.Title)
+	// 记录超出模型上下文时先分段压缩再汇总
+	if llm.EstimateTokens(prompt.user) > s.promptBudget(prompt.system) {
+		if prompt, err = s.reduceRecords(ctx, report, prompt, records); err != nil {
+			return s.failModelCall(ctx, report, job, err)
 		}
-		s.logger.Error("call model failed", zap.String("report_id", reportID), zap.Int("gen_version", genVersion), zap.Error(err))
-		return v1.ErrCallLLMFailed
 	}
 
-	if err := s.reportRepo.UpdateGenerated(ctx, reportID, genVersion, content, abstract); err != nil {
-		return err
-	}
-	return nil
+	return s.generate(ctx, report, job, prompt)
 }
 
-func (s *reportService) processYearReport(ctx context.Context, report *model.Report, genVersion int) error {
-	// 年报生成：优先使用已确认月报/周报，按月构建素材包，减少碎片与上下文占用
+// processRollupReport 季度、半年、年度等长周期按月构建素材包：优先使用已确认月报/周报，减少碎片与上下文占用
+func (s *reportService) processRollupReport(ctx context.Context, report *model.Report, job *model.ReportJob) error {
+	genVersion := job.GenVersion
 	startTime, err := time.Parse(reportDateLayout, report.StartDate)
 	if err != nil {
 		return v1.ErrInvalidDate
@@ -318,10 +512,10 @@ func (s *reportService) processYearReport(ctx context.Context, report *model.Rep
 		return v1.ErrInvalidDate
 	}
 
-	// 拉取已确认月报/周报作为高层素材来源
+	// 拉取周期内已确认月报/周报作为高层素材来源
 	monthReports, err := s.reportRepo.ListConfirmedByPeriod(ctx, report.UserID, string(v1.ReportPeriodMonth), report.StartDate, report.EndDate)
 	if err != nil {
-		if updateErr := s.reportRepo.UpdateFailed(ctx, report.ReportID, genVersion, "获取月报失败"); updateErr != nil {
+		if updateErr := s.markFailed(ctx, report, job, "获取月报失败", err, nil); updateErr != nil {
 			s.logger.Error("mark report failed status error", zap.String("report_id", report.ReportID), zap.Int("gen_version", genVersion), zap.Error(updateErr))
 			return updateErr
 		}
@@ -329,77 +523,81 @@ func (s *reportService) processYearReport(ctx context.Context, report *model.Rep
 	}
 	weekReports, err := s.reportRepo.ListConfirmedByPeriod(ctx, report.UserID, string(v1.ReportPeriodWeek), report.StartDate, report.EndDate)
 	if err != nil {
-		if updateErr := s.reportRepo.UpdateFailed(ctx, report.ReportID, genVersion, "获取周报失败"); updateErr != nil {
+		if updateErr := s.markFailed(ctx, report, job, "获取周报失败", err, nil); updateErr != nil {
 			s.logger.Error("mark report failed status error", zap.String("report_id", report.ReportID), zap.Int("gen_version", genVersion), zap.Error(updateErr))
 			return updateErr
 		}
 		return err
 	}
 
-	// monthMap：每月一条月报；weekMap：每月多条周报
-	monthMap := make(map[int]*model.Report)
+	// monthMap：每月一条月报；weekMap：每月多条周报，均以「年-月」为键，支持跨年的自定义周期
+	monthMap := make(map[string]*model.Report)
 	for _, item := range monthReports {
 		t, err := time.Parse(reportDateLayout, item.StartDate)
 		if err != nil {
 			continue
 		}
-		monthMap[int(t.Month())] = item
+		monthMap[t.Format("2006-01")] = item
 	}
 
-	weekMap := make(map[int][]*model.Report)
+	weekMap := make(map[string][]*model.Report)
 	for _, item := range weekReports {
 		t, err := time.Parse(reportDateLayout, item.StartDate)
 		if err != nil {
 			continue
 		}
-		month := int(t.Month())
+		month := t.Format("2006-01")
 		weekMap[month] = append(weekMap[month], item)
 	}
 
-	// 覆盖度阈值：月报足够则以月报为主，否则周报足够则以周报为主，否则降级用日记补齐
-	monthCoverageThreshold := 6
-	weekCoverageThreshold := 20
+	// 覆盖度阈值按周期长度折算（全年为 6 份月报、20 份周报）：月报足够则以月报为主，否则周报足够则以周报为主，否则降级用日记补齐
+	firstMonth := time.Date(startTime.Year(), startTime.Month(), 1, 0, 0, 0, 0, startTime.Location())
+	months := (endTime.Year()-startTime.Year())*12 + int(endTime.Month()) - int(startTime.Month()) + 1
+	weeks := (daysBetween(startTime, endTime) + 1) / 7
+	monthCoverageThreshold := (months + 1) / 2
+	weekCoverageThreshold := max(weeks*5/13, 1)
 	level := "day"
 	if len(monthMap) >= monthCoverageThreshold {
 		level = "month"
 	} else if len(weekReports) >= weekCoverageThreshold {
 		level = "week"
 	}
+	sameYear := startTime.Year() == endTime.Year()
 
 	// 按月构建素材：月报 > 周报 > 日记
-	materials := make([]monthMaterial, 0, 12)
-	for m := 1; m <= 12; m++ {
-		monthStart := time.Date(startTime.Year(), time.Month(m), 1, 0, 0, 0, 0, startTime.Location())
-		if monthStart.After(endTime) {
-			break
+	materials := make([]monthMaterial, 0, months)
+	for monthStart := firstMonth; !monthStart.After(endTime); monthStart = monthStart.AddDate(0, 1, 0) {
+		bucketStart := monthStart
+		if bucketStart.Before(startTime) {
+			bucketStart = startTime
 		}
-		monthEnd := monthStart.AddDate(0, 1, -1)
-		if monthEnd.After(endTime) {
-			monthEnd = endTime
+		bucketEnd := monthStart.AddDate(0, 1, -1)
+		if bucketEnd.After(endTime) {
+			bucketEnd = endTime
 		}
+		key := monthStart.Format("2006-01")
 
 		// 选择当月素材来源
 		var text string
-		if reportMonth, ok := monthMap[m]; ok {
-			// 优先月报：使用摘要（无摘要则用正文）
-			text = pickAbstract(reportMonth)
-		} else if level != "day" && len(weekMap[m]) > 0 {
-			// 次优周报：合并当月所有周报摘要
-			var parts []string
-			for _, w := range weekMap[m] {
-				parts = append(parts, pickAbstract(w))
-			}
-			text = strings.Join(parts, "\n")
+		if reportMonth, ok := monthMap[key]; ok {
+			// 优先月报：使用结构化摘要（无摘要则用正文）
+			text = s.summaryMaterial(ctx, []*model.Report{reportMonth})
+		} else if level != "day" && len(weekMap[key]) > 0 {
+			// 次优周报：合并当月所有周报的结构化摘要
+			text = s.summaryMaterial(ctx, weekMap[key])
 		} else {
-			// 降级日记：仅使用当月日记，避免一次性塞入全年碎片
-			records, err := s.recordSvr.QueryUserRecordsByDateRange(ctx, report.UserID, monthStart.Format(reportDateLayout), monthEnd.Format(reportDateLayout))
+			// 降级日记：仅使用当月日记，避免一次性塞入整个周期的碎片
+			records, err := s.recordSvr.QueryUserRecordsByDateRange(ctx, report.UserID, bucketStart.Format(reportDateLayout), bucketEnd.Format(reportDateLayout))
 			if err != nil {
-				if updateErr := s.reportRepo.UpdateFailed(ctx, report.ReportID, genVersion, "获取记录失败"); updateErr != nil {
+				if updateErr := s.markFailed(ctx, report, job, "获取记录失败", err, nil); updateErr != nil {
 					s.logger.Error("mark report failed status error", zap.String("report_id", report.ReportID), zap.Int("gen_version", genVersion), zap.Error(updateErr))
 					return updateErr
 				}
 				return err
 			}
+			if missing := s.withDecrypted(report.ReportID, genVersion, records); missing > 0 {
+				return s.failEncrypted(ctx, report, job, missing)
+			}
 			if len(records) > 0 {
 				var parts []string
 				for _, l := range records {
@@ -409,12 +607,9 @@ func (s *reportService) processYearReport(ctx context.Context, report *model.Rep
 			}
 		}
 
-		// 统一加月份标签，保证模型输入结构稳定
-		label := fmt.Sprintf("%02d月", m)
-		if text == "" {
-			text = fmt.Sprintf("%s：暂无素材", label)
-		} else {
-			text = fmt.Sprintf("%s：\n%s", label, text)
+		label := fmt.Sprintf("%02d月", int(monthStart.Month()))
+		if !sameYear {
+			label = monthStart.Format("2006年01月")
 		}
 		materials = append(materials, monthMaterial{
 			monthLabel: label,
@@ -422,30 +617,203 @@ func (s *reportService) processYearReport(ctx context.Context, report *model.Rep
 		})
 	}
 
-	// 组合年报提示词并调用模型生成「正文 + 结构化摘要」
-	userPrompt := buildYearPrompt(report.StartDate, report.EndDate, materials)
-	systemPrompt := s.pickSystemPrompt(string(v1.ReportPeriodYear), string(v1.ReportTemplateFormal), nil)
-	content, abstract, err := s.callModel(ctx, systemPrompt, userPrompt)
+	// 组合提示词并调用模型生成「正文 + 结构化摘要」；素材超出模型上下文时先逐月压缩
+	prompt := reportPrompt{
+		system: s.pickSystemPrompt(report.PeriodType, s.reportTemplate(ctx, report), nil),
+		user:   buildRollupPrompt(report.PeriodType, report.StartDate, report.EndDate, materials),
+	}
+	if llm.EstimateTokens(prompt.user) > s.promptBudget(prompt.system) {
+		if prompt, err = s.reduceRollupMaterials(ctx, report, prompt, materials); err != nil {
+			return s.failModelCall(ctx, report, job, err)
+		}
+	}
+	return s.generate(ctx, report, job, prompt)
+}
+
+// generate 流式调用模型并写回正文与摘要，同时把每次尝试和最终应答模型记录到报告上；
+// 生成过程中的增量实时推给订阅方，并定期落盘，便于其他进程或断线重连读取。
+// prompt.refs 不为空时解析正文中的记录引用，去除标注后写入 meta.citations；
+// 随后对照输入素材核对正文中的事实声明，无依据的写入 meta.verification 供确认前复核；
+// 对比生成时附上上期报告摘要，实际使用的对比基准写入 meta.comparison
+func (s *reportService) generate(ctx context.Context, report *model.Report, job *model.ReportJob, prompt reportPrompt) error {
+	genVersion := job.GenVersion
+	prompt, baseline := s.withComparison(ctx, report, prompt)
+	systemPrompt, userPrompt := prompt.system, prompt.user
+	// 提示词含客户端解密的明文时不落库
+	savedPrompt := userPrompt
+	if _, ok := s.getDecrypted(report.ReportID, genVersion); ok {
+		savedPrompt = decryptedPromptPlaceholder
+	}
+	if err := s.reportJobRepo.MarkProcessing(ctx, job, systemPrompt, savedPrompt); err != nil {
+		s.logger.Warn("mark report job processing failed", zap.String("report_job_id", job.ReportJobID), zap.Error(err))
+	}
+
+	live := s.startLive(report.ReportID, genVersion)
+	defer s.finishLive(report.ReportID, genVersion, live)
+
+	lastSave := time.Now()
+	completion, err := s.callModel(ctx, systemPrompt, userPrompt, func(delta string) {
+		live.append(delta)
+		if time.Since(lastSave) < partialSaveInterval {
+			return
+		}
+		lastSave = time.Now()
+		if err := s.reportRepo.UpdatePartial(ctx, report.ReportID, genVersion, report.LeaseOwner, live.text()); err != nil {
+			s.logger.Warn("save partial report failed", zap.String("report_id", report.ReportID), zap.Int("gen_version", genVersion), zap.Error(err))
+		}
+	})
 	if err != nil {
-		if updateErr := s.reportRepo.UpdateFailed(ctx, report.ReportID, genVersion, "生成失败"); updateErr != nil {
-			s.logger.Error("mark report failed status error", zap.String("report_id", report.ReportID), zap.Int("gen_version", genVersion), zap.Error(updateErr))
-			return v1.ErrGenReportFailed
+		return s.failModelCall(ctx, report, job, err)
+	}
+
+	// 写回正文与结构化摘要，并将状态置为 ready；摘要提取失败不影响报告
+	s.saveJobAttempts(ctx, job, completion.Attempts)
+	meta := withGenerationMeta(report.Meta, completion.Model, completion.Attempts)
+	content := completion.Content
+	var citations *reportCitations
+	if prompt.refs != nil {
+		content, citations = extractCitations(content, prompt.refs, report.StartDate, report.EndDate)
+		if citations.Rejected > 0 {
+			s.logger.Warn("reject report citations", zap.String("report_id", report.ReportID), zap.Int("gen_version", genVersion), zap.Int("rejected", citations.Rejected))
 		}
-		s.logger.Error("call model failed", zap.String("report_id", report.ReportID), zap.Int("gen_version", genVersion), zap.Error(err))
-		return v1.ErrCallLLMFailed
 	}
-	// 写回正文与摘要，并将状态置为 ready
-	if err := s.reportRepo.UpdateGenerated(ctx, report.ReportID, genVersion, content, abstract); err != nil {
+	meta = withCitationMeta(meta, citations)
+	sources := prompt.sources
+	if sources == "" {
+		sources = userPrompt
+	}
+	meta = withVerificationMeta(meta, s.verifyReport(ctx, report.ReportID, content, sources))
+	meta = withComparisonMeta(meta, baseline)
+	summary, abstract := s.summarize(ctx, report.ReportID, content, baseline != nil)
+	// 正文与历史版本同一事务写入；租约已丢失时均不写入
+	written := false
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		written, err = s.reportRepo.UpdateGenerated(ctx, report.ReportID, genVersion, report.LeaseOwner, content, abstract, summary, completion.Model, meta)
+		if err != nil || !written {
+			return err
+		}
+		return s.versionRepo.Create(ctx, &model.ReportVersion{
+			ReportID:   report.ReportID,
+			UserID:     report.UserID,
+			Author:     v1.ReportAuthorLLM,
+			Action:     v1.ReportVersionGenerate,
+			Version:    report.Version,
+			GenVersion: genVersion,
+			LLMModel:   completion.Model,
+			Template:   report.Template,
+			Content:    content,
+		})
+	})
+	if err != nil {
 		return err
 	}
+	// 结果未写入时任务由重新领取的 worker 或新一轮生成结束，不记为成功
+	if !written {
+		s.logger.Warn("discard generated report", zap.String("report_id", report.ReportID), zap.Int("gen_version", genVersion), zap.String("lease_owner", report.LeaseOwner))
+		return nil
+	}
+	if err := s.reportJobRepo.Finish(ctx, job, string(v1.ReportStatusReady), completion.Model, completion.Content, ""); err != nil {
+		s.logger.Warn("finish report job failed", zap.String("report_job_id", job.ReportJobID), zap.Error(err))
+	}
 	return nil
 }
 
-func (s *reportService) callModel(ctx context.Context, systemPrompt string, userPrompt string) (string, string, error) {
-	if s.openAIClient == nil {
-		return "", "", errors.New("llm client not initialized")
+// failModelCall 模型调用失败时记录各次尝试并将报告置为失败
+func (s *reportService) failModelCall(ctx context.Context, report *model.Report, job *model.ReportJob, err error) error {
+	reason := "生成失败"
+	var attempts []llm.Attempt
+	var chainErr *llm.ChainError
+	if errors.As(err, &chainErr) {
+		reason = chainErr.Reason()
+		attempts = chainErr.Attempts
+	}
+	s.saveJobAttempts(ctx, job, attempts)
+	meta := withGenerationMeta(report.Meta, "", attempts)
+	if updateErr := s.markFailed(ctx, report, job, reason, err, meta); updateErr != nil {
+		s.logger.Error("mark report failed status error", zap.String("report_id", report.ReportID), zap.Int("gen_version", job.GenVersion), zap.Error(updateErr))
+		return v1.ErrGenReportFailed
 	}
-	return s.openAIClient.GenerateReport(ctx, systemPrompt, userPrompt)
+	s.logger.Error("call model failed", zap.String("report_id", report.ReportID), zap.Int("gen_version", job.GenVersion), zap.Error(err))
+	return v1.ErrCallLLMFailed
+}
+
+// createReportJob 每次发起生成登记一条任务，与报告占位在同一事务中写入
+func (s *reportService) createReportJob(ctx context.Context, report *model.Report) error {
+	id, err := s.sid.GenString()
+	if err != nil {
+		return err
+	}
+	return s.reportJobRepo.Create(ctx, &model.ReportJob{
+		ReportJobID: ReportJobPrefix + id,
+		ReportID:    report.ReportID,
+		GenVersion:  report.GenVersion,
+		UserID:      report.UserID,
+		PeriodType:  report.PeriodType,
+		StartDate:   report.StartDate,
+		EndDate:     report.EndDate,
+		Template:    report.Template,
+		Status:      string(v1.ReportStatusQueued),
+	})
+}
+
+// getOrCreateReportJob 兼容任务表上线前已排队的报告
+func (s *reportService) getOrCreateReportJob(ctx context.Context, report *model.Report) (*model.ReportJob, error) {
+	job, err := s.reportJobRepo.GetByReportVersion(ctx, report.ReportID, report.GenVersion)
+	if err == nil {
+		return job, nil
+	}
+	if !errors.Is(err, v1.ErrNotFound) {
+		return nil, err
+	}
+	if err := s.createReportJob(ctx, report); err != nil {
+		return nil, err
+	}
+	return s.reportJobRepo.GetByReportVersion(ctx, report.ReportID, report.GenVersion)
+}
+
+// markFailed 报告置为失败（failed_reason 面向用户），任务记录包含底层错误的完整原因
+func (s *reportService) markFailed(ctx context.Context, report *model.Report, job *model.ReportJob, reason string, cause error, meta datatypes.JSONMap) error {
+	if err := s.reportRepo.UpdateFailed(ctx, report.ReportID, job.GenVersion, report.LeaseOwner, reason, meta); err != nil {
+		return err
+	}
+	detail := reason
+	if cause != nil {
+		detail = reason + ": " + cause.Error()
+	}
+	if err := s.reportJobRepo.Finish(ctx, job, string(v1.ReportStatusFailed), "", "", detail); err != nil {
+		s.logger.Warn("finish report job failed", zap.String("report_job_id", job.ReportJobID), zap.Error(err))
+	}
+	return nil
+}
+
+func (s *reportService) saveJobAttempts(ctx context.Context, job *model.ReportJob, attempts []llm.Attempt) {
+	rows := make([]*model.ReportJobAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		rows = append(rows, &model.ReportJobAttempt{
+			ReportJobID:      job.ReportJobID,
+			Provider:         attempt.Provider,
+			Model:            attempt.Model,
+			Try:              attempt.Try,
+			LatencyMs:        attempt.LatencyMs,
+			PromptTokens:     attempt.Usage.PromptTokens,
+			CompletionTokens: attempt.Usage.CompletionTokens,
+			TotalTokens:      attempt.Usage.TotalTokens,
+			Raw:              attempt.Raw,
+			Error:            attempt.Error,
+			Skipped:          attempt.Skipped,
+		})
+	}
+	if err := s.reportJobRepo.CreateAttempts(ctx, rows); err != nil {
+		s.logger.Warn("save report job attempts failed", zap.String("report_job_id", job.ReportJobID), zap.Error(err))
+	}
+}
+
+func (s *reportService) callModel(ctx context.Context, systemPrompt string, userPrompt string, onDelta func(delta string)) (*llm.Completion, error) {
+	if s.llmProvider == nil {
+		return nil, errors.New("llm client not initialized")
+	}
+	return llm.Stream(ctx, s.llmProvider, systemPrompt, userPrompt, onDelta)
 }
 
 func (s *reportService) toReportItem(report *model.Report) v1.ReportItem {
@@ -456,68 +824,82 @@ func (s *reportService) toReportItem(report *model.Report) v1.ReportItem {
 		EndDate:      report.EndDate,
 		Title:        report.Title,
 		Content:      report.Content,
-		Abstract:     report.Abstract,
+		Abstract:     reportAbstract(report),
+		Summary:      toReportSummaryItem(decodeSummary(report.Summary)),
+		Citations:    reportCitationItems(report.Content, report.Meta),
+		Verification: reportVerificationItem(report.Content, report.Meta),
+		Comparison:   reportComparisonItem(report.Meta),
 		Confirmed:    report.Confirmed,
+		ConfirmedAt:  formatTime(report.ConfirmedAt),
 		Template:     report.Template,
 		Status:       report.Status,
 		FailedReason: report.FailedReason,
+		LLMModel:     report.LLMModel,
+		LLMAttempts:  generationAttempts(report.Meta),
+		Version:      report.Version,
+		GenVersion:   report.GenVersion,
 		CreatedAt:    formatTime(&report.CreatedAt),
 		UpdatedAt:    formatTime(&report.UpdatedAt),
 	}
 }
 
-func validateReportPeriod(periodType string) error {
-	switch v1.ReportPeriodType(periodType) {
-	case v1.ReportPeriodWeek, v1.ReportPeriodMonth, v1.ReportPeriodYear:
-		return nil
-	default:
-		return v1.ErrInvalidReportPeriod
+// validateReportTemplate template 为模板 ID，内置模板 ID 即 formal/simple
+func (s *reportService) validateReportTemplate(ctx context.Context, userId string, templateId string, periodType string) error {
+	tpl, err := loadReportTemplate(ctx, s.templateRepo, s.builtinTemplates, userId, templateId)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return v1.ErrInvalidReportTemplate
+		}
+		s.logger.Error("get report template failed", zap.String("user_id", userId), zap.String("template_id", templateId), zap.Error(err))
+		return v1.ErrInternalServerError
 	}
+	if !templateSupports(tpl, periodType) {
+		return v1.ErrReportTemplatePeriod
+	}
+	return nil
 }
 
-func validateReportTemplate(template string) error {
-	switch v1.ReportTemplateType(template) {
-	case v1.ReportTemplateFormal, v1.ReportTemplateSimple:
+// reportTemplate 生成时读取报告的模板，模板已删除或读取失败时返回 nil，按正式版生成
+func (s *reportService) reportTemplate(ctx context.Context, report *model.Report) *model.ReportTemplate {
+	tpl, err := loadReportTemplate(ctx, s.templateRepo, s.builtinTemplates, report.UserID, report.Template)
+	if err != nil {
+		s.logger.Warn("load report template failed, fallback to formal", zap.String("report_id", report.ReportID), zap.String("template_id", report.Template), zap.Error(err))
 		return nil
-	default:
-		return v1.ErrInvalidReportTemplate
 	}
+	return tpl
 }
 
-func validateDateRange(periodType string, start time.Time, end time.Time) error {
-	switch v1.ReportPeriodType(periodType) {
-	case v1.ReportPeriodWeek:
-		if start.Weekday() != time.Monday || end.Weekday() != time.Sunday {
-			return v1.ErrInvalidDate
-		}
-		if end.Sub(start).Hours() != 6*24 {
-			return v1.ErrInvalidDate
-		}
-	case v1.ReportPeriodMonth:
-		if start.Year() != end.Year() || start.Month() != end.Month() {
-			return v1.ErrInvalidDate
-		}
-		if start.Day() != 1 {
-			return v1.ErrInvalidDate
-		}
-		lastDay := start.AddDate(0, 1, -1).Day()
-		if end.Day() != lastDay {
-			return v1.ErrInvalidDate
-		}
-	case v1.ReportPeriodYear:
-		if start.Year() != end.Year() {
-			return v1.ErrInvalidDate
-		}
-		if start.Month() != time.January || start.Day() != 1 {
-			return v1.ErrInvalidDate
-		}
-		if end.Month() != time.December || end.Day() != 31 {
-			return v1.ErrInvalidDate
-		}
-	default:
-		return v1.ErrInvalidReportPeriod
+const reportMetaGeneration = "generation"
+
+// withGenerationMeta 复制 meta 并写入本次生成记录，避免覆盖其他扩展字段
+func withGenerationMeta(meta datatypes.JSONMap, llmModel string, attempts []llm.Attempt) datatypes.JSONMap {
+	result := make(datatypes.JSONMap, len(meta)+1)
+	for k, v := range meta {
+		result[k] = v
 	}
-	return nil
+	result[reportMetaGeneration] = map[string]any{
+		"llm_model": llmModel,
+		"attempts":  attempts,
+	}
+	return result
+}
+
+func generationAttempts(meta datatypes.JSONMap) []v1.LLMAttempt {
+	raw, ok := meta[reportMetaGeneration]
+	if !ok {
+		return nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var generation struct {
+		Attempts []v1.LLMAttempt `json:"attempts"`
+	}
+	if err := json.Unmarshal(b, &generation); err != nil {
+		return nil
+	}
+	return generation.Attempts
 }
 
 type monthMaterial struct {
@@ -525,22 +907,20 @@ type monthMaterial struct {
 	text       string
 }
 
-func pickAbstract(report *model.Report) string {
-	if report.Abstract != "" {
-		return report.Abstract
-	}
-	return report.Content
-}
-
-func buildYearPrompt(start string, end string, materials []monthMaterial) string {
+func buildRollupPrompt(periodType string, start string, end string, materials []monthMaterial) string {
 	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("类型：年报\n"))
+	builder.WriteString(fmt.Sprintf("类型：%s\n", periodName(periodType)))
 	builder.WriteString(fmt.Sprintf("开始日期：%s\n", start))
 	builder.WriteString(fmt.Sprintf("结束日期：%s\n\n", end))
 	builder.WriteString("记录列表：\n")
 	for _, m := range materials {
 		builder.WriteString(fmt.Sprintf("- 月份：%s\n", m.monthLabel))
-		lines := strings.Split(m.text, "\n")
+		// 统一加月份标签，保证模型输入结构稳定
+		text := fmt.Sprintf("%s：暂无素材", m.monthLabel)
+		if m.text != "" {
+			text = fmt.Sprintf("%s：\n%s", m.monthLabel, m.text)
+		}
+		lines := strings.Split(text, "\n")
 		for _, line := range lines {
 			trimmed := strings.TrimSpace(line)
 			if trimmed == "" {
@@ -565,6 +945,23 @@ func buildReportTitle(periodType string, startDate string, endDate string) strin
 			return fmt.Sprintf("%s 月报", startLabel)
 		}
 		return fmt.Sprintf("%d年%02d月月报", t.Year(), int(t.Month()))
+	case v1.ReportPeriodQuarter:
+		t, err := time.Parse(reportDateLayout, startDate)
+		if err != nil {
+			return fmt.Sprintf("%s 季报", startLabel)
+		}
+		return fmt.Sprintf("%d年第%d季度季报", t.Year(), (int(t.Month())-1)/3+1)
+	case v1.ReportPeriodHalfYear:
+		t, err := time.Parse(reportDateLayout, startDate)
+		if err != nil {
+			return fmt.Sprintf("%s 半年报", startLabel)
+		}
+		if t.Month() <= time.June {
+			return fmt.Sprintf("%d年上半年半年报", t.Year())
+		}
+		return fmt.Sprintf("%d年下半年半年报", t.Year())
+	case v1.ReportPeriodSprint, v1.ReportPeriodCustom:
+		return fmt.Sprintf("%s-%s %s", startLabel, endLabel, periodName(periodType))
 	case v1.ReportPeriodYear:
 		t, err := time.Parse(reportDateLayout, startDate)
 		if err != nil {
@@ -584,55 +981,76 @@ func toZhDate(date string) string {
 	return t.Format("2006年01月02日")
 }
 
-func (s *reportService) buildUserPrompt(periodType string, template string, settings *model.UserSettings, records []v1.RecordItem, title string) reportPrompt {
-	systemPrompt := s.pickSystemPrompt(periodType, template, settings)
+func (s *reportService) buildUserPrompt(periodType string, tpl *model.ReportTemplate, settings *model.UserSettings, records []v1.RecordItem, title string) reportPrompt {
+	systemPrompt := s.pickSystemPrompt(periodType, tpl, settings)
 
 	builder := strings.Builder{}
-	builder.WriteString("生成类型：")
-	switch v1.ReportPeriodType(periodType) {
-	case v1.ReportPeriodWeek:
-		builder.WriteString("周报")
-	case v1.ReportPeriodMonth:
-		builder.WriteString("月报")
-	case v1.ReportPeriodYear:
-		builder.WriteString("年终总结")
-	}
-	builder.WriteString("\n")
-	builder.WriteString("标题：")
-	builder.WriteString(title)
-	builder.WriteString("\n")
+	builder.WriteString(promptHeader(periodType, title))
 	builder.WriteString("记录列表：\n")
 
 	if len(records) == 0 {
 		builder.WriteString("- 日期：无\n  内容：无记录\n")
 	} else {
-		for _, r := range records {
-			builder.WriteString(fmt.Sprintf("- 日期：%s\n", r.Date))
-			builder.WriteString("  内容：\n")
-			lines := strings.Split(r.Content, "\n")
-			empty := true
-			for _, line := range lines {
-				trimmed := strings.TrimSpace(line)
-				if trimmed == "" {
-					continue
-				}
-				builder.WriteString(fmt.Sprintf("  - %s\n", trimmed))
-				empty = false
-			}
-			if empty {
-				builder.WriteString("  - 无记录\n")
-			}
+		for i, r := range records {
+			builder.WriteString(renderRecord(i+1, r))
 		}
 	}
 
+	if len(records) == 0 {
+		return reportPrompt{
+			system: systemPrompt,
+			user:   builder.String(),
+		}
+	}
 	return reportPrompt{
-		system: systemPrompt,
+		system: systemPrompt + "\n" + citationInstruction,
 		user:   builder.String(),
+		refs:   citationRefs(records),
+	}
+}
+
+// promptHeader 周报/月报用户提示词开头的类型与标题
+func promptHeader(periodType string, title string) string {
+	builder := strings.Builder{}
+	builder.WriteString("生成类型：")
+	if v1.ReportPeriodType(periodType) == v1.ReportPeriodYear {
+		builder.WriteString("年终总结")
+	} else {
+		builder.WriteString(periodName(periodType))
 	}
+	builder.WriteString("\n")
+	builder.WriteString("标题：")
+	builder.WriteString(title)
+	builder.WriteString("\n")
+	return builder.String()
 }
 
-func (s *reportService) pickSystemPrompt(periodType string, template string, settings *model.UserSettings) string {
-	// 用户自定义系统 prompt 优先
+// renderRecord 以编号 [Rn] 渲染一条记录
+func renderRecord(ref int, r v1.RecordItem) string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("- [R%d] 日期：%s\n", ref, r.Date))
+	builder.WriteString("  内容：\n")
+	empty := true
+	for _, line := range strings.Split(r.Content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("  - %s\n", trimmed))
+		empty = false
+	}
+	if empty {
+		builder.WriteString("  - 无记录\n")
+	}
+	return builder.String()
+}
+
+func (s *reportService) pickSystemPrompt(periodType string, tpl *model.ReportTemplate, settings *model.UserSettings) string {
+	// 用户自建模板优先
+	if tpl != nil && !tpl.Builtin {
+		return s.enforceMarkdownSystemPrompt(templateSystemPrompt(tpl))
+	}
+	// 选择内置模板时沿用用户设置中的周报/月报提示词
 	if settings != nil {
 		if v1.ReportPeriodType(periodType) == v1.ReportPeriodWeek && settings.ReportTemplateWeek != "" {
 			return s.enforceMarkdownSystemPrompt(settings.ReportTemplateWeek)
@@ -642,20 +1060,12 @@ func (s *reportService) pickSystemPrompt(periodType string, template string, set
 		}
 	}
 
-	// 默认模板：按报告版式选择
-	switch v1.ReportTemplateType(template) {
-	case v1.ReportTemplateSimple:
-		if s.promptSet.Simple != "" {
-			return s.enforceMarkdownSystemPrompt(s.promptSet.Simple)
-		}
-	case v1.ReportTemplateFormal:
-		if s.promptSet.Formal != "" {
-			return s.enforceMarkdownSystemPrompt(s.promptSet.Formal)
-		}
-	default:
-		if s.promptSet.Formal != "" {
-			return s.enforceMarkdownSystemPrompt(s.promptSet.Formal)
-		}
+	// 内置模板；模板缺失时使用正式版
+	if tpl != nil {
+		return s.enforceMarkdownSystemPrompt(templateSystemPrompt(tpl))
+	}
+	if s.promptSet.Formal != "" {
+		return s.enforceMarkdownSystemPrompt(s.promptSet.Formal)
 	}
 	// 兜底
 	return s.enforceMarkdownSystemPrompt("你是工作报告助手，突出关键产出、风险和计划，不要编造。")
@@ -0,0 +1,262 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 16:31:52
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 16:31:52
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/model"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultChunkMaxTokens = 8000 // 单段上限，避免单次调用超过 llm.retry.attempt_timeout
+	defaultChunkCacheSize = 256
+	minChunkTokens        = 256
+	maxReduceRounds       = 3 // 压缩后仍超出预算时最多再分组压缩的轮数
+)
+
+const chunkSystemPrompt = `你是工作记录整理助手。请把给出的工作记录压缩为要点列表，供后续汇总成报告。
+要求：
+1. 每条要点以“- ”开头，写明日期，保留数字、项目/功能名称、人员等事实，不要编造或推测。
+2. 原文带有记录编号（如 [R1]）时，每条要点末尾保留其依据的编号，如 [R1] 或 [R1,R3]，编号沿用原文。
+3. 合并重复或琐碎的事项，只输出要点列表，不要输出标题或解释。`
+
+const reducedRecordsNote = "说明：记录较多，以下为按时间顺序分段整理的记录要点，方括号内为原始记录编号。\n记录要点：\n"
+
+var recordHeaderRe = regexp.MustCompile(`^- \[R\d+\] 日期：`)
+
+// promptBudget 单次调用可用于用户提示词的 token 数：上下文窗口扣除系统提示词与预留的输出长度
+func (s *reportService) promptBudget(systemPrompt string) int {
+	window := llm.ContextWindow(s.llmProvider)
+	reserve := min(max(window/4, 1024), 8192)
+	return window - reserve - llm.EstimateTokens(systemPrompt)
+}
+
+// chunkBudget 分段压缩时每段的 token 数，由模型上下文窗口决定，并受 report.chunk.max_tokens 限制
+func (s *reportService) chunkBudget() int {
+	return max(min(s.promptBudget(chunkSystemPrompt), s.chunkMaxTokens), minChunkTokens)
+}
+
+// reduceRecords 周报/月报记录超出单次调用预算时，按预算切分记录逐段提取要点（map），
+// 再以要点代替原始记录生成报告（reduce）；要点保留 [Rn] 编号，引用解析不受影响，事实核对仍以原始记录为依据
+func (s *reportService) reduceRecords(ctx context.Context, report *model.Report, prompt reportPrompt, records []v1.RecordItem) (reportPrompt, error) {
+	header := promptHeader(report.PeriodType, report.Title) + reducedRecordsNote
+	blocks := make([]string, 0, len(records))
+	for i, r := range records {
+		blocks = append(blocks, renderRecord(i+1, r))
+	}
+	target := s.promptBudget(prompt.system) - llm.EstimateTokens(header)
+	notes, err := s.mapChunks(ctx, report, blocks, target)
+	if err != nil {
+		return prompt, err
+	}
+	prompt.sources = prompt.user
+	prompt.user = header + notes + "\n"
+	return prompt, nil
+}
+
+// reduceRollupMaterials 长周期素材超出预算时，将超过平均份额的月份逐月压缩
+func (s *reportService) reduceRollupMaterials(ctx context.Context, report *model.Report, prompt reportPrompt, materials []monthMaterial) (reportPrompt, error) {
+	if len(materials) == 0 {
+		return prompt, nil
+	}
+	share := (s.promptBudget(prompt.system) - llm.EstimateTokens(buildRollupPrompt(report.PeriodType, report.StartDate, report.EndDate, nil))) / len(materials)
+	reduced := make([]monthMaterial, len(materials))
+	for i, m := range materials {
+		reduced[i] = m
+		if llm.EstimateTokens(m.text) <= share {
+			continue
+		}
+		notes, err := s.mapChunks(ctx, report, strings.Split(m.text, "\n"), share)
+		if err != nil {
+			return prompt, err
+		}
+		reduced[i].text = notes
+	}
+	prompt.sources = prompt.user
+	prompt.user = buildRollupPrompt(report.PeriodType, report.StartDate, report.EndDate, reduced)
+	return prompt, nil
+}
+
+// mapChunks 按预算打包后逐段压缩；结果仍超出 target 时把要点再分组压缩，至多 maxReduceRounds 轮
+func (s *reportService) mapChunks(ctx context.Context, report *model.Report, blocks []string, target int) (string, error) {
+	if s.llmProvider == nil {
+		return "", errors.New("llm client not initialized")
+	}
+	// 含客户端解密明文的报告不缓存压缩结果，明文只在生成期间保留
+	_, decrypted := s.getDecrypted(report.ReportID, report.GenVersion)
+	budget := s.chunkBudget()
+	calls, cached := 0, 0
+	var notes []string
+	for round := 1; ; round++ {
+		chunks := packChunks(blocks, budget)
+		notes = make([]string, 0, len(chunks))
+		for _, chunk := range chunks {
+			key := chunkCacheKey(report.UserID, s.llmProvider.Model(), chunk)
+			if note, ok := s.chunkCache.get(key); ok && !decrypted {
+				notes = append(notes, note)
+				cached += 1
+				continue
+			}
+			completion, err := s.llmProvider.Complete(ctx, chunkSystemPrompt, chunk)
+			if err != nil {
+				return "", err
+			}
+			calls += 1
+			note := strings.TrimSpace(completion.Content)
+			if !decrypted {
+				s.chunkCache.add(key, note)
+			}
+			notes = append(notes, note)
+		}
+		if len(chunks) <= 1 || round >= maxReduceRounds || llm.EstimateTokens(strings.Join(notes, "\n")) <= target {
+			s.logger.Info("map report chunks", zap.String("report_id", report.ReportID), zap.Int("gen_version", report.GenVersion),
+				zap.Int("rounds", round), zap.Int("calls", calls), zap.Int("cached", cached))
+			return strings.Join(notes, "\n"), nil
+		}
+		blocks = notes
+	}
+}
+
+// packChunks 按顺序把文本块装入不超过 budget 的分段，单块超出时按行拆分
+func packChunks(blocks []string, budget int) []string {
+	var chunks []string
+	var current strings.Builder
+	used := 0
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			used = 0
+		}
+	}
+	for _, block := range blocks {
+		for _, piece := range splitBlock(block, budget) {
+			tokens := llm.EstimateTokens(piece)
+			if used+tokens > budget {
+				flush()
+			}
+			current.WriteString(strings.TrimRight(piece, "\n"))
+			current.WriteString("\n")
+			used += tokens
+		}
+	}
+	flush()
+	return chunks
+}
+
+// splitBlock 超出预算的文本块按行拆分，记录块的「[Rn] 日期」行在每段重复，保证编号不丢失；单行过长时按字截断
+func splitBlock(block string, budget int) []string {
+	if llm.EstimateTokens(block) <= budget {
+		return []string{block}
+	}
+	lines := strings.Split(strings.TrimRight(block, "\n"), "\n")
+	header := ""
+	if recordHeaderRe.MatchString(lines[0]) {
+		header, lines = lines[0]+"\n", lines[1:]
+	}
+	limit := max(budget-llm.EstimateTokens(header), minChunkTokens/2)
+
+	var pieces []string
+	var current strings.Builder
+	used := 0
+	flush := func() {
+		if current.Len() > 0 {
+			pieces = append(pieces, header+current.String())
+			current.Reset()
+			used = 0
+		}
+	}
+	for _, line := range lines {
+		for _, part := range splitLine(line, limit) {
+			tokens := llm.EstimateTokens(part)
+			if used+tokens > limit {
+				flush()
+			}
+			current.WriteString(part)
+			current.WriteString("\n")
+			used += tokens
+		}
+	}
+	flush()
+	return pieces
+}
+
+// splitLine 每段不超过 limit 个字符，按 1 字 1 token 估算不会超出预算
+func splitLine(line string, limit int) []string {
+	runes := []rune(line)
+	if llm.EstimateTokens(line) <= limit {
+		return []string{line}
+	}
+	var parts []string
+	for start := 0; start < len(runes); start += limit {
+		parts = append(parts, string(runes[start:min(start+limit, len(runes))]))
+	}
+	return parts
+}
+
+func chunkCacheKey(userID string, llmModel string, chunk string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%s", userID, llmModel, chunkSystemPrompt, chunk)))
+	return hex.EncodeToString(sum[:])
+}
+
+// chunkCache 分段压缩结果的进程内 LRU 缓存，以用户、模型与分段内容的哈希为键，
+// 重新生成或租约过期后重新处理时内容未变的分段不再调用模型；压缩结果含记录内容，不落库
+type chunkCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+type chunkCacheEntry struct {
+	key   string
+	value string
+}
+
+func newChunkCache(size int) *chunkCache {
+	return &chunkCache{size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *chunkCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*chunkCacheEntry).value, true
+}
+
+func (c *chunkCache) add(key string, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*chunkCacheEntry).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&chunkCacheEntry{key: key, value: value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*chunkCacheEntry).key)
+	}
+}
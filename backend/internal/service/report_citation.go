@@ -0,0 +1,174 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 13:20:44
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 13:20:44
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/datatypes"
+)
+
+const reportMetaCitations = "citations"
+
+const citationInstruction = "引用要求：每条要点末尾用方括号标注其依据的记录编号，多条用逗号分隔，如 [R1] 或 [R1,R3]；只能引用记录列表中给出的编号，无法对应到具体记录的内容不要标注。"
+
+var (
+	citationMarkerRe = regexp.MustCompile(`\s*\[(R\d+(?:\s*[,，、]\s*R\d+)*)\]`)
+	citationRefRe    = regexp.MustCompile(`R\d+`)
+	listPrefixRe     = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+`)
+)
+
+// citationSource 提示词中记录编号对应的原始记录
+type citationSource struct {
+	RecordID string `json:"record_id"`
+	Date     string `json:"date"`
+}
+
+// reportCitation 存于 report.meta.citations，以要点文本的哈希关联（meta 不加密，不保存正文片段），
+// 编辑后文本不再出现的引用自动失效
+type reportCitation struct {
+	Hash    string           `json:"hash"`
+	Records []citationSource `json:"records"`
+}
+
+type reportCitations struct {
+	Items    []reportCitation `json:"items"`
+	Rejected int              `json:"rejected,omitempty"` // 被拒绝的引用数：编号不存在或记录不在报告周期内
+}
+
+// citationRefs 按提示词中的顺序为记录编号 R1..Rn
+func citationRefs(records []v1.RecordItem) map[string]citationSource {
+	refs := make(map[string]citationSource, len(records))
+	for i, r := range records {
+		refs[fmt.Sprintf("R%d", i+1)] = citationSource{RecordID: r.RecordID, Date: r.Date}
+	}
+	return refs
+}
+
+// extractCitations 从模型输出中解析并去除引用标注；编号不存在或记录日期不在 [startDate, endDate] 内的引用被拒绝
+func extractCitations(content string, refs map[string]citationSource, startDate string, endDate string) (string, *reportCitations) {
+	result := &reportCitations{}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		matches := citationMarkerRe.FindAllStringSubmatch(line, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		lines[i] = strings.TrimRight(citationMarkerRe.ReplaceAllString(line, ""), " \t")
+
+		var records []citationSource
+		seen := make(map[string]bool)
+		for _, match := range matches {
+			for _, ref := range citationRefRe.FindAllString(match[1], -1) {
+				source, ok := refs[ref]
+				if !ok || source.Date < startDate || source.Date > endDate {
+					result.Rejected += 1
+					continue
+				}
+				if !seen[source.RecordID] {
+					seen[source.RecordID] = true
+					records = append(records, source)
+				}
+			}
+		}
+		if text := citationText(lines[i]); text != "" && len(records) > 0 {
+			result.Items = append(result.Items, reportCitation{Hash: lineHash(text), Records: records})
+		}
+	}
+	return strings.Join(lines, "\n"), result
+}
+
+// citationText 去掉列表与标题标记，作为要点与正文行的匹配键
+func citationText(line string) string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSpace(strings.TrimLeft(line, "#"))
+	return strings.TrimSpace(listPrefixRe.ReplaceAllString(line, ""))
+}
+
+func lineHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:8])
+}
+
+// lineIndex 当前正文中各要点文本哈希对应的行号与文本，相同文本出现多次时按顺序消费
+type lineIndex map[string][]indexedLine
+
+type indexedLine struct {
+	line int
+	text string
+}
+
+func newLineIndex(content string) lineIndex {
+	index := make(lineIndex)
+	for i, line := range strings.Split(content, "\n") {
+		if text := citationText(line); text != "" {
+			hash := lineHash(text)
+			index[hash] = append(index[hash], indexedLine{line: i + 1, text: text})
+		}
+	}
+	return index
+}
+
+func (index lineIndex) take(hash string) (indexedLine, bool) {
+	lines := index[hash]
+	if len(lines) == 0 {
+		return indexedLine{}, false
+	}
+	index[hash] = lines[1:]
+	return lines[0], true
+}
+
+// withCitationMeta 覆盖上一次生成的引用，无引用时移除
+func withCitationMeta(meta datatypes.JSONMap, citations *reportCitations) datatypes.JSONMap {
+	if meta == nil {
+		meta = make(datatypes.JSONMap)
+	}
+	if citations == nil || (len(citations.Items) == 0 && citations.Rejected == 0) {
+		delete(meta, reportMetaCitations)
+		return meta
+	}
+	meta[reportMetaCitations] = citations
+	return meta
+}
+
+// reportCitationItems 按当前正文定位引用所在行，要点被编辑删改后不再返回
+func reportCitationItems(content string, meta datatypes.JSONMap) []v1.ReportCitation {
+	raw, ok := meta[reportMetaCitations]
+	if !ok {
+		return nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var citations reportCitations
+	if err := json.Unmarshal(b, &citations); err != nil || len(citations.Items) == 0 {
+		return nil
+	}
+
+	index := newLineIndex(content)
+	items := make([]v1.ReportCitation, 0, len(citations.Items))
+	for _, c := range citations.Items {
+		line, ok := index.take(c.Hash)
+		if !ok {
+			continue
+		}
+		records := make([]v1.CitationRecord, 0, len(c.Records))
+		for _, r := range c.Records {
+			records = append(records, v1.CitationRecord{RecordID: r.RecordID, Date: r.Date})
+		}
+		items = append(items, v1.ReportCitation{Line: line.line, Text: line.text, Records: records})
+	}
+	return items
+}
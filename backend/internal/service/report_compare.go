@@ -0,0 +1,147 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 17:42:08
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 17:42:08
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+)
+
+const reportMetaComparison = "comparison"
+
+const comparisonSystemPrompt = `
+
+另外：用户提示词末尾附有上一周期报告的摘要。请在正文末尾增加「## 与上期对比」一节，分为「持续推进」「新增事项」「未延续事项」「变化趋势」四部分，逐条列出；对比须基于本期素材与上期摘要，不要编造，某部分没有内容时写“无”。`
+
+// reportBaseline 存于 report.meta.comparison，只保存上期报告的定位信息，不保存正文
+type reportBaseline struct {
+	ReportID   string `json:"report_id"`
+	PeriodType string `json:"period_type"`
+	StartDate  string `json:"start_date"`
+	EndDate    string `json:"end_date"`
+	Title      string `json:"title"`
+	Version    int    `json:"version"`
+}
+
+// previousPeriod 上一周期的起止日期：固定周期取开始日期前一天所在周期，迭代与自定义周期取紧邻的等长区间
+func previousPeriod(periodType string, start time.Time, end time.Time, sprint sprintConfig) (time.Time, time.Time, error) {
+	switch v1.ReportPeriodType(periodType) {
+	case v1.ReportPeriodSprint, v1.ReportPeriodCustom:
+		days := daysBetween(start, end) + 1
+		return start.AddDate(0, 0, -days), start.AddDate(0, 0, -1), nil
+	default:
+		return periodRange(periodType, start.AddDate(0, 0, -1), sprint)
+	}
+}
+
+// comparisonBaseline 查找上一周期已确认的同类报告，没有时返回 ErrNoComparisonBaseline
+func (s *reportService) comparisonBaseline(ctx context.Context, userId string, req *v1.GenReportReq, start time.Time, end time.Time, sprint sprintConfig) (*reportBaseline, error) {
+	prevStart, prevEnd, err := previousPeriod(req.PeriodType, start, end, sprint)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := s.reportRepo.GetByUnique(ctx, userId, req.PeriodType, prevStart.Format(reportDateLayout), prevEnd.Format(reportDateLayout))
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return nil, v1.ErrNoComparisonBaseline
+		}
+		s.logger.Error("query comparison baseline failed", zap.String("user_id", userId), zap.Error(err))
+		return nil, v1.ErrGetReportsFailed
+	}
+	if !prev.Confirmed {
+		return nil, v1.ErrNoComparisonBaseline
+	}
+	return &reportBaseline{
+		ReportID:   prev.ReportID,
+		PeriodType: prev.PeriodType,
+		StartDate:  prev.StartDate,
+		EndDate:    prev.EndDate,
+		Title:      prev.Title,
+		Version:    prev.Version,
+	}, nil
+}
+
+// withComparison 报告为对比生成时，把上期报告的结构化摘要（无摘要时用正文）附在提示词末尾，
+// 并要求输出「与上期对比」一节；上期报告已删除或取消确认时不再对比，返回 nil
+func (s *reportService) withComparison(ctx context.Context, report *model.Report, prompt reportPrompt) (reportPrompt, *reportBaseline) {
+	baseline := comparisonOf(report.Meta)
+	if baseline == nil {
+		return prompt, nil
+	}
+	prev, err := s.reportRepo.GetByID(ctx, report.UserID, baseline.ReportID)
+	if err != nil || !prev.Confirmed {
+		s.logger.Warn("comparison baseline unavailable", zap.String("report_id", report.ReportID), zap.String("baseline_id", baseline.ReportID), zap.Error(err))
+		return prompt, nil
+	}
+	material := s.summaryMaterial(ctx, []*model.Report{prev})
+	if strings.TrimSpace(material) == "" {
+		return prompt, nil
+	}
+	baseline.Title, baseline.Version = prev.Title, prev.Version
+
+	section := fmt.Sprintf("\n上期报告（%s，%s 至 %s）摘要：\n%s\n", prev.Title, prev.StartDate, prev.EndDate, material)
+	if prompt.sources == "" {
+		prompt.sources = prompt.user
+	}
+	prompt.sources += section
+	prompt.user += section
+	prompt.system += comparisonSystemPrompt
+	return prompt, baseline
+}
+
+func withComparisonMeta(meta datatypes.JSONMap, baseline *reportBaseline) datatypes.JSONMap {
+	if meta == nil {
+		meta = make(datatypes.JSONMap)
+	}
+	if baseline == nil {
+		delete(meta, reportMetaComparison)
+		return meta
+	}
+	meta[reportMetaComparison] = baseline
+	return meta
+}
+
+func comparisonOf(meta datatypes.JSONMap) *reportBaseline {
+	raw, ok := meta[reportMetaComparison]
+	if !ok {
+		return nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var baseline reportBaseline
+	if err := json.Unmarshal(b, &baseline); err != nil || baseline.ReportID == "" {
+		return nil
+	}
+	return &baseline
+}
+
+func reportComparisonItem(meta datatypes.JSONMap) *v1.ReportBaseline {
+	baseline := comparisonOf(meta)
+	if baseline == nil {
+		return nil
+	}
+	return &v1.ReportBaseline{
+		ReportID:   baseline.ReportID,
+		PeriodType: baseline.PeriodType,
+		StartDate:  baseline.StartDate,
+		EndDate:    baseline.EndDate,
+		Title:      baseline.Title,
+		Version:    baseline.Version,
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 21:40:12
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 21:40:12
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	encryptedRecordsFailedReason = "周期内有 %d 条加密记录，需在客户端解密后随生成请求提交"
+	decryptedPromptPlaceholder   = "[包含客户端解密的记录明文，提示词不保存]"
+)
+
+// decryptedRecords 校验客户端随生成请求提交的明文，只接受周期内的加密记录。
+// 直接使用记录的周期内加密记录必须全部提供；按月汇总的长周期优先使用已确认的月报/周报，是否用到日记在生成时才确定，缺少明文时届时失败
+func (s *reportService) decryptedRecords(ctx context.Context, userId string, req *v1.GenReportReq) (map[string]string, error) {
+	rollup := usesRollup(req.PeriodType, req.StartDate, req.EndDate)
+	if len(req.DecryptedRecords) == 0 && rollup {
+		return nil, nil
+	}
+	records, err := s.recordSvr.QueryUserRecordsByDateRange(ctx, userId, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := make(map[string]bool)
+	for _, record := range records {
+		if record.Encrypted {
+			encrypted[record.RecordID] = true
+		}
+	}
+
+	payload := make(map[string]string, len(req.DecryptedRecords))
+	for _, item := range req.DecryptedRecords {
+		if !encrypted[item.RecordID] {
+			return nil, v1.ErrBadRequest
+		}
+		payload[item.RecordID] = item.Content
+	}
+	if !rollup && len(payload) < len(encrypted) {
+		return nil, v1.ErrEncryptedRecords
+	}
+	return payload, nil
+}
+
+// claimDecrypted 明文只保存在本进程内存中，报告占位直接由本进程领取，不进入队列
+func (s *reportService) claimDecrypted(report *model.Report) {
+	expires := time.Now().Add(s.leaseTTL)
+	report.Status = string(v1.ReportStatusProcessing)
+	report.LeaseOwner = s.leaseOwner
+	report.LeaseExpires = &expires
+	report.ClaimCount = 1
+}
+
+// runDecrypted 在本进程后台完成生成，结束后丢弃明文；进程中断时租约过期重新排队，届时因缺少明文而失败
+func (s *reportService) runDecrypted(report *model.Report, payload map[string]string) {
+	s.payloads.Store(liveKey(report.ReportID, report.GenVersion), payload)
+	go func() {
+		if err := s.processClaimed(context.Background(), report.ReportID, report.GenVersion, s.leaseOwner); err != nil {
+			s.logger.Warn("generate report with decrypted records failed", zap.String("report_id", report.ReportID), zap.Int("gen_version", report.GenVersion), zap.Error(err))
+		}
+	}()
+}
+
+func (s *reportService) getDecrypted(reportID string, genVersion int) (map[string]string, bool) {
+	value, ok := s.payloads.Load(liveKey(reportID, genVersion))
+	if !ok {
+		return nil, false
+	}
+	return value.(map[string]string), true
+}
+
+// withDecrypted 用客户端提交的明文替换加密记录，返回仍缺少明文的条数
+func (s *reportService) withDecrypted(reportID string, genVersion int, records []v1.RecordItem) int {
+	payload, _ := s.getDecrypted(reportID, genVersion)
+	missing := 0
+	for i := range records {
+		if !records[i].Encrypted {
+			continue
+		}
+		content, ok := payload[records[i].RecordID]
+		if !ok {
+			missing += 1
+			continue
+		}
+		records[i].Content = content
+		records[i].Encrypted = false
+		records[i].Envelope = nil
+	}
+	return missing
+}
+
+func (s *reportService) failEncrypted(ctx context.Context, report *model.Report, job *model.ReportJob, missing int) error {
+	reason := fmt.Sprintf(encryptedRecordsFailedReason, missing)
+	if updateErr := s.markFailed(ctx, report, job, reason, v1.ErrEncryptedRecords, nil); updateErr != nil {
+		s.logger.Error("mark report failed status error", zap.String("report_id", report.ReportID), zap.Int("gen_version", job.GenVersion), zap.Error(updateErr))
+		return updateErr
+	}
+	return v1.ErrEncryptedRecords
+}
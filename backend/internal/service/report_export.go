@@ -0,0 +1,200 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 09:12:40
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 09:12:40
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/repository"
+	"backend/pkg/render"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+var (
+	brandingNameRe    = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	exportFileNameBad = strings.NewReplacer("/", "-", "\\", "-", ":", "-", "*", "-", "?", "-", "\"", "-", "<", "-", ">", "-", "|", "-")
+)
+
+// ReportExportFile 导出结果，由 handler 以附件形式返回；Warnings 为渲染限制说明，经响应头 X-Export-Warning 返回
+type ReportExportFile struct {
+	Name        string
+	ContentType string
+	Data        []byte
+	Warnings    []string
+}
+
+const (
+	exportWarnFontNotEmbedded = "font-not-embedded"    // PDF 未嵌入中文字体，显示效果取决于阅读器
+	exportWarnUnsupportedChar = "unsupported-chars=%d" // PDF 中替换为 ? 的字符数
+)
+
+// ReportExportService 将已确认报告的 Markdown 在服务端渲染为 PDF/DOCX/HTML，
+// 团队品牌模板放在 report.export.branding_dir 下，按 <name>.html / <name>.docx 查找
+type ReportExportService interface {
+	ExportReport(ctx context.Context, userId string, req *v1.ExportReportReq) (*ReportExportFile, error)
+}
+
+func NewReportExportService(
+	conf *viper.Viper,
+	service *Service,
+	reportRepo repository.ReportRepository,
+	userRepo repository.UserRepository,
+	userSettingsRepo repository.UserSettingsRepository,
+) ReportExportService {
+	return &reportExportService{
+		Service:          service,
+		reportRepo:       reportRepo,
+		userRepo:         userRepo,
+		userSettingsRepo: userSettingsRepo,
+		brandingDir:      conf.GetString("report.export.branding_dir"),
+		defaultBranding:  conf.GetString("report.export.default_branding"),
+	}
+}
+
+type reportExportService struct {
+	*Service
+	reportRepo       repository.ReportRepository
+	userRepo         repository.UserRepository
+	userSettingsRepo repository.UserSettingsRepository
+	brandingDir      string
+	defaultBranding  string
+}
+
+func (s *reportExportService) ExportReport(ctx context.Context, userId string, req *v1.ExportReportReq) (*ReportExportFile, error) {
+	format := v1.ReportExportFormat(strings.ToLower(req.Format))
+	if format == "" {
+		format = v1.ReportExportMarkdown
+	}
+	switch format {
+	case v1.ReportExportPDF, v1.ReportExportDOCX, v1.ReportExportHTML, v1.ReportExportMarkdown:
+	default:
+		return nil, v1.ErrInvalidExportFormat
+	}
+	if req.Branding != "" && !brandingNameRe.MatchString(req.Branding) {
+		return nil, v1.ErrInvalidBranding
+	}
+
+	report, err := s.reportRepo.GetByID(ctx, userId, req.ReportID)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return nil, v1.ErrReportNotExist
+		}
+		s.logger.Error("get report failed", zap.String("user_id", userId), zap.String("report_id", req.ReportID), zap.Error(err))
+		return nil, v1.ErrGetReportsFailed
+	}
+	if report.Status != string(v1.ReportStatusReady) {
+		return nil, v1.ErrReportNotReady
+	}
+	if !report.Confirmed {
+		return nil, v1.ErrReportNotConfirmed
+	}
+
+	doc := render.Document{
+		Title:    report.Title,
+		Period:   report.StartDate + " ~ " + report.EndDate,
+		Markdown: report.Content,
+	}
+	if user, err := s.userRepo.GetByID(ctx, userId); err == nil {
+		doc.Author = user.Username
+	}
+	// 功能上线前确认的报告没有确认时间，以最后更新时间代替
+	confirmedAt := report.UpdatedAt
+	if report.ConfirmedAt != nil {
+		confirmedAt = *report.ConfirmedAt
+	}
+	if loc, err := userLocation(ctx, s.userSettingsRepo, userId); err == nil {
+		confirmedAt = confirmedAt.In(loc)
+	}
+	doc.ConfirmedAt = confirmedAt.Format(dateLayout)
+
+	file := &ReportExportFile{Name: exportFileNameBad.Replace(report.Title) + "." + string(format)}
+	var tmpl []byte
+	switch format {
+	case v1.ReportExportPDF:
+		file.ContentType = "application/pdf"
+		var replaced int
+		file.Data, replaced, err = render.PDF(doc)
+		file.Warnings = append(file.Warnings, exportWarnFontNotEmbedded)
+		if replaced > 0 {
+			file.Warnings = append(file.Warnings, fmt.Sprintf(exportWarnUnsupportedChar, replaced))
+		}
+	case v1.ReportExportDOCX:
+		file.ContentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		if tmpl, err = s.loadBranding(req.Branding, ".docx"); err != nil {
+			return nil, err
+		}
+		file.Data, err = render.DOCX(doc, tmpl)
+	case v1.ReportExportHTML:
+		file.ContentType = "text/html; charset=utf-8"
+		if tmpl, err = s.loadBranding(req.Branding, ".html"); err != nil {
+			return nil, err
+		}
+		file.Data, err = render.HTML(doc, string(tmpl))
+	default:
+		file.ContentType = "text/markdown; charset=utf-8"
+		file.Data = []byte(markdownWithHeader(doc))
+	}
+	if err != nil {
+		if len(tmpl) > 0 {
+			s.logger.Warn("render report with branding failed", zap.String("report_id", report.ReportID), zap.String("branding", req.Branding), zap.Error(err))
+			return nil, v1.ErrInvalidBranding
+		}
+		s.logger.Error("render report failed", zap.String("report_id", report.ReportID), zap.String("format", string(format)), zap.Error(err))
+		return nil, v1.ErrRenderReportFailed
+	}
+	return file, nil
+}
+
+// loadBranding 读取品牌模板；请求指定的模板必须存在，默认模板缺少对应格式时使用内置版式
+func (s *reportExportService) loadBranding(name string, ext string) ([]byte, error) {
+	explicit := name != ""
+	if !explicit {
+		name = s.defaultBranding
+	}
+	if name == "" {
+		return nil, nil
+	}
+	if s.brandingDir == "" || !brandingNameRe.MatchString(name) {
+		if explicit {
+			return nil, v1.ErrInvalidBranding
+		}
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(s.brandingDir, name+ext))
+	if err != nil {
+		if explicit {
+			return nil, v1.ErrInvalidBranding
+		}
+		if !os.IsNotExist(err) {
+			s.logger.Warn("read default branding failed", zap.String("branding", name), zap.Error(err))
+		}
+		return nil, nil
+	}
+	return data, nil
+}
+
+func markdownWithHeader(doc render.Document) string {
+	var b strings.Builder
+	b.WriteString("# " + doc.Title + "\n\n")
+	b.WriteString("- 周期：" + doc.Period + "\n")
+	if doc.Author != "" {
+		b.WriteString("- 作者：" + doc.Author + "\n")
+	}
+	b.WriteString("- 确认日期：" + doc.ConfirmedAt + "\n\n---\n\n")
+	b.WriteString(strings.TrimSpace(doc.Markdown))
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,121 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 17:46:03
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 17:46:03
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLeaseTTL     = 2 * time.Minute
+	defaultMaxClaims    = 3
+	defaultPerUserLimit = 2
+
+	leaseExpiredRequeueReason = "处理进程中断，租约过期后重新排队"
+	leaseExpiredFailedReason  = "生成失败：处理进程多次中断"
+)
+
+// InstanceID 进程标识，用作租约持有者及 worker id 前缀；可通过 report.worker.instance_id 指定（如 Pod 名）
+func InstanceID(conf *viper.Viper) string {
+	if id := conf.GetString("report.worker.instance_id"); id != "" {
+		return id
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// keepLease 按租约时长的 1/3 续租；租约丢失说明报告已被回收或重新生成，取消本次生成
+func (s *reportService) keepLease(ctx context.Context, cancel context.CancelFunc, reportID string, genVersion int, owner string) {
+	ticker := time.NewTicker(s.leaseTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		renewed, err := s.reportRepo.RenewLease(ctx, reportID, genVersion, owner, s.leaseTTL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// 偶发的数据库错误不立即放弃，租约到期前还有重试机会
+			s.logger.Warn("renew report lease failed", zap.String("report_id", reportID), zap.Int("gen_version", genVersion), zap.Error(err))
+			continue
+		}
+		if !renewed {
+			s.logger.Warn("report lease lost, cancel generation", zap.String("report_id", reportID), zap.Int("gen_version", genVersion))
+			cancel()
+			return
+		}
+	}
+}
+
+// RecoverExpiredReports 回收租约过期的报告：领取次数未耗尽时重新排队，否则置为失败
+func (s *reportService) RecoverExpiredReports(ctx context.Context, limit int) (int, error) {
+	if limit <= 0 {
+		return 0, nil
+	}
+	now := time.Now()
+	reports, err := s.reportRepo.ListExpiredLeases(ctx, now, limit)
+	if err != nil {
+		s.logger.Error("scan expired report leases failed", zap.Error(err))
+		return 0, err
+	}
+
+	recovered := 0
+	for _, report := range reports {
+		requeue := report.ClaimCount < s.maxClaims
+		var ok bool
+		if requeue {
+			ok, err = s.reportRepo.RequeueExpired(ctx, report.ReportID, report.GenVersion, now)
+		} else {
+			ok, err = s.reportRepo.FailExpired(ctx, report.ReportID, report.GenVersion, now, leaseExpiredFailedReason)
+		}
+		if err != nil {
+			s.logger.Error("recover expired report failed", zap.String("report_id", report.ReportID), zap.Int("gen_version", report.GenVersion), zap.Error(err))
+			continue
+		}
+		if !ok {
+			// 期间已续租或已完成
+			continue
+		}
+		recovered += 1
+		s.logger.Warn("report lease expired",
+			zap.String("report_id", report.ReportID),
+			zap.Int("gen_version", report.GenVersion),
+			zap.String("lease_owner", report.LeaseOwner),
+			zap.Int("claim_count", report.ClaimCount),
+			zap.Bool("requeue", requeue),
+		)
+
+		job, err := s.reportJobRepo.GetByReportVersion(ctx, report.ReportID, report.GenVersion)
+		if err != nil {
+			continue
+		}
+		status, reason := string(v1.ReportStatusQueued), leaseExpiredRequeueReason
+		if !requeue {
+			status, reason = string(v1.ReportStatusFailed), leaseExpiredFailedReason
+		}
+		detail := fmt.Sprintf("%s（lease_owner=%s，第 %d 次领取）", reason, report.LeaseOwner, report.ClaimCount)
+		if err := s.reportJobRepo.Finish(ctx, job, status, "", "", detail); err != nil {
+			s.logger.Warn("update report job failed", zap.String("report_job_id", job.ReportJobID), zap.Error(err))
+		}
+	}
+	return recovered, nil
+}
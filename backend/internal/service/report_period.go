@@ -0,0 +1,183 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 17:10:26
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 17:10:26
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"time"
+)
+
+const (
+	defaultSprintLength = 14
+	maxSprintLength     = 60
+	maxCustomDays       = 366 // 自定义周期最长一年
+	rollupMinDays       = 32  // 迭代、自定义周期超过一个月时按月汇总素材
+)
+
+// sprintConfig 迭代长度与锚点，锚点为空时迭代须从周一开始
+type sprintConfig struct {
+	length int
+	anchor *time.Time
+}
+
+func sprintLength(settings *model.UserSettings) int {
+	if settings == nil || settings.SprintLengthDays <= 0 {
+		return defaultSprintLength
+	}
+	return settings.SprintLengthDays
+}
+
+func sprintOf(settings *model.UserSettings) sprintConfig {
+	sprint := sprintConfig{length: sprintLength(settings)}
+	if settings != nil && settings.SprintAnchorDate != "" {
+		if anchor, err := time.Parse(reportDateLayout, settings.SprintAnchorDate); err == nil {
+			sprint.anchor = &anchor
+		}
+	}
+	return sprint
+}
+
+// normalizeSprint 长度未传时为默认值，锚点可为空
+func normalizeSprint(length int, anchor string) (int, string, error) {
+	if length == 0 {
+		length = defaultSprintLength
+	}
+	if length < 1 || length > maxSprintLength {
+		return 0, "", v1.ErrInvalidSprint
+	}
+	if anchor != "" {
+		t, err := time.Parse(reportDateLayout, anchor)
+		if err != nil {
+			return 0, "", v1.ErrInvalidSprint
+		}
+		anchor = t.Format(reportDateLayout)
+	}
+	return length, anchor, nil
+}
+
+func validateReportPeriod(periodType string) error {
+	switch v1.ReportPeriodType(periodType) {
+	case v1.ReportPeriodWeek, v1.ReportPeriodMonth, v1.ReportPeriodQuarter, v1.ReportPeriodHalfYear,
+		v1.ReportPeriodYear, v1.ReportPeriodSprint, v1.ReportPeriodCustom:
+		return nil
+	default:
+		return v1.ErrInvalidReportPeriod
+	}
+}
+
+// reportPeriods 全部报告周期，内置模板适用于所有周期
+func reportPeriods() []string {
+	return []string{
+		string(v1.ReportPeriodWeek), string(v1.ReportPeriodMonth), string(v1.ReportPeriodQuarter), string(v1.ReportPeriodHalfYear),
+		string(v1.ReportPeriodYear), string(v1.ReportPeriodSprint), string(v1.ReportPeriodCustom),
+	}
+}
+
+// periodRange 返回 date 所在周期的起止日期，自定义周期无固定边界
+func periodRange(periodType string, date time.Time, sprint sprintConfig) (time.Time, time.Time, error) {
+	switch v1.ReportPeriodType(periodType) {
+	case v1.ReportPeriodWeek:
+		start := date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 6), nil
+	case v1.ReportPeriodMonth:
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+		return start, start.AddDate(0, 1, -1), nil
+	case v1.ReportPeriodQuarter:
+		start := time.Date(date.Year(), (date.Month()-1)/3*3+1, 1, 0, 0, 0, 0, date.Location())
+		return start, start.AddDate(0, 3, -1), nil
+	case v1.ReportPeriodHalfYear:
+		start := time.Date(date.Year(), (date.Month()-1)/6*6+1, 1, 0, 0, 0, 0, date.Location())
+		return start, start.AddDate(0, 6, -1), nil
+	case v1.ReportPeriodYear:
+		start := time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, date.Location())
+		return start, start.AddDate(1, 0, -1), nil
+	case v1.ReportPeriodSprint:
+		var start time.Time
+		if sprint.anchor == nil {
+			start = date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+		} else {
+			offset := daysBetween(*sprint.anchor, date)
+			n := offset / sprint.length
+			if offset < 0 && offset%sprint.length != 0 {
+				n--
+			}
+			start = sprint.anchor.AddDate(0, 0, n*sprint.length)
+		}
+		return start, start.AddDate(0, 0, sprint.length-1), nil
+	default:
+		return time.Time{}, time.Time{}, v1.ErrInvalidReportPeriod
+	}
+}
+
+func validateDateRange(periodType string, start time.Time, end time.Time, sprint sprintConfig) error {
+	switch v1.ReportPeriodType(periodType) {
+	case v1.ReportPeriodCustom:
+		if end.Before(start) || daysBetween(start, end)+1 > maxCustomDays {
+			return v1.ErrInvalidDate
+		}
+		return nil
+	case v1.ReportPeriodSprint:
+		if sprint.anchor == nil && start.Weekday() != time.Monday {
+			return v1.ErrInvalidDate
+		}
+	}
+	wantStart, wantEnd, err := periodRange(periodType, start, sprint)
+	if err != nil {
+		return err
+	}
+	if !start.Equal(wantStart) || !end.Equal(wantEnd) {
+		return v1.ErrInvalidDate
+	}
+	return nil
+}
+
+// usesRollup 季度、半年、年度以及超过一个月的迭代/自定义周期按月汇总素材，优先使用已确认的月报、周报
+func usesRollup(periodType string, startDate string, endDate string) bool {
+	switch v1.ReportPeriodType(periodType) {
+	case v1.ReportPeriodQuarter, v1.ReportPeriodHalfYear, v1.ReportPeriodYear:
+		return true
+	case v1.ReportPeriodSprint, v1.ReportPeriodCustom:
+		start, err := time.Parse(reportDateLayout, startDate)
+		if err != nil {
+			return false
+		}
+		end, err := time.Parse(reportDateLayout, endDate)
+		if err != nil {
+			return false
+		}
+		return daysBetween(start, end)+1 >= rollupMinDays
+	default:
+		return false
+	}
+}
+
+func periodName(periodType string) string {
+	switch v1.ReportPeriodType(periodType) {
+	case v1.ReportPeriodWeek:
+		return "周报"
+	case v1.ReportPeriodMonth:
+		return "月报"
+	case v1.ReportPeriodQuarter:
+		return "季报"
+	case v1.ReportPeriodHalfYear:
+		return "半年报"
+	case v1.ReportPeriodYear:
+		return "年报"
+	case v1.ReportPeriodSprint:
+		return "迭代报告"
+	case v1.ReportPeriodCustom:
+		return "阶段报告"
+	default:
+		return "报告"
+	}
+}
+
+func daysBetween(start time.Time, end time.Time) int {
+	return int(end.Sub(start).Hours() / 24)
+}
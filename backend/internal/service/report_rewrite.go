@@ -0,0 +1,181 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 19:26:45
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 19:26:45
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const rewriteSystemPrompt = `你是工作报告编辑助手。请按修改要求改写给出的报告片段，只改写该片段，不要改动或重复片段之外的内容。
+要求：
+1. 保持 Markdown 格式与原有结构；片段以标题开头时保留标题行，除非修改要求涉及标题（如翻译）。
+2. 不要编造原文没有的事实；要求补充量化时只能使用片段或报告全文中已有的数字，缺少数据时用「[待补充]」占位。
+3. 仅输出改写后的片段原文，不要使用代码块包裹，不要输出任何解释性文字。`
+
+var rewriteHeadingRe = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+
+// rewriteSpan 被改写内容在正文中的字节区间与行号
+type rewriteSpan struct {
+	start     int
+	end       int
+	startLine int
+	endLine   int
+}
+
+// RewriteReport 生成章节或片段的改写建议，不修改报告；接受时由客户端把替换后的正文提交到 EditReport，
+// 以返回的 version/gen_version 作为条件，期间报告被修改则冲突
+func (s *reportService) RewriteReport(ctx context.Context, userId string, req *v1.RewriteReportReq) (*v1.RewriteReportResp, error) {
+	report, err := s.getReport(ctx, userId, req.ReportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != string(v1.ReportStatusReady) {
+		return nil, v1.ErrReportNotReady
+	}
+	if (req.Version != nil && *req.Version != report.Version) || (req.GenVersion != nil && *req.GenVersion != report.GenVersion) {
+		return nil, v1.ErrReportConflict
+	}
+	instruction := strings.TrimSpace(req.Instruction)
+	if instruction == "" {
+		return nil, v1.ErrBadRequest
+	}
+	span, err := locateRewriteSpan(report.Content, req.Heading, req.Text)
+	if err != nil {
+		return nil, err
+	}
+	original := report.Content[span.start:span.end]
+
+	if s.llmProvider == nil {
+		return nil, v1.ErrCallLLMFailed
+	}
+	userPrompt := fmt.Sprintf("报告：%s（%s 至 %s）\n修改要求：%s\n", report.Title, report.StartDate, report.EndDate, instruction)
+	// 全文仅作上下文，超出单次调用预算时只提交片段
+	reference := fmt.Sprintf("\n报告全文（仅供参考）：\n%s\n", report.Content)
+	if llm.EstimateTokens(userPrompt+reference+original) <= s.promptBudget(rewriteSystemPrompt) {
+		userPrompt += reference
+	}
+	userPrompt += fmt.Sprintf("\n需要改写的片段：\n%s\n", original)
+
+	completion, err := s.llmProvider.Complete(ctx, rewriteSystemPrompt, userPrompt)
+	if err != nil {
+		s.logger.Error("rewrite report failed", zap.String("report_id", report.ReportID), zap.Error(err))
+		return nil, v1.ErrCallLLMFailed
+	}
+	replacement := trimCodeFence(completion.Content)
+	if replacement == "" {
+		s.logger.Warn("rewrite report returned empty content", zap.String("report_id", report.ReportID), zap.String("model", completion.Model))
+		return nil, v1.ErrCallLLMFailed
+	}
+	// 保留片段原有的结尾换行，避免与后续内容粘连
+	if strings.HasSuffix(original, "\n") {
+		replacement += "\n"
+	}
+
+	s.logger.Info("rewrite report", zap.String("report_id", report.ReportID), zap.Int("version", report.Version),
+		zap.Int("start_line", span.startLine), zap.Int("end_line", span.endLine), zap.String("model", completion.Model))
+	return &v1.RewriteReportResp{
+		ReportID:    report.ReportID,
+		Version:     report.Version,
+		GenVersion:  report.GenVersion,
+		StartLine:   span.startLine,
+		EndLine:     span.endLine,
+		Original:    original,
+		Replacement: replacement,
+		Content:     report.Content[:span.start] + replacement + report.Content[span.end:],
+		LLMModel:    completion.Model,
+	}, nil
+}
+
+// locateRewriteSpan 按标题定位章节（至下一个同级或更高级标题为止），或按原文片段定位；均须唯一
+func locateRewriteSpan(content string, heading string, text string) (rewriteSpan, error) {
+	heading = strings.TrimSpace(heading)
+	if (heading == "") == (text == "") {
+		return rewriteSpan{}, v1.ErrInvalidRewriteTarget
+	}
+	if text != "" {
+		if strings.TrimSpace(text) == "" || strings.Count(content, text) != 1 {
+			return rewriteSpan{}, v1.ErrInvalidRewriteTarget
+		}
+		start := strings.Index(content, text)
+		startLine := strings.Count(content[:start], "\n") + 1
+		return rewriteSpan{
+			start:     start,
+			end:       start + len(text),
+			startLine: startLine,
+			endLine:   startLine + strings.Count(strings.TrimSuffix(text, "\n"), "\n"),
+		}, nil
+	}
+
+	lines := strings.Split(content, "\n")
+	levels := make([]int, len(lines))
+	match, level := -1, 0
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		m := rewriteHeadingRe.FindStringSubmatch(line)
+		if inFence || m == nil {
+			continue
+		}
+		levels[i] = len(m[1])
+		if strings.TrimSpace(line) != heading && m[2] != heading {
+			continue
+		}
+		if match >= 0 {
+			return rewriteSpan{}, v1.ErrInvalidRewriteTarget
+		}
+		match, level = i, len(m[1])
+	}
+	if match < 0 {
+		return rewriteSpan{}, v1.ErrInvalidRewriteTarget
+	}
+	end := len(lines)
+	for i := match + 1; i < len(lines); i++ {
+		if levels[i] > 0 && levels[i] <= level {
+			end = i
+			break
+		}
+	}
+	// 章节末尾的空行留在原处
+	for end > match+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+
+	start := 0
+	for _, line := range lines[:match] {
+		start += len(line) + 1
+	}
+	section := strings.Join(lines[match:end], "\n")
+	stop := start + len(section)
+	if stop < len(content) {
+		stop += 1 // 包含行尾换行
+	}
+	return rewriteSpan{start: start, end: stop, startLine: match + 1, endLine: end}, nil
+}
+
+// trimCodeFence 去除模型输出首尾的空白与包裹全文的代码块
+func trimCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") || !strings.HasSuffix(content, "```") || len(content) < 6 {
+		return content
+	}
+	body := strings.TrimSuffix(content, "```")
+	if i := strings.Index(body, "\n"); i >= 0 {
+		return strings.TrimSpace(body[i+1:])
+	}
+	return content
+}
@@ -0,0 +1,123 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 18:31:20
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 18:31:20
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	reportTimeLayout   = "15:04"
+	autoGenerateWindow = 24 * time.Hour // 错过计划时刻（如 task 进程重启）后仍补偿生成的时长
+	autoGenerateBatch  = 200
+	autoRetryInterval  = 30 * time.Minute // 失败的自动报告重新排队的最小间隔，避免每分钟重复调用模型
+)
+
+// AutoGenerateReports 为开启自动生成的用户创建到期的周报/月报。已确认、排队中、生成中或已生成的报告跳过，
+// 不会覆盖用户的编辑；生成失败的报告在补偿窗口内按 autoRetryInterval 重新排队；多实例同时触发时由报告唯一索引保证只创建一次
+func (s *reportService) AutoGenerateReports(ctx context.Context, now time.Time) (int, error) {
+	created := 0
+	cursor := ""
+	for {
+		settingsList, err := s.userSettingsRepo.ListAutoGenerate(ctx, cursor, autoGenerateBatch)
+		if err != nil {
+			s.logger.Error("list auto generate settings failed", zap.Error(err))
+			return created, err
+		}
+		for _, settings := range settingsList {
+			for _, req := range DueAutoReports(settings, now) {
+				select {
+				case <-ctx.Done():
+					return created, ctx.Err()
+				default:
+				}
+				if s.autoGenerate(ctx, settings.UserID, req, now) {
+					created += 1
+				}
+			}
+		}
+		if len(settingsList) < autoGenerateBatch {
+			return created, nil
+		}
+		cursor = settingsList[len(settingsList)-1].UserID
+	}
+}
+
+func (s *reportService) autoGenerate(ctx context.Context, userId string, req v1.GenReportReq, now time.Time) bool {
+	report, err := s.reportRepo.GetByUnique(ctx, userId, req.PeriodType, req.StartDate, req.EndDate)
+	if err == nil {
+		if report.Confirmed || report.Status != string(v1.ReportStatusFailed) || now.Sub(report.UpdatedAt) < autoRetryInterval {
+			return false
+		}
+	} else if !errors.Is(err, v1.ErrNotFound) {
+		s.logger.Error("query report failed", zap.String("user_id", userId), zap.Error(err))
+		return false
+	}
+	reportID, err := s.GenerateReport(ctx, userId, &req)
+	if err != nil {
+		s.logger.Warn("auto generate report failed", zap.String("user_id", userId), zap.String("period_type", req.PeriodType), zap.String("start_date", req.StartDate), zap.Error(err))
+		return false
+	}
+	s.logger.Info("auto generate report queued", zap.String("user_id", userId), zap.String("report_id", reportID), zap.String("period_type", req.PeriodType), zap.String("start_date", req.StartDate))
+	return true
+}
+
+// DueAutoReports 计算 now 时刻到期的自动报告：周报在周日、月报在当月最后一天的设定时刻（用户时区）到期，
+// 到期后 autoGenerateWindow 内都视为到期
+func DueAutoReports(settings *model.UserSettings, now time.Time) []v1.GenReportReq {
+	loc := loadLocation(settings.Timezone)
+	local := now.In(loc)
+	var reqs []v1.GenReportReq
+
+	if settings.AutoGenerateWeekly {
+		hour, minute := parseReportTime(settings.WeeklyReportTime)
+		sunday := time.Date(local.Year(), local.Month(), local.Day()-int(local.Weekday()), hour, minute, 0, 0, loc)
+		if sunday.After(local) {
+			sunday = sunday.AddDate(0, 0, -7)
+		}
+		if local.Sub(sunday) < autoGenerateWindow {
+			reqs = append(reqs, v1.GenReportReq{
+				PeriodType: string(v1.ReportPeriodWeek),
+				StartDate:  sunday.AddDate(0, 0, -6).Format(reportDateLayout),
+				EndDate:    sunday.Format(reportDateLayout),
+				Template:   string(v1.ReportTemplateFormal),
+			})
+		}
+	}
+
+	if settings.AutoGenerateMonthly {
+		hour, minute := parseReportTime(settings.MonthlyReportTime)
+		lastDay := time.Date(local.Year(), local.Month()+1, 0, hour, minute, 0, 0, loc)
+		if lastDay.After(local) {
+			lastDay = time.Date(local.Year(), local.Month(), 0, hour, minute, 0, 0, loc)
+		}
+		if local.Sub(lastDay) < autoGenerateWindow {
+			reqs = append(reqs, v1.GenReportReq{
+				PeriodType: string(v1.ReportPeriodMonth),
+				StartDate:  time.Date(lastDay.Year(), lastDay.Month(), 1, 0, 0, 0, 0, loc).Format(reportDateLayout),
+				EndDate:    lastDay.Format(reportDateLayout),
+				Template:   string(v1.ReportTemplateFormal),
+			})
+		}
+	}
+	return reqs
+}
+
+func parseReportTime(value string) (int, int) {
+	t, err := time.Parse(reportTimeLayout, value)
+	if err != nil {
+		t, _ = time.Parse(reportTimeLayout, defaultReportTime)
+	}
+	return t.Hour(), t.Minute()
+}
@@ -0,0 +1,182 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 16:20:12
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 16:20:12
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	partialSaveInterval = 2 * time.Second // 生成中正文落盘间隔
+	streamPollInterval  = time.Second     // 非本进程生成时轮询数据库的间隔
+)
+
+// liveReport 本进程正在生成的报告正文，供 SSE 订阅方逐段读取
+type liveReport struct {
+	mu      sync.Mutex
+	content []rune
+	changed chan struct{} // 每次追加内容或结束时关闭并替换，用于唤醒订阅方
+	done    bool
+}
+
+func newLiveReport() *liveReport {
+	return &liveReport{changed: make(chan struct{})}
+}
+
+func (l *liveReport) append(delta string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.content = append(l.content, []rune(delta)...)
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+func (l *liveReport) finish() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return
+	}
+	l.done = true
+	close(l.changed)
+}
+
+func (l *liveReport) text() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return string(l.content)
+}
+
+// since 返回 offset 之后的内容、新的 offset、下次变更通知及是否已结束
+func (l *liveReport) since(offset int) (string, int, <-chan struct{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if offset > len(l.content) {
+		offset = len(l.content)
+	}
+	return string(l.content[offset:]), len(l.content), l.changed, l.done
+}
+
+func liveKey(reportID string, genVersion int) string {
+	return fmt.Sprintf("%s#%d", reportID, genVersion)
+}
+
+func (s *reportService) startLive(reportID string, genVersion int) *liveReport {
+	live := newLiveReport()
+	s.lives.Store(liveKey(reportID, genVersion), live)
+	return live
+}
+
+// finishLive 在最终结果写库之后调用，订阅方随后从数据库读取终态
+func (s *reportService) finishLive(reportID string, genVersion int, live *liveReport) {
+	live.finish()
+	s.lives.CompareAndDelete(liveKey(reportID, genVersion), live)
+}
+
+func (s *reportService) getLive(reportID string, genVersion int) (*liveReport, bool) {
+	value, ok := s.lives.Load(liveKey(reportID, genVersion))
+	if !ok {
+		return nil, false
+	}
+	return value.(*liveReport), true
+}
+
+// StreamReport 推送报告生成过程，只跟随 worker 的生成、不自行领取（领取须经 ClaimNext 的用户并发上限）：
+// 本进程生成时逐段推送，其余情况轮询数据库中定期落盘的正文
+func (s *reportService) StreamReport(ctx context.Context, userId string, req *v1.StreamReportReq, emit func(event v1.ReportStreamEvent) error) error {
+	report, err := s.reportRepo.GetByID(ctx, userId, req.ReportID)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return v1.ErrReportNotExist
+		}
+		s.logger.Error("get report failed", zap.String("user_id", userId), zap.String("report_id", req.ReportID), zap.Error(err))
+		return v1.ErrGetReportsFailed
+	}
+
+	offset := req.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	return s.followReport(ctx, userId, report.ReportID, report.GenVersion, offset, emit)
+}
+
+func (s *reportService) followReport(ctx context.Context, userId string, reportID string, genVersion int, offset int, emit func(event v1.ReportStreamEvent) error) error {
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if live, ok := s.getLive(reportID, genVersion); ok {
+			text, next, changed, done := live.since(offset)
+			if text != "" {
+				offset = next
+				if err := emit(v1.ReportStreamEvent{Event: v1.ReportStreamDelta, Offset: offset, Data: v1.ReportStreamDeltaData{Content: text}}); err != nil {
+					return err
+				}
+			}
+			if !done {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-changed:
+					continue
+				}
+			}
+		}
+
+		report, err := s.reportRepo.GetByID(ctx, userId, reportID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, v1.ErrNotFound) {
+				return v1.ErrReportNotExist
+			}
+			s.logger.Error("get report failed", zap.String("user_id", userId), zap.String("report_id", reportID), zap.Error(err))
+			return v1.ErrGetReportsFailed
+		}
+		if report.GenVersion != genVersion {
+			// 期间被重新生成，旧内容作废
+			genVersion = report.GenVersion
+			offset = 0
+			if err := emit(v1.ReportStreamEvent{Event: v1.ReportStreamReset, Offset: offset, Data: v1.ReportStreamResetData{GenVersion: genVersion}}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// 落盘的正文可能落后于客户端已收到的内容（跨实例重连），此时等待追上即可
+		content := []rune(report.Content)
+		if len(content) > offset {
+			text := string(content[offset:])
+			offset = len(content)
+			if err := emit(v1.ReportStreamEvent{Event: v1.ReportStreamDelta, Offset: offset, Data: v1.ReportStreamDeltaData{Content: text}}); err != nil {
+				return err
+			}
+		}
+
+		switch v1.ReportStatus(report.Status) {
+		case v1.ReportStatusReady:
+			return emit(v1.ReportStreamEvent{Event: v1.ReportStreamDone, Offset: offset, Data: s.toReportItem(report)})
+		case v1.ReportStatusFailed:
+			return emit(v1.ReportStreamEvent{Event: v1.ReportStreamError, Offset: offset, Data: v1.ReportStreamErrorData{FailedReason: report.FailedReason}})
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,165 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 11:05:26
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 11:05:26
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/model"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// summarize 从正文提取结构化摘要，返回摘要 JSON 与渲染后的摘要文本；失败时均为空，由年报取用时补写。
+// compare 为 true 时同时提取「与上期对比」
+func (s *reportService) summarize(ctx context.Context, reportID string, content string, compare bool) (string, string) {
+	if s.llmProvider == nil || strings.TrimSpace(content) == "" {
+		return "", ""
+	}
+	summarizeFn := llm.Summarize
+	if compare {
+		summarizeFn = llm.SummarizeComparison
+	}
+	summary, completion, err := summarizeFn(ctx, s.llmProvider, content)
+	if err != nil {
+		s.logger.Warn("summarize report failed", zap.String("report_id", reportID), zap.Error(err))
+		return "", ""
+	}
+	b, err := json.Marshal(summary)
+	if err != nil {
+		return "", ""
+	}
+	s.logger.Info("summarize report", zap.String("report_id", reportID), zap.String("model", completion.Model),
+		zap.Int("total_tokens", completion.Usage.TotalTokens))
+	return string(b), summary.Text()
+}
+
+// ensureSummary 返回报告的结构化摘要；尚无摘要（旧报告或编辑后）时即时提取并补写，失败返回 nil
+func (s *reportService) ensureSummary(ctx context.Context, report *model.Report) *llm.ReportSummary {
+	if summary := decodeSummary(report.Summary); summary != nil {
+		return summary
+	}
+	raw, abstract := s.summarize(ctx, report.ReportID, report.Content, comparisonOf(report.Meta) != nil)
+	if raw == "" {
+		return nil
+	}
+	report.Summary, report.Abstract = raw, abstract
+	if _, err := s.reportRepo.UpdateSummary(ctx, report); err != nil {
+		s.logger.Warn("save report summary failed", zap.String("report_id", report.ReportID), zap.Error(err))
+	}
+	return decodeSummary(raw)
+}
+
+// summaryMaterial 合并多份报告的结构化摘要作为年报素材；无法提取摘要的报告退回使用正文
+func (s *reportService) summaryMaterial(ctx context.Context, reports []*model.Report) string {
+	summaries := make([]*llm.ReportSummary, 0, len(reports))
+	var fallback []string
+	for _, report := range reports {
+		if summary := s.ensureSummary(ctx, report); summary != nil {
+			summaries = append(summaries, summary)
+		} else if report.Content != "" {
+			fallback = append(fallback, report.Content)
+		}
+	}
+	var parts []string
+	if len(summaries) > 0 {
+		parts = append(parts, mergeSummaries(summaries).Text())
+	}
+	parts = append(parts, fallback...)
+	return strings.Join(parts, "\n")
+}
+
+// mergeSummaries 按时间顺序合并：各列表去重拼接，后续计划只保留最后一份（之前的计划已被后续进展覆盖）
+func mergeSummaries(summaries []*llm.ReportSummary) *llm.ReportSummary {
+	merged := &llm.ReportSummary{}
+	var overview []string
+	seen := make(map[string]bool)
+	appendUnique := func(dst []string, field string, items []string) []string {
+		for _, item := range items {
+			if key := field + "\x00" + item; !seen[key] {
+				seen[key] = true
+				dst = append(dst, item)
+			}
+		}
+		return dst
+	}
+	for _, summary := range summaries {
+		if summary.Summary != "" {
+			overview = append(overview, summary.Summary)
+		}
+		merged.KeyOutputs = appendUnique(merged.KeyOutputs, "key_outputs", summary.KeyOutputs)
+		merged.Risks = appendUnique(merged.Risks, "risks", summary.Risks)
+		merged.Projects = appendUnique(merged.Projects, "projects", summary.Projects)
+		for _, m := range summary.Metrics {
+			if key := "metrics\x00" + m.Name + "\x00" + m.Value; !seen[key] {
+				seen[key] = true
+				merged.Metrics = append(merged.Metrics, m)
+			}
+		}
+	}
+	merged.Summary = strings.Join(overview, "；")
+	merged.NextSteps = summaries[len(summaries)-1].NextSteps
+	return merged
+}
+
+// decodeSummary 解析已存储的结构化摘要，为空或格式不符时返回 nil
+func decodeSummary(raw string) *llm.ReportSummary {
+	if raw == "" {
+		return nil
+	}
+	summary, err := llm.ParseReportSummary(raw)
+	if err != nil {
+		return nil
+	}
+	return summary
+}
+
+// reportAbstract 开启静态加密时摘要文本不落库，由结构化摘要渲染
+func reportAbstract(report *model.Report) string {
+	if report.Abstract != "" {
+		return report.Abstract
+	}
+	if summary := decodeSummary(report.Summary); summary != nil {
+		return summary.Text()
+	}
+	return ""
+}
+
+func toReportSummaryItem(summary *llm.ReportSummary) *v1.ReportSummary {
+	if summary == nil {
+		return nil
+	}
+	metrics := make([]v1.ReportMetric, 0, len(summary.Metrics))
+	for _, m := range summary.Metrics {
+		metrics = append(metrics, v1.ReportMetric{Name: m.Name, Value: m.Value})
+	}
+	return &v1.ReportSummary{
+		Summary:    summary.Summary,
+		KeyOutputs: summary.KeyOutputs,
+		Metrics:    metrics,
+		Risks:      summary.Risks,
+		NextSteps:  summary.NextSteps,
+		Projects:   summary.Projects,
+		Comparison: toComparisonItem(summary.Comparison),
+	}
+}
+
+func toComparisonItem(comparison *llm.Comparison) *v1.ReportSummaryComparison {
+	if comparison == nil {
+		return nil
+	}
+	return &v1.ReportSummaryComparison{
+		Continued: comparison.Continued,
+		Started:   comparison.Started,
+		Dropped:   comparison.Dropped,
+		Trends:    comparison.Trends,
+	}
+}
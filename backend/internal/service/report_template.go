@@ -0,0 +1,353 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 10:21:36
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 10:21:36
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	ReportTemplatePrefix = "tplid_"
+
+	maxUserReportTemplates = 50
+	maxTemplateNameLen     = 64
+	maxTemplatePromptLen   = 8000
+	maxTemplateSections    = 20
+	maxTemplateSectionLen  = 64
+)
+
+// ReportTemplateService 报告模板管理；内置模板（formal/simple）对所有用户可见且只读
+type ReportTemplateService interface {
+	ListTemplates(ctx context.Context, userId string, req *v1.GetReportTemplatesReq) ([]v1.ReportTemplateItem, error)
+	GetTemplate(ctx context.Context, userId string, templateId string) (v1.ReportTemplateItem, error)
+	CreateTemplate(ctx context.Context, userId string, req *v1.SaveReportTemplateReq) (v1.ReportTemplateItem, error)
+	UpdateTemplate(ctx context.Context, userId string, templateId string, req *v1.SaveReportTemplateReq) (v1.ReportTemplateItem, error)
+	DeleteTemplate(ctx context.Context, userId string, templateId string) error
+}
+
+func NewReportTemplateService(service *Service, templateRepo repository.ReportTemplateRepository) ReportTemplateService {
+	return &reportTemplateService{
+		Service:      service,
+		templateRepo: templateRepo,
+		builtins:     BuiltinReportTemplates(llm.LoadPrompts(service.logger)),
+	}
+}
+
+type reportTemplateService struct {
+	*Service
+	templateRepo repository.ReportTemplateRepository
+	builtins     []*model.ReportTemplate
+}
+
+// BuiltinReportTemplates 由内嵌提示词生成内置模板，章节大纲取提示词中输出结构的二级标题（可选章节除外）
+func BuiltinReportTemplates(prompts llm.PromptSet) []*model.ReportTemplate {
+	periods := strings.Join(reportPeriods(), ",")
+	var tpls []*model.ReportTemplate
+	for _, b := range []struct {
+		id     string
+		name   string
+		prompt string
+	}{
+		{string(v1.ReportTemplateFormal), "正式版", prompts.Formal},
+		{string(v1.ReportTemplateSimple), "简洁版", prompts.Simple},
+	} {
+		if strings.TrimSpace(b.prompt) == "" {
+			continue
+		}
+		var sections []string
+		for _, line := range strings.Split(b.prompt, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "## ") && !strings.Contains(line, "可选") {
+				sections = append(sections, strings.TrimSpace(strings.TrimPrefix(line, "## ")))
+			}
+		}
+		raw, _ := json.Marshal(sections)
+		tpls = append(tpls, &model.ReportTemplate{
+			TemplateID:   b.id,
+			Name:         b.name,
+			PeriodTypes:  periods,
+			SystemPrompt: stripPromptHeader(b.prompt),
+			Sections:     raw,
+			Builtin:      true,
+		})
+	}
+	return tpls
+}
+
+// stripPromptHeader 去掉提示词文件开头的 HTML 注释（文件头信息）
+func stripPromptHeader(prompt string) string {
+	prompt = strings.TrimSpace(prompt)
+	if strings.HasPrefix(prompt, "<!--") {
+		if end := strings.Index(prompt, "-->"); end >= 0 {
+			prompt = prompt[end+len("-->"):]
+		}
+	}
+	return strings.TrimSpace(prompt)
+}
+
+func (s *reportTemplateService) ListTemplates(ctx context.Context, userId string, req *v1.GetReportTemplatesReq) ([]v1.ReportTemplateItem, error) {
+	tpls, err := s.templateRepo.List(ctx, userId)
+	if err != nil {
+		s.logger.Error("list report templates failed", zap.String("user_id", userId), zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	// 未执行迁移写入内置模板时使用内嵌版本
+	if len(tpls) == 0 || !tpls[0].Builtin {
+		tpls = append(append([]*model.ReportTemplate{}, s.builtins...), tpls...)
+	}
+	items := make([]v1.ReportTemplateItem, 0, len(tpls))
+	for _, tpl := range tpls {
+		if req.PeriodType != "" && !templateSupports(tpl, req.PeriodType) {
+			continue
+		}
+		items = append(items, toReportTemplateItem(tpl))
+	}
+	return items, nil
+}
+
+func (s *reportTemplateService) GetTemplate(ctx context.Context, userId string, templateId string) (v1.ReportTemplateItem, error) {
+	tpl, err := loadReportTemplate(ctx, s.templateRepo, s.builtins, userId, templateId)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return v1.ReportTemplateItem{}, v1.ErrReportTemplateNotExist
+		}
+		s.logger.Error("get report template failed", zap.String("user_id", userId), zap.String("template_id", templateId), zap.Error(err))
+		return v1.ReportTemplateItem{}, v1.ErrInternalServerError
+	}
+	return toReportTemplateItem(tpl), nil
+}
+
+func (s *reportTemplateService) CreateTemplate(ctx context.Context, userId string, req *v1.SaveReportTemplateReq) (v1.ReportTemplateItem, error) {
+	tpl, err := normalizeReportTemplate(req)
+	if err != nil {
+		return v1.ReportTemplateItem{}, err
+	}
+	count, err := s.templateRepo.CountByUser(ctx, userId)
+	if err != nil {
+		s.logger.Error("count report templates failed", zap.String("user_id", userId), zap.Error(err))
+		return v1.ReportTemplateItem{}, v1.ErrSaveReportTemplateFailed
+	}
+	if count >= maxUserReportTemplates {
+		return v1.ReportTemplateItem{}, v1.ErrReportTemplateLimit
+	}
+	if err := s.checkName(ctx, userId, tpl.Name, ""); err != nil {
+		return v1.ReportTemplateItem{}, err
+	}
+	id, err := s.sid.GenString()
+	if err != nil {
+		return v1.ReportTemplateItem{}, v1.ErrInternalServerError
+	}
+	tpl.TemplateID = ReportTemplatePrefix + id
+	tpl.UserID = userId
+	if err := s.templateRepo.Create(ctx, tpl); err != nil {
+		s.logger.Error("create report template failed", zap.String("user_id", userId), zap.Error(err))
+		return v1.ReportTemplateItem{}, v1.ErrSaveReportTemplateFailed
+	}
+	return toReportTemplateItem(tpl), nil
+}
+
+func (s *reportTemplateService) UpdateTemplate(ctx context.Context, userId string, templateId string, req *v1.SaveReportTemplateReq) (v1.ReportTemplateItem, error) {
+	existing, err := s.ownTemplate(ctx, userId, templateId)
+	if err != nil {
+		return v1.ReportTemplateItem{}, err
+	}
+	tpl, err := normalizeReportTemplate(req)
+	if err != nil {
+		return v1.ReportTemplateItem{}, err
+	}
+	if err := s.checkName(ctx, userId, tpl.Name, templateId); err != nil {
+		return v1.ReportTemplateItem{}, err
+	}
+	existing.Name = tpl.Name
+	existing.PeriodTypes = tpl.PeriodTypes
+	existing.SystemPrompt = tpl.SystemPrompt
+	existing.Sections = tpl.Sections
+	ok, err := s.templateRepo.Update(ctx, existing)
+	if err != nil {
+		s.logger.Error("update report template failed", zap.String("user_id", userId), zap.String("template_id", templateId), zap.Error(err))
+		return v1.ReportTemplateItem{}, v1.ErrSaveReportTemplateFailed
+	}
+	if !ok {
+		return v1.ReportTemplateItem{}, v1.ErrReportTemplateNotExist
+	}
+	return s.GetTemplate(ctx, userId, templateId)
+}
+
+// DeleteTemplate 已用该模板生成的报告保留模板 ID，重新生成时需另选模板，排队中的生成退回正式版
+func (s *reportTemplateService) DeleteTemplate(ctx context.Context, userId string, templateId string) error {
+	if _, err := s.ownTemplate(ctx, userId, templateId); err != nil {
+		return err
+	}
+	ok, err := s.templateRepo.Delete(ctx, userId, templateId)
+	if err != nil {
+		s.logger.Error("delete report template failed", zap.String("user_id", userId), zap.String("template_id", templateId), zap.Error(err))
+		return v1.ErrSaveReportTemplateFailed
+	}
+	if !ok {
+		return v1.ErrReportTemplateNotExist
+	}
+	return nil
+}
+
+// ownTemplate 获取可修改的模板，内置模板返回只读错误
+func (s *reportTemplateService) ownTemplate(ctx context.Context, userId string, templateId string) (*model.ReportTemplate, error) {
+	tpl, err := loadReportTemplate(ctx, s.templateRepo, s.builtins, userId, templateId)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return nil, v1.ErrReportTemplateNotExist
+		}
+		s.logger.Error("get report template failed", zap.String("user_id", userId), zap.String("template_id", templateId), zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if tpl.Builtin {
+		return nil, v1.ErrReportTemplateReadOnly
+	}
+	return tpl, nil
+}
+
+func (s *reportTemplateService) checkName(ctx context.Context, userId string, name string, excludeId string) error {
+	exists, err := s.templateRepo.NameExists(ctx, userId, name, excludeId)
+	if err != nil {
+		s.logger.Error("check report template name failed", zap.String("user_id", userId), zap.Error(err))
+		return v1.ErrSaveReportTemplateFailed
+	}
+	if exists {
+		return v1.ErrReportTemplateNameExists
+	}
+	return nil
+}
+
+// loadReportTemplate 查询用户可见的模板；内置模板未写入数据库时使用内嵌版本
+func loadReportTemplate(ctx context.Context, repo repository.ReportTemplateRepository, builtins []*model.ReportTemplate, userId string, templateId string) (*model.ReportTemplate, error) {
+	tpl, err := repo.GetByID(ctx, userId, templateId)
+	if err == nil || !errors.Is(err, v1.ErrNotFound) {
+		return tpl, err
+	}
+	for _, b := range builtins {
+		if b.TemplateID == templateId {
+			return b, nil
+		}
+	}
+	return nil, err
+}
+
+func normalizeReportTemplate(req *v1.SaveReportTemplateReq) (*model.ReportTemplate, error) {
+	name := strings.TrimSpace(req.Name)
+	prompt := strings.TrimSpace(req.SystemPrompt)
+	if name == "" || utf8.RuneCountInString(name) > maxTemplateNameLen || prompt == "" || utf8.RuneCountInString(prompt) > maxTemplatePromptLen {
+		return nil, v1.ErrInvalidReportTemplateArg
+	}
+
+	var periods []string
+	for _, p := range req.PeriodTypes {
+		p = strings.TrimSpace(p)
+		if validateReportPeriod(p) != nil {
+			return nil, v1.ErrInvalidReportTemplateArg
+		}
+		if !containsString(periods, p) {
+			periods = append(periods, p)
+		}
+	}
+	if len(periods) == 0 {
+		return nil, v1.ErrInvalidReportTemplateArg
+	}
+
+	sections := []string{}
+	for _, section := range req.Sections {
+		section = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(section), "#"))
+		if section == "" {
+			continue
+		}
+		if strings.ContainsAny(section, "\r\n") || utf8.RuneCountInString(section) > maxTemplateSectionLen || containsString(sections, section) {
+			return nil, v1.ErrInvalidReportTemplateArg
+		}
+		sections = append(sections, section)
+	}
+	if len(sections) > maxTemplateSections {
+		return nil, v1.ErrInvalidReportTemplateArg
+	}
+	raw, _ := json.Marshal(sections)
+
+	return &model.ReportTemplate{
+		Name:         name,
+		PeriodTypes:  strings.Join(periods, ","),
+		SystemPrompt: prompt,
+		Sections:     raw,
+	}, nil
+}
+
+func templateSections(tpl *model.ReportTemplate) []string {
+	sections := []string{}
+	if len(tpl.Sections) > 0 {
+		_ = json.Unmarshal(tpl.Sections, &sections)
+	}
+	return sections
+}
+
+func templateSupports(tpl *model.ReportTemplate, periodType string) bool {
+	return containsString(strings.Split(tpl.PeriodTypes, ","), periodType)
+}
+
+// templateSystemPrompt 提示词未写明全部章节时追加章节要求
+func templateSystemPrompt(tpl *model.ReportTemplate) string {
+	prompt := strings.TrimSpace(tpl.SystemPrompt)
+	sections := templateSections(tpl)
+	missing := false
+	for _, section := range sections {
+		if !strings.Contains(prompt, "## "+section) {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return prompt
+	}
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\n正文必须依次包含以下二级标题，不可增删或改名，某章节无内容时写“暂无”：\n")
+	for _, section := range sections {
+		b.WriteString("## " + section + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func toReportTemplateItem(tpl *model.ReportTemplate) v1.ReportTemplateItem {
+	item := v1.ReportTemplateItem{
+		TemplateID:   tpl.TemplateID,
+		Name:         tpl.Name,
+		PeriodTypes:  strings.Split(tpl.PeriodTypes, ","),
+		SystemPrompt: tpl.SystemPrompt,
+		Sections:     templateSections(tpl),
+		Builtin:      tpl.Builtin,
+	}
+	// 内嵌版本的内置模板没有时间戳
+	if !tpl.CreatedAt.IsZero() {
+		item.CreatedAt = formatTime(&tpl.CreatedAt)
+		item.UpdatedAt = formatTime(&tpl.UpdatedAt)
+	}
+	return item
+}
+
+func containsString(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
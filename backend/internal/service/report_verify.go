@@ -0,0 +1,297 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 15:06:37
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 15:06:37
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+)
+
+const reportMetaVerification = "verification"
+
+// 生成后核对方式，配置 report.verify.mode
+const (
+	verifyModeOff     = "off"
+	verifyModeLexical = "lexical" // 词法匹配：数字、日期、名称、@人员需在输入素材中出现
+	verifyModeLLM     = "llm"     // 再调用一次模型核对，失败时退回词法匹配
+)
+
+var (
+	claimDateRe   = regexp.MustCompile(`(\d{4})[-/.年](\d{1,2})[-/.月](\d{1,2})日?|(\d{1,2})月(\d{1,2})[日号]`)
+	claimTermRe   = regexp.MustCompile("[A-Za-z][A-Za-z0-9_.+#-]*[A-Za-z0-9+#]|「[^」]+」|“[^”]+”|《[^》]+》|`[^`]+`|@[\\p{Han}A-Za-z0-9_]+")
+	claimNumberRe = regexp.MustCompile(`\d+(?:\.\d+)?%?`)
+)
+
+var claimReasons = map[string]string{
+	llm.ClaimNumber: "输入记录中未找到该数字",
+	llm.ClaimDate:   "输入记录中未找到该日期",
+	llm.ClaimTerm:   "输入记录中未提及该名称",
+	llm.ClaimPerson: "输入记录中未提及该人员",
+}
+
+// reportVerification 存于 report.meta.verification
+type reportVerification struct {
+	Mode    string         `json:"mode"`
+	Checked int            `json:"checked"` // 核对的事实声明数
+	Flagged []flaggedClaim `json:"flagged"`
+}
+
+// flaggedClaim 以要点文本哈希与字符区间定位，不保存正文片段
+type flaggedClaim struct {
+	Hash  string `json:"hash"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Kind  string `json:"kind"`
+}
+
+// verifyReport 核对正文中的事实声明是否能在输入素材（提示词）中找到依据
+func (s *reportService) verifyReport(ctx context.Context, reportID string, content string, sources string) *reportVerification {
+	switch s.verifyMode {
+	case verifyModeOff:
+		return nil
+	case verifyModeLLM:
+		result, err := s.verifyWithModel(ctx, content, sources)
+		if err == nil {
+			return result
+		}
+		s.logger.Warn("verify report with llm failed, fallback to lexical", zap.String("report_id", reportID), zap.Error(err))
+	}
+	return verifyLexical(content, sources)
+}
+
+func (s *reportService) verifyWithModel(ctx context.Context, content string, sources string) (*reportVerification, error) {
+	if s.llmProvider == nil {
+		return nil, fmt.Errorf("llm client not initialized")
+	}
+	claims, _, err := llm.VerifyClaims(ctx, s.llmProvider, content, sources)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0)
+	for _, line := range strings.Split(content, "\n") {
+		if text := citationText(line); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	result := &reportVerification{Mode: verifyModeLLM, Checked: len(claims), Flagged: []flaggedClaim{}}
+	for _, claim := range claims {
+		if claim.Supported {
+			continue
+		}
+		// 模型给出的片段不在正文中时无法定位，忽略
+		for _, text := range lines {
+			if i := strings.Index(text, claim.Quote); i >= 0 {
+				start := utf8.RuneCountInString(text[:i])
+				result.Flagged = append(result.Flagged, flaggedClaim{
+					Hash: lineHash(text), Start: start, End: start + utf8.RuneCountInString(claim.Quote), Kind: claim.Kind,
+				})
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+type lexicalClaim struct {
+	start int // 字节区间
+	end   int
+	kind  string
+	value string // 规整后的比较值
+}
+
+func verifyLexical(content string, sources string) *reportVerification {
+	source := newClaimSource(sources)
+	result := &reportVerification{Mode: verifyModeLexical, Flagged: []flaggedClaim{}}
+	for _, line := range strings.Split(content, "\n") {
+		text := citationText(line)
+		if text == "" {
+			continue
+		}
+		for _, claim := range extractClaims(text) {
+			result.Checked += 1
+			if source.supports(claim) {
+				continue
+			}
+			start := utf8.RuneCountInString(text[:claim.start])
+			result.Flagged = append(result.Flagged, flaggedClaim{
+				Hash: lineHash(text), Start: start, End: start + utf8.RuneCountInString(text[claim.start:claim.end]), Kind: claim.kind,
+			})
+		}
+	}
+	return result
+}
+
+// extractClaims 依次抽取日期、名称与数字并按出现位置排序，已被日期或名称覆盖的数字不再单独核对；个位整数（序号、周数等）不核对
+func extractClaims(text string) []lexicalClaim {
+	var claims []lexicalClaim
+	taken := make([]bool, len(text))
+	take := func(start int, end int) bool {
+		for i := start; i < end; i++ {
+			if taken[i] {
+				return false
+			}
+		}
+		for i := start; i < end; i++ {
+			taken[i] = true
+		}
+		return true
+	}
+
+	for _, m := range claimDateRe.FindAllStringSubmatchIndex(text, -1) {
+		if take(m[0], m[1]) {
+			claims = append(claims, lexicalClaim{start: m[0], end: m[1], kind: llm.ClaimDate, value: dateValue(text, m)})
+		}
+	}
+	for _, m := range claimTermRe.FindAllStringIndex(text, -1) {
+		term := text[m[0]:m[1]]
+		kind := llm.ClaimTerm
+		if strings.HasPrefix(term, "@") {
+			kind = llm.ClaimPerson
+		}
+		if take(m[0], m[1]) {
+			claims = append(claims, lexicalClaim{start: m[0], end: m[1], kind: kind, value: termValue(term)})
+		}
+	}
+	for _, m := range claimNumberRe.FindAllStringIndex(text, -1) {
+		number := text[m[0]:m[1]]
+		if len(number) == 1 || !take(m[0], m[1]) {
+			continue
+		}
+		claims = append(claims, lexicalClaim{start: m[0], end: m[1], kind: llm.ClaimNumber, value: numberValue(number)})
+	}
+	sort.Slice(claims, func(i, j int) bool { return claims[i].start < claims[j].start })
+	return claims
+}
+
+// dateValue 规整为「月-日」，兼容 2024-03-04 与 3月4日 等写法
+func dateValue(text string, m []int) string {
+	month, day := m[4], m[6]
+	if m[2] < 0 {
+		month, day = m[8], m[10]
+	}
+	mm, _ := strconv.Atoi(text[month:m[indexEnd(m, month)]])
+	dd, _ := strconv.Atoi(text[day:m[indexEnd(m, day)]])
+	return fmt.Sprintf("%d-%d", mm, dd)
+}
+
+// indexEnd 子匹配起点对应的终点下标
+func indexEnd(m []int, start int) int {
+	for i := 2; i < len(m); i += 2 {
+		if m[i] == start {
+			return i + 1
+		}
+	}
+	return 1
+}
+
+func termValue(term string) string {
+	term = strings.TrimPrefix(term, "@")
+	term = strings.Trim(term, "「」“”《》`")
+	return strings.ToLower(strings.TrimSpace(term))
+}
+
+func numberValue(number string) string {
+	number = strings.TrimSuffix(number, "%")
+	if f, err := strconv.ParseFloat(number, 64); err == nil {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return number
+}
+
+// claimSource 输入素材中出现的日期、数字与文本
+type claimSource struct {
+	text    string
+	dates   map[string]bool
+	numbers map[string]bool
+}
+
+func newClaimSource(sources string) *claimSource {
+	source := &claimSource{
+		text:    strings.ToLower(sources),
+		dates:   make(map[string]bool),
+		numbers: make(map[string]bool),
+	}
+	for _, m := range claimDateRe.FindAllStringSubmatchIndex(sources, -1) {
+		source.dates[dateValue(sources, m)] = true
+	}
+	for _, number := range claimNumberRe.FindAllString(sources, -1) {
+		source.numbers[numberValue(number)] = true
+	}
+	return source
+}
+
+func (s *claimSource) supports(claim lexicalClaim) bool {
+	switch claim.kind {
+	case llm.ClaimDate:
+		return s.dates[claim.value]
+	case llm.ClaimNumber:
+		return s.numbers[claim.value]
+	default:
+		return claim.value == "" || strings.Contains(s.text, claim.value)
+	}
+}
+
+func withVerificationMeta(meta datatypes.JSONMap, verification *reportVerification) datatypes.JSONMap {
+	if meta == nil {
+		meta = make(datatypes.JSONMap)
+	}
+	if verification == nil {
+		delete(meta, reportMetaVerification)
+		return meta
+	}
+	meta[reportMetaVerification] = verification
+	return meta
+}
+
+// reportVerificationItem 按当前正文还原被标记的声明，所在要点已被编辑的标记不再返回
+func reportVerificationItem(content string, meta datatypes.JSONMap) *v1.ReportVerification {
+	raw, ok := meta[reportMetaVerification]
+	if !ok {
+		return nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var verification reportVerification
+	if err := json.Unmarshal(b, &verification); err != nil {
+		return nil
+	}
+
+	index := newLineIndex(content)
+	item := &v1.ReportVerification{Mode: verification.Mode, Checked: verification.Checked, Flagged: []v1.ReportClaim{}}
+	for _, flag := range verification.Flagged {
+		lines := index[flag.Hash]
+		if len(lines) == 0 {
+			continue
+		}
+		runes := []rune(lines[0].text)
+		if flag.Start < 0 || flag.End > len(runes) || flag.Start >= flag.End {
+			continue
+		}
+		item.Flagged = append(item.Flagged, v1.ReportClaim{
+			Line:    lines[0].line,
+			Claim:   string(runes[flag.Start:flag.End]),
+			Context: lines[0].text,
+			Kind:    flag.Kind,
+			Reason:  claimReasons[flag.Kind],
+		})
+	}
+	return item
+}
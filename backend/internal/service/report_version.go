@@ -0,0 +1,194 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 18:34:17
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 18:34:17
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"context"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// overwriteContent 以读取时的版本号为条件写入人工修改的正文，并追加一条历史版本，两步在同一事务内完成；
+// 写入后摘要失效、报告需重新确认，期间报告已被修改或重新生成时返回 ErrReportConflict
+func (s *reportService) overwriteContent(ctx context.Context, report *model.Report, content string, action string) error {
+	version := report.Version
+	updated := *report
+	updated.Content = content
+	// 编辑后摘要失效，年报取用时按新正文补写
+	updated.Abstract = ""
+	updated.Summary = ""
+	updated.Version = report.Version + 1
+	updated.Confirmed = false
+	updated.ConfirmedAt = nil
+
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		ok, err := s.reportRepo.UpdateContentIfVersion(ctx, &updated, version, report.GenVersion)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return v1.ErrReportConflict
+		}
+		return s.versionRepo.Create(ctx, &model.ReportVersion{
+			ReportID:   report.ReportID,
+			UserID:     report.UserID,
+			Author:     v1.ReportAuthorUser,
+			Action:     action,
+			Version:    updated.Version,
+			GenVersion: updated.GenVersion,
+			Template:   report.Template,
+			Content:    content,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	*report = updated
+	return nil
+}
+
+// legacyVersion 历史版本功能上线前的报告没有版本记录，重新生成清空正文前先补存当前正文
+func (s *reportService) legacyVersion(ctx context.Context, report *model.Report) (*model.ReportVersion, error) {
+	if report.Status != string(v1.ReportStatusReady) || report.Content == "" {
+		return nil, nil
+	}
+	latest, err := s.versionRepo.GetLatest(ctx, report.ReportID)
+	if err != nil && !errors.Is(err, v1.ErrNotFound) {
+		return nil, err
+	}
+	if latest != nil && latest.Version == report.Version && latest.GenVersion == report.GenVersion {
+		return nil, nil
+	}
+	version := &model.ReportVersion{
+		ReportID:   report.ReportID,
+		UserID:     report.UserID,
+		Author:     v1.ReportAuthorLLM,
+		Action:     v1.ReportVersionGenerate,
+		Version:    report.Version,
+		GenVersion: report.GenVersion,
+		LLMModel:   report.LLMModel,
+		Template:   report.Template,
+		Content:    report.Content,
+	}
+	if report.Version > 0 {
+		version.Author, version.Action, version.LLMModel = v1.ReportAuthorUser, v1.ReportVersionEdit, ""
+	}
+	return version, nil
+}
+
+func (s *reportService) getReport(ctx context.Context, userId string, reportID string) (*model.Report, error) {
+	report, err := s.reportRepo.GetByID(ctx, userId, reportID)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return nil, v1.ErrReportNotExist
+		}
+		s.logger.Error("get report failed", zap.String("user_id", userId), zap.String("report_id", reportID), zap.Error(err))
+		return nil, v1.ErrGetReportsFailed
+	}
+	return report, nil
+}
+
+func (s *reportService) ListVersions(ctx context.Context, userId string, reportID string) (*v1.ReportVersionsResp, error) {
+	report, err := s.getReport(ctx, userId, reportID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.versionRepo.ListByReport(ctx, report.ReportID)
+	if err != nil {
+		s.logger.Error("list report versions failed", zap.String("report_id", reportID), zap.Error(err))
+		return nil, v1.ErrGetReportVersionsFailed
+	}
+
+	resp := &v1.ReportVersionsResp{ReportID: report.ReportID, Versions: make([]v1.ReportVersionItem, 0, len(versions))}
+	for _, v := range versions {
+		if resp.Current == 0 && report.Status == string(v1.ReportStatusReady) && v.Version == report.Version && v.GenVersion == report.GenVersion {
+			resp.Current = v.Seq
+		}
+		resp.Versions = append(resp.Versions, v1.ReportVersionItem{
+			Seq:        v.Seq,
+			Author:     v.Author,
+			Action:     v.Action,
+			Version:    v.Version,
+			GenVersion: v.GenVersion,
+			LLMModel:   v.LLMModel,
+			Template:   v.Template,
+			CreatedAt:  formatTime(&v.CreatedAt),
+		})
+	}
+	return resp, nil
+}
+
+func (s *reportService) DiffVersions(ctx context.Context, userId string, req *v1.ReportVersionDiffReq) (*v1.ReportVersionDiffResp, error) {
+	report, err := s.getReport(ctx, userId, req.ReportID)
+	if err != nil {
+		return nil, err
+	}
+	to := req.To
+	if to <= 0 {
+		latest, err := s.versionRepo.GetLatest(ctx, report.ReportID)
+		if err != nil {
+			return nil, s.versionError(report.ReportID, err)
+		}
+		to = latest.Seq
+	}
+	from, err := s.versionRepo.GetBySeq(ctx, report.ReportID, req.From)
+	if err != nil {
+		return nil, s.versionError(report.ReportID, err)
+	}
+	target, err := s.versionRepo.GetBySeq(ctx, report.ReportID, to)
+	if err != nil {
+		return nil, s.versionError(report.ReportID, err)
+	}
+
+	resp := &v1.ReportVersionDiffResp{From: req.From, To: to, Diffs: DiffLines(from.Content, target.Content)}
+	for _, d := range resp.Diffs {
+		lines := strings.Count(strings.TrimSuffix(d.Text, "\n"), "\n") + 1
+		switch d.Op {
+		case v1.RecordDiffInsert:
+			resp.Added += lines
+		case v1.RecordDiffDelete:
+			resp.Removed += lines
+		}
+	}
+	return resp, nil
+}
+
+// RestoreVersion 以历史版本的正文作为一次人工编辑写回，生成新的历史版本；报告需重新确认
+func (s *reportService) RestoreVersion(ctx context.Context, userId string, reportID string, seq int) (v1.ReportItem, error) {
+	report, err := s.getReport(ctx, userId, reportID)
+	if err != nil {
+		return v1.ReportItem{}, err
+	}
+	if report.Status != string(v1.ReportStatusReady) {
+		return v1.ReportItem{}, v1.ErrReportNotReady
+	}
+	version, err := s.versionRepo.GetBySeq(ctx, report.ReportID, seq)
+	if err != nil {
+		return v1.ReportItem{}, s.versionError(report.ReportID, err)
+	}
+	if err := s.overwriteContent(ctx, report, version.Content, v1.ReportVersionRestore); err != nil {
+		if errors.Is(err, v1.ErrReportConflict) {
+			return v1.ReportItem{}, err
+		}
+		s.logger.Error("restore report version failed", zap.String("report_id", reportID), zap.Int("seq", seq), zap.Error(err))
+		return v1.ReportItem{}, v1.ErrUpdateReportFailed
+	}
+	return s.toReportItem(report), nil
+}
+
+func (s *reportService) versionError(reportID string, err error) error {
+	if errors.Is(err, v1.ErrNotFound) {
+		return v1.ErrReportVersionNotExist
+	}
+	s.logger.Error("get report version failed", zap.String("report_id", reportID), zap.Error(err))
+	return v1.ErrGetReportVersionsFailed
+}
@@ -0,0 +1,222 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 19:30:08
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 19:30:08
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/repository"
+	"context"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 50
+	maxSearchQueryLen     = 100 // 查询词最大字符数
+
+	snippetLen     = 120 // 摘录片段字符数
+	snippetLeading = 30  // 命中词之前保留的字符数
+	highlightOpen  = "<mark>"
+	highlightClose = "</mark>"
+)
+
+type SearchService interface {
+	Search(ctx context.Context, userId string, req *v1.SearchReq) (*v1.SearchResp, error)
+}
+
+func NewSearchService(
+	service *Service,
+	searchRepo repository.SearchRepository,
+) SearchService {
+	return &searchService{
+		Service:    service,
+		searchRepo: searchRepo,
+	}
+}
+
+type searchService struct {
+	*Service
+	searchRepo repository.SearchRepository
+}
+
+func (s *searchService) Search(ctx context.Context, userId string, req *v1.SearchReq) (*v1.SearchResp, error) {
+	query := strings.Join(strings.Fields(req.Q), " ")
+	if query == "" || utf8.RuneCountInString(query) > maxSearchQueryLen {
+		return nil, v1.ErrBadRequest
+	}
+
+	filter := &repository.SearchFilter{
+		UserID:     userId,
+		Query:      query,
+		StartDate:  req.StartDate,
+		EndDate:    req.EndDate,
+		PeriodType: req.PeriodType,
+	}
+	switch req.Type {
+	case "", v1.SearchTypeAll:
+	case v1.SearchTypeRecord, v1.SearchTypeReport:
+		filter.Types = []string{req.Type}
+	default:
+		return nil, v1.ErrInvalidSearchType
+	}
+	if req.PeriodType != "" {
+		if err := validateReportPeriod(req.PeriodType); err != nil {
+			return nil, err
+		}
+		// 周期类型只对报告有意义
+		if req.Type == v1.SearchTypeRecord {
+			return nil, v1.ErrInvalidSearchType
+		}
+		filter.Types = []string{v1.SearchTypeReport}
+	}
+	for _, date := range []string{req.StartDate, req.EndDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(reportDateLayout, date); err != nil {
+			return nil, v1.ErrInvalidDate
+		}
+	}
+	if req.StartDate != "" && req.EndDate != "" && req.StartDate > req.EndDate {
+		return nil, v1.ErrInvalidDate
+	}
+
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultSearchPageSize
+	}
+	if pageSize > maxSearchPageSize {
+		pageSize = maxSearchPageSize
+	}
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+
+	result, err := s.searchRepo.Search(ctx, filter)
+	if err != nil {
+		s.logger.Error("search failed", zap.String("user_id", userId), zap.String("q", query), zap.Error(err))
+		return nil, v1.ErrSearchFailed
+	}
+
+	terms := strings.Fields(query)
+	items := make([]v1.SearchItem, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		item := v1.SearchItem{
+			Type:  hit.DocType,
+			ID:    hit.DocID,
+			Score: hit.Score,
+		}
+		if hit.DocType == v1.SearchTypeRecord {
+			item.Date = hit.StartDate
+			item.Snippet = Snippet(hit.Content, terms)
+		} else {
+			item.PeriodType = hit.PeriodType
+			item.StartDate = hit.StartDate
+			item.EndDate = hit.EndDate
+			item.Title = hit.Title
+			item.Snippet = reportSnippet(hit, terms)
+		}
+		items = append(items, item)
+	}
+	if result.Partial {
+		s.logger.Warn("search sealed content truncated", zap.String("user_id", userId), zap.String("q", query))
+	}
+	return &v1.SearchResp{
+		Total:    result.Total,
+		Page:     page,
+		PageSize: pageSize,
+		Partial:  result.Partial,
+		Items:    items,
+	}, nil
+}
+
+// reportSnippet 优先从正文摘录，正文未直接包含查询词时（如仅标题或摘要命中）依次尝试摘要、标题
+func reportSnippet(hit *repository.SearchHit, terms []string) string {
+	for _, text := range []string{hit.Content, hit.Abstract, hit.Title} {
+		if firstMatch([]rune(strings.ToLower(text)), terms) >= 0 {
+			return Snippet(text, terms)
+		}
+	}
+	return Snippet(hit.Content, terms)
+}
+
+// Snippet 截取首个命中词附近的片段并高亮全部命中词；结果已做 HTML 转义，仅 <mark> 标签为原样输出。
+// 未找到命中词（分词结果与原文不一致）时返回开头部分
+func Snippet(text string, terms []string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	start := 0
+	if pos := firstMatch(lower, terms); pos > snippetLeading {
+		start = pos - snippetLeading
+	}
+	end := start + snippetLen
+	if end > len(runes) {
+		end = len(runes)
+		if end-snippetLen > 0 && end-snippetLen < start {
+			start = end - snippetLen
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		n := matchAt(lower, i, end, terms)
+		if n == 0 {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+		b.WriteString(highlightOpen)
+		b.WriteString(html.EscapeString(string(runes[i : i+n])))
+		b.WriteString(highlightClose)
+		i += n
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// firstMatch 返回任一查询词在 lower 中最早出现的位置（按字符计），未命中返回 -1
+func firstMatch(lower []rune, terms []string) int {
+	for i := range lower {
+		if matchAt(lower, i, len(lower), terms) > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchAt 返回 pos 处命中的最长查询词长度，不跨越 end
+func matchAt(lower []rune, pos int, end int, terms []string) int {
+	best := 0
+	for _, term := range terms {
+		t := []rune(strings.ToLower(term))
+		if len(t) <= best || pos+len(t) > end {
+			continue
+		}
+		if string(lower[pos:pos+len(t)]) == string(t) {
+			best = len(t)
+		}
+	}
+	return best
+}
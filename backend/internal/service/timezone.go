@@ -0,0 +1,59 @@
+/*
+ * @Description:
+ * @Author: zyq
+ * @Date: 2026-10-17 19:05:42
+ * @LastEditors: zyq
+ * @LastEditTime: 2026-10-17 19:05:42
+ */
+package service
+
+import (
+	v1 "backend/api/v1"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"time"
+	_ "time/tzdata" // 内置 IANA 时区库，不依赖部署环境的 zoneinfo
+)
+
+const defaultTimezone = "Asia/Shanghai"
+
+// validateTimezone 仅接受 IANA 时区名（如 Asia/Shanghai、America/Los_Angeles）
+func validateTimezone(timezone string) error {
+	if timezone == "" || timezone == "Local" {
+		return v1.ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return v1.ErrInvalidTimezone
+	}
+	return nil
+}
+
+func loadLocation(timezone string) *time.Location {
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc, _ = time.LoadLocation(defaultTimezone)
+	}
+	return loc
+}
+
+// userLocation 用户设置的时区，未设置时使用默认时区
+func userLocation(ctx context.Context, settingsRepo repository.UserSettingsRepository, userId string) (*time.Location, error) {
+	settings, err := settingsRepo.GetByID(ctx, userId)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return loadLocation(""), nil
+		}
+		return nil, err
+	}
+	return loadLocation(settings.Timezone), nil
+}
+
+// localToday 用户时区下的「今天」，以 UTC 零点表示，可直接与 time.Parse 解析出的日期比较
+func localToday(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -30,6 +30,7 @@ type UserService interface {
 	Login(ctx context.Context, req *v1.LoginReq) (v1.LoginRespData, error)
 	GetUserSettings(ctx context.Context, userId string) (*v1.UserSettings, error)
 	UpdateUserSettings(ctx context.Context, userId string, req *v1.UpdateUserSettingsReq) error
+	SetEncryptionKey(ctx context.Context, userId string, req *v1.SetEncryptionKeyReq) error
 	GetUserInfo(ctx context.Context, userId string) (*v1.UserInfo, error)
 }
 
@@ -147,13 +148,42 @@ func (s *userService) GetUserSettings(ctx context.Context, userId string) (*v1.U
 	}
 	return &v1.UserSettings{
 		UserID:              userSettings.UserID,
+		Timezone:            userSettings.Timezone,
 		ReportTemplateWeek:  userSettings.ReportTemplateWeek,
 		ReportTemplateMonth: userSettings.ReportTemplateMonth,
 		AutoGenerateWeekly:  userSettings.AutoGenerateWeekly,
 		WeeklyReportTime:    userSettings.WeeklyReportTime,
+		AutoGenerateMonthly: userSettings.AutoGenerateMonthly,
+		MonthlyReportTime:   userSettings.MonthlyReportTime,
+		SprintLengthDays:    sprintLength(userSettings),
+		SprintAnchorDate:    userSettings.SprintAnchorDate,
+		Encryption:          toEncryptionKey(userSettings),
 	}, nil
 }
 
+// SetEncryptionKey 保存端到端加密密钥的派生参数。更换密钥（key_id 变化）前客户端需用新密钥重新加密已有记录，
+// 记录上的 key_id 用于识别仍由旧密钥加密的内容
+func (s *userService) SetEncryptionKey(ctx context.Context, userId string, req *v1.SetEncryptionKeyReq) error {
+	if err := validateEncryptionKey(&req.EncryptionKey); err != nil {
+		return err
+	}
+	userSettings, err := s.userSettingsRepo.GetByID(ctx, userId)
+	if err != nil {
+		s.logger.Error("get user settings failed.", zap.String("user_id", userId))
+		return v1.ErrGetUserSettingsFailed
+	}
+	userSettings.EncKeyID = req.KeyID
+	userSettings.EncKDF = req.KDF
+	userSettings.EncKDFParams = req.KDFParams
+	userSettings.EncSalt = req.Salt
+	userSettings.EncVerifier = req.Verifier
+	if err := s.userSettingsRepo.Update(ctx, userSettings); err != nil {
+		s.logger.Error("update user settings failed.", zap.String("user_id", userId), zap.Error(err))
+		return v1.ErrUpdateUserSettingsFailed
+	}
+	return nil
+}
+
 func (s *userService) UpdateUserSettings(ctx context.Context, userId string, req *v1.UpdateUserSettingsReq) error {
 	userSettings, err := s.userSettingsRepo.GetByID(ctx, userId)
 	if err != nil {
@@ -165,10 +195,34 @@ func (s *userService) UpdateUserSettings(ctx context.Context, userId string, req
 		return v1.ErrUserIDNotMatch
 	}
 
+	// 未传时区时保持原设置
+	if req.Timezone != "" {
+		if err := validateTimezone(req.Timezone); err != nil {
+			return err
+		}
+		userSettings.Timezone = req.Timezone
+	}
+	weeklyTime, err := normalizeReportTime(req.WeeklyReportTime)
+	if err != nil {
+		return err
+	}
+	monthlyTime, err := normalizeReportTime(req.MonthlyReportTime)
+	if err != nil {
+		return err
+	}
+	sprintLength, sprintAnchor, err := normalizeSprint(req.SprintLengthDays, req.SprintAnchorDate)
+	if err != nil {
+		return err
+	}
+
 	userSettings.ReportTemplateWeek = req.ReportTemplateWeek
 	userSettings.ReportTemplateMonth = req.ReportTemplateMonth
 	userSettings.AutoGenerateWeekly = req.AutoGenerateWeekly
-	userSettings.WeeklyReportTime = req.WeeklyReportTime
+	userSettings.WeeklyReportTime = weeklyTime
+	userSettings.AutoGenerateMonthly = req.AutoGenerateMonthly
+	userSettings.MonthlyReportTime = monthlyTime
+	userSettings.SprintLengthDays = sprintLength
+	userSettings.SprintAnchorDate = sprintAnchor
 
 	if err = s.userSettingsRepo.Update(ctx, userSettings); err != nil {
 		s.logger.Error("update user settings failed.", zap.String("user_id", userId))
@@ -193,6 +247,20 @@ func (s *userService) GetUserInfo(ctx context.Context, userId string) (*v1.UserI
 	}, nil
 }
 
+const defaultReportTime = "22:00"
+
+// normalizeReportTime 校验自动生成时间（HH:MM），为空时使用默认值
+func normalizeReportTime(value string) (string, error) {
+	if value == "" {
+		return defaultReportTime, nil
+	}
+	t, err := time.Parse(reportTimeLayout, value)
+	if err != nil {
+		return "", v1.ErrInvalidReportTime
+	}
+	return t.Format(reportTimeLayout), nil
+}
+
 func formatTime(t *time.Time) string {
 	if t == nil {
 		return ""
@@ -0,0 +1,59 @@
+package task
+
+import (
+	"backend/internal/service"
+	"context"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRevisionKeep   = 50
+	defaultRevisionMaxAge = 90 * 24 * time.Hour
+)
+
+type RecordTask interface {
+	PruneRevisions(ctx context.Context) error
+}
+
+func NewRecordTask(
+	conf *viper.Viper,
+	task *Task,
+	recordService service.RecordService,
+) RecordTask {
+	// 未配置时使用默认值，显式配置为 0 表示该维度不限制
+	keep, maxAge := defaultRevisionKeep, defaultRevisionMaxAge
+	if conf.IsSet("record.revision.keep") {
+		keep = conf.GetInt("record.revision.keep")
+	}
+	if conf.IsSet("record.revision.max_age") {
+		maxAge = conf.GetDuration("record.revision.max_age")
+	}
+	return &recordTask{
+		recordService: recordService,
+		keep:          keep,
+		maxAge:        maxAge,
+		Task:          task,
+	}
+}
+
+type recordTask struct {
+	recordService service.RecordService
+	keep          int           // 每条记录保留的最新版本数
+	maxAge        time.Duration // 超过该时长的版本被清理
+	*Task
+}
+
+// PruneRevisions 按保留策略清理工作记录历史版本
+func (t *recordTask) PruneRevisions(ctx context.Context) error {
+	deleted, err := t.recordService.PruneRevisions(ctx, t.keep, t.maxAge)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		t.logger.Info("prune record revisions", zap.Int64("count", deleted), zap.Int("keep", t.keep), zap.Duration("max_age", t.maxAge))
+	}
+	return nil
+}
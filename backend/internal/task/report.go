@@ -1,54 +1,60 @@
 package task
 
 import (
-	v1 "backend/api/v1"
-	"backend/internal/repository"
 	"backend/internal/service"
 	"context"
+	"fmt"
 	"sync"
+	"time"
 
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
 type ReportTask interface {
 	Start(ctx context.Context) error
 	ProcessReportQueue(ctx context.Context) error
+	RecoverExpiredReports(ctx context.Context) error
+	AutoGenerateReports(ctx context.Context) error
 	Stop(ctx context.Context) error
 }
 
 func NewReportTask(
+	conf *viper.Viper,
 	task *Task,
-	reportRepo repository.ReportRepository,
 	reportService service.ReportService,
 ) ReportTask {
+	workerSize := conf.GetInt("report.worker.size")
+	if workerSize <= 0 {
+		workerSize = reportWorkerSize
+	}
 	return &reportTask{
-		reportRepo:    reportRepo,
 		reportService: reportService,
-		queue:         make(chan reportJob, reportQueueSize),
+		instanceID:    service.InstanceID(conf),
+		workerSize:    workerSize,
+		wake:          make(chan struct{}, workerSize),
 		stopChan:      make(chan struct{}),
 		Task:          task,
 	}
 }
 
+// reportTask 每个 worker 直接从数据库领取报告（见 ReportRepository.ClaimNext），
+// 多个 task 实例可同时运行，不依赖进程内队列
 type reportTask struct {
-	reportRepo    repository.ReportRepository
 	reportService service.ReportService
-	queue         chan reportJob
+	instanceID    string
+	workerSize    int
+	wake          chan struct{} // 定时扫描时唤醒空闲 worker
 	stopChan      chan struct{}
 	workerOnce    sync.Once //确保worker池只初始化一次,防止定时器每次触发都重复起worker
 	stopOnce      sync.Once //保证stopChain只会被close一次
 	*Task
 }
 
-type reportJob struct {
-	reportID   string
-	genVersion int
-}
-
 const (
-	reportQueueSize  = 100
-	reportWorkerSize = 5
-	reportScanLimit  = 20
+	reportWorkerSize   = 5
+	reportIdleInterval = 5 * time.Second
+	reportReapLimit    = 50
 )
 
 func (t *reportTask) Start(ctx context.Context) error {
@@ -64,6 +70,7 @@ func (t *reportTask) Stop(ctx context.Context) error {
 	return nil
 }
 
+// ProcessReportQueue 确保 worker 已启动并唤醒空闲 worker 立即领取
 func (t *reportTask) ProcessReportQueue(ctx context.Context) error {
 	select {
 	case <-t.stopChan:
@@ -73,50 +80,99 @@ func (t *reportTask) ProcessReportQueue(ctx context.Context) error {
 
 	t.startWorkers(ctx)
 
-	reports, err := t.reportRepo.ListByStatus(ctx, string(v1.ReportStatusQueued), reportScanLimit)
-	if err != nil {
-		t.logger.Error("scan queued reports failed", zap.Error(err))
-		return err
-	}
-
-	for _, report := range reports {
+	for i := 0; i < t.workerSize; i++ {
 		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-t.stopChan:
-			return nil
-		case t.queue <- reportJob{reportID: report.ReportID, genVersion: report.GenVersion}:
+		case t.wake <- struct{}{}:
 		default:
-			t.logger.Info("report queue full, skip this round")
 			return nil
 		}
 	}
 	return nil
 }
 
+// RecoverExpiredReports 回收处理进程崩溃后遗留在 processing 的报告
+func (t *reportTask) RecoverExpiredReports(ctx context.Context) error {
+	select {
+	case <-t.stopChan:
+		return nil
+	default:
+	}
+
+	recovered, err := t.reportService.RecoverExpiredReports(ctx, reportReapLimit)
+	if err != nil {
+		return err
+	}
+	if recovered > 0 {
+		t.logger.Info("recover expired reports", zap.Int("count", recovered))
+	}
+	return nil
+}
+
+// AutoGenerateReports 按用户设置的时区与时刻自动排队周报/月报
+func (t *reportTask) AutoGenerateReports(ctx context.Context) error {
+	select {
+	case <-t.stopChan:
+		return nil
+	default:
+	}
+
+	created, err := t.reportService.AutoGenerateReports(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if created > 0 {
+		t.logger.Info("auto generate reports", zap.Int("count", created))
+		// 新报告已排队，唤醒 worker 立即领取
+		return t.ProcessReportQueue(ctx)
+	}
+	return nil
+}
+
 func (t *reportTask) startWorkers(ctx context.Context) {
 	t.workerOnce.Do(func() {
-		for i := 0; i < reportWorkerSize; i++ {
-			go t.runWorker(ctx, i)
+		for i := 0; i < t.workerSize; i++ {
+			go t.runWorker(ctx, fmt.Sprintf("%s/w%d", t.instanceID, i))
 		}
 	})
 }
 
-func (t *reportTask) runWorker(ctx context.Context, index int) {
-	t.logger.Info("runWorker", zap.Int("worker_id", index))
+func (t *reportTask) runWorker(ctx context.Context, workerID string) {
+	t.logger.Info("runWorker", zap.String("worker_id", workerID))
+	idle := time.NewTimer(0)
+	defer idle.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-t.stopChan:
 			return
-		case job := <-t.queue:
-			t.logger.Info("report generate start", zap.Int("worker", index), zap.String("report_id", job.reportID))
-			if err := t.reportService.ProcessReport(ctx, job.reportID, job.genVersion); err != nil {
-				t.logger.Error("report generate failed", zap.Int("worker", index), zap.String("report_id", job.reportID), zap.Error(err))
-				continue
+		case <-t.wake:
+		case <-idle.C:
+		}
+
+		// 连续领取直到没有可处理的报告，再进入空闲等待
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.stopChan:
+				return
+			default:
+			}
+			processed, err := t.reportService.ProcessNextReport(ctx, workerID)
+			if err != nil {
+				t.logger.Error("report generate failed", zap.String("worker_id", workerID), zap.Error(err))
+			}
+			if !processed {
+				break
+			}
+		}
+		if !idle.Stop() {
+			select {
+			case <-idle.C:
+			default:
 			}
-			t.logger.Info("report generate done", zap.Int("worker", index), zap.String("report_id", job.reportID))
 		}
+		idle.Reset(reportIdleInterval)
 	}
 }
@@ -0,0 +1,166 @@
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+)
+
+const DataKeySize = 32
+
+var (
+	ErrNoMasterKey  = errors.New("keyring: no current master key")
+	ErrUnknownKey   = errors.New("keyring: unknown master key")
+	ErrInvalidInput = errors.New("keyring: ciphertext too short")
+)
+
+// MasterKey 主密钥，key 为 32 字节的 base64
+type MasterKey struct {
+	ID  string `mapstructure:"id" json:"id"`
+	Key string `mapstructure:"key" json:"key"`
+}
+
+// Keyring 持有主密钥，用于包装/解包用户数据密钥（信封加密）。
+// 主密钥来自配置 encryption.at_rest.master_keys，或由本地 KMS 替身 encryption.at_rest.kms_key_file 提供；
+// 接入云 KMS 时替换 Wrap/Unwrap 的实现即可，主密钥不必离开 KMS
+type Keyring struct {
+	enabled bool
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring 读取 encryption.at_rest 配置；未开启加密时仍加载已配置的主密钥，以便读取存量密文。配置错误时 panic
+func NewKeyring(conf *viper.Viper) *Keyring {
+	var items []MasterKey
+	if file := conf.GetString("encryption.at_rest.kms_key_file"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			panic(fmt.Sprintf("keyring: read kms key file: %s", err))
+		}
+		if err := json.Unmarshal(data, &items); err != nil {
+			panic(fmt.Sprintf("keyring: parse kms key file: %s", err))
+		}
+	} else if err := conf.UnmarshalKey("encryption.at_rest.master_keys", &items); err != nil {
+		panic(fmt.Sprintf("keyring: parse master_keys: %s", err))
+	}
+
+	k, err := New(conf.GetBool("encryption.at_rest.enabled"), conf.GetString("encryption.at_rest.master_key_id"), items)
+	if err != nil {
+		panic(err.Error())
+	}
+	return k
+}
+
+func New(enabled bool, current string, items []MasterKey) (*Keyring, error) {
+	k := &Keyring{enabled: enabled, current: current, keys: make(map[string]cipher.AEAD, len(items))}
+	for _, item := range items {
+		raw, err := base64.StdEncoding.DecodeString(item.Key)
+		if err != nil || len(raw) != DataKeySize {
+			return nil, fmt.Errorf("keyring: master key %q must be 32 bytes base64", item.ID)
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[item.ID] = aead
+	}
+	if enabled {
+		if _, ok := k.keys[current]; !ok {
+			return nil, ErrNoMasterKey
+		}
+	}
+	return k, nil
+}
+
+// Enabled 新写入是否加密；为 nil 时视为未开启
+func (k *Keyring) Enabled() bool {
+	return k != nil && k.enabled
+}
+
+func (k *Keyring) CurrentKeyID() string {
+	if k == nil {
+		return ""
+	}
+	return k.current
+}
+
+// Wrap 用当前主密钥包装数据密钥，返回主密钥 id
+func (k *Keyring) Wrap(dataKey []byte) (string, []byte, error) {
+	if k == nil {
+		return "", nil, ErrNoMasterKey
+	}
+	aead, ok := k.keys[k.current]
+	if !ok {
+		return "", nil, ErrNoMasterKey
+	}
+	wrapped, err := seal(aead, dataKey, []byte(k.current))
+	return k.current, wrapped, err
+}
+
+func (k *Keyring) Unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	if k == nil {
+		return nil, ErrUnknownKey
+	}
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	return open(aead, wrapped, []byte(keyID))
+}
+
+// NewDataKey 生成随机数据密钥
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, DataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Seal 以数据密钥 AES-256-GCM 加密，输出 nonce||ciphertext；aad 用于绑定归属（如 user_id），防止密文被挪用
+func Seal(dataKey []byte, plaintext []byte, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, plaintext, aad)
+}
+
+func Open(dataKey []byte, sealed []byte, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, sealed, aad)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext []byte, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrInvalidInput
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
@@ -0,0 +1,298 @@
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DOCX 品牌模板中的占位符，需在 Word 中作为连续文本输入（不要对占位符局部改格式）
+const (
+	PlaceholderTitle       = "{{title}}"
+	PlaceholderPeriod      = "{{period}}"
+	PlaceholderAuthor      = "{{author}}"
+	PlaceholderConfirmedAt = "{{confirmed_at}}"
+	PlaceholderContent     = "{{content}}"
+)
+
+var (
+	ErrInvalidDocxTemplate = errors.New("invalid docx template")
+
+	docxPartRe = regexp.MustCompile(`^word/(document|header\d*|footer\d*)\.xml$`)
+)
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+</Types>`
+
+const docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`
+
+const docxDocumentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// docxStyles 东亚字体用微软雅黑，未安装时 Word/WPS 会自动替换为系统中文字体
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Microsoft YaHei" w:cs="Calibri"/><w:sz w:val="21"/><w:szCs w:val="21"/><w:lang w:val="en-US" w:eastAsia="zh-CN"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="300" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="200"/></w:pPr><w:rPr><w:b/><w:sz w:val="40"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="200"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="28"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="160"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:b/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:b/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:b/></w:rPr></w:style>
+</w:styles>`
+
+const (
+	docxDocumentOpen  = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" + `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`
+	docxDocumentClose = `<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr></w:body></w:document>`
+	docxCodeFont      = `<w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:eastAsia="Microsoft YaHei"/>`
+	docxBorder        = `w:val="single" w:sz="4" w:space="0" w:color="D0D7DE"`
+)
+
+// DOCX 渲染 Word 文档；tmpl 为品牌模板 .docx 内容，为空时生成默认版式
+func DOCX(doc Document, tmpl []byte) ([]byte, error) {
+	body := docxBody(Parse(doc.Markdown))
+	if len(tmpl) > 0 {
+		return docxFromTemplate(doc, body, tmpl)
+	}
+
+	var b strings.Builder
+	b.WriteString(docxDocumentOpen)
+	b.WriteString(`<w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr>`)
+	b.WriteString(docxRun(Run{Text: doc.Title}, ""))
+	b.WriteString(`</w:p>`)
+	for _, field := range [][2]string{{"周期", doc.Period}, {"作者", doc.Author}, {"确认日期", doc.ConfirmedAt}} {
+		if field[1] == "" {
+			continue
+		}
+		b.WriteString(`<w:p><w:pPr><w:spacing w:after="0"/></w:pPr>`)
+		b.WriteString(docxRun(Run{Text: field[0] + "：", Bold: true}, `<w:color w:val="57606A"/>`))
+		b.WriteString(docxRun(Run{Text: field[1]}, `<w:color w:val="57606A"/>`))
+		b.WriteString(`</w:p>`)
+	}
+	b.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="12" w:space="1" w:color="D0D7DE"/></w:pBdr></w:pPr></w:p>`)
+	b.WriteString(body)
+	b.WriteString(docxDocumentClose)
+
+	return writeZip([][2]string{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRels},
+		{"word/_rels/document.xml.rels", docxDocumentRels},
+		{"word/document.xml", b.String()},
+		{"word/styles.xml", docxStyles},
+	})
+}
+
+// docxFromTemplate 替换正文与页眉页脚中的占位符，{{content}} 所在段落整体替换为报告正文，其余部件原样复制
+func docxFromTemplate(doc Document, body string, tmpl []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(tmpl), int64(len(tmpl)))
+	if err != nil {
+		return nil, ErrInvalidDocxTemplate
+	}
+	replacer := strings.NewReplacer(
+		PlaceholderTitle, xmlEscape(doc.Title),
+		PlaceholderPeriod, xmlEscape(doc.Period),
+		PlaceholderAuthor, xmlEscape(doc.Author),
+		PlaceholderConfirmedAt, xmlEscape(doc.ConfirmedAt),
+	)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	found := false
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, ErrInvalidDocxTemplate
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, ErrInvalidDocxTemplate
+		}
+		if docxPartRe.MatchString(f.Name) {
+			// 先替换头部字段再插入正文，正文里出现的占位符文本保持原样
+			text := replacer.Replace(string(data))
+			if f.Name == "word/document.xml" {
+				text, found = replaceContentParagraph(text, body)
+			}
+			data = []byte(text)
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: f.Modified})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrInvalidDocxTemplate
+	}
+	return buf.Bytes(), nil
+}
+
+func replaceContentParagraph(document string, body string) (string, bool) {
+	idx := strings.Index(document, PlaceholderContent)
+	if idx < 0 {
+		return document, false
+	}
+	start := max(strings.LastIndex(document[:idx], "<w:p>"), strings.LastIndex(document[:idx], "<w:p "))
+	end := strings.Index(document[idx:], "</w:p>")
+	if start < 0 || end < 0 {
+		return document, false
+	}
+	end = idx + end + len("</w:p>")
+	return document[:start] + body + document[end:], true
+}
+
+func docxBody(blocks []Block) string {
+	var b strings.Builder
+	for _, block := range blocks {
+		ppr := ""
+		if block.Quote {
+			ppr = `<w:pBdr><w:left ` + docxBorder + `/></w:pBdr><w:ind w:left="480"/>`
+		}
+		switch block.Kind {
+		case BlockHeading:
+			level := min(max(block.Level, 1), 6)
+			b.WriteString(`<w:p><w:pPr><w:pStyle w:val="Heading` + strconv.Itoa(level) + `"/>` + ppr + `</w:pPr>`)
+			for _, run := range block.Runs {
+				run.Bold = true
+				b.WriteString(docxRun(run, ""))
+			}
+			b.WriteString(`</w:p>`)
+		case BlockListItem:
+			indent := 360 * max(block.Level, 1)
+			if block.Quote {
+				indent += 480
+			}
+			b.WriteString(`<w:p><w:pPr><w:spacing w:after="60"/><w:ind w:left="` + strconv.Itoa(indent) + `" w:hanging="360"/></w:pPr>`)
+			b.WriteString(docxRun(Run{Text: block.Marker + " "}, ""))
+			b.WriteString(docxRuns(block.Runs))
+			b.WriteString(`</w:p>`)
+		case BlockCode:
+			b.WriteString(`<w:p><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/><w:spacing w:line="240" w:lineRule="auto"/>` + ppr + `</w:pPr>`)
+			b.WriteString(docxRun(Run{Text: block.Code, Code: true}, ""))
+			b.WriteString(`</w:p>`)
+		case BlockRule:
+			b.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom ` + docxBorder + `/></w:pBdr></w:pPr></w:p>`)
+		case BlockTable:
+			b.WriteString(docxTable(block))
+		default:
+			b.WriteString(`<w:p>`)
+			if ppr != "" {
+				b.WriteString(`<w:pPr>` + ppr + `</w:pPr>`)
+			}
+			b.WriteString(docxRuns(block.Runs))
+			b.WriteString(`</w:p>`)
+		}
+	}
+	return b.String()
+}
+
+func docxTable(block Block) string {
+	var b strings.Builder
+	b.WriteString(`<w:tbl><w:tblPr><w:tblW w:w="0" w:type="auto"/><w:tblBorders>`)
+	for _, side := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+		b.WriteString(`<w:` + side + ` ` + docxBorder + `/>`)
+	}
+	b.WriteString(`</w:tblBorders></w:tblPr>`)
+	for i, row := range block.Rows {
+		b.WriteString(`<w:tr>`)
+		for _, cell := range row {
+			b.WriteString(`<w:tc><w:p><w:pPr><w:spacing w:after="0"/></w:pPr>`)
+			b.WriteString(docxRun(Run{Text: cell, Bold: i == 0 && block.HasHead}, ""))
+			b.WriteString(`</w:p></w:tc>`)
+		}
+		b.WriteString(`</w:tr>`)
+	}
+	// 表格后紧跟空段落，避免与下一张表格粘连
+	b.WriteString(`</w:tbl><w:p/>`)
+	return b.String()
+}
+
+func docxRuns(runs []Run) string {
+	var b strings.Builder
+	for _, run := range runs {
+		b.WriteString(docxRun(run, ""))
+	}
+	return b.String()
+}
+
+func docxRun(run Run, extra string) string {
+	var b strings.Builder
+	b.WriteString(`<w:r><w:rPr>`)
+	if run.Code {
+		b.WriteString(docxCodeFont)
+	}
+	if run.Bold {
+		b.WriteString(`<w:b/>`)
+	}
+	if run.Italic {
+		b.WriteString(`<w:i/>`)
+	}
+	if run.Strike {
+		b.WriteString(`<w:strike/>`)
+	}
+	b.WriteString(extra)
+	if run.Code {
+		b.WriteString(`<w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/>`)
+	}
+	b.WriteString(`</w:rPr>`)
+	for i, line := range strings.Split(run.Text, "\n") {
+		if i > 0 {
+			b.WriteString(`<w:br/>`)
+		}
+		if line != "" {
+			b.WriteString(`<w:t xml:space="preserve">` + xmlEscape(line) + `</w:t>`)
+		}
+	}
+	b.WriteString(`</w:r>`)
+	return b.String()
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func writeZip(files [][2]string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.Create(file[0])
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(file[1])); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,107 @@
+package render
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+//go:embed default.html
+var defaultHTMLTemplate string
+
+// Document 导出文档：头部信息 + Markdown 正文
+type Document struct {
+	Title       string
+	Period      string
+	Author      string
+	ConfirmedAt string
+	Markdown    string
+}
+
+// htmlData 模板可用字段，Content 为已转义处理的正文 HTML
+type htmlData struct {
+	Title       string
+	Period      string
+	Author      string
+	ConfirmedAt string
+	Content     template.HTML
+}
+
+// MarkdownToHTML 正文中的原始 HTML 会被丢弃，避免模板注入；链接与图片只保留 http(s)/ftp/mailto 与站内路径，
+// javascript:、data: 等链接渲染为纯文本、图片替换为替代文字，外部链接加 nofollow/noreferrer
+func MarkdownToHTML(markdown string) string {
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+		Flags: blackfriday.CommonHTMLFlags | blackfriday.SkipHTML | blackfriday.Safelink | blackfriday.NofollowLinks | blackfriday.NoreferrerLinks,
+	})
+	root := blackfriday.New(blackfriday.WithExtensions(markdownExtensions)).Parse([]byte(markdown))
+	dropUnsafeImages(root)
+
+	var buf bytes.Buffer
+	renderer.RenderHeader(&buf, root)
+	root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return renderer.RenderNode(&buf, node, entering)
+	})
+	renderer.RenderFooter(&buf, root)
+	return buf.String()
+}
+
+// dropUnsafeImages Safelink 只作用于链接，地址不安全的图片替换为其替代文字
+func dropUnsafeImages(root *blackfriday.Node) {
+	var unsafe []*blackfriday.Node
+	root.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && node.Type == blackfriday.Image && !isSafeLink(node.LinkData.Destination) {
+			unsafe = append(unsafe, node)
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+	for _, node := range unsafe {
+		var alt bytes.Buffer
+		node.Walk(func(child *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+			if entering && child.Type == blackfriday.Text {
+				alt.Write(child.Literal)
+			}
+			return blackfriday.GoToNext
+		})
+		text := blackfriday.NewNode(blackfriday.Text)
+		text.Literal = alt.Bytes()
+		node.InsertBefore(text)
+		node.Unlink()
+	}
+}
+
+// isSafeLink 与 blackfriday.Safelink 的判定一致
+func isSafeLink(link []byte) bool {
+	lower := bytes.ToLower(bytes.TrimSpace(link))
+	for _, prefix := range []string{"/", "./", "../", "http://", "https://", "ftp://", "mailto:"} {
+		if bytes.HasPrefix(lower, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// HTML 渲染独立 HTML 文件；tmpl 为空时使用内置模板，自定义模板使用 html/template 语法
+func HTML(doc Document, tmpl string) ([]byte, error) {
+	if tmpl == "" {
+		tmpl = defaultHTMLTemplate
+	}
+	t, err := template.New("report").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, htmlData{
+		Title:       doc.Title,
+		Period:      doc.Period,
+		Author:      doc.Author,
+		ConfirmedAt: doc.ConfirmedAt,
+		Content:     template.HTML(MarkdownToHTML(doc.Markdown)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
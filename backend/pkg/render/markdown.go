@@ -0,0 +1,179 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+const markdownExtensions = blackfriday.CommonExtensions
+
+type BlockKind int
+
+const (
+	BlockParagraph BlockKind = iota
+	BlockHeading
+	BlockListItem
+	BlockCode
+	BlockTable
+	BlockRule
+)
+
+// Run 一段同样式的行内文本
+type Run struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Code   bool
+	Strike bool
+}
+
+// Block 供 DOCX/PDF 排版的块级元素，嵌套结构已展开；原始 HTML 丢弃
+type Block struct {
+	Kind    BlockKind
+	Level   int    // 标题级别，或列表嵌套深度（从 1 开始）
+	Marker  string // 列表项符号，如 "·"、"2."
+	Quote   bool   // 位于引用块内
+	Runs    []Run
+	Code    string
+	Rows    [][]string // 表格，首行为表头
+	HasHead bool
+}
+
+// Parse 把 Markdown 解析为块序列
+func Parse(markdown string) []Block {
+	root := blackfriday.New(blackfriday.WithExtensions(markdownExtensions)).Parse([]byte(markdown))
+	p := &blockParser{}
+	p.children(root, 0, false)
+	return p.blocks
+}
+
+type blockParser struct {
+	blocks []Block
+}
+
+func (p *blockParser) children(node *blackfriday.Node, depth int, quote bool) {
+	index := 0
+	for child := node.FirstChild; child != nil; child = child.Next {
+		switch child.Type {
+		case blackfriday.Paragraph:
+			p.blocks = append(p.blocks, Block{Kind: BlockParagraph, Quote: quote, Runs: inlineRuns(child)})
+		case blackfriday.Heading:
+			p.blocks = append(p.blocks, Block{Kind: BlockHeading, Level: child.HeadingData.Level, Quote: quote, Runs: inlineRuns(child)})
+		case blackfriday.BlockQuote:
+			p.children(child, depth, true)
+		case blackfriday.List:
+			p.children(child, depth+1, quote)
+		case blackfriday.Item:
+			index += 1
+			p.item(child, depth, index, quote)
+		case blackfriday.CodeBlock:
+			p.blocks = append(p.blocks, Block{Kind: BlockCode, Quote: quote, Code: strings.TrimRight(string(child.Literal), "\n")})
+		case blackfriday.HorizontalRule:
+			p.blocks = append(p.blocks, Block{Kind: BlockRule})
+		case blackfriday.Table:
+			p.blocks = append(p.blocks, tableBlock(child))
+		}
+	}
+}
+
+// item 列表项的首个段落带列表符号，其后的段落与嵌套列表顺延
+func (p *blockParser) item(node *blackfriday.Node, depth int, index int, quote bool) {
+	marker := "·"
+	if node.ListData.ListFlags&blackfriday.ListTypeOrdered != 0 {
+		marker = strconv.Itoa(index) + "."
+	}
+	first := true
+	for child := node.FirstChild; child != nil; child = child.Next {
+		if first && (child.Type == blackfriday.Paragraph || child.Type == blackfriday.Heading) {
+			p.blocks = append(p.blocks, Block{Kind: BlockListItem, Level: depth, Marker: marker, Quote: quote, Runs: inlineRuns(child)})
+			first = false
+			continue
+		}
+		wrapper := &blackfriday.Node{Type: blackfriday.Document}
+		wrapper.FirstChild = child
+		next := child.Next
+		child.Next = nil
+		p.children(wrapper, depth, quote)
+		child.Next = next
+	}
+	if first {
+		p.blocks = append(p.blocks, Block{Kind: BlockListItem, Level: depth, Marker: marker, Quote: quote})
+	}
+}
+
+func tableBlock(node *blackfriday.Node) Block {
+	block := Block{Kind: BlockTable}
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering {
+			return blackfriday.GoToNext
+		}
+		switch n.Type {
+		case blackfriday.TableRow:
+			block.Rows = append(block.Rows, nil)
+		case blackfriday.TableCell:
+			if n.TableCellData.IsHeader {
+				block.HasHead = true
+			}
+			row := len(block.Rows) - 1
+			block.Rows[row] = append(block.Rows[row], PlainText(inlineRuns(n)))
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+	return block
+}
+
+// inlineRuns 展开行内样式；链接保留文字，图片保留替代文本
+func inlineRuns(node *blackfriday.Node) []Run {
+	var runs []Run
+	var style Run
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if n == node {
+			return blackfriday.GoToNext
+		}
+		switch n.Type {
+		case blackfriday.Strong:
+			style.Bold = entering
+		case blackfriday.Emph:
+			style.Italic = entering
+		case blackfriday.Del:
+			style.Strike = entering
+		case blackfriday.Text:
+			if entering && len(n.Literal) > 0 {
+				// 段落内的单个换行是软换行，按空格处理
+				run := style
+				run.Text = strings.ReplaceAll(string(n.Literal), "\n", " ")
+				runs = append(runs, run)
+			}
+		case blackfriday.Code:
+			if entering {
+				run := style
+				run.Text = string(n.Literal)
+				run.Code = true
+				runs = append(runs, run)
+			}
+		case blackfriday.Softbreak:
+			if entering {
+				runs = append(runs, Run{Text: " "})
+			}
+		case blackfriday.Hardbreak:
+			if entering {
+				runs = append(runs, Run{Text: "\n"})
+			}
+		case blackfriday.HTMLSpan:
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+	return runs
+}
+
+func PlainText(runs []Run) string {
+	var b strings.Builder
+	for _, run := range runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
@@ -0,0 +1,405 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// PDF 使用 Adobe 预定义的简体中文字体 STSong-Light（UniGB-UCS2-H 编码），不嵌入字体文件，生成的文件只有几十 KB。
+// 中文能否显示取决于阅读器：Acrobat 需安装亚洲语言字体包，部分阅读器会替换字形或显示空白；
+// BMP 之外的字符（如 emoji、扩展 B 区汉字）无法编码，替换为 ?，替换数由 PDF 返回。粗体为描边模拟，斜体为错切模拟。
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 56.0
+	pdfBodySize   = 10.5
+	pdfCodeSize   = 9.0
+	pdfTableSize  = 9.5
+	pdfLineHeight = 1.7
+	pdfContentW   = pdfPageWidth - 2*pdfMargin
+	pdfFooterY    = pdfMargin / 2
+	pdfListIndent = 16.0
+	pdfQuoteShift = 14.0
+)
+
+var pdfHeadingSizes = [...]float64{18, 15, 13, 11.5, 11, 10.5}
+
+type pdfColor string
+
+const (
+	pdfBlack  pdfColor = "0 0 0"
+	pdfGray   pdfColor = "0.34 0.38 0.42"
+	pdfBorder pdfColor = "0.82 0.84 0.87"
+	pdfShade  pdfColor = "0.965 0.973 0.98"
+)
+
+// PDF 渲染 A4 PDF 文档，同时返回因字体不支持而替换为 ? 的字符数
+func PDF(doc Document) ([]byte, int, error) {
+	l := &pdfLayout{}
+	l.newPage()
+	l.header(doc)
+	for _, block := range Parse(doc.Markdown) {
+		l.block(block)
+	}
+	data, err := l.output(doc.Title)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, l.replaced, nil
+}
+
+type pdfLayout struct {
+	pages    []*bytes.Buffer
+	page     *bytes.Buffer
+	y        float64
+	replaced int // 替换为 ? 的字符数
+}
+
+func (l *pdfLayout) newPage() {
+	l.page = &bytes.Buffer{}
+	l.pages = append(l.pages, l.page)
+	l.y = pdfPageHeight - pdfMargin
+}
+
+// ensure 剩余高度不足时换页
+func (l *pdfLayout) ensure(h float64) {
+	if l.y-h < pdfMargin && l.y < pdfPageHeight-pdfMargin {
+		l.newPage()
+	}
+}
+
+func (l *pdfLayout) header(doc Document) {
+	l.paragraph([]Run{{Text: doc.Title, Bold: true}}, 20, pdfMargin, pdfContentW, pdfBlack, 1.5)
+	l.y -= 4
+	for _, field := range [][2]string{{"周期", doc.Period}, {"作者", doc.Author}, {"确认日期", doc.ConfirmedAt}} {
+		if field[1] != "" {
+			l.paragraph([]Run{{Text: field[0] + "：", Bold: true}, {Text: field[1]}}, 10, pdfMargin, pdfContentW, pdfGray, 1.6)
+		}
+	}
+	l.y -= 6
+	l.hline(l.y, pdfMargin, pdfMargin+pdfContentW, 1.5)
+	l.y -= 14
+}
+
+func (l *pdfLayout) block(block Block) {
+	x, width, color := pdfMargin, pdfContentW, pdfBlack
+	if block.Quote {
+		x, width, color = x+pdfQuoteShift, width-pdfQuoteShift, pdfGray
+	}
+	top := l.y
+	page := l.page
+	switch block.Kind {
+	case BlockHeading:
+		size := pdfHeadingSizes[min(max(block.Level, 1), len(pdfHeadingSizes))-1]
+		l.y -= size * 0.5
+		l.ensure(size * 3)
+		runs := make([]Run, len(block.Runs))
+		for i, run := range block.Runs {
+			run.Bold = true
+			runs[i] = run
+		}
+		l.paragraph(runs, size, x, width, color, 1.5)
+		l.y -= 2
+	case BlockListItem:
+		indent := pdfListIndent * float64(max(block.Level, 1))
+		// 先为首行留出空间，列表符号与首行同页
+		l.ensure(pdfBodySize * pdfLineHeight)
+		w := measure(block.Marker, pdfBodySize)
+		l.text(block.Marker, Run{}, pdfBodySize, x+indent-w-4, baseline(l.y, pdfBodySize, pdfLineHeight), color)
+		l.paragraph(block.Runs, pdfBodySize, x+indent, width-indent, color, pdfLineHeight)
+		l.y -= 1
+	case BlockCode:
+		lh := pdfCodeSize * 1.5
+		l.y -= 2
+		for _, line := range wrap([]Run{{Text: strings.ReplaceAll(block.Code, "\t", "    ")}}, pdfCodeSize, width-12) {
+			l.ensure(lh)
+			l.rect(x, l.y-lh, width, lh, pdfShade)
+			l.line(line, pdfCodeSize, x+6, baseline(l.y, pdfCodeSize, 1.5), color)
+			l.y -= lh
+		}
+		l.y -= 8
+	case BlockRule:
+		l.ensure(16)
+		l.hline(l.y-8, pdfMargin, pdfMargin+pdfContentW, 0.8)
+		l.y -= 16
+	case BlockTable:
+		l.table(block, x, width)
+		l.y -= 8
+	default:
+		l.paragraph(block.Runs, pdfBodySize, x, width, color, pdfLineHeight)
+		l.y -= 4
+	}
+	if block.Quote && l.page == page {
+		l.rect(pdfMargin, l.y, 3, top-l.y, pdfBorder)
+	}
+}
+
+// paragraph 按宽度折行输出，y 游标下移
+func (l *pdfLayout) paragraph(runs []Run, size float64, x float64, width float64, color pdfColor, lineHeight float64) {
+	lh := size * lineHeight
+	for _, line := range wrap(runs, size, width) {
+		l.ensure(lh)
+		l.line(line, size, x, baseline(l.y, size, lineHeight), color)
+		l.y -= lh
+	}
+}
+
+func (l *pdfLayout) table(block Block, x float64, width float64) {
+	cols := 0
+	for _, row := range block.Rows {
+		cols = max(cols, len(row))
+	}
+	if cols == 0 {
+		return
+	}
+	colW := width / float64(cols)
+	lh := pdfTableSize * 1.5
+	for i, row := range block.Rows {
+		head := i == 0 && block.HasHead
+		cells := make([][][]Run, cols)
+		lines := 1
+		for c := 0; c < cols; c++ {
+			text := ""
+			if c < len(row) {
+				text = row[c]
+			}
+			cells[c] = wrap([]Run{{Text: text, Bold: head}}, pdfTableSize, colW-8)
+			lines = max(lines, len(cells[c]))
+		}
+		h := float64(lines)*lh + 6
+		l.ensure(h)
+		if head {
+			l.rect(x, l.y-h, width, h, pdfShade)
+		}
+		for c, cell := range cells {
+			cx := x + float64(c)*colW
+			for n, line := range cell {
+				l.line(line, pdfTableSize, cx+4, baseline(l.y-3-float64(n)*lh, pdfTableSize, 1.5), pdfBlack)
+			}
+			fmt.Fprintf(l.page, "q %s RG 0.6 w %.2f %.2f %.2f %.2f re S Q\n", pdfBorder, cx, l.y-h, colW, h)
+		}
+		l.y -= h
+	}
+}
+
+func (l *pdfLayout) line(line []Run, size float64, x float64, y float64, color pdfColor) {
+	for _, run := range line {
+		w := measure(run.Text, size)
+		if run.Code {
+			l.rect(x, y-size*0.28, w, size*1.2, pdfShade)
+		}
+		l.text(run.Text, run, size, x, y, color)
+		if run.Strike {
+			fmt.Fprintf(l.page, "q %s RG %.2f w %.2f %.2f m %.2f %.2f l S Q\n", color, size*0.06, x, y+size*0.3, x+w, y+size*0.3)
+		}
+		x += w
+	}
+}
+
+func (l *pdfLayout) text(text string, style Run, size float64, x float64, y float64, color pdfColor) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	skew := 0.0
+	if style.Italic {
+		skew = 0.21
+	}
+	mode := "0 Tr"
+	if style.Bold {
+		mode = fmt.Sprintf("2 Tr %.2f w", size*0.035)
+	}
+	fmt.Fprintf(l.page, "q %s rg %s RG BT /F1 %.2f Tf %s 1 0 %.2f 1 %.2f %.2f Tm <%s> Tj ET Q\n",
+		color, color, size, mode, skew, x, y, l.hex(text))
+}
+
+func (l *pdfLayout) rect(x float64, y float64, w float64, h float64, color pdfColor) {
+	fmt.Fprintf(l.page, "q %s rg %.2f %.2f %.2f %.2f re f Q\n", color, x, y, w, h)
+}
+
+func (l *pdfLayout) hline(y float64, x1 float64, x2 float64, width float64) {
+	fmt.Fprintf(l.page, "q %s RG %.2f w %.2f %.2f m %.2f %.2f l S Q\n", pdfBorder, width, x1, y, x2, y)
+}
+
+// output 写出 PDF 对象、交叉引用表与页码
+func (l *pdfLayout) output(title string) ([]byte, error) {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	const firstPage = 7
+	kids := make([]string, len(l.pages))
+	for i := range l.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(l.pages)))
+	obj("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>")
+	obj("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light /CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 4 >> /FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>")
+	obj("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] /ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	obj(fmt.Sprintf("<< /Title <FEFF%s> /Producer (thinking-calendar) >>", utf16Hex(title)))
+
+	for i, page := range l.pages {
+		footer := fmt.Sprintf("%d / %d", i+1, len(l.pages))
+		fmt.Fprintf(page, "q %s rg BT /F1 9 Tf %.2f %.2f Td <%s> Tj ET Q\n",
+			pdfGray, (pdfPageWidth-measure(footer, 9))/2, pdfFooterY, ucs2Hex(footer))
+
+		var stream bytes.Buffer
+		zw := zlib.NewWriter(&stream)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, firstPage+2*i+1))
+		obj(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes(), nil
+}
+
+// wrap 贪心折行：英文单词尽量不拆开，中文逐字可断，\n 强制换行
+func wrap(runs []Run, size float64, width float64) [][]Run {
+	var lines [][]Run
+	var line []Run
+	lineW := 0.0
+	flush := func() {
+		lines = append(lines, line)
+		line, lineW = nil, 0
+	}
+	add := func(style Run, atom string) {
+		if n := len(line); n > 0 && sameStyle(line[n-1], style) {
+			line[n-1].Text += atom
+		} else {
+			run := style
+			run.Text = atom
+			line = append(line, run)
+		}
+		lineW += measure(atom, size)
+	}
+	place := func(style Run, atom string) {
+		w := measure(atom, size)
+		if lineW+w > width && lineW > 0 {
+			flush()
+			if atom == " " {
+				return
+			}
+		}
+		if w <= width {
+			add(style, atom)
+			return
+		}
+		// 超长单词（如 URL）逐字符断开
+		for _, r := range atom {
+			if rw := measure(string(r), size); lineW+rw > width && lineW > 0 {
+				flush()
+			}
+			add(style, string(r))
+		}
+	}
+
+	for _, run := range runs {
+		for i, segment := range strings.Split(run.Text, "\n") {
+			if i > 0 {
+				flush()
+			}
+			word := ""
+			for _, r := range segment {
+				switch {
+				case r < utf8.RuneSelf && r > ' ':
+					word += string(r)
+					continue
+				case word != "":
+					place(run, word)
+					word = ""
+				}
+				if r == '\t' || r == ' ' {
+					place(run, " ")
+				} else if r >= ' ' {
+					place(run, string(r))
+				}
+			}
+			if word != "" {
+				place(run, word)
+			}
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
+
+func sameStyle(a Run, b Run) bool {
+	return a.Bold == b.Bold && a.Italic == b.Italic && a.Code == b.Code && a.Strike == b.Strike
+}
+
+// measure 与字体 W 数组一致：ASCII 半角，其余全角
+func measure(text string, size float64) float64 {
+	w := 0.0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			w += 0.5
+		} else {
+			w += 1
+		}
+	}
+	return w * size
+}
+
+func baseline(top float64, size float64, lineHeight float64) float64 {
+	return top - size*lineHeight/2 - size*0.35
+}
+
+// hex 正文编码并统计被替换的字符
+func (l *pdfLayout) hex(text string) string {
+	l.replaced += unsupportedRunes(text)
+	return ucs2Hex(text)
+}
+
+// ucs2Hex UCS-2 大端十六进制；BMP 之外的字符（如 emoji）字体不支持，替换为 ?
+func ucs2Hex(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r > 0xFFFF {
+			r = '?'
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	return b.String()
+}
+
+func unsupportedRunes(text string) int {
+	n := 0
+	for _, r := range text {
+		if r > 0xFFFF {
+			n++
+		}
+	}
+	return n
+}
+
+// utf16Hex 文档信息字典中的文本不经过字体，可用代理对完整保留
+func utf16Hex(text string) string {
+	var b strings.Builder
+	for _, u := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package llm_test
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/llm"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWindow(t *testing.T) {
+	conf := viper.New()
+	conf.Set("llm.api_key", "test-key")
+	conf.Set("llm.openai.base_url", "http://127.0.0.1")
+	conf.Set("llm.openai.model", "qwen3-max")
+	openaiClient, err := llm.NewOpenAIClient(conf)
+	assert.NoError(t, err)
+	assert.Equal(t, 262144, llm.ContextWindow(openaiClient))
+
+	conf.Set("llm.ollama.model", "qwen2.5:7b")
+	conf.Set("llm.ollama.context_window", 32768)
+	ollamaClient, err := llm.NewOllamaClient(conf)
+	assert.NoError(t, err)
+	assert.Equal(t, 32768, llm.ContextWindow(ollamaClient))
+
+	// 调用链取最小窗口；未声明窗口的供应商按默认值计
+	chain := llm.NewChain([]llm.Provider{openaiClient, ollamaClient}, llm.RetryPolicy{}, 5, time.Minute)
+	assert.Equal(t, 32768, llm.ContextWindow(chain))
+	assert.Equal(t, llm.DefaultContextWindow, llm.ContextWindow(&fakeProvider{name: "fake"}))
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, llm.EstimateTokens(""))
+	assert.Equal(t, 4, llm.EstimateTokens("完成联调"))
+	assert.Equal(t, 3, llm.EstimateTokens("deploy v2.1"))
+	assert.Equal(t, 6, llm.EstimateTokens("完成 API 联调"))
+}
@@ -0,0 +1,197 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/internal/llm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProvider struct {
+	name  string
+	errs  []error
+	calls int
+}
+
+func (p *fakeProvider) Name() string  { return p.name }
+func (p *fakeProvider) Model() string { return p.name + "-model" }
+
+func (p *fakeProvider) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*llm.Completion, error) {
+	p.calls++
+	if p.calls <= len(p.errs) && p.errs[p.calls-1] != nil {
+		return nil, p.errs[p.calls-1]
+	}
+	return &llm.Completion{Content: "# " + p.name, Model: p.Model()}, nil
+}
+
+var fastRetry = llm.RetryPolicy{
+	MaxAttempts:    3,
+	AttemptTimeout: time.Second,
+	BaseBackoff:    time.Millisecond,
+	MaxBackoff:     2 * time.Millisecond,
+}
+
+func TestChain_RetryThenSucceed(t *testing.T) {
+	primary := &fakeProvider{name: "primary", errs: []error{
+		&llm.StatusError{StatusCode: http.StatusTooManyRequests},
+		&llm.StatusError{StatusCode: http.StatusBadGateway},
+	}}
+	chain := llm.NewChain([]llm.Provider{primary}, fastRetry, 5, time.Minute)
+
+	completion, err := chain.Complete(context.Background(), "system", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "primary-model", completion.Model)
+	assert.Len(t, completion.Attempts, 3)
+	assert.Equal(t, 3, primary.calls)
+}
+
+func TestChain_FallbackOnNonRetryable(t *testing.T) {
+	primary := &fakeProvider{name: "primary", errs: []error{&llm.StatusError{StatusCode: http.StatusUnauthorized}}}
+	backup := &fakeProvider{name: "backup"}
+	chain := llm.NewChain([]llm.Provider{primary, backup}, fastRetry, 5, time.Minute)
+
+	completion, err := chain.Complete(context.Background(), "system", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "backup-model", completion.Model)
+	assert.Equal(t, 1, primary.calls)
+	assert.Len(t, completion.Attempts, 2)
+}
+
+func TestChain_BreakerSkipsFailingProvider(t *testing.T) {
+	down := errors.New("boom")
+	primary := &fakeProvider{name: "primary", errs: []error{down, down, down, down}}
+	backup := &fakeProvider{name: "backup"}
+	chain := llm.NewChain([]llm.Provider{primary, backup}, fastRetry, 2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		_, err := chain.Complete(context.Background(), "system", "user")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, primary.calls)
+
+	completion, err := chain.Complete(context.Background(), "system", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, primary.calls)
+	assert.True(t, completion.Attempts[0].Skipped)
+}
+
+// probeProvider 首次调用立即失败，之后的调用阻塞到 release 关闭后以 502 失败
+type probeProvider struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *probeProvider) Name() string  { return "primary" }
+func (p *probeProvider) Model() string { return "primary-model" }
+
+func (p *probeProvider) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*llm.Completion, error) {
+	if p.calls.Add(1) > 1 {
+		close(p.started)
+		<-p.release
+	}
+	return nil, &llm.StatusError{StatusCode: http.StatusBadGateway}
+}
+
+func TestChain_BreakerHalfOpenAdmitsSingleProbe(t *testing.T) {
+	primary := &probeProvider{started: make(chan struct{}), release: make(chan struct{})}
+	chain := llm.NewChain([]llm.Provider{primary}, fastRetry, 1, 10*time.Millisecond)
+
+	_, err := chain.Complete(context.Background(), "system", "user")
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, primary.calls.Load())
+	time.Sleep(20 * time.Millisecond)
+
+	// 冷却结束后并发请求：只有一个试探请求到达供应商，其余直接跳过
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := chain.Complete(context.Background(), "system", "user")
+		var chainErr *llm.ChainError
+		if assert.True(t, errors.As(err, &chainErr)) {
+			// 试探失败后熔断重新打开，不再重试
+			assert.Len(t, chainErr.Attempts, 1)
+		}
+	}()
+	<-primary.started
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := chain.Complete(context.Background(), "system", "user")
+			var chainErr *llm.ChainError
+			if assert.True(t, errors.As(err, &chainErr)) && assert.Len(t, chainErr.Attempts, 1) {
+				assert.True(t, chainErr.Attempts[0].Skipped)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(primary.release)
+	wg.Wait()
+	assert.EqualValues(t, 2, primary.calls.Load())
+
+	_, err = chain.Complete(context.Background(), "system", "user")
+	assert.Error(t, err)
+	assert.EqualValues(t, 2, primary.calls.Load())
+}
+
+// hangProvider 在 hang 为 true 时阻塞到调用方取消
+type hangProvider struct {
+	hang  bool
+	calls int
+}
+
+func (p *hangProvider) Name() string  { return "primary" }
+func (p *hangProvider) Model() string { return "primary-model" }
+
+func (p *hangProvider) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*llm.Completion, error) {
+	p.calls++
+	if p.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &llm.Completion{Content: "ok", Model: p.Model()}, nil
+}
+
+func TestChain_CancelledCallsDoNotOpenBreaker(t *testing.T) {
+	primary := &hangProvider{hang: true}
+	chain := llm.NewChain([]llm.Provider{primary}, fastRetry, 2, time.Minute)
+
+	// 客户端断开不计入供应商失败
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(5*time.Millisecond, cancel)
+		_, err := chain.Complete(ctx, "system", "user")
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+	assert.Equal(t, 5, primary.calls)
+
+	primary.hang = false
+	completion, err := chain.Complete(context.Background(), "system", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", completion.Content)
+	assert.Equal(t, 6, primary.calls)
+}
+
+func TestChain_AllFailed(t *testing.T) {
+	primary := &fakeProvider{name: "primary", errs: []error{
+		&llm.StatusError{StatusCode: http.StatusTooManyRequests},
+		&llm.StatusError{StatusCode: http.StatusTooManyRequests},
+		&llm.StatusError{StatusCode: http.StatusTooManyRequests},
+	}}
+	chain := llm.NewChain([]llm.Provider{primary}, fastRetry, 5, time.Minute)
+
+	_, err := chain.Complete(context.Background(), "system", "user")
+	var chainErr *llm.ChainError
+	assert.True(t, errors.As(err, &chainErr))
+	assert.Len(t, chainErr.Attempts, 3)
+	assert.Equal(t, "生成失败：模型 primary-model 限流(429)，共尝试 3 次", chainErr.Reason())
+}
@@ -0,0 +1,97 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/llm"
+	"backend/pkg/log"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogger() *log.Logger {
+	conf := viper.New()
+	conf.Set("log.mode", "console")
+	conf.Set("log.encoding", "console")
+	conf.Set("log.log_level", "error")
+	return log.NewLog(conf)
+}
+
+func TestProviders_Registered(t *testing.T) {
+	assert.Equal(t, []string{"anthropic", "echo", "ollama", "openai"}, llm.Providers())
+}
+
+func TestNewProvider_Echo(t *testing.T) {
+	conf := viper.New()
+	conf.Set("llm.provider", "echo")
+
+	p := llm.NewProvider(conf, newTestLogger())
+	assert.Equal(t, "echo", p.Name())
+
+	content, abstract, err := llm.GenerateReport(context.Background(), p, "system", "标题：测试周报\n记录列表：\n- 日期：2025-12-01")
+	assert.NoError(t, err)
+	assert.Contains(t, content, "标题：测试周报")
+	assert.Equal(t, "标题：测试周报\n关键产出：日期：2025-12-01", abstract)
+}
+
+func TestNewProvider_MissingKeyDoesNotPanic(t *testing.T) {
+	conf := viper.New()
+	conf.Set("llm.provider", "openai")
+	conf.Set("llm.openai.base_url", "http://127.0.0.1:1")
+
+	p := llm.NewProvider(conf, newTestLogger())
+	assert.NotNil(t, p)
+
+	_, _, err := llm.GenerateReport(context.Background(), p, "system", "user")
+	assert.Error(t, err)
+}
+
+func TestOllamaClient_Complete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, false, body["stream"])
+		assert.Equal(t, map[string]any{"num_ctx": float64(llm.DefaultContextWindow)}, body["options"])
+		_, _ = w.Write([]byte(`{"model":"qwen2.5:7b","message":{"role":"assistant","content":"# 周报\n完成联调"},"done":true,"prompt_eval_count":12,"eval_count":8}`))
+	}))
+	defer srv.Close()
+
+	conf := viper.New()
+	conf.Set("llm.ollama.base_url", srv.URL)
+	conf.Set("llm.ollama.model", "qwen2.5:7b")
+	client, err := llm.NewOllamaClient(conf)
+	assert.NoError(t, err)
+
+	completion, err := client.Complete(context.Background(), "system", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "# 周报\n完成联调", completion.Content)
+	assert.Equal(t, 20, completion.Usage.TotalTokens)
+}
+
+func TestAnthropicClient_Complete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		_, _ = w.Write([]byte(`{"model":"claude-test","content":[{"type":"text","text":"# 月报"}],"usage":{"input_tokens":5,"output_tokens":3}}`))
+	}))
+	defer srv.Close()
+
+	conf := viper.New()
+	conf.Set("llm.api_key", "test-key")
+	conf.Set("llm.anthropic.base_url", srv.URL)
+	conf.Set("llm.anthropic.model", "claude-test")
+	client, err := llm.NewAnthropicClient(conf)
+	assert.NoError(t, err)
+
+	completion, err := client.Complete(context.Background(), "system", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "# 月报", completion.Content)
+	assert.Equal(t, "claude-test", completion.Model)
+	assert.Equal(t, 8, completion.Usage.TotalTokens)
+}
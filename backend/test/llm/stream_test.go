@@ -0,0 +1,76 @@
+package llm_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/llm"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// brokenStreamer 输出部分内容后中断
+type brokenStreamer struct {
+	fakeProvider
+}
+
+func (p *brokenStreamer) Stream(ctx context.Context, systemPrompt string, userPrompt string, onDelta func(delta string)) (*llm.Completion, error) {
+	p.calls++
+	onDelta("# 半截")
+	return nil, &llm.StatusError{StatusCode: http.StatusBadGateway}
+}
+
+func TestStream_FallbackToComplete(t *testing.T) {
+	p := &fakeProvider{name: "plain"}
+
+	var deltas []string
+	completion, err := llm.Stream(context.Background(), p, "system", "user", func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"# plain"}, deltas)
+	assert.Equal(t, "# plain", completion.Content)
+}
+
+func TestOllamaClient_Stream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"model":"qwen2.5:7b","message":{"role":"assistant","content":"# 周报\n"},"done":false}` + "\n"))
+		_, _ = w.Write([]byte(`{"model":"qwen2.5:7b","message":{"role":"assistant","content":"完成联调"},"done":false}` + "\n"))
+		_, _ = w.Write([]byte(`{"model":"qwen2.5:7b","message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":12,"eval_count":8}` + "\n"))
+	}))
+	defer srv.Close()
+
+	conf := viper.New()
+	conf.Set("llm.ollama.base_url", srv.URL)
+	conf.Set("llm.ollama.model", "qwen2.5:7b")
+	client, err := llm.NewOllamaClient(conf)
+	assert.NoError(t, err)
+
+	var deltas []string
+	completion, err := client.Stream(context.Background(), "system", "user", func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"# 周报\n", "完成联调"}, deltas)
+	assert.Equal(t, "# 周报\n完成联调", completion.Content)
+	assert.Equal(t, 20, completion.Usage.TotalTokens)
+}
+
+func TestChain_StreamNoRetryAfterEmit(t *testing.T) {
+	primary := &brokenStreamer{fakeProvider{name: "primary"}}
+	backup := &fakeProvider{name: "backup"}
+	chain := llm.NewChain([]llm.Provider{primary, backup}, fastRetry, 5, 0)
+
+	var builder strings.Builder
+	_, err := chain.Stream(context.Background(), "system", "user", func(delta string) {
+		builder.WriteString(delta)
+	})
+	assert.Error(t, err)
+	assert.Equal(t, "# 半截", builder.String())
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, 0, backup.calls)
+}
@@ -0,0 +1,114 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/llm"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type plainProvider struct {
+	content      string
+	systemPrompt string
+}
+
+func (p *plainProvider) Name() string  { return "plain" }
+func (p *plainProvider) Model() string { return "plain-model" }
+
+func (p *plainProvider) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*llm.Completion, error) {
+	p.systemPrompt = systemPrompt
+	return &llm.Completion{Content: p.content, Model: p.Model()}, nil
+}
+
+func TestParseReportSummary(t *testing.T) {
+	summary, err := llm.ParseReportSummary(`{"summary":" 完成重构 ","key_outputs":["迁移","迁移"," "],"metrics":[{"name":"延迟","value":"-30%"},{"name":"","value":"1"}],"risks":[],"next_steps":["灰度"],"projects":["支付"]}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "完成重构", summary.Summary)
+	assert.Equal(t, []string{"迁移"}, summary.KeyOutputs)
+	assert.Equal(t, []llm.Metric{{Name: "延迟", Value: "-30%"}}, summary.Metrics)
+	assert.Equal(t, "完成重构\n关键产出：迁移\n关键数据：延迟 -30%\n后续计划：灰度\n涉及项目：支付", summary.Text())
+
+	_, err = llm.ParseReportSummary(`{"summary":"","key_outputs":[],"metrics":[],"risks":[],"next_steps":[],"projects":[]}`)
+	assert.ErrorIs(t, err, llm.ErrEmptySummary)
+	_, err = llm.ParseReportSummary(`{"summary":["not a string"]}`)
+	assert.Error(t, err)
+	_, err = llm.ParseReportSummary("# 周报")
+	assert.Error(t, err)
+}
+
+func TestSummarize_FallbackToPrompt(t *testing.T) {
+	p := &plainProvider{content: "```json\n{\"summary\":\"完成联调\",\"key_outputs\":[\"登录接口\"],\"metrics\":[],\"risks\":[],\"next_steps\":[],\"projects\":[]}\n```"}
+
+	summary, completion, err := llm.Summarize(context.Background(), p, "# 周报\n- 登录接口")
+	assert.NoError(t, err)
+	assert.Equal(t, "完成联调", summary.Summary)
+	assert.Equal(t, "plain-model", completion.Model)
+	assert.Contains(t, p.systemPrompt, `"required":["summary","key_outputs","metrics","risks","next_steps","projects"]`)
+}
+
+func TestSummarizeComparison(t *testing.T) {
+	p := &plainProvider{content: `{"summary":"完成联调","key_outputs":[],"metrics":[],"risks":[],"next_steps":[],"projects":[],` +
+		`"comparison":{"continued":["支付网关联调"],"started":["对账服务"],"dropped":[],"trends":["线上故障减少"]}}`}
+
+	summary, _, err := llm.SummarizeComparison(context.Background(), p, "# 月报\n## 与上期对比")
+	assert.NoError(t, err)
+	assert.Equal(t, &llm.Comparison{Continued: []string{"支付网关联调"}, Started: []string{"对账服务"}, Dropped: []string{}, Trends: []string{"线上故障减少"}}, summary.Comparison)
+	assert.Equal(t, "完成联调\n较上期持续推进：支付网关联调\n较上期新增：对账服务\n变化趋势：线上故障减少", summary.Text())
+	assert.Contains(t, p.systemPrompt, `"required":["summary","key_outputs","metrics","risks","next_steps","projects","comparison"]`)
+
+	// 各项均为空时不返回对比
+	summary, err = llm.ParseReportSummary(`{"summary":"完成联调","key_outputs":[],"metrics":[],"risks":[],"next_steps":[],"projects":[],"comparison":{"continued":[],"started":[" "],"dropped":[],"trends":[]}}`)
+	assert.NoError(t, err)
+	assert.Nil(t, summary.Comparison)
+}
+
+func TestAnthropicClient_CompleteJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]any{"type": "tool", "name": "report_summary"}, body["tool_choice"])
+		_, _ = w.Write([]byte(`{"model":"claude-test","content":[{"type":"tool_use","name":"report_summary","input":{"summary":"完成联调","key_outputs":[],"metrics":[],"risks":["排期紧张"],"next_steps":[],"projects":[]}}],"usage":{"input_tokens":5,"output_tokens":3}}`))
+	}))
+	defer srv.Close()
+
+	conf := viper.New()
+	conf.Set("llm.api_key", "test-key")
+	conf.Set("llm.anthropic.base_url", srv.URL)
+	conf.Set("llm.anthropic.model", "claude-test")
+	client, err := llm.NewAnthropicClient(conf)
+	assert.NoError(t, err)
+
+	summary, _, err := llm.Summarize(context.Background(), client, "# 周报")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"排期紧张"}, summary.Risks)
+}
+
+func TestOpenAIClient_CompleteJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		format, _ := body["response_format"].(map[string]any)
+		assert.Equal(t, "json_schema", format["type"])
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"qwen-test","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"{\"summary\":\"完成联调\",\"key_outputs\":[],\"metrics\":[],\"risks\":[],\"next_steps\":[],\"projects\":[\"网关\"]}"}}],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`))
+	}))
+	defer srv.Close()
+
+	conf := viper.New()
+	conf.Set("llm.api_key", "test-key")
+	conf.Set("llm.openai.base_url", srv.URL)
+	conf.Set("llm.openai.model", "qwen-test")
+	client, err := llm.NewOpenAIClient(conf)
+	assert.NoError(t, err)
+
+	summary, completion, err := llm.Summarize(context.Background(), client, "# 周报")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"网关"}, summary.Projects)
+	assert.Equal(t, 8, completion.Usage.TotalTokens)
+}
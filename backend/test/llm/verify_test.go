@@ -0,0 +1,26 @@
+package llm_test
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/llm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyClaims(t *testing.T) {
+	p := &plainProvider{content: `{"claims":[{"quote":" 35% ","kind":"number","supported":false},{"quote":"李雷","kind":"person","supported":true},{"quote":"未知","kind":"other","supported":false},{"quote":"","kind":"term","supported":false}]}`}
+
+	claims, _, err := llm.VerifyClaims(context.Background(), p, "- 提升 35%", "- [R1] 日期：2024-03-04")
+	assert.NoError(t, err)
+	assert.Equal(t, []llm.ClaimCheck{
+		{Quote: "35%", Kind: llm.ClaimNumber, Supported: false},
+		{Quote: "李雷", Kind: llm.ClaimPerson, Supported: true},
+	}, claims)
+	assert.Contains(t, p.systemPrompt, `"required":["quote","kind","supported"]`)
+
+	p.content = "无法核对"
+	_, _, err = llm.VerifyClaims(context.Background(), p, "- 提升 35%", "")
+	assert.Error(t, err)
+}
@@ -64,6 +64,21 @@ func (mr *MockUserSettingsRepositoryMockRecorder) GetByID(ctx, userId interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserSettingsRepository)(nil).GetByID), ctx, userId)
 }
 
+// ListAutoGenerate mocks base method.
+func (m *MockUserSettingsRepository) ListAutoGenerate(ctx context.Context, afterUserID string, limit int) ([]*model.UserSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAutoGenerate", ctx, afterUserID, limit)
+	ret0, _ := ret[0].([]*model.UserSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAutoGenerate indicates an expected call of ListAutoGenerate.
+func (mr *MockUserSettingsRepositoryMockRecorder) ListAutoGenerate(ctx, afterUserID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAutoGenerate", reflect.TypeOf((*MockUserSettingsRepository)(nil).ListAutoGenerate), ctx, afterUserID, limit)
+}
+
 // Update mocks base method.
 func (m *MockUserSettingsRepository) Update(ctx context.Context, userSettings *model.UserSettings) error {
 	m.ctrl.T.Helper()
@@ -94,6 +94,20 @@ func (mr *MockUserServiceMockRecorder) Register(ctx, req interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUserService)(nil).Register), ctx, req)
 }
 
+// SetEncryptionKey mocks base method.
+func (m *MockUserService) SetEncryptionKey(ctx context.Context, userId string, req *v1.SetEncryptionKeyReq) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEncryptionKey", ctx, userId, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEncryptionKey indicates an expected call of SetEncryptionKey.
+func (mr *MockUserServiceMockRecorder) SetEncryptionKey(ctx, userId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEncryptionKey", reflect.TypeOf((*MockUserService)(nil).SetEncryptionKey), ctx, userId, req)
+}
+
 // UpdateUserSettings mocks base method.
 func (m *MockUserService) UpdateUserSettings(ctx context.Context, userId string, req *v1.UpdateUserSettingsReq) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/keyring"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const (
+	testMasterKey1 = "bWFzdGVyLWtleS0xLTMyLWJ5dGVzLWZvci10ZXN0cyE="
+	testMasterKey2 = "bWFzdGVyLWtleS0yLTMyLWJ5dGVzLWZvci10ZXN0cyE="
+)
+
+func openEncryptedDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Record{}, &model.RecordRevision{}, &model.Report{}, &model.ReportJob{}, &model.ReportJobAttempt{}, &model.UserDataKey{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func newKeyring(t *testing.T, enabled bool, current string) *keyring.Keyring {
+	k, err := keyring.New(enabled, current, []keyring.MasterKey{
+		{ID: "mk-1", Key: testMasterKey1},
+		{ID: "mk-2", Key: testMasterKey2},
+	})
+	assert.NoError(t, err)
+	return k
+}
+
+func TestContentCipher_RecordRoundTrip(t *testing.T) {
+	db := openEncryptedDB(t)
+	ctx := context.Background()
+	recordRepo := repository.NewRecordRepository(repository.NewRepository(logger, db, newKeyring(t, true, "mk-1")))
+
+	record := &model.Record{RecordID: "recordid_1", UserID: "u1", Date: "2025-12-01", Content: "联调登录接口"}
+	assert.NoError(t, recordRepo.Create(ctx, record))
+	assert.Equal(t, "联调登录接口", record.Content)
+
+	var raw model.Record
+	assert.NoError(t, db.Where("record_id = ?", "recordid_1").First(&raw).Error)
+	assert.Equal(t, 1, raw.KeyVersion)
+	assert.NotContains(t, raw.Content, "联调")
+
+	got, err := recordRepo.GetByID(ctx, "u1", "recordid_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "联调登录接口", got.Content)
+
+	// 关闭加密后仍能读取存量密文，新写入为明文
+	plainRepo := repository.NewRecordRepository(repository.NewRepository(logger, db, newKeyring(t, false, "mk-1")))
+	got, err = plainRepo.GetByID(ctx, "u1", "recordid_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "联调登录接口", got.Content)
+	got.Content = "改为明文"
+	assert.NoError(t, plainRepo.Update(ctx, got))
+	assert.NoError(t, db.Where("record_id = ?", "recordid_1").First(&raw).Error)
+	assert.Equal(t, 0, raw.KeyVersion)
+	assert.Equal(t, "改为明文", raw.Content)
+}
+
+func TestRekeyRepository_Rotate(t *testing.T) {
+	db := openEncryptedDB(t)
+	ctx := context.Background()
+	old := repository.NewRepository(logger, db, newKeyring(t, true, "mk-1"))
+	assert.NoError(t, repository.NewRecordRepository(old).Create(ctx, &model.Record{RecordID: "recordid_1", UserID: "u1", Date: "2025-12-01", Content: "周一"}))
+	assert.NoError(t, repository.NewReportRepository(old).Create(ctx, &model.Report{ReportID: "reportid_1", UserID: "u1", PeriodType: "week", StartDate: "2025-12-01", EndDate: "2025-12-07", Title: "周报", Content: "本周总结",
+		Abstract: "完成联调", Summary: `{"summary":"完成联调"}`}))
+	// 开启加密前写入的明文
+	assert.NoError(t, db.Create(&model.Record{RecordID: "recordid_2", UserID: "u2", Date: "2025-12-01", Content: "明文"}).Error)
+	assert.NoError(t, db.Create(&model.Report{ReportID: "reportid_2", UserID: "u2", PeriodType: "week", StartDate: "2025-12-01", EndDate: "2025-12-07", Title: "周报", Content: "明文周报",
+		Abstract: "明文摘要", Summary: `{"summary":"明文摘要"}`}).Error)
+
+	r := repository.NewRepository(logger, db, newKeyring(t, true, "mk-2"))
+	rekeyRepo := repository.NewRekeyRepository(r)
+	rewrapped, err := rekeyRepo.RewrapDataKeys(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rewrapped)
+
+	rotated, err := rekeyRepo.RotateDataKeys(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rotated)
+
+	changed, err := rekeyRepo.ReencryptRecords(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, changed)
+	changed, err = rekeyRepo.ReencryptReports(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, changed)
+	// 再次执行无需迁移
+	changed, err = rekeyRepo.ReencryptRecords(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, changed)
+
+	var keys []model.UserDataKey
+	assert.NoError(t, db.Order("user_id, version").Find(&keys).Error)
+	for _, key := range keys {
+		assert.Equal(t, "mk-2", key.MasterKeyID)
+	}
+	var raw model.Record
+	assert.NoError(t, db.Where("record_id = ?", "recordid_1").First(&raw).Error)
+	assert.Equal(t, 2, raw.KeyVersion)
+	var rawReport model.Report
+	assert.NoError(t, db.Where("report_id = ?", "reportid_1").First(&rawReport).Error)
+	assert.NotContains(t, rawReport.Summary, "完成联调")
+	assert.Empty(t, rawReport.Abstract)
+	// 存量明文迁移到加密后，由摘要渲染的 abstract 不再保留明文
+	var legacy model.Report
+	assert.NoError(t, db.Where("report_id = ?", "reportid_2").First(&legacy).Error)
+	assert.Equal(t, 1, legacy.KeyVersion)
+	assert.NotContains(t, legacy.Content, "明文")
+	assert.Empty(t, legacy.Abstract)
+
+	// 旧主密钥下线后仍可读取
+	onlyNew, err := keyring.New(true, "mk-2", []keyring.MasterKey{{ID: "mk-2", Key: testMasterKey2}})
+	assert.NoError(t, err)
+	fresh := repository.NewRepository(logger, db, onlyNew)
+	for userID, id := range map[string]string{"u1": "recordid_1", "u2": "recordid_2"} {
+		got, err := repository.NewRecordRepository(fresh).GetByID(ctx, userID, id)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, got.Content)
+	}
+	report, err := repository.NewReportRepository(fresh).GetByReportID(ctx, "reportid_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "本周总结", report.Content)
+	assert.Equal(t, `{"summary":"完成联调"}`, report.Summary)
+}
+
+func TestContentCipher_ReportJob(t *testing.T) {
+	db := openEncryptedDB(t)
+	ctx := context.Background()
+	jobRepo := repository.NewReportJobRepository(repository.NewRepository(logger, db, newKeyring(t, true, "mk-1")))
+
+	for i, id := range []string{"jobid_1", "jobid_2"} {
+		assert.NoError(t, jobRepo.Create(ctx, &model.ReportJob{ReportJobID: id, ReportID: "reportid_1", GenVersion: i + 1, UserID: "u1",
+			PeriodType: "week", StartDate: "2025-12-01", EndDate: "2025-12-07", Template: "formal"}))
+	}
+	job, err := jobRepo.GetByReportVersion(ctx, "reportid_1", 1)
+	assert.NoError(t, err)
+	assert.NoError(t, jobRepo.MarkProcessing(ctx, job, "system", "2025-12-01：联调登录接口"))
+	assert.NoError(t, jobRepo.CreateAttempts(ctx, []*model.ReportJobAttempt{{ReportJobID: "jobid_1", Provider: "openai", Model: "gpt", Try: 1, Raw: `{"content":"本周总结"}`}}))
+	assert.NoError(t, jobRepo.Finish(ctx, job, "ready", "gpt", "# 周报\n\n本周总结", ""))
+	// 失败的任务没有生成结果
+	failed, err := jobRepo.GetByReportVersion(ctx, "reportid_1", 2)
+	assert.NoError(t, err)
+	assert.NoError(t, jobRepo.MarkProcessing(ctx, failed, "system", "2025-12-02：修复登录超时"))
+	assert.NoError(t, jobRepo.Finish(ctx, failed, "failed", "", "", "调用大模型失败"))
+
+	var raw model.ReportJob
+	assert.NoError(t, db.Where("report_job_id = ?", "jobid_1").First(&raw).Error)
+	assert.Equal(t, 1, raw.KeyVersion)
+	assert.NotContains(t, raw.Prompt, "联调")
+	assert.NotContains(t, raw.Result, "本周总结")
+	var attempt model.ReportJobAttempt
+	assert.NoError(t, db.First(&attempt).Error)
+	assert.Empty(t, attempt.Raw)
+
+	// 轮换数据密钥后迁移，仍可读取
+	r := repository.NewRepository(logger, db, newKeyring(t, true, "mk-1"))
+	rekeyRepo := repository.NewRekeyRepository(r)
+	_, err = rekeyRepo.RotateDataKeys(ctx, 10)
+	assert.NoError(t, err)
+	changed, err := rekeyRepo.ReencryptReportJobs(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, changed)
+
+	jobs, err := repository.NewReportJobRepository(r).ListByReport(ctx, "u1", "reportid_1")
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 2) {
+		assert.Equal(t, "2025-12-02：修复登录超时", jobs[0].Prompt)
+		assert.Empty(t, jobs[0].Result)
+		assert.Equal(t, "2025-12-01：联调登录接口", jobs[1].Prompt)
+		assert.Equal(t, "# 周报\n\n本周总结", jobs[1].Result)
+		assert.Equal(t, 2, jobs[1].KeyVersion)
+	}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportJobRepository_FinishRunningOnly(t *testing.T) {
+	r := setupSQLiteRepository(t)
+	exportRepo := repository.NewExportJobRepository(r)
+	ctx := context.Background()
+
+	stale := time.Now().Add(-time.Hour)
+	assert.NoError(t, exportRepo.Create(ctx, &model.ExportJob{ExportID: "exportid_1", UserID: "u1",
+		Status: string(v1.ExportStatusRunning), UpdatedAt: stale}))
+
+	// 生成期间刷新心跳
+	ok, err := exportRepo.Touch(ctx, "exportid_1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	job, err := exportRepo.GetByExportID(ctx, "exportid_1")
+	assert.NoError(t, err)
+	assert.True(t, job.UpdatedAt.After(stale))
+
+	// 判定为中断后，迟到的生成结果不能覆盖
+	now := time.Now()
+	ok, err = exportRepo.Finish(ctx, &model.ExportJob{ExportID: "exportid_1", Status: string(v1.ExportStatusFailed), Error: "interrupted", FinishedAt: &now})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = exportRepo.Finish(ctx, &model.ExportJob{ExportID: "exportid_1", Status: string(v1.ExportStatusReady), FilePath: "late.zip", FinishedAt: &now})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = exportRepo.Touch(ctx, "exportid_1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	job, err = exportRepo.GetByExportID(ctx, "exportid_1")
+	assert.NoError(t, err)
+	assert.Equal(t, string(v1.ExportStatusFailed), job.Status)
+	assert.Equal(t, "interrupted", job.Error)
+	assert.Empty(t, job.FilePath)
+}
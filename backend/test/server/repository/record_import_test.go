@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordImportRepository_RunningOnly(t *testing.T) {
+	importRepo := repository.NewRecordImportRepository(setupSQLiteRepository(t))
+	ctx := context.Background()
+
+	stale := time.Now().Add(-time.Hour)
+	job := &model.RecordImportJob{ImportJobID: "importid_1", UserID: "u1", Policy: "skip", Status: string(v1.RecordImportStatusRunning), Total: 50, UpdatedAt: stale}
+	assert.NoError(t, importRepo.Create(ctx, job))
+
+	// 写入期间刷新心跳后不会被判定为中断
+	ok, err := importRepo.Touch(ctx, "importid_1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = importRepo.FailStale(ctx, "importid_1", time.Now().Add(-10*time.Minute), "interrupted")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	job.Processed = 20
+	ok, err = importRepo.UpdateProgress(ctx, job)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 判定为中断后，迟到的进度与完成状态不能覆盖
+	ok, err = importRepo.FailStale(ctx, "importid_1", time.Now().Add(time.Minute), "interrupted")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	now := time.Now()
+	job.Processed, job.Status, job.FinishedAt = 50, string(v1.RecordImportStatusDone), &now
+	ok, err = importRepo.UpdateProgress(ctx, job)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = importRepo.Touch(ctx, "importid_1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	got, err := importRepo.GetByID(ctx, "u1", "importid_1")
+	assert.NoError(t, err)
+	assert.Equal(t, string(v1.RecordImportStatusFailed), got.Status)
+	assert.Equal(t, "interrupted", got.Error)
+	assert.Equal(t, 20, got.Processed)
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordRevisionRepository_Prune(t *testing.T) {
+	r := setupSQLiteRepository(t)
+	ctx := context.Background()
+	assert.NoError(t, r.DB(ctx).AutoMigrate(&model.RecordRevision{}))
+	revisionRepo := repository.NewRecordRevisionRepository(r)
+
+	for _, recordID := range []string{"recordid_1", "recordid_2"} {
+		for i := 1; i <= 5; i++ {
+			assert.NoError(t, revisionRepo.Create(ctx, &model.RecordRevision{
+				RecordID: recordID,
+				Revision: i,
+				UserID:   "u1",
+				Date:     "2025-12-01",
+				Content:  fmt.Sprintf("%s v%d", recordID, i),
+			}))
+		}
+	}
+	// recordid_2 的前两个版本已过期
+	old := time.Now().Add(-100 * 24 * time.Hour)
+	assert.NoError(t, r.DB(ctx).Model(&model.RecordRevision{}).Where("record_id = ? AND revision <= ?", "recordid_2", 2).Update("created_at", old).Error)
+
+	rev, err := revisionRepo.GetByRevision(ctx, "recordid_1", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "recordid_1 v3", rev.Content)
+	_, err = revisionRepo.GetByRevision(ctx, "recordid_1", 6)
+	assert.ErrorIs(t, err, v1.ErrNotFound)
+
+	deleted, err := revisionRepo.Prune(ctx, 4, time.Now().Add(-90*24*time.Hour), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	revisions, err := revisionRepo.ListByRecord(ctx, "recordid_1")
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 4)
+	assert.Equal(t, 5, revisions[0].Revision)
+	assert.Empty(t, revisions[0].Content)
+
+	revisions, err = revisionRepo.ListByRecord(ctx, "recordid_2")
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 3)
+	assert.Equal(t, 3, revisions[2].Revision)
+
+	// 分批删除
+	deleted, err = revisionRepo.Prune(ctx, 1, time.Time{}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
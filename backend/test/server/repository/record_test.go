@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordRepository_UpdateIfVersion(t *testing.T) {
+	r := setupSQLiteRepository(t)
+	ctx := context.Background()
+	assert.NoError(t, r.DB(ctx).AutoMigrate(&model.Record{}))
+	recordRepo := repository.NewRecordRepository(r)
+
+	assert.NoError(t, recordRepo.Create(ctx, &model.Record{RecordID: "recordid_1", UserID: "u1", Date: "2025-12-01", Content: "v1"}))
+	tab1, err := recordRepo.GetByID(ctx, "u1", "recordid_1")
+	assert.NoError(t, err)
+	tab2, err := recordRepo.GetByID(ctx, "u1", "recordid_1")
+	assert.NoError(t, err)
+
+	tab1.Content, tab1.Version = "tab1", tab1.Version+1
+	ok, err := recordRepo.UpdateIfVersion(ctx, tab1, 1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 另一端基于旧版本的写入被拒绝
+	tab2.Content, tab2.Version = "tab2", tab2.Version+1
+	ok, err = recordRepo.UpdateIfVersion(ctx, tab2, 1)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	current, err := recordRepo.GetByID(ctx, "u1", "recordid_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "tab1", current.Content)
+	assert.Equal(t, 2, current.Version)
+}
+
+func TestReportRepository_UpdateContentIfVersion(t *testing.T) {
+	reportRepo := repository.NewReportRepository(setupSQLiteRepository(t))
+	ctx := context.Background()
+
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{
+		ReportID: "reportid_1", UserID: "u1", PeriodType: "week", StartDate: "2025-12-01", EndDate: "2025-12-07",
+		Title: "周报", Content: "# 周报", GenVersion: 1, Status: string(v1.ReportStatusReady),
+	}))
+	report, err := reportRepo.GetByID(ctx, "u1", "reportid_1")
+	assert.NoError(t, err)
+
+	report.Content, report.Version = "# 编辑", 1
+	ok, err := reportRepo.UpdateContentIfVersion(ctx, report, 0, 1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 重新生成后，基于旧生成版本的编辑视为冲突
+	assert.NoError(t, reportRepo.Update(ctx, &model.Report{
+		ReportID: "reportid_1", UserID: "u1", PeriodType: "week", StartDate: "2025-12-01", EndDate: "2025-12-07",
+		Title: "周报", Content: "# 重新生成", Version: 1, GenVersion: 2, Status: string(v1.ReportStatusReady),
+	}))
+	report.Content, report.Version = "# 旧编辑", 2
+	ok, err = reportRepo.UpdateContentIfVersion(ctx, report, 1, 1)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	current, err := reportRepo.GetByID(ctx, "u1", "reportid_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "# 重新生成", current.Content)
+}
+
+func TestReportRepository_ConfirmIfVersion(t *testing.T) {
+	reportRepo := repository.NewReportRepository(setupSQLiteRepository(t))
+	ctx := context.Background()
+
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{
+		ReportID: "reportid_1", UserID: "u1", PeriodType: "week", StartDate: "2025-12-01", EndDate: "2025-12-07",
+		Title: "周报", Content: "# 周报", GenVersion: 1, Status: string(v1.ReportStatusReady),
+	}))
+	report, err := reportRepo.GetByID(ctx, "u1", "reportid_1")
+	assert.NoError(t, err)
+
+	// 读取后被编辑：基于旧版本的确认不生效，也不回滚编辑内容
+	report.Content, report.Version = "# 编辑", 1
+	ok, err := reportRepo.UpdateContentIfVersion(ctx, report, 0, 1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = reportRepo.ConfirmIfVersion(ctx, "reportid_1", 0, 1, time.Now())
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	current, err := reportRepo.GetByID(ctx, "u1", "reportid_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "# 编辑", current.Content)
+	assert.False(t, current.Confirmed)
+
+	ok, err = reportRepo.ConfirmIfVersion(ctx, "reportid_1", 1, 1, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	current, err = reportRepo.GetByID(ctx, "u1", "reportid_1")
+	assert.NoError(t, err)
+	assert.True(t, current.Confirmed)
+	assert.NotNil(t, current.ConfirmedAt)
+	assert.Equal(t, "# 编辑", current.Content)
+
+	// 生成中的报告不能确认
+	current.Status, current.GenVersion = string(v1.ReportStatusProcessing), 2
+	assert.NoError(t, reportRepo.Update(ctx, current))
+	ok, err = reportRepo.ConfirmIfVersion(ctx, "reportid_1", 1, 2, time.Now())
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupSQLiteRepository(t *testing.T) *repository.Repository {
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&model.Report{}, &model.ReportJob{}, &model.ReportJobAttempt{}, &model.ExportJob{}, &model.RecordImportJob{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return repository.NewRepository(logger, db, nil)
+}
+
+func TestReportJobRepository_History(t *testing.T) {
+	jobRepo := repository.NewReportJobRepository(setupSQLiteRepository(t))
+	ctx := context.Background()
+
+	for i, id := range []string{"jobid_1", "jobid_2"} {
+		err := jobRepo.Create(ctx, &model.ReportJob{
+			ReportJobID: id,
+			ReportID:    "reportid_1",
+			GenVersion:  i + 1,
+			UserID:      "u1",
+			PeriodType:  "week",
+			StartDate:   "2025-12-01",
+			EndDate:     "2025-12-07",
+			Template:    "formal",
+			Status:      string(v1.ReportStatusQueued),
+		})
+		assert.NoError(t, err)
+	}
+
+	job, err := jobRepo.GetByReportVersion(ctx, "reportid_1", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "jobid_2", job.ReportJobID)
+	_, err = jobRepo.GetByReportVersion(ctx, "reportid_1", 3)
+	assert.ErrorIs(t, err, v1.ErrNotFound)
+
+	job, err = jobRepo.GetByReportVersion(ctx, "reportid_1", 1)
+	assert.NoError(t, err)
+	assert.NoError(t, jobRepo.MarkProcessing(ctx, job, "system", "user"))
+	assert.NoError(t, jobRepo.CreateAttempts(ctx, []*model.ReportJobAttempt{
+		{ReportJobID: "jobid_1", Provider: "openai", Model: "gpt", Try: 1, Error: "llm http status 429"},
+		{ReportJobID: "jobid_1", Provider: "ollama", Model: "qwen", Try: 1, TotalTokens: 20, Raw: "{}"},
+	}))
+	assert.NoError(t, jobRepo.Finish(ctx, job, string(v1.ReportStatusReady), "qwen", "# 周报", ""))
+
+	jobs, err := jobRepo.ListByReport(ctx, "u1", "reportid_1")
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, 2, jobs[0].GenVersion)
+	assert.Equal(t, string(v1.ReportStatusReady), jobs[1].Status)
+	assert.Equal(t, "user", jobs[1].Prompt)
+
+	attempts, err := jobRepo.ListAttempts(ctx, []string{"jobid_1", "jobid_2"})
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 2)
+	assert.Equal(t, "openai", attempts[0].Provider)
+	assert.Equal(t, 20, attempts[1].TotalTokens)
+}
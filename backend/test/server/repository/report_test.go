@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportRepository_LeaseLifecycle(t *testing.T) {
+	reportRepo := repository.NewReportRepository(setupSQLiteRepository(t))
+	ctx := context.Background()
+
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{
+		ReportID:   "reportid_1",
+		UserID:     "u1",
+		PeriodType: "week",
+		StartDate:  "2025-12-01",
+		EndDate:    "2025-12-07",
+		Title:      "周报",
+		Status:     string(v1.ReportStatusQueued),
+		GenVersion: 1,
+	}))
+
+	claimed, err := reportRepo.TryMarkProcessing(ctx, "reportid_1", 1, "worker-a", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = reportRepo.TryMarkProcessing(ctx, "reportid_1", 1, "worker-b", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	renewed, err := reportRepo.RenewLease(ctx, "reportid_1", 1, "worker-b", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, renewed)
+
+	// 租约未过期时不会被回收
+	expired, err := reportRepo.ListExpiredLeases(ctx, time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, expired)
+
+	// worker-a 崩溃：租约到期后被放回队列，旧进程的写回不再生效
+	later := time.Now().Add(2 * time.Minute)
+	expired, err = reportRepo.ListExpiredLeases(ctx, later, 10)
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, 1, expired[0].ClaimCount)
+
+	requeued, err := reportRepo.RequeueExpired(ctx, "reportid_1", 1, later)
+	assert.NoError(t, err)
+	assert.True(t, requeued)
+	written, err := reportRepo.UpdateGenerated(ctx, "reportid_1", 1, "worker-a", "# 旧结果", "", "", "", nil)
+	assert.NoError(t, err)
+	assert.False(t, written)
+
+	report, err := reportRepo.GetByReportID(ctx, "reportid_1")
+	assert.NoError(t, err)
+	assert.Equal(t, string(v1.ReportStatusQueued), report.Status)
+	assert.Empty(t, report.Content)
+
+	claimed, err = reportRepo.TryMarkProcessing(ctx, "reportid_1", 1, "worker-b", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	failed, err := reportRepo.FailExpired(ctx, "reportid_1", 1, later, "生成失败：处理进程多次中断")
+	assert.NoError(t, err)
+	assert.True(t, failed)
+
+	report, err = reportRepo.GetByReportID(ctx, "reportid_1")
+	assert.NoError(t, err)
+	assert.Equal(t, string(v1.ReportStatusFailed), report.Status)
+	assert.Equal(t, 2, report.ClaimCount)
+	assert.Empty(t, report.LeaseOwner)
+	assert.Nil(t, report.LeaseExpires)
+}
+
+func TestReportRepository_ClaimNextFairAcrossUsers(t *testing.T) {
+	reportRepo := repository.NewReportRepository(setupSQLiteRepository(t))
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	create := func(reportID string, userID string, startDate string, queuedAt time.Time) {
+		assert.NoError(t, reportRepo.Create(ctx, &model.Report{
+			ReportID:   reportID,
+			UserID:     userID,
+			PeriodType: "year",
+			StartDate:  startDate,
+			EndDate:    startDate,
+			Title:      reportID,
+			Status:     string(v1.ReportStatusQueued),
+			GenVersion: 1,
+			UpdatedAt:  queuedAt,
+		}))
+	}
+	// 用户 a 积压在前，用户 b 之后才排队
+	for i, date := range []string{"2021-01-01", "2022-01-01", "2023-01-01", "2024-01-01"} {
+		create("reportid_a"+date, "a", date, base.Add(time.Duration(i)*time.Minute))
+	}
+	create("reportid_b", "b", "2024-01-01", base.Add(10*time.Minute))
+
+	var users []string
+	for i := 0; i < 3; i++ {
+		report, err := reportRepo.ClaimNext(ctx, "task-0/w0", time.Minute, 2)
+		assert.NoError(t, err)
+		users = append(users, report.UserID)
+	}
+	assert.Equal(t, []string{"a", "b", "a"}, users)
+
+	// 用户 a 已有 2 条处理中，其余积压本轮不再领取
+	_, err := reportRepo.ClaimNext(ctx, "task-0/w1", time.Minute, 2)
+	assert.ErrorIs(t, err, v1.ErrNotFound)
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchRepository_SQLite(t *testing.T) {
+	r := setupSQLiteRepository(t)
+	ctx := context.Background()
+	db := r.DB(ctx)
+	assert.NoError(t, db.AutoMigrate(&model.Record{}))
+
+	// 建索引前已存在的数据需要回填
+	assert.NoError(t, db.Create(&model.Record{RecordID: "recordid_1", UserID: "u1", Date: "2025-12-01", Content: "完成登录接口联调，修复 token 过期问题"}).Error)
+	assert.NoError(t, repository.EnsureSearchIndex(db))
+	assert.NoError(t, repository.EnsureSearchIndex(db))
+
+	assert.NoError(t, db.Create(&model.Record{RecordID: "recordid_2", UserID: "u1", Date: "2025-12-02", Content: "整理周会纪要"}).Error)
+	assert.NoError(t, db.Create(&model.Record{RecordID: "recordid_3", UserID: "u2", Date: "2025-12-02", Content: "登录接口联调"}).Error)
+	assert.NoError(t, db.Create(&model.Record{RecordID: "recordid_4", UserID: "u1", Date: "2025-12-03", Content: "登录接口联调（加密）", IsEncrypted: true}).Error)
+	assert.NoError(t, db.Create(&model.Report{ReportID: "reportid_1", UserID: "u1", PeriodType: "week", StartDate: "2025-12-01", EndDate: "2025-12-07", Title: "第一周周报", Content: "本周完成登录接口联调", Status: string(v1.ReportStatusReady)}).Error)
+	assert.NoError(t, db.Create(&model.Report{ReportID: "reportid_2", UserID: "u1", PeriodType: "month", StartDate: "2025-12-01", EndDate: "2025-12-31", Title: "十二月月报", Content: "登录接口联调生成中", Status: string(v1.ReportStatusProcessing)}).Error)
+
+	searchRepo := repository.NewSearchRepository(r)
+	result, err := searchRepo.Search(ctx, &repository.SearchFilter{UserID: "u1", Query: "接口联调", Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+	ids := []string{}
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.DocID)
+	}
+	assert.ElementsMatch(t, []string{"recordid_1", "reportid_1"}, ids)
+
+	// 更新后索引同步
+	assert.NoError(t, db.Model(&model.Record{}).Where("record_id = ?", "recordid_2").Update("content", "周会讨论接口联调排期").Error)
+	result, err = searchRepo.Search(ctx, &repository.SearchFilter{UserID: "u1", Query: "接口联调", Types: []string{"record"}, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.Total)
+
+	// 日期与分页
+	result, err = searchRepo.Search(ctx, &repository.SearchFilter{UserID: "u1", Query: "接口联调", Types: []string{"record"}, StartDate: "2025-12-02", Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+	assert.Equal(t, "recordid_2", result.Hits[0].DocID)
+	result, err = searchRepo.Search(ctx, &repository.SearchFilter{UserID: "u1", Query: "接口联调", Offset: 2, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	assert.Len(t, result.Hits, 1)
+
+	// 短词退化为 LIKE；报告标题同样可检索
+	result, err = searchRepo.Search(ctx, &repository.SearchFilter{UserID: "u1", Query: "周报", PeriodType: "week", Types: []string{"report"}, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+	assert.Equal(t, "第一周周报", result.Hits[0].Title)
+
+	// 删除后不再命中
+	assert.NoError(t, db.Where("record_id = ?", "recordid_1").Delete(&model.Record{}).Error)
+	result, err = searchRepo.Search(ctx, &repository.SearchFilter{UserID: "u1", Query: "token 过期", Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), result.Total)
+}
+
+func TestSearchRepository_SealedContent(t *testing.T) {
+	db := openEncryptedDB(t)
+	ctx := context.Background()
+	assert.NoError(t, repository.EnsureSearchIndex(db))
+	r := repository.NewRepository(logger, db, newKeyring(t, true, "mk-1"))
+	recordRepo := repository.NewRecordRepository(r)
+	reportRepo := repository.NewReportRepository(r)
+
+	// 开启静态加密前写入的明文记录仍走索引
+	assert.NoError(t, db.Create(&model.Record{RecordID: "recordid_1", UserID: "u1", Date: "2025-12-01", Content: "完成登录接口联调"}).Error)
+	assert.NoError(t, recordRepo.Create(ctx, &model.Record{RecordID: "recordid_2", UserID: "u1", Date: "2025-12-02", Content: "接口联调：修复 Token 过期，接口联调收尾"}))
+	assert.NoError(t, recordRepo.Create(ctx, &model.Record{RecordID: "recordid_3", UserID: "u1", Date: "2025-12-03", Content: "整理周会纪要"}))
+	assert.NoError(t, recordRepo.Create(ctx, &model.Record{RecordID: "recordid_4", UserID: "u2", Date: "2025-12-02", Content: "接口联调"}))
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_1", UserID: "u1", PeriodType: "week", StartDate: "2025-12-01", EndDate: "2025-12-07",
+		Title: "第一周周报", Content: "本周完成接口联调", Status: string(v1.ReportStatusReady)}))
+	var raw model.Record
+	assert.NoError(t, db.Where("record_id = ?", "recordid_2").First(&raw).Error)
+	assert.Equal(t, 1, raw.KeyVersion)
+
+	searchRepo := repository.NewSearchRepository(r)
+	result, err := searchRepo.Search(ctx, &repository.SearchFilter{UserID: "u1", Query: "接口联调", Limit: 10})
+	assert.NoError(t, err)
+	assert.False(t, result.Partial)
+	assert.Equal(t, int64(3), result.Total)
+	if assert.Len(t, result.Hits, 3) {
+		// 命中次数多的排在前面，加密内容以明文返回
+		assert.Equal(t, "recordid_2", result.Hits[0].DocID)
+		assert.Contains(t, result.Hits[0].Content, "修复 Token 过期")
+		ids := []string{result.Hits[1].DocID, result.Hits[2].DocID}
+		assert.ElementsMatch(t, []string{"recordid_1", "reportid_1"}, ids)
+	}
+
+	// 多词须全部命中，大小写不敏感；筛选与分页同样生效
+	result, err = searchRepo.Search(ctx, &repository.SearchFilter{UserID: "u1", Query: "token 联调", Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+	result, err = searchRepo.Search(ctx, &repository.SearchFilter{UserID: "u1", Query: "接口联调", Types: []string{"record"}, StartDate: "2025-12-02", Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.Total)
+	result, err = searchRepo.Search(ctx, &repository.SearchFilter{UserID: "u1", Query: "周报", PeriodType: "week", Types: []string{"report"}, Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, result.Hits, 1) {
+		assert.Equal(t, "第一周周报", result.Hits[0].Title)
+	}
+	result, err = searchRepo.Search(ctx, &repository.SearchFilter{UserID: "u1", Query: "接口联调", Offset: 2, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+	assert.Len(t, result.Hits, 1)
+}
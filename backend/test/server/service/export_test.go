@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"archive/zip"
+	"context"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestExportService_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&model.User{}, &model.UserSettings{}, &model.Record{}, &model.RecordRevision{},
+		&model.Report{}, &model.RecordImportJob{}, &model.ExportJob{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	r := repository.NewRepository(logger, db, nil)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	reportRepo := repository.NewReportRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	conf := viper.New()
+	conf.Set("export.dir", t.TempDir())
+	conf.Set("export.sign_key", "test-sign-key")
+	exportSvc := service.NewExportService(conf, srv, repository.NewExportJobRepository(r), repository.NewUserRepository(r), settingsRepo, reportRepo, recordSvc)
+	importSvc := service.NewRecordImportService(srv, recordSvc, repository.NewRecordImportRepository(r), settingsRepo)
+
+	assert.NoError(t, db.Create(&model.User{UserID: "u1", Username: "alice", Password: "x"}).Error)
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-04", Content: "周一联调"}))
+	// 正文中的日期标题不应在导回时被切分
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-05", Content: "## 2024-03-01 回顾\n补记上周"}))
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_1", UserID: "u1", PeriodType: "week",
+		StartDate: "2024-03-04", EndDate: "2024-03-10", Title: "第 10 周周报", Content: "# 本周\n联调", Status: "ready", Confirmed: true, GenVersion: 2}))
+
+	job, err := exportSvc.StartExport(ctx, "u1")
+	assert.NoError(t, err)
+	again, err := exportSvc.StartExport(ctx, "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, job.ExportID, again.ExportID)
+	assert.Eventually(t, func() bool {
+		job, err = exportSvc.GetExport(ctx, "u1", job.ExportID)
+		return err == nil && job.Status != string(v1.ExportStatusRunning)
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, string(v1.ExportStatusReady), job.Status)
+	assert.Equal(t, 2, job.Records)
+	assert.Equal(t, 1, job.Reports)
+
+	link, err := url.Parse(job.DownloadURL)
+	assert.NoError(t, err)
+	expires, _ := strconv.ParseInt(link.Query().Get("expires"), 10, 64)
+	req := &v1.ExportDownloadReq{ExportID: job.ExportID, Expires: expires, Sig: link.Query().Get("sig")}
+	path, name, err := exportSvc.OpenDownload(ctx, req)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(name, ".zip"))
+	_, _, err = exportSvc.OpenDownload(ctx, &v1.ExportDownloadReq{ExportID: job.ExportID, Expires: expires, Sig: strings.Repeat("0", 64)})
+	assert.ErrorIs(t, err, v1.ErrExportLinkInvalid)
+	_, _, err = exportSvc.OpenDownload(ctx, &v1.ExportDownloadReq{ExportID: job.ExportID, Expires: expires + 3600, Sig: req.Sig})
+	assert.ErrorIs(t, err, v1.ErrExportLinkInvalid)
+
+	zr, err := zip.OpenReader(path)
+	assert.NoError(t, err)
+	names := make(map[string]bool)
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	zr.Close()
+	for _, want := range []string{"manifest.json", "settings.json", "records/2024-03-04.md", "records/2024-03-05.md", "reports/week/2024-03-04_2024-03-10.md"} {
+		assert.True(t, names[want], want)
+	}
+
+	// 导出的压缩包可直接导入到另一个账号，报告文件跳过
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	imported, err := importSvc.RunImport(ctx, "u2", &v1.ImportRecordsReq{}, name, []service.ImportSource{{Name: name, Data: data}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, imported.Created)
+	assert.Equal(t, 0, imported.Failed)
+	for _, date := range []string{"2024-03-04", "2024-03-05"} {
+		want, _ := recordSvc.QueryUserRecordsByDate(ctx, "u1", date)
+		got, err := recordSvc.QueryUserRecordsByDate(ctx, "u2", date)
+		assert.NoError(t, err)
+		assert.Equal(t, want.Content, got.Content)
+	}
+}
@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newImportService(t *testing.T) (service.RecordImportService, service.RecordService) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&model.UserSettings{}, &model.Record{}, &model.RecordRevision{}, &model.RecordImportJob{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	r := repository.NewRepository(logger, db, nil)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	return service.NewRecordImportService(srv, recordSvc, repository.NewRecordImportRepository(r), settingsRepo), recordSvc
+}
+
+func importZip(t *testing.T, files map[string]string) []service.ImportSource {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		assert.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+	return []service.ImportSource{{Name: "notes.zip", Data: buf.Bytes()}}
+}
+
+func TestRecordImportService_Append(t *testing.T) {
+	ctx := context.Background()
+	importSvc, recordSvc := newImportService(t)
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-05", Content: "旧内容"}))
+
+	sources := importZip(t, map[string]string{
+		"daily/2024-03-04.md": "---\ntags: [daily]\n---\n周一联调",
+		"daily/2024-03-05.md": "新内容",
+		"journal.md":          "# 2024 工作日志\n\n## 2024-03-06 周三\n评审\n### 细节\n接口定稿\n\n## 2024/03/07\n上线\n",
+		"daily/misc.md":       "没有日期",
+		"assets/shot.png":     "png",
+		"__MACOSX/._x.md":     "mac",
+	})
+	req := &v1.ImportRecordsReq{Policy: string(v1.RecordImportAppend)}
+
+	preview, err := importSvc.PreviewImport(ctx, "u1", req, sources)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, preview.Total)
+	assert.Equal(t, 3, preview.Create)
+	assert.Equal(t, 1, preview.Append)
+	assert.Equal(t, 1, preview.Invalid)
+	assert.Equal(t, "2024-03-06", preview.Entries[2].Date)
+	assert.Equal(t, "journal.md", preview.Entries[2].Source)
+	assert.Equal(t, v1.RecordImportActionInvalid, preview.Entries[4].Action)
+
+	job, err := importSvc.RunImport(ctx, "u1", req, "notes.zip", sources)
+	assert.NoError(t, err)
+	assert.Equal(t, string(v1.RecordImportStatusDone), job.Status)
+	assert.Equal(t, 5, job.Processed)
+	assert.Equal(t, 3, job.Created)
+	assert.Equal(t, 1, job.Updated)
+	assert.Equal(t, 1, job.Failed)
+	assert.Equal(t, "daily/misc.md", job.Errors[0].Source)
+
+	record, err := recordSvc.QueryUserRecordsByDate(ctx, "u1", "2024-03-05")
+	assert.NoError(t, err)
+	assert.Equal(t, "旧内容\n\n新内容", record.Content)
+	record, err = recordSvc.QueryUserRecordsByDate(ctx, "u1", "2024-03-04")
+	assert.NoError(t, err)
+	assert.Equal(t, "周一联调", record.Content)
+	record, err = recordSvc.QueryUserRecordsByDate(ctx, "u1", "2024-03-06")
+	assert.NoError(t, err)
+	assert.Equal(t, "评审\n### 细节\n接口定稿", record.Content)
+
+	// 重复导入不会重复追加
+	job, err = importSvc.RunImport(ctx, "u1", req, "notes.zip", sources)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, job.Skipped)
+	assert.Equal(t, 0, job.Created+job.Updated)
+
+	got, err := importSvc.GetImportJob(ctx, "u1", job.JobID)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, got.Skipped)
+	_, err = importSvc.GetImportJob(ctx, "u2", job.JobID)
+	assert.ErrorIs(t, err, v1.ErrImportJobNotExist)
+}
+
+func TestRecordImportService_Policies(t *testing.T) {
+	ctx := context.Background()
+	importSvc, recordSvc := newImportService(t)
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-05", Content: "旧内容"}))
+	sources := []service.ImportSource{{Name: "2024-03-05.txt", Data: []byte("新内容")}}
+
+	preview, err := importSvc.PreviewImport(ctx, "u1", &v1.ImportRecordsReq{}, sources)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.RecordImportActionSkip, preview.Entries[0].Action)
+
+	job, err := importSvc.RunImport(ctx, "u1", &v1.ImportRecordsReq{Policy: string(v1.RecordImportOverwrite)}, "2024-03-05.txt", sources)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, job.Updated)
+	record, err := recordSvc.QueryUserRecordsByDate(ctx, "u1", "2024-03-05")
+	assert.NoError(t, err)
+	assert.Equal(t, "新内容", record.Content)
+	assert.Equal(t, 2, record.Version)
+
+	_, err = importSvc.PreviewImport(ctx, "u1", &v1.ImportRecordsReq{Policy: "merge"}, sources)
+	assert.ErrorIs(t, err, v1.ErrInvalidImportPolicy)
+	_, err = importSvc.PreviewImport(ctx, "u1", &v1.ImportRecordsReq{}, []service.ImportSource{{Name: "a.pdf", Data: []byte("x")}})
+	assert.ErrorIs(t, err, v1.ErrInvalidImportFile)
+	preview, err = importSvc.PreviewImport(ctx, "u1", &v1.ImportRecordsReq{}, []service.ImportSource{{Name: "2999-01-01.md", Data: []byte("未来")}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, preview.Invalid)
+}
@@ -0,0 +1,22 @@
+package service_test
+
+import (
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	diffs := service.DiffLines("周一\n联调登录\n周会\n", "周一\n联调注册\n周会\n")
+	assert.Equal(t, []v1.RecordDiffItem{
+		{Op: v1.RecordDiffEqual, Text: "周一\n"},
+		{Op: v1.RecordDiffDelete, Text: "联调登录\n"},
+		{Op: v1.RecordDiffInsert, Text: "联调注册\n"},
+		{Op: v1.RecordDiffEqual, Text: "周会\n"},
+	}, diffs)
+
+	assert.Equal(t, []v1.RecordDiffItem{{Op: v1.RecordDiffInsert, Text: "新增"}}, service.DiffLines("", "新增"))
+}
@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+var chunkRefRe = regexp.MustCompile(`R\d+`)
+
+// windowProvider 包装离线实现，声明较小的上下文窗口；分段压缩时只返回一条带记录编号的要点，并统计调用次数
+type windowProvider struct {
+	llm.Provider
+	window int
+
+	mu         sync.Mutex
+	chunkCalls int
+	prompts    []string
+}
+
+func (p *windowProvider) ContextWindow() int { return p.window }
+
+func (p *windowProvider) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*llm.Completion, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prompts = append(p.prompts, userPrompt)
+	if strings.HasPrefix(systemPrompt, "你是工作记录整理助手") {
+		p.chunkCalls += 1
+		refs := chunkRefRe.FindAllString(userPrompt, -1)
+		return &llm.Completion{Content: fmt.Sprintf("- 完成支付网关联调与压测 [%s]", strings.Join(refs, ",")), Model: p.Model()}, nil
+	}
+	return p.Provider.Complete(ctx, systemPrompt, userPrompt)
+}
+
+func TestReportService_MapReduce(t *testing.T) {
+	ctx := context.Background()
+	_, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+
+	conf := viper.New()
+	echo, err := llm.NewEchoClient(conf)
+	assert.NoError(t, err)
+	provider := &windowProvider{Provider: echo, window: 3000}
+	reportSvc := service.NewReportService(conf, srv, repository.NewReportRepository(r), repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+		recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), provider)
+
+	for day := 1; day <= 28; day++ {
+		content := fmt.Sprintf("第%d天：%s", day, strings.Repeat("完成支付网关联调与压测，修复对账差异。", 12))
+		assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: fmt.Sprintf("2024-02-%02d", day), Content: content}))
+	}
+	// 单条记录超出分段预算时拆分，每段保留记录编号
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-02-29", Content: strings.Repeat("整理季度复盘材料。", 400)}))
+
+	req := &v1.GenReportReq{PeriodType: "month", StartDate: "2024-02-01", EndDate: "2024-02-29", Template: "formal"}
+	reportId, err := reportSvc.GenerateReport(ctx, "u1", req)
+	assert.NoError(t, err)
+	ok, err := reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	item, err := reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Equal(t, string(v1.ReportStatusReady), item.Status)
+	firstCalls := provider.chunkCalls
+	assert.Greater(t, firstCalls, 1)
+	// 每段不超过模型窗口，最终提示词以要点代替原始记录且保留记录编号
+	for _, prompt := range provider.prompts {
+		assert.LessOrEqual(t, llm.EstimateTokens(prompt), 3000)
+	}
+	jobs, err := reportSvc.GetReportJobs(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Contains(t, jobs[0].Prompt, "记录要点：")
+	assert.Contains(t, jobs[0].Prompt, "[R1,R2")
+	assert.Equal(t, 2, strings.Count(jobs[0].Prompt, "[R29]"))
+	assert.NotContains(t, jobs[0].Prompt, "记录列表：")
+	assert.NotEmpty(t, item.Citations)
+
+	// 重新生成时内容未变的分段命中缓存
+	_, err = reportSvc.GenerateReport(ctx, "u1", req)
+	assert.NoError(t, err)
+	ok, err = reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, firstCalls, provider.chunkCalls)
+}
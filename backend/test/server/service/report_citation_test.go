@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportService_Citations(t *testing.T) {
+	ctx := context.Background()
+	_, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+
+	fixture := filepath.Join(t.TempDir(), "report.md")
+	assert.NoError(t, os.WriteFile(fixture, []byte("# 周报\n\n## 重点产出\n- 完成接口联调 [R1]\n- 修复登录问题 [R1, R2]\n- 编造的成果 [R9]\n"), 0o644))
+	conf := viper.New()
+	conf.Set("llm.provider", "echo")
+	conf.Set("llm.echo.fixture", fixture)
+	reportSvc := service.NewReportService(conf, srv, repository.NewReportRepository(r), repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+		recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), llm.NewProvider(conf, logger))
+
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-04", Content: "联调接口"}))
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-05", Content: "修复登录"}))
+	records, err := recordSvc.QueryUserRecordsByDateRange(ctx, "u1", "2024-03-04", "2024-03-10")
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	reportId, err := reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "week", StartDate: "2024-03-04", EndDate: "2024-03-10", Template: "formal"})
+	assert.NoError(t, err)
+	ok, err := reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	jobs, err := reportSvc.GetReportJobs(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Contains(t, jobs[0].Prompt, "- [R1] 日期：2024-03-04")
+	assert.Contains(t, jobs[0].SystemPrompt, "[R1,R3]")
+
+	item, err := reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Equal(t, "# 周报\n\n## 重点产出\n- 完成接口联调\n- 修复登录问题\n- 编造的成果\n", item.Content)
+	assert.Equal(t, []v1.ReportCitation{
+		{Line: 4, Text: "完成接口联调", Records: []v1.CitationRecord{{RecordID: records[0].RecordID, Date: "2024-03-04"}}},
+		{Line: 5, Text: "修复登录问题", Records: []v1.CitationRecord{
+			{RecordID: records[0].RecordID, Date: "2024-03-04"},
+			{RecordID: records[1].RecordID, Date: "2024-03-05"},
+		}},
+	}, item.Citations)
+
+	// 编辑后只保留仍存在的要点，行号按新正文计算
+	assert.NoError(t, reportSvc.EditReport(ctx, "u1", &v1.EditReportReq{ReportID: reportId, Content: "# 周报\n\n- 修复登录问题\n"}))
+	item, err = reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	if assert.Len(t, item.Citations, 1) {
+		assert.Equal(t, 3, item.Citations[0].Line)
+		assert.Len(t, item.Citations[0].Records, 2)
+	}
+}
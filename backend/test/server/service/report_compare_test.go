@@ -0,0 +1,104 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// compareProvider 包装离线实现，记录生成报告的提示词；摘要请求要求 comparison 时返回固定的对比结果
+type compareProvider struct {
+	llm.Provider
+
+	mu      sync.Mutex
+	systems []string
+	prompts []string
+}
+
+func (p *compareProvider) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*llm.Completion, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if strings.HasPrefix(systemPrompt, "你是工作报告摘要助手") {
+		content := `{"summary":"完成对账服务","key_outputs":["对账服务上线"],"metrics":[],"risks":[],"next_steps":[],"projects":[]}`
+		if strings.Contains(systemPrompt, `"comparison"`) {
+			content = strings.TrimSuffix(content, "}") + `,"comparison":{"continued":["支付网关联调"],"started":["对账服务"],"dropped":["压测"],"trends":[]}}`
+		}
+		return &llm.Completion{Content: content, Model: p.Model()}, nil
+	}
+	p.systems = append(p.systems, systemPrompt)
+	p.prompts = append(p.prompts, userPrompt)
+	return p.Provider.Complete(ctx, systemPrompt, userPrompt)
+}
+
+func TestReportService_CompareWithPreviousPeriod(t *testing.T) {
+	ctx := context.Background()
+	_, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	reportRepo := repository.NewReportRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	conf := viper.New()
+	echo, err := llm.NewEchoClient(conf)
+	assert.NoError(t, err)
+	provider := &compareProvider{Provider: echo}
+	reportSvc := service.NewReportService(conf, srv, reportRepo, repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+		recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), provider)
+
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_jan", UserID: "u1", PeriodType: "month",
+		StartDate: "2024-01-01", EndDate: "2024-01-31", Title: "2024年01月月报", Content: "# 1 月月报\n\n- 支付网关联调\n- 压测", Status: "ready", Confirmed: true, Version: 2,
+		Summary: `{"summary":"完成支付网关联调","key_outputs":["支付网关联调","压测"],"metrics":[],"risks":[],"next_steps":["对账服务"],"projects":[]}`}))
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-02-05", Content: "对账服务上线"}))
+
+	req := &v1.GenReportReq{PeriodType: "month", StartDate: "2024-02-01", EndDate: "2024-02-29", Template: "formal", Compare: true}
+	reportId, err := reportSvc.GenerateReport(ctx, "u1", req)
+	assert.NoError(t, err)
+	ok, err := reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	if assert.Len(t, provider.prompts, 1) {
+		assert.Contains(t, provider.systems[0], "与上期对比")
+		assert.Contains(t, provider.prompts[0], "上期报告（2024年01月月报，2024-01-01 至 2024-01-31）摘要：")
+		assert.Contains(t, provider.prompts[0], "关键产出：支付网关联调；压测")
+		assert.Contains(t, provider.prompts[0], "对账服务上线")
+	}
+	item, err := reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Equal(t, &v1.ReportBaseline{ReportID: "reportid_jan", PeriodType: "month", StartDate: "2024-01-01", EndDate: "2024-01-31", Title: "2024年01月月报", Version: 2}, item.Comparison)
+	if assert.NotNil(t, item.Summary) && assert.NotNil(t, item.Summary.Comparison) {
+		assert.Equal(t, []string{"对账服务"}, item.Summary.Comparison.Started)
+		assert.Equal(t, []string{"压测"}, item.Summary.Comparison.Dropped)
+	}
+	assert.Contains(t, item.Abstract, "较上期未延续：压测")
+
+	// 上一周期报告未确认或不存在
+	_, err = reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "month", StartDate: "2024-03-01", EndDate: "2024-03-31", Template: "formal", Compare: true})
+	assert.ErrorIs(t, err, v1.ErrNoComparisonBaseline)
+	_, err = reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "week", StartDate: "2024-02-05", EndDate: "2024-02-11", Template: "formal", Compare: true})
+	assert.ErrorIs(t, err, v1.ErrNoComparisonBaseline)
+
+	// 不对比重新生成时移除对比基准
+	req.Compare = false
+	_, err = reportSvc.GenerateReport(ctx, "u1", req)
+	assert.NoError(t, err)
+	ok, err = reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	item, err = reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Nil(t, item.Comparison)
+	if assert.NotNil(t, item.Summary) {
+		assert.Nil(t, item.Summary.Comparison)
+	}
+	assert.NotContains(t, provider.prompts[len(provider.prompts)-1], "上期报告")
+}
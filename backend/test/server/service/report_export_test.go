@@ -0,0 +1,206 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "backend/api/v1"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/pkg/render"
+
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const exportReportContent = "## 本周进展\n\n- 完成 **接口联调**\n- 修复 `wire` 生成问题\n\n| 事项 | 状态 |\n|---|---|\n| 导出 | 完成 |\n\n<script>alert(1)</script>\n"
+
+func newReportExportService(t *testing.T, conf *viper.Viper) (service.ReportExportService, repository.ReportRepository) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&model.User{}, &model.UserSettings{}, &model.Report{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	r := repository.NewRepository(logger, db, nil)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	reportRepo := repository.NewReportRepository(r)
+	assert.NoError(t, db.Create(&model.User{UserID: "u1", Username: "alice", Password: "x"}).Error)
+	assert.NoError(t, db.Create(&model.UserSettings{UserID: "u1", Timezone: "Asia/Shanghai"}).Error)
+	confirmedAt := time.Date(2024, 3, 10, 20, 0, 0, 0, time.UTC)
+	assert.NoError(t, reportRepo.Create(context.Background(), &model.Report{ReportID: "reportid_1", UserID: "u1", PeriodType: "week",
+		StartDate: "2024-03-04", EndDate: "2024-03-10", Title: "第 10 周周报", Content: exportReportContent,
+		Status: "ready", Confirmed: true, ConfirmedAt: &confirmedAt}))
+	assert.NoError(t, reportRepo.Create(context.Background(), &model.Report{ReportID: "reportid_2", UserID: "u1", PeriodType: "week",
+		StartDate: "2024-03-11", EndDate: "2024-03-17", Title: "第 11 周周报", Content: "草稿", Status: "ready"}))
+	return service.NewReportExportService(conf, srv, reportRepo, repository.NewUserRepository(r), repository.NewUserSettingsRepository(r)), reportRepo
+}
+
+func docxPart(t *testing.T, data []byte, name string) string {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	for _, f := range zr.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			assert.NoError(t, err)
+			defer rc.Close()
+			content, err := io.ReadAll(rc)
+			assert.NoError(t, err)
+			return string(content)
+		}
+	}
+	t.Fatalf("part %s not found", name)
+	return ""
+}
+
+func TestReportExportService_Formats(t *testing.T) {
+	ctx := context.Background()
+	exportSvc, _ := newReportExportService(t, viper.New())
+
+	file, err := exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "第 10 周周报.md", file.Name)
+	md := string(file.Data)
+	assert.True(t, strings.HasPrefix(md, "# 第 10 周周报\n"))
+	assert.Contains(t, md, "- 作者：alice\n")
+	// 确认时间按用户时区显示
+	assert.Contains(t, md, "- 确认日期：2024-03-11\n")
+
+	file, err = exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_1", Format: "html"})
+	assert.NoError(t, err)
+	html := string(file.Data)
+	assert.Contains(t, html, "<title>第 10 周周报</title>")
+	assert.Contains(t, html, "<strong>接口联调</strong>")
+	assert.Contains(t, html, "<td>完成</td>")
+	assert.Contains(t, html, "2024-03-04 ~ 2024-03-10")
+	assert.NotContains(t, html, "<script>")
+
+	file, err = exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_1", Format: "pdf"})
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", file.ContentType)
+	assert.True(t, bytes.HasPrefix(file.Data, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(file.Data, []byte("%%EOF\n")))
+	assert.Contains(t, string(file.Data), "/BaseFont /STSong-Light")
+	assert.Equal(t, []string{"font-not-embedded"}, file.Warnings)
+
+	file, err = exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_1", Format: "docx"})
+	assert.NoError(t, err)
+	document := docxPart(t, file.Data, "word/document.xml")
+	assert.Contains(t, document, "第 10 周周报")
+	assert.Contains(t, document, `<w:pStyle w:val="Heading2"/>`)
+	assert.Contains(t, document, "接口联调")
+	assert.Contains(t, docxPart(t, file.Data, "word/styles.xml"), `w:eastAsia="Microsoft YaHei"`)
+
+	_, err = exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_1", Format: "xlsx"})
+	assert.ErrorIs(t, err, v1.ErrInvalidExportFormat)
+	_, err = exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_2", Format: "pdf"})
+	assert.ErrorIs(t, err, v1.ErrReportNotConfirmed)
+	_, err = exportSvc.ExportReport(ctx, "u2", &v1.ExportReportReq{ReportID: "reportid_1"})
+	assert.ErrorIs(t, err, v1.ErrReportNotExist)
+}
+
+func TestReportExportService_PDFUnsupportedChars(t *testing.T) {
+	ctx := context.Background()
+	exportSvc, reportRepo := newReportExportService(t, viper.New())
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_3", UserID: "u1", PeriodType: "week",
+		StartDate: "2024-03-18", EndDate: "2024-03-24", Title: "第 12 周周报", Content: "- 上线 😀\n- 𠀀 字库",
+		Status: "ready", Confirmed: true}))
+
+	file, err := exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_3", Format: "pdf"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"font-not-embedded", "unsupported-chars=2"}, file.Warnings)
+	// 其他格式不受字体限制
+	file, err = exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_3", Format: "html"})
+	assert.NoError(t, err)
+	assert.Empty(t, file.Warnings)
+}
+
+func TestPDF_UnsupportedCharsFallback(t *testing.T) {
+	data, replaced, err := render.PDF(render.Document{Title: "周报😀", Markdown: "完成😀"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, replaced)
+	// 文档信息中的标题不经过字体，emoji 以代理对保留
+	assert.Contains(t, string(data), "/Title <FEFF546862A5D83DDE00>")
+
+	start := bytes.Index(data, []byte("stream\n"))
+	end := bytes.Index(data, []byte("\nendstream"))
+	assert.True(t, start >= 0 && end > start)
+	zr, err := zlib.NewReader(bytes.NewReader(data[start+len("stream\n") : end]))
+	assert.NoError(t, err)
+	page, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	// 正文中的 emoji 替换为 ?（U+003F）
+	assert.Contains(t, string(page), "<5B8C6210003F>")
+	assert.NotContains(t, string(page), "D83D")
+}
+
+func TestReportExportService_Branding(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "acme.html"),
+		[]byte(`<html><body><h1 class="acme">{{.Title}} / {{.Author}}</h1>{{.Content}}</body></html>`), 0o644))
+
+	var tmpl bytes.Buffer
+	w := zip.NewWriter(&tmpl)
+	for name, content := range map[string]string{
+		"[Content_Types].xml": "<Types/>",
+		"word/document.xml":   `<w:document><w:body><w:p><w:r><w:t>{{title}} {{period}}</w:t></w:r></w:p><w:p><w:pPr/><w:r><w:t>{{content}}</w:t></w:r></w:p><w:p><w:r><w:t>ACME 内部资料</w:t></w:r></w:p></w:body></w:document>`,
+		"word/header1.xml":    `<w:hdr><w:p><w:r><w:t>{{author}} {{confirmed_at}}</w:t></w:r></w:p></w:hdr>`,
+		"word/media/logo.png": "png",
+	} {
+		f, err := w.Create(name)
+		assert.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "acme.docx"), tmpl.Bytes(), 0o644))
+
+	conf := viper.New()
+	conf.Set("report.export.branding_dir", dir)
+	exportSvc, _ := newReportExportService(t, conf)
+
+	file, err := exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_1", Format: "html", Branding: "acme"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(file.Data), `<h1 class="acme">第 10 周周报 / alice</h1>`)
+	assert.Contains(t, string(file.Data), "<li>完成 <strong>接口联调</strong></li>")
+
+	file, err = exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_1", Format: "docx", Branding: "acme"})
+	assert.NoError(t, err)
+	document := docxPart(t, file.Data, "word/document.xml")
+	assert.Contains(t, document, "第 10 周周报 2024-03-04 ~ 2024-03-10")
+	assert.Contains(t, document, "接口联调")
+	assert.Contains(t, document, "ACME 内部资料")
+	assert.NotContains(t, document, "{{content}}")
+	assert.Equal(t, `<w:hdr><w:p><w:r><w:t>alice 2024-03-11</w:t></w:r></w:p></w:hdr>`, docxPart(t, file.Data, "word/header1.xml"))
+	assert.Equal(t, "png", docxPart(t, file.Data, "word/media/logo.png"))
+
+	_, err = exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_1", Format: "docx", Branding: "missing"})
+	assert.ErrorIs(t, err, v1.ErrInvalidBranding)
+	_, err = exportSvc.ExportReport(ctx, "u1", &v1.ExportReportReq{ReportID: "reportid_1", Format: "html", Branding: "../acme"})
+	assert.ErrorIs(t, err, v1.ErrInvalidBranding)
+}
+
+func TestMarkdownToHTML_UnsafeLinks(t *testing.T) {
+	html := render.MarkdownToHTML("- [点我](javascript:alert(1))\n- [大写](JavaScript:alert(1))\n- [数据](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)\n" +
+		"- ![图](javascript:alert(1)) ![矢量](data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=)\n- [文档](https://example.com/doc) [站内](/reports/1)\n")
+	assert.NotContains(t, strings.ToLower(html), "javascript:")
+	assert.NotContains(t, html, "data:")
+	assert.Contains(t, html, "<tt>点我</tt>")
+	assert.Contains(t, html, "图")
+	assert.Contains(t, html, "矢量")
+	assert.Contains(t, html, `<a href="https://example.com/doc" rel="nofollow noreferrer">文档</a>`)
+	assert.Contains(t, html, `<a href="/reports/1">站内</a>`)
+}
@@ -0,0 +1,137 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportService_PeriodBoundaries(t *testing.T) {
+	ctx := context.Background()
+	_, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	conf := viper.New()
+	conf.Set("llm.provider", "echo")
+	reportSvc := service.NewReportService(conf, srv, repository.NewReportRepository(r), repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+		recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), llm.NewProvider(conf, logger))
+	assert.NoError(t, settingsRepo.Create(ctx, &model.UserSettings{UserID: "u2", Timezone: "Asia/Shanghai", SprintLengthDays: 10, SprintAnchorDate: "2024-01-03"}))
+
+	mode := gin.Mode()
+	gin.SetMode(gin.ReleaseMode)
+	defer gin.SetMode(mode)
+
+	cases := []struct {
+		user   string
+		period string
+		start  string
+		end    string
+		err    error
+	}{
+		{"u1", "quarter", "2024-04-01", "2024-06-30", nil},
+		{"u1", "quarter", "2024-04-01", "2024-05-31", v1.ErrInvalidDate},
+		{"u1", "half_year", "2024-07-01", "2024-12-31", nil},
+		{"u1", "half_year", "2024-04-01", "2024-09-30", v1.ErrInvalidDate},
+		// 未设置锚点：默认 14 天，从周一开始
+		{"u1", "sprint", "2024-03-04", "2024-03-17", nil},
+		{"u1", "sprint", "2024-03-05", "2024-03-18", v1.ErrInvalidDate},
+		// 锚点 2024-01-03，长度 10 天
+		{"u2", "sprint", "2024-01-23", "2024-02-01", nil},
+		{"u2", "sprint", "2023-12-24", "2024-01-02", nil},
+		{"u2", "sprint", "2024-01-22", "2024-01-31", v1.ErrInvalidDate},
+		{"u1", "custom", "2024-02-10", "2024-03-05", nil},
+		{"u1", "custom", "2023-01-01", "2024-01-02", v1.ErrInvalidDate},
+		{"u1", "fortnight", "2024-03-04", "2024-03-17", v1.ErrInvalidReportPeriod},
+	}
+	for _, c := range cases {
+		_, err := reportSvc.GenerateReport(ctx, c.user, &v1.GenReportReq{PeriodType: c.period, StartDate: c.start, EndDate: c.end, Template: "formal"})
+		if c.err == nil {
+			assert.NoError(t, err, "%s %s~%s", c.period, c.start, c.end)
+		} else {
+			assert.ErrorIs(t, err, c.err, "%s %s~%s", c.period, c.start, c.end)
+		}
+	}
+
+	titles := make(map[string]string)
+	for _, period := range []string{"quarter", "half_year", "sprint", "custom"} {
+		reports, err := reportSvc.GetReports(ctx, "u1", &v1.GetReportsReq{PeriodType: period})
+		assert.NoError(t, err)
+		for _, rep := range reports {
+			titles[rep.PeriodType] = rep.Title
+		}
+	}
+	assert.Equal(t, "2024年第2季度季报", titles["quarter"])
+	assert.Equal(t, "2024年下半年半年报", titles["half_year"])
+	assert.Equal(t, "2024年03月04日-2024年03月17日 迭代报告", titles["sprint"])
+	assert.Equal(t, "2024年02月10日-2024年03月05日 阶段报告", titles["custom"])
+}
+
+func TestReportService_QuarterRollup(t *testing.T) {
+	ctx := context.Background()
+	_, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	reportRepo := repository.NewReportRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	conf := viper.New()
+	conf.Set("llm.provider", "echo")
+	reportSvc := service.NewReportService(conf, srv, reportRepo, repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+		recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), llm.NewProvider(conf, logger))
+	dashboardSvc := service.NewDashboardService(srv, repository.NewRecordRepository(r), reportRepo, settingsRepo)
+
+	// 3 个月中 2 个月已确认月报，按月报汇总；有月报的月份不使用日记，缺月报的月份以日记补齐
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-01-08", Content: "日记不应进入季报素材"}))
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-05", Content: "完成季度复盘"}))
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_jan", UserID: "u1", PeriodType: "month",
+		StartDate: "2024-01-01", EndDate: "2024-01-31", Title: "1 月月报", Content: "# 1 月月报\n\n完成支付系统重构", Status: "ready", Confirmed: true}))
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_feb", UserID: "u1", PeriodType: "month",
+		StartDate: "2024-02-01", EndDate: "2024-02-29", Title: "2 月月报", Content: "# 2 月月报\n\n上线灰度发布", Status: "ready", Confirmed: true}))
+
+	period, err := dashboardSvc.GetPeriod(ctx, "u1", &v1.PeriodDashboardReq{PeriodType: "quarter", Date: "2024-02-15"})
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-01-01", period.StartDate)
+	assert.Equal(t, "2024-03-31", period.EndDate)
+	assert.Equal(t, "2024年第1季度季报", period.Title)
+	assert.Equal(t, 2, period.RecordedDays)
+	assert.Equal(t, 2, period.ConfirmedMonths)
+	assert.Nil(t, period.Report)
+
+	reportId, err := reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "quarter", StartDate: period.StartDate, EndDate: period.EndDate, Template: "formal"})
+	assert.NoError(t, err)
+	ok, err := reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	jobs, err := reportSvc.GetReportJobs(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 1) {
+		assert.Contains(t, jobs[0].Prompt, "类型：季报")
+		assert.Contains(t, jobs[0].Prompt, "完成支付系统重构")
+		assert.Contains(t, jobs[0].Prompt, "2024-03-05：完成季度复盘")
+		assert.NotContains(t, jobs[0].Prompt, "日记不应进入季报素材")
+	}
+
+	period, err = dashboardSvc.GetPeriod(ctx, "u1", &v1.PeriodDashboardReq{PeriodType: "quarter", Date: "2024-03-31"})
+	assert.NoError(t, err)
+	if assert.NotNil(t, period.Report) {
+		assert.Equal(t, reportId, period.Report.ReportID)
+		assert.Equal(t, string(v1.ReportStatusReady), period.Report.Status)
+	}
+
+	// 未来的周期与不合法的自定义范围
+	_, err = dashboardSvc.GetPeriod(ctx, "u1", &v1.PeriodDashboardReq{PeriodType: "quarter", Date: time.Now().AddDate(1, 0, 0).Format("2006-01-02")})
+	assert.ErrorIs(t, err, v1.ErrInvalidDate)
+	_, err = dashboardSvc.GetPeriod(ctx, "u1", &v1.PeriodDashboardReq{PeriodType: "custom", StartDate: "2024-03-10", EndDate: "2024-03-01"})
+	assert.ErrorIs(t, err, v1.ErrInvalidDate)
+}
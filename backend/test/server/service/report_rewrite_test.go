@@ -0,0 +1,104 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// rewriteProvider 记录改写请求的提示词，返回固定的改写结果
+type rewriteProvider struct {
+	llm.Provider
+
+	output  string
+	prompts []string
+}
+
+func (p *rewriteProvider) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*llm.Completion, error) {
+	if strings.HasPrefix(systemPrompt, "你是工作报告编辑助手") {
+		p.prompts = append(p.prompts, userPrompt)
+		return &llm.Completion{Content: p.output, Model: p.Model()}, nil
+	}
+	return p.Provider.Complete(ctx, systemPrompt, userPrompt)
+}
+
+func TestReportService_Rewrite(t *testing.T) {
+	ctx := context.Background()
+	_, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	reportRepo := repository.NewReportRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	conf := viper.New()
+	echo, err := llm.NewEchoClient(conf)
+	assert.NoError(t, err)
+	provider := &rewriteProvider{Provider: echo}
+	reportSvc := service.NewReportService(conf, srv, reportRepo, repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+		recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), provider)
+
+	content := "# 周报\n\n## 本周工作\n\n- 完成支付网关联调\n- 修复登录超时\n\n### 细节\n\n- 联调覆盖 3 个渠道\n\n## 下周计划\n\n- 推进对账服务\n"
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_rewrite", UserID: "u1", PeriodType: "week",
+		StartDate: "2024-03-04", EndDate: "2024-03-10", Title: "周报", Content: content, Status: "ready", Version: 2, GenVersion: 1}))
+
+	// 按标题改写：包含下级标题，至下一个同级标题为止
+	provider.output = "```markdown\n## 本周工作\n\n- 完成支付网关联调（3 个渠道），修复登录超时\n```"
+	resp, err := reportSvc.RewriteReport(ctx, "u1", &v1.RewriteReportReq{ReportID: "reportid_rewrite", Heading: "本周工作", Instruction: "更简洁一些"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, resp.StartLine)
+	assert.Equal(t, 10, resp.EndLine)
+	assert.Equal(t, "## 本周工作\n\n- 完成支付网关联调\n- 修复登录超时\n\n### 细节\n\n- 联调覆盖 3 个渠道\n", resp.Original)
+	assert.Equal(t, "# 周报\n\n## 本周工作\n\n- 完成支付网关联调（3 个渠道），修复登录超时\n\n## 下周计划\n\n- 推进对账服务\n", resp.Content)
+	assert.Equal(t, 2, resp.Version)
+	assert.Equal(t, "echo", resp.LLMModel)
+	if assert.Len(t, provider.prompts, 1) {
+		assert.Contains(t, provider.prompts[0], "修改要求：更简洁一些")
+		assert.Contains(t, provider.prompts[0], "报告全文（仅供参考）：\n"+content)
+	}
+
+	// 按片段改写后接受：经 EditReport 写入并留下人工编辑的历史版本
+	provider.output = "- Follow up on the reconciliation service"
+	resp, err = reportSvc.RewriteReport(ctx, "u1", &v1.RewriteReportReq{ReportID: "reportid_rewrite", Text: "- 推进对账服务", Instruction: "翻译为英文"})
+	assert.NoError(t, err)
+	assert.Equal(t, 14, resp.StartLine)
+	assert.Equal(t, 14, resp.EndLine)
+	assert.True(t, strings.HasSuffix(resp.Content, "## 下周计划\n\n- Follow up on the reconciliation service\n"))
+	assert.NoError(t, reportSvc.EditReport(ctx, "u1", &v1.EditReportReq{ReportID: "reportid_rewrite", Content: resp.Content, Version: &resp.Version, GenVersion: &resp.GenVersion}))
+	item, err := reportSvc.GetReportByID(ctx, "u1", "reportid_rewrite")
+	assert.NoError(t, err)
+	assert.Equal(t, resp.Content, item.Content)
+	versions, err := reportSvc.ListVersions(ctx, "u1", "reportid_rewrite")
+	assert.NoError(t, err)
+	if assert.Len(t, versions.Versions, 1) {
+		assert.Equal(t, "user", versions.Versions[0].Author)
+		assert.Equal(t, "edit", versions.Versions[0].Action)
+	}
+
+	// 基于旧版本的建议不能再接受
+	err = reportSvc.EditReport(ctx, "u1", &v1.EditReportReq{ReportID: "reportid_rewrite", Content: resp.Content, Version: &resp.Version, GenVersion: &resp.GenVersion})
+	assert.ErrorIs(t, err, v1.ErrReportConflict)
+	_, err = reportSvc.RewriteReport(ctx, "u1", &v1.RewriteReportReq{ReportID: "reportid_rewrite", Heading: "## 下周计划", Instruction: "补充量化", Version: &resp.Version})
+	assert.ErrorIs(t, err, v1.ErrReportConflict)
+
+	// 定位不到、不唯一或未指定范围
+	for _, req := range []v1.RewriteReportReq{
+		{Heading: "## 风险"},
+		{Text: "- "},
+		{Heading: "## 下周计划", Text: "推进"},
+		{},
+	} {
+		req.ReportID, req.Instruction = "reportid_rewrite", "更简洁一些"
+		_, err = reportSvc.RewriteReport(ctx, "u1", &req)
+		assert.ErrorIs(t, err, v1.ErrInvalidRewriteTarget)
+	}
+	_, err = reportSvc.RewriteReport(ctx, "u2", &v1.RewriteReportReq{ReportID: "reportid_rewrite", Heading: "## 下周计划", Instruction: "更简洁一些"})
+	assert.ErrorIs(t, err, v1.ErrReportNotExist)
+}
@@ -0,0 +1,107 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDueAutoReports_Weekly(t *testing.T) {
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	settings := &model.UserSettings{
+		Timezone:           "Asia/Shanghai",
+		AutoGenerateWeekly: true,
+		WeeklyReportTime:   "22:00",
+	}
+
+	// 周日 22:00 之前未到期，上周的补偿窗口也已过
+	assert.Empty(t, service.DueAutoReports(settings, time.Date(2025, 12, 7, 21, 59, 0, 0, shanghai)))
+
+	expected := []v1.GenReportReq{{PeriodType: "week", StartDate: "2025-12-01", EndDate: "2025-12-07", Template: "formal"}}
+	assert.Equal(t, expected, service.DueAutoReports(settings, time.Date(2025, 12, 7, 22, 0, 0, 0, shanghai)))
+	// 周一仍在补偿窗口内
+	assert.Equal(t, expected, service.DueAutoReports(settings, time.Date(2025, 12, 8, 21, 0, 0, 0, shanghai)))
+	assert.Empty(t, service.DueAutoReports(settings, time.Date(2025, 12, 8, 22, 0, 0, 0, shanghai)))
+}
+
+func TestDueAutoReports_UserTimezone(t *testing.T) {
+	settings := &model.UserSettings{
+		Timezone:           "America/New_York",
+		AutoGenerateWeekly: true,
+		WeeklyReportTime:   "21:00",
+	}
+
+	// UTC 周一 02:30 为纽约周日 21:30
+	reqs := service.DueAutoReports(settings, time.Date(2025, 12, 8, 2, 30, 0, 0, time.UTC))
+	assert.Len(t, reqs, 1)
+	assert.Equal(t, "2025-12-01", reqs[0].StartDate)
+	assert.Equal(t, "2025-12-07", reqs[0].EndDate)
+}
+
+func TestDueAutoReports_Monthly(t *testing.T) {
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	settings := &model.UserSettings{
+		Timezone:            "Asia/Shanghai",
+		AutoGenerateMonthly: true,
+		MonthlyReportTime:   "20:30",
+	}
+
+	assert.Empty(t, service.DueAutoReports(settings, time.Date(2026, 2, 28, 20, 0, 0, 0, shanghai)))
+	assert.Equal(t, []v1.GenReportReq{{PeriodType: "month", StartDate: "2026-02-01", EndDate: "2026-02-28", Template: "formal"}},
+		service.DueAutoReports(settings, time.Date(2026, 3, 1, 8, 0, 0, 0, shanghai)))
+}
+
+func TestReportService_AutoGenerateRetriesFailed(t *testing.T) {
+	ctx := context.Background()
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	db, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	reportRepo := repository.NewReportRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	conf := viper.New()
+	conf.Set("llm.provider", "echo")
+	reportSvc := service.NewReportService(conf, srv, reportRepo, repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+		recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), llm.NewProvider(conf, logger))
+
+	for _, userId := range []string{"u1", "u2"} {
+		assert.NoError(t, db.Create(&model.UserSettings{UserID: userId, Timezone: "Asia/Shanghai", AutoGenerateWeekly: true, WeeklyReportTime: "22:00"}).Error)
+	}
+	failedAt := time.Date(2025, 12, 7, 22, 5, 0, 0, shanghai)
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_failed", UserID: "u1", PeriodType: "week", StartDate: "2025-12-01",
+		EndDate: "2025-12-07", Title: "周报", Status: "failed", FailedReason: "call llm failed", GenVersion: 1, Template: "formal", UpdatedAt: failedAt}))
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_ready", UserID: "u2", PeriodType: "week", StartDate: "2025-12-01",
+		EndDate: "2025-12-07", Title: "周报", Content: "# 周报", Status: "ready", GenVersion: 1, Template: "formal", UpdatedAt: failedAt}))
+
+	// 刚失败的报告等待重试间隔，已生成的报告不受影响
+	created, err := reportSvc.AutoGenerateReports(ctx, failedAt.Add(5*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, created)
+
+	created, err = reportSvc.AutoGenerateReports(ctx, failedAt.Add(35*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, created)
+	report, err := reportRepo.GetByID(ctx, "u1", "reportid_failed")
+	assert.NoError(t, err)
+	assert.Equal(t, "queued", report.Status)
+	assert.Equal(t, 2, report.GenVersion)
+	assert.Empty(t, report.FailedReason)
+	ready, err := reportRepo.GetByID(ctx, "u2", "reportid_ready")
+	assert.NoError(t, err)
+	assert.Equal(t, "# 周报", ready.Content)
+	assert.Equal(t, 1, ready.GenVersion)
+
+	// 已重新排队的报告不再重复排队
+	created, err = reportSvc.AutoGenerateReports(ctx, failedAt.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, created)
+}
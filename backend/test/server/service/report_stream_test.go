@@ -0,0 +1,63 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportService_StreamFollowsWorker(t *testing.T) {
+	ctx := context.Background()
+	_, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	conf := viper.New()
+	conf.Set("llm.provider", "echo")
+	reportSvc := service.NewReportService(conf, srv, repository.NewReportRepository(r), repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+		recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), llm.NewProvider(conf, logger))
+
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-04", Content: "完成接口联调"}))
+	reportId, err := reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "week", StartDate: "2024-03-04", EndDate: "2024-03-10", Template: "formal"})
+	assert.NoError(t, err)
+
+	// 订阅不会抢占排队中的报告
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, reportSvc.StreamReport(short, "u1", &v1.StreamReportReq{ReportID: reportId}, func(event v1.ReportStreamEvent) error {
+		t.Errorf("unexpected event %s", event.Event)
+		return nil
+	}))
+	item, err := reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Equal(t, "queued", item.Status)
+
+	// worker 领取生成后订阅方收到完成事件
+	events := make(chan v1.ReportStreamEvent, 16)
+	go func() {
+		_ = reportSvc.StreamReport(ctx, "u1", &v1.StreamReportReq{ReportID: reportId}, func(event v1.ReportStreamEvent) error {
+			events <- event
+			return nil
+		})
+		close(events)
+	}()
+	ok, err := reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	var last v1.ReportStreamEvent
+	for event := range events {
+		last = event
+	}
+	assert.Equal(t, v1.ReportStreamDone, last.Event)
+	if done, ok := last.Data.(v1.ReportItem); assert.True(t, ok) {
+		assert.Equal(t, "ready", done.Status)
+	}
+}
@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportService_StructuredSummary(t *testing.T) {
+	ctx := context.Background()
+	db, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	reportRepo := repository.NewReportRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	conf := viper.New()
+	conf.Set("llm.provider", "echo")
+	reportSvc := service.NewReportService(conf, srv, reportRepo, repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+		recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), llm.NewProvider(conf, logger))
+
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-04", Content: "完成接口联调"}))
+	reportId, err := reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "week", StartDate: "2024-03-04", EndDate: "2024-03-10", Template: "formal"})
+	assert.NoError(t, err)
+	ok, err := reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	item, err := reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	if assert.NotNil(t, item.Summary) {
+		assert.NotEmpty(t, item.Summary.Summary)
+		assert.NotEmpty(t, item.Summary.KeyOutputs)
+	}
+	assert.Contains(t, item.Abstract, "关键产出：")
+
+	// 编辑后摘要失效
+	assert.NoError(t, reportSvc.EditReport(ctx, "u1", &v1.EditReportReq{ReportID: reportId, Content: "# 周报\n\n手工改写"}))
+	item, err = reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Nil(t, item.Summary)
+	assert.Empty(t, item.Abstract)
+
+	// 年报：1 月月报已有结构化摘要，2 月为旧月报，取用时补写摘要
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_jan", UserID: "u1", PeriodType: "month",
+		StartDate: "2024-01-01", EndDate: "2024-01-31", Title: "1 月月报", Content: "# 1 月月报\n\n正文不应进入年报素材", Status: "ready", Confirmed: true,
+		Summary: `{"summary":"完成支付系统重构","key_outputs":["支付链路迁移"],"metrics":[{"name":"接口延迟","value":"降低 30%"}],"risks":[],"next_steps":["灰度发布"],"projects":["支付系统"]}`}))
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_feb", UserID: "u1", PeriodType: "month",
+		StartDate: "2024-02-01", EndDate: "2024-02-29", Title: "2 月月报", Content: "# 2 月月报\n\n上线灰度\n\n- 完成灰度发布", Status: "ready", Confirmed: true}))
+
+	yearId, err := reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "year", StartDate: "2024-01-01", EndDate: "2024-12-31", Template: "formal"})
+	assert.NoError(t, err)
+	ok, err = reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	jobs, err := reportSvc.GetReportJobs(ctx, "u1", yearId)
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 1) {
+		assert.Contains(t, jobs[0].Prompt, "完成支付系统重构")
+		assert.Contains(t, jobs[0].Prompt, "关键数据：接口延迟 降低 30%")
+		assert.Contains(t, jobs[0].Prompt, "涉及项目：支付系统")
+		assert.NotContains(t, jobs[0].Prompt, "正文不应进入年报素材")
+		assert.Contains(t, jobs[0].Prompt, "关键产出：完成灰度发布")
+	}
+
+	var feb model.Report
+	assert.NoError(t, db.Where("report_id = ?", "reportid_feb").First(&feb).Error)
+	assert.Contains(t, feb.Summary, `"key_outputs":["完成灰度发布"]`)
+	assert.Equal(t, "上线灰度\n关键产出：完成灰度发布", feb.Abstract)
+}
@@ -0,0 +1,137 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newReportTemplateDB(t *testing.T) (*gorm.DB, *repository.Repository) {
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&model.UserSettings{}, &model.Record{}, &model.RecordRevision{}, &model.Report{}, &model.ReportVersion{},
+		&model.ReportJob{}, &model.ReportJobAttempt{}, &model.ReportTemplate{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db, repository.NewRepository(logger, db, nil)
+}
+
+func TestReportTemplateService_CRUD(t *testing.T) {
+	ctx := context.Background()
+	db, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	templateSvc := service.NewReportTemplateService(srv, repository.NewReportTemplateRepository(r))
+
+	// 未写入内置模板时使用内嵌版本
+	items, err := templateSvc.ListTemplates(ctx, "u1", &v1.GetReportTemplatesReq{})
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, "formal", items[0].TemplateID)
+	assert.True(t, items[0].Builtin)
+	assert.Contains(t, items[0].Sections, "重点产出")
+	assert.NotContains(t, items[0].Sections, "需要协作/支持（可选）")
+	assert.False(t, strings.HasPrefix(items[0].SystemPrompt, "<!--"))
+
+	assert.NoError(t, repository.SeedReportTemplates(db, service.BuiltinReportTemplates(llm.LoadPrompts(logger))))
+	assert.NoError(t, repository.SeedReportTemplates(db, service.BuiltinReportTemplates(llm.LoadPrompts(logger))))
+
+	created, err := templateSvc.CreateTemplate(ctx, "u1", &v1.SaveReportTemplateReq{
+		Name:         " 研发周报 ",
+		PeriodTypes:  []string{"week", "week"},
+		SystemPrompt: "你是研发团队的周报助手。",
+		Sections:     []string{"## 产出", "风险", " ", "下周计划"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.TemplateID, service.ReportTemplatePrefix))
+	assert.Equal(t, "研发周报", created.Name)
+	assert.Equal(t, []string{"week"}, created.PeriodTypes)
+	assert.Equal(t, []string{"产出", "风险", "下周计划"}, created.Sections)
+
+	items, err = templateSvc.ListTemplates(ctx, "u1", &v1.GetReportTemplatesReq{})
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+	assert.Equal(t, created.TemplateID, items[2].TemplateID)
+	items, err = templateSvc.ListTemplates(ctx, "u1", &v1.GetReportTemplatesReq{PeriodType: "month"})
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	items, err = templateSvc.ListTemplates(ctx, "u2", &v1.GetReportTemplatesReq{})
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	updated, err := templateSvc.UpdateTemplate(ctx, "u1", created.TemplateID, &v1.SaveReportTemplateReq{
+		Name: "研发周月报", PeriodTypes: []string{"week", "month"}, SystemPrompt: "新提示词", Sections: []string{"产出"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"week", "month"}, updated.PeriodTypes)
+	assert.Equal(t, "新提示词", updated.SystemPrompt)
+
+	_, err = templateSvc.CreateTemplate(ctx, "u1", &v1.SaveReportTemplateReq{Name: "研发周月报", PeriodTypes: []string{"week"}, SystemPrompt: "x"})
+	assert.ErrorIs(t, err, v1.ErrReportTemplateNameExists)
+	_, err = templateSvc.CreateTemplate(ctx, "u1", &v1.SaveReportTemplateReq{Name: "季报", PeriodTypes: []string{"decade"}, SystemPrompt: "x"})
+	assert.ErrorIs(t, err, v1.ErrInvalidReportTemplateArg)
+	_, err = templateSvc.CreateTemplate(ctx, "u1", &v1.SaveReportTemplateReq{Name: "重复章节", PeriodTypes: []string{"week"}, SystemPrompt: "x", Sections: []string{"产出", "## 产出"}})
+	assert.ErrorIs(t, err, v1.ErrInvalidReportTemplateArg)
+	_, err = templateSvc.UpdateTemplate(ctx, "u1", "formal", &v1.SaveReportTemplateReq{Name: "改内置", PeriodTypes: []string{"week"}, SystemPrompt: "x"})
+	assert.ErrorIs(t, err, v1.ErrReportTemplateReadOnly)
+	assert.ErrorIs(t, templateSvc.DeleteTemplate(ctx, "u1", "simple"), v1.ErrReportTemplateReadOnly)
+	assert.ErrorIs(t, templateSvc.DeleteTemplate(ctx, "u2", created.TemplateID), v1.ErrReportTemplateNotExist)
+
+	assert.NoError(t, templateSvc.DeleteTemplate(ctx, "u1", created.TemplateID))
+	_, err = templateSvc.GetTemplate(ctx, "u1", created.TemplateID)
+	assert.ErrorIs(t, err, v1.ErrReportTemplateNotExist)
+}
+
+func TestReportService_GenerateWithTemplate(t *testing.T) {
+	ctx := context.Background()
+	_, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	templateRepo := repository.NewReportTemplateRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	conf := viper.New()
+	conf.Set("llm.provider", "echo")
+	reportSvc := service.NewReportService(conf, srv, repository.NewReportRepository(r), repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+		recordSvc, settingsRepo, templateRepo, llm.NewProvider(conf, logger))
+	templateSvc := service.NewReportTemplateService(srv, templateRepo)
+
+	tpl, err := templateSvc.CreateTemplate(ctx, "u1", &v1.SaveReportTemplateReq{
+		Name: "研发周报", PeriodTypes: []string{"week"}, SystemPrompt: "你是研发团队的周报助手。", Sections: []string{"产出", "风险", "下周计划"},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-04", Content: "完成接口联调"}))
+
+	_, err = reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "month", StartDate: "2024-03-01", EndDate: "2024-03-31", Template: tpl.TemplateID})
+	assert.ErrorIs(t, err, v1.ErrReportTemplatePeriod)
+	_, err = reportSvc.GenerateReport(ctx, "u2", &v1.GenReportReq{PeriodType: "week", StartDate: "2024-03-04", EndDate: "2024-03-10", Template: tpl.TemplateID})
+	assert.ErrorIs(t, err, v1.ErrInvalidReportTemplate)
+
+	reportId, err := reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "week", StartDate: "2024-03-04", EndDate: "2024-03-10", Template: tpl.TemplateID})
+	assert.NoError(t, err)
+	ok, err := reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	jobs, err := reportSvc.GetReportJobs(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, tpl.TemplateID, jobs[0].Template)
+	assert.Contains(t, jobs[0].SystemPrompt, "你是研发团队的周报助手。")
+	assert.Contains(t, jobs[0].SystemPrompt, "## 产出\n## 风险\n## 下周计划")
+
+	// 内置模板 ID 仍可直接使用
+	_, err = reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "month", StartDate: "2024-03-01", EndDate: "2024-03-31", Template: "simple"})
+	assert.NoError(t, err)
+}
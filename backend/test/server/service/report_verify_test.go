@@ -0,0 +1,77 @@
+package service_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportService_Verification(t *testing.T) {
+	ctx := context.Background()
+	_, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+
+	fixture := filepath.Join(t.TempDir(), "report.md")
+	assert.NoError(t, os.WriteFile(fixture, []byte("# 周报\n\n## 重点产出\n- 联调 Gateway 接口，耗时 12 小时\n- 支付成功率提升 35%，完成 PaymentV2 上线\n- 3月6日与 @李雷 评审方案\n"), 0o644))
+	newReportSvc := func(mode string) service.ReportService {
+		conf := viper.New()
+		conf.Set("llm.provider", "echo")
+		conf.Set("llm.echo.fixture", fixture)
+		conf.Set("report.verify.mode", mode)
+		return service.NewReportService(conf, srv, repository.NewReportRepository(r), repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+			recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), llm.NewProvider(conf, logger))
+	}
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-04", Content: "联调 gateway 接口，花了 12 小时"}))
+
+	reportSvc := newReportSvc("lexical")
+	reportId, err := reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "week", StartDate: "2024-03-04", EndDate: "2024-03-10", Template: "formal"})
+	assert.NoError(t, err)
+	ok, err := reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	item, err := reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	if assert.NotNil(t, item.Verification) {
+		assert.Equal(t, "lexical", item.Verification.Mode)
+		assert.Equal(t, 6, item.Verification.Checked)
+		assert.Equal(t, []v1.ReportClaim{
+			{Line: 5, Claim: "35%", Context: "支付成功率提升 35%，完成 PaymentV2 上线", Kind: "number", Reason: "输入记录中未找到该数字"},
+			{Line: 5, Claim: "PaymentV2", Context: "支付成功率提升 35%，完成 PaymentV2 上线", Kind: "term", Reason: "输入记录中未提及该名称"},
+			{Line: 6, Claim: "3月6日", Context: "3月6日与 @李雷 评审方案", Kind: "date", Reason: "输入记录中未找到该日期"},
+			{Line: 6, Claim: "@李雷", Context: "3月6日与 @李雷 评审方案", Kind: "person", Reason: "输入记录中未提及该人员"},
+		}, item.Verification.Flagged)
+	}
+
+	// 编辑掉被标记的要点后不再返回对应标记，核对结果不阻止确认
+	assert.NoError(t, reportSvc.EditReport(ctx, "u1", &v1.EditReportReq{ReportID: reportId, Content: "# 周报\n\n- 3月6日与 @李雷 评审方案\n"}))
+	item, err = reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	if assert.NotNil(t, item.Verification) {
+		assert.Len(t, item.Verification.Flagged, 2)
+		assert.Equal(t, 3, item.Verification.Flagged[0].Line)
+	}
+	assert.NoError(t, reportSvc.ConfirmReport(ctx, "u1", &v1.ConfirmReportReq{ReportID: reportId}))
+
+	// 关闭核对后不返回核对结果
+	reportSvc = newReportSvc("off")
+	reportId, err = reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "week", StartDate: "2024-03-04", EndDate: "2024-03-10", Template: "formal", OverwriteConfirmed: true})
+	assert.NoError(t, err)
+	ok, err = reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	item, err = reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Nil(t, item.Verification)
+}
@@ -0,0 +1,156 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "backend/api/v1"
+	"backend/internal/llm"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportService_Versions(t *testing.T) {
+	ctx := context.Background()
+	_, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	reportRepo := repository.NewReportRepository(r)
+	versionRepo := repository.NewReportVersionRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	conf := viper.New()
+	conf.Set("llm.provider", "echo")
+	reportSvc := service.NewReportService(conf, srv, reportRepo, repository.NewReportJobRepository(r), versionRepo,
+		recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), llm.NewProvider(conf, logger))
+
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-04", Content: "完成接口联调"}))
+	req := &v1.GenReportReq{PeriodType: "week", StartDate: "2024-03-04", EndDate: "2024-03-10", Template: "formal"}
+	reportId, err := reportSvc.GenerateReport(ctx, "u1", req)
+	assert.NoError(t, err)
+	ok, err := reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 手工编辑后确认；未显式确认时不允许重新生成
+	assert.NoError(t, reportSvc.EditReport(ctx, "u1", &v1.EditReportReq{ReportID: reportId, Content: "# 周报\n\n- 完成接口联调\n- 补充：修复登录超时\n"}))
+	stale := 0
+	assert.ErrorIs(t, reportSvc.ConfirmReport(ctx, "u1", &v1.ConfirmReportReq{ReportID: reportId, Version: &stale}), v1.ErrReportConflict)
+	assert.NoError(t, reportSvc.ConfirmReport(ctx, "u1", &v1.ConfirmReportReq{ReportID: reportId}))
+	_, err = reportSvc.GenerateReport(ctx, "u1", req)
+	assert.ErrorIs(t, err, v1.ErrReportConfirmed)
+
+	req.OverwriteConfirmed = true
+	_, err = reportSvc.GenerateReport(ctx, "u1", req)
+	assert.NoError(t, err)
+	ok, err = reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	resp, err := reportSvc.ListVersions(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, resp.Current)
+	if assert.Len(t, resp.Versions, 3) {
+		assert.Equal(t, v1.ReportVersionItem{Seq: 3, Author: "llm", Action: "generate", Version: 1, GenVersion: 2, LLMModel: "echo", Template: "formal",
+			CreatedAt: resp.Versions[0].CreatedAt}, resp.Versions[0])
+		assert.Equal(t, "user", resp.Versions[1].Author)
+		assert.Equal(t, "edit", resp.Versions[1].Action)
+		assert.Equal(t, 1, resp.Versions[1].Version)
+		assert.Equal(t, 1, resp.Versions[2].GenVersion)
+	}
+
+	diff, err := reportSvc.DiffVersions(ctx, "u1", &v1.ReportVersionDiffReq{ReportID: reportId, From: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, diff.To)
+	assert.NotEmpty(t, diff.Diffs)
+	assert.Positive(t, diff.Removed)
+	_, err = reportSvc.DiffVersions(ctx, "u1", &v1.ReportVersionDiffReq{ReportID: reportId, From: 9})
+	assert.ErrorIs(t, err, v1.ErrReportVersionNotExist)
+
+	// 恢复人工编辑的版本：作为一次编辑写回，需重新确认
+	item, err := reportSvc.RestoreVersion(ctx, "u1", reportId, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "# 周报\n\n- 完成接口联调\n- 补充：修复登录超时\n", item.Content)
+	assert.Equal(t, 2, item.Version)
+	assert.False(t, item.Confirmed)
+	resp, err = reportSvc.ListVersions(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, resp.Current)
+	assert.Equal(t, "restore", resp.Versions[0].Action)
+	_, err = reportSvc.ListVersions(ctx, "u2", reportId)
+	assert.ErrorIs(t, err, v1.ErrReportNotExist)
+
+	// 历史版本功能之前的报告：重新生成前补存当前正文
+	assert.NoError(t, reportRepo.Create(ctx, &model.Report{ReportID: "reportid_legacy", UserID: "u1", PeriodType: "week",
+		StartDate: "2024-03-11", EndDate: "2024-03-17", Title: "旧周报", Content: "# 旧周报\n\n手工整理", Status: "ready", Version: 3, GenVersion: 1, Template: "formal"}))
+	_, err = reportSvc.GenerateReport(ctx, "u1", &v1.GenReportReq{PeriodType: "week", StartDate: "2024-03-11", EndDate: "2024-03-17", Template: "formal"})
+	assert.NoError(t, err)
+	legacy, err := versionRepo.GetBySeq(ctx, "reportid_legacy", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "# 旧周报\n\n手工整理", legacy.Content)
+	assert.Equal(t, "user", legacy.Author)
+	assert.Equal(t, 3, legacy.Version)
+	resp, err = reportSvc.ListVersions(ctx, "u1", "reportid_legacy")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, resp.Current)
+}
+
+// supersedeProvider 首次调用期间执行 hook（模拟生成过程中报告被重新生成）
+type supersedeProvider struct {
+	llm.Provider
+
+	hook func()
+}
+
+func (p *supersedeProvider) Complete(ctx context.Context, systemPrompt string, userPrompt string) (*llm.Completion, error) {
+	if p.hook != nil {
+		hook := p.hook
+		p.hook = nil
+		hook()
+	}
+	return p.Provider.Complete(ctx, systemPrompt, userPrompt)
+}
+
+func TestReportService_DiscardSupersededGeneration(t *testing.T) {
+	ctx := context.Background()
+	_, r := newReportTemplateDB(t)
+	srv := service.NewService(repository.NewTransaction(r), logger, sf, j)
+	settingsRepo := repository.NewUserSettingsRepository(r)
+	recordSvc := service.NewRecordService(srv, repository.NewRecordRepository(r), settingsRepo, repository.NewRecordRevisionRepository(r))
+	conf := viper.New()
+	echo, err := llm.NewEchoClient(conf)
+	assert.NoError(t, err)
+	provider := &supersedeProvider{Provider: echo}
+	reportSvc := service.NewReportService(conf, srv, repository.NewReportRepository(r), repository.NewReportJobRepository(r), repository.NewReportVersionRepository(r),
+		recordSvc, settingsRepo, repository.NewReportTemplateRepository(r), provider)
+
+	assert.NoError(t, recordSvc.UpsertUserRecord(ctx, "u1", &v1.UpsertRecordReq{Date: "2024-03-04", Content: "完成接口联调"}))
+	req := &v1.GenReportReq{PeriodType: "week", StartDate: "2024-03-04", EndDate: "2024-03-10", Template: "formal"}
+	reportId, err := reportSvc.GenerateReport(ctx, "u1", req)
+	assert.NoError(t, err)
+	provider.hook = func() {
+		_, err := reportSvc.GenerateReport(ctx, "u1", req)
+		assert.NoError(t, err)
+	}
+	ok, err := reportSvc.ProcessNextReport(ctx, "worker-1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// 第一轮结果未写入，任务不记为成功
+	jobs, err := reportSvc.GetReportJobs(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 2) {
+		assert.Equal(t, 2, jobs[0].GenVersion)
+		assert.Equal(t, "queued", jobs[0].Status)
+		assert.Equal(t, 1, jobs[1].GenVersion)
+		assert.NotEqual(t, "ready", jobs[1].Status)
+		assert.Empty(t, jobs[1].Result)
+	}
+	item, err := reportSvc.GetReportByID(ctx, "u1", reportId)
+	assert.NoError(t, err)
+	assert.Equal(t, "queued", item.Status)
+	assert.Empty(t, item.Content)
+}
@@ -0,0 +1,23 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"backend/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnippet(t *testing.T) {
+	assert.Equal(t, "完成<mark>登录</mark>接口<mark>联调</mark>", service.Snippet("完成登录接口联调", []string{"登录", "联调"}))
+	assert.Equal(t, "修复 <mark>Token</mark> &lt;过期&gt;", service.Snippet("修复\nToken <过期>", []string{"token"}))
+
+	long := strings.Repeat("甲", 200) + "联调" + strings.Repeat("乙", 200)
+	snippet := service.Snippet(long, []string{"联调"})
+	assert.True(t, strings.HasPrefix(snippet, "…"+strings.Repeat("甲", 30)+"<mark>联调</mark>"))
+	assert.True(t, strings.HasSuffix(snippet, "…"))
+
+	// 未命中时返回开头
+	assert.Equal(t, "整理周会纪要", service.Snippet("整理周会纪要", []string{"联调"}))
+}
@@ -308,3 +308,107 @@ func TestUserService_UpdateProfile_UserNotFound(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestUserService_UpdateUserSettings_InvalidTimezone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockUserSettingsRepo := mock_repository.NewMockUserSettingsRepository(ctrl)
+	mockTm := mock_repository.NewMockTransaction(ctrl)
+	srv := service.NewService(mockTm, logger, sf, j)
+	userService := service.NewUserService(srv, mockUserRepo, mockUserSettingsRepo)
+
+	ctx := context.Background()
+	userId := "123"
+	for _, tz := range []string{"Mars/Olympus", "Local", "+08:00"} {
+		mockUserSettingsRepo.EXPECT().GetByID(ctx, userId).Return(&model.UserSettings{
+			UserID:   userId,
+			Timezone: "Asia/Shanghai",
+		}, nil)
+
+		err := userService.UpdateUserSettings(ctx, userId, &v1.UpdateUserSettingsReq{
+			UserSettings: v1.UserSettings{UserID: userId, Timezone: tz},
+		})
+		assert.ErrorIs(t, err, v1.ErrInvalidTimezone)
+	}
+}
+
+func TestUserService_UpdateUserSettings_Timezone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockUserSettingsRepo := mock_repository.NewMockUserSettingsRepository(ctrl)
+	mockTm := mock_repository.NewMockTransaction(ctrl)
+	srv := service.NewService(mockTm, logger, sf, j)
+	userService := service.NewUserService(srv, mockUserRepo, mockUserSettingsRepo)
+
+	ctx := context.Background()
+	userId := "123"
+	mockUserSettingsRepo.EXPECT().GetByID(ctx, userId).Return(&model.UserSettings{
+		UserID:   userId,
+		Timezone: "Asia/Shanghai",
+	}, nil)
+	mockUserSettingsRepo.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, settings *model.UserSettings) error {
+		assert.Equal(t, "America/Los_Angeles", settings.Timezone)
+		assert.Equal(t, "22:00", settings.WeeklyReportTime)
+		return nil
+	})
+
+	err := userService.UpdateUserSettings(ctx, userId, &v1.UpdateUserSettingsReq{
+		UserSettings: v1.UserSettings{UserID: userId, Timezone: "America/Los_Angeles"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestUserService_SetEncryptionKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockUserSettingsRepo := mock_repository.NewMockUserSettingsRepository(ctrl)
+	mockTm := mock_repository.NewMockTransaction(ctrl)
+	srv := service.NewService(mockTm, logger, sf, j)
+	userService := service.NewUserService(srv, mockUserRepo, mockUserSettingsRepo)
+
+	ctx := context.Background()
+	userId := "123"
+	key := v1.EncryptionKey{
+		KeyID:     "k1",
+		KDF:       v1.EncKDFPBKDF2SHA256,
+		KDFParams: map[string]any{"iterations": float64(600000)},
+		Salt:      "MDEyMzQ1Njc4OWFiY2RlZg==",
+		Verifier:  "dmVyaWZpZXI=",
+	}
+	mockUserSettingsRepo.EXPECT().GetByID(ctx, userId).Return(&model.UserSettings{UserID: userId}, nil)
+	mockUserSettingsRepo.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, settings *model.UserSettings) error {
+		assert.Equal(t, "k1", settings.EncKeyID)
+		assert.Equal(t, v1.EncKDFPBKDF2SHA256, settings.EncKDF)
+		assert.Equal(t, key.Salt, settings.EncSalt)
+		return nil
+	})
+	assert.NoError(t, userService.SetEncryptionKey(ctx, userId, &v1.SetEncryptionKeyReq{EncryptionKey: key}))
+}
+
+func TestUserService_SetEncryptionKey_Weak(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockUserSettingsRepo := mock_repository.NewMockUserSettingsRepository(ctrl)
+	mockTm := mock_repository.NewMockTransaction(ctrl)
+	srv := service.NewService(mockTm, logger, sf, j)
+	userService := service.NewUserService(srv, mockUserRepo, mockUserSettingsRepo)
+
+	ctx := context.Background()
+	for _, key := range []v1.EncryptionKey{
+		{KeyID: "k1", KDF: v1.EncKDFPBKDF2SHA256, KDFParams: map[string]any{"iterations": float64(1000)}, Salt: "MDEyMzQ1Njc4OWFiY2RlZg==", Verifier: "v"},
+		{KeyID: "k1", KDF: v1.EncKDFPBKDF2SHA256, KDFParams: map[string]any{"iterations": float64(600000)}, Salt: "c2FsdA==", Verifier: "v"},
+		{KeyID: "k1", KDF: v1.EncKDFArgon2id, KDFParams: map[string]any{"memory": float64(65536)}, Salt: "MDEyMzQ1Njc4OWFiY2RlZg==", Verifier: "v"},
+		{KeyID: "k1", KDF: "scrypt", Salt: "MDEyMzQ1Njc4OWFiY2RlZg==", Verifier: "v"},
+	} {
+		err := userService.SetEncryptionKey(ctx, "123", &v1.SetEncryptionKeyReq{EncryptionKey: key})
+		assert.ErrorIs(t, err, v1.ErrInvalidEncryptionKey)
+	}
+}